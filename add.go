@@ -2,8 +2,11 @@ package buildah
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -19,6 +22,7 @@ import (
 	"syscall"
 	"time"
 
+	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/docker/go-connections/tlsconfig"
 	"github.com/hashicorp/go-multierror"
 	"github.com/moby/sys/userns"
@@ -29,14 +33,21 @@ import (
 	"github.com/tonistiigi/dchapes-mode"
 	"go.podman.io/buildah/copier"
 	"go.podman.io/buildah/define"
+	"go.podman.io/buildah/internal/downloadcache"
+	"go.podman.io/buildah/internal/gitsource"
+	"go.podman.io/buildah/internal/spool"
 	"go.podman.io/buildah/internal/tmpdir"
 	"go.podman.io/buildah/internal/urlsource"
 	"go.podman.io/buildah/pkg/chrootuser"
+	"go.podman.io/buildah/pkg/sshagent"
 	"go.podman.io/common/pkg/retry"
+	"go.podman.io/image/v5/pkg/compression"
 	"go.podman.io/image/v5/pkg/tlsclientconfig"
 	"go.podman.io/image/v5/types"
+	"go.podman.io/storage"
 	"go.podman.io/storage/pkg/fileutils"
 	"go.podman.io/storage/pkg/idtools"
+	"golang.org/x/sync/semaphore"
 )
 
 // AddAndCopyOptions holds options for add and copy commands.
@@ -48,7 +59,9 @@ type AddAndCopyOptions struct {
 	// otherwise be set to 0:0.
 	Chown string
 	// Checksum is a standard container digest string (e.g. <algorithm>:<digest>)
-	// and is the expected hash of the content being copied.
+	// and is the expected hash of the content being copied.  For Git
+	// repositories, it is instead the ID of the commit, or a prefix of it,
+	// which the requested branch or tag is expected to resolve to.
 	Checksum string
 	// PreserveOwnership, if Chown is not set, tells us to avoid setting
 	// ownership of copied items to 0:0, instead using whatever ownership
@@ -126,14 +139,78 @@ type AddAndCopyOptions struct {
 	// FollowSymlink controls whether symlinks should be followed when copying content.
 	// When set to false, symlinks are not dereferenced.
 	FollowSymlink types.OptionalBool
+	// ContentManifest, if set, causes a ContentManifest describing each
+	// item that was written into the container to be added to the
+	// Builder's ContentManifests list.
+	ContentManifest bool
+	// Jobs is the number of sources, or items matched by sources, which
+	// can be read at the same time.  They are always written into the
+	// container in order.  Values less than 2 mean that each is read
+	// while it is being written.
+	Jobs int
+	// ExtractRemoteArchives, if set, causes content retrieved from HTTP(S)
+	// locations to be extracted if it is an archive, after it has been
+	// verified using Checksum.
+	ExtractRemoteArchives bool
+	// GitAuthToken, if set, is used as the password when fetching Git
+	// repositories using HTTP(S).
+	GitAuthToken string
+	// GitSSHSource, if set, provides the keys, or the agent, which are
+	// used when fetching Git repositories using SSH.
+	GitSSHSource *sshagent.Source
 }
 
-// getURL writes a tar archive containing the named content
-func getURL(src string, chown *idtools.IDPair, mountpoint, renameTarget string, writer io.Writer, chmod string, srcDigest digest.Digest, certPath string, insecureSkipTLSVerify types.OptionalBool, timestamp *time.Time) error {
-	url, err := url.Parse(src)
+// ContentManifest describes the items that one call to Add() wrote into the
+// container.
+type ContentManifest struct {
+	Command     string                 `json:"command"` // "ADD" or "COPY"
+	Sources     []string               `json:"sources"`
+	Destination string                 `json:"destination"`
+	Items       []copier.ManifestEntry `json:"items"`
+}
+
+// contentManifestsBigDataKey is the key under which the content manifests
+// which describe an image's contents are saved with the image in local
+// storage, so that containers which are based on it, including those which
+// are used for later steps of a build, carry them forward.
+const contentManifestsBigDataKey = "buildah-content-manifests"
+
+// ImageContentManifests returns the content manifests which were saved with
+// an image, if there are any.
+func ImageContentManifests(store storage.Store, imageID string) ([]ContentManifest, error) {
+	keys, err := store.ListImageBigData(imageID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("listing data items for image %q: %w", imageID, err)
+	}
+	if !slices.Contains(keys, contentManifestsBigDataKey) {
+		return nil, nil
+	}
+	data, err := store.ImageBigData(imageID, contentManifestsBigDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading content manifests for image %q: %w", imageID, err)
+	}
+	var manifests []ContentManifest
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("parsing content manifests for image %q: %w", imageID, err)
+	}
+	return manifests, nil
+}
+
+// saveImageContentManifests saves content manifests with an image.
+func saveImageContentManifests(store storage.Store, imageID string, manifests []ContentManifest) error {
+	data, err := json.Marshal(manifests)
+	if err != nil {
+		return fmt.Errorf("encoding content manifests for image %q: %w", imageID, err)
+	}
+	if err := store.SetImageBigData(imageID, contentManifestsBigDataKey, data, nil); err != nil {
+		return fmt.Errorf("saving content manifests for image %q: %w", imageID, err)
 	}
+	return nil
+}
+
+// newHTTPClient returns a client for retrieving content from HTTP(S)
+// locations.
+func newHTTPClient(certPath string, insecureSkipTLSVerify types.OptionalBool) (*http.Client, error) {
 	tlsClientConfig := &tls.Config{
 		// As of 2025-08, tlsconfig.ClientDefault() differs from Go 1.23 defaults only in CipherSuites;
 		// so, limit us to only using that value. If go-connections/tlsconfig changes its policy, we
@@ -143,7 +220,7 @@ func getURL(src string, chown *idtools.IDPair, mountpoint, renameTarget string,
 		CipherSuites: tlsconfig.ClientDefault().CipherSuites,
 	}
 	if err := tlsclientconfig.SetupCertificates(certPath, tlsClientConfig); err != nil {
-		return err
+		return nil, err
 	}
 	tlsClientConfig.InsecureSkipVerify = insecureSkipTLSVerify == types.OptionalBoolTrue
 
@@ -151,67 +228,25 @@ func getURL(src string, chown *idtools.IDPair, mountpoint, renameTarget string,
 		TLSClientConfig: tlsClientConfig,
 		Proxy:           http.ProxyFromEnvironment,
 	}
-	httpClient := &http.Client{Transport: tr}
-	response, err := httpClient.Get(src)
-	if err != nil {
-		return err
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
-		return fmt.Errorf("invalid response status %d", response.StatusCode)
-	}
+	return &http.Client{Transport: tr}, nil
+}
 
-	// Figure out what to name the new content.
-	name := renameTarget
-	if name == "" {
-		name = path.Base(url.Path)
-	}
+// urlFileHeader builds the header for the tar archive entry for content
+// which we retrieved from a URL and are adding as a file.
+func urlFileHeader(lastModified string, name string, size int64, chown *idtools.IDPair, chmod string, timestamp *time.Time) (*tar.Header, error) {
 	// If there's a date on the content, use it.  If not, use the Unix epoch
 	// or a specified value for compatibility.
 	date := time.Unix(0, 0).UTC()
 	if timestamp != nil {
 		date = timestamp.UTC()
-	} else {
-		lastModified := response.Header.Get("Last-Modified")
-		if lastModified != "" {
-			d, err := time.Parse(time.RFC1123, lastModified)
-			if err != nil {
-				return fmt.Errorf("parsing last-modified time %q: %w", lastModified, err)
-			}
-			date = d.UTC()
-		}
-	}
-	// Figure out the size of the content.
-	size := response.ContentLength
-	var responseBody io.Reader = response.Body
-	if size < 0 {
-		// Create a temporary file and copy the content to it, so that
-		// we can figure out how much content there is.
-		f, err := os.CreateTemp(mountpoint, "download")
-		if err != nil {
-			return fmt.Errorf("creating temporary file to hold %q: %w", src, err)
-		}
-		defer os.Remove(f.Name())
-		defer f.Close()
-		size, err = io.Copy(f, response.Body)
+	} else if lastModified != "" {
+		d, err := time.Parse(time.RFC1123, lastModified)
 		if err != nil {
-			return fmt.Errorf("writing %q to temporary file %q: %w", src, f.Name(), err)
+			return nil, fmt.Errorf("parsing last-modified time %q: %w", lastModified, err)
 		}
-		_, err = f.Seek(0, io.SeekStart)
-		if err != nil {
-			return fmt.Errorf("setting up to read %q from temporary file %q: %w", src, f.Name(), err)
-		}
-		responseBody = f
-	}
-	var digester digest.Digester
-	if srcDigest != "" {
-		digester = srcDigest.Algorithm().Digester()
-		responseBody = io.TeeReader(responseBody, digester.Hash())
+		date = d.UTC()
 	}
-	// Write the output archive.  Set permissions for compatibility.
-	tw := tar.NewWriter(writer)
-	defer tw.Close()
+	// Set permissions for compatibility.
 	uid := 0
 	gid := 0
 	if chown != nil {
@@ -222,11 +257,11 @@ func getURL(src string, chown *idtools.IDPair, mountpoint, renameTarget string,
 	if chmod != "" {
 		p, err := mode.Parse(chmod)
 		if err != nil {
-			return fmt.Errorf("parsing chmod %q: %w", chmod, err)
+			return nil, fmt.Errorf("parsing chmod %q: %w", chmod, err)
 		}
 		mod = int64(p.Apply(os.FileMode(mod)))
 	}
-	hdr := tar.Header{
+	return &tar.Header{
 		Typeflag: tar.TypeReg,
 		Name:     name,
 		Size:     size,
@@ -234,23 +269,134 @@ func getURL(src string, chown *idtools.IDPair, mountpoint, renameTarget string,
 		Gid:      gid,
 		Mode:     mod,
 		ModTime:  date,
+	}, nil
+}
+
+// downloadURL retrieves the content at src, using the cache if one is
+// provided, and checks that it matches srcDigest if one is specified.
+func downloadURL(src string, srcDigest digest.Digest, certPath string, insecureSkipTLSVerify types.OptionalBool, cache *downloadcache.Cache) (*downloadcache.Download, error) {
+	httpClient, err := newHTTPClient(certPath, insecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		cache = downloadcache.OpenTemporary(tmpdir.GetTempDir())
 	}
-	err = tw.WriteHeader(&hdr)
+	download, err := cache.Get(httpClient, src, srcDigest)
 	if err != nil {
-		return fmt.Errorf("writing header: %w", err)
+		return nil, fmt.Errorf("downloading %q: %w", src, err)
 	}
+	return download, nil
+}
 
-	if _, err := io.Copy(tw, responseBody); err != nil {
+// writeURLFile writes a tar archive entry for downloaded content.
+func writeURLFile(tw *tar.Writer, src string, download *downloadcache.Download, name string, chown *idtools.IDPair, chmod string, timestamp *time.Time) error {
+	st, err := download.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := urlFileHeader(download.LastModified, name, st.Size(), chown, chmod, timestamp)
+	if err != nil {
+		return err
+	}
+	if err = tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	if _, err := io.Copy(tw, download); err != nil {
 		return fmt.Errorf("writing content from %q to tar stream: %w", src, err)
 	}
+	return nil
+}
+
+// getURL writes a tar archive containing the named content.  If cache is not
+// nil, the content is reused from the cache if it's there and unchanged, and
+// added to the cache if it isn't.
+func getURL(src string, chown *idtools.IDPair, renameTarget string, writer io.Writer, chmod string, srcDigest digest.Digest, certPath string, insecureSkipTLSVerify types.OptionalBool, timestamp *time.Time, cache *downloadcache.Cache) error {
+	url, err := url.Parse(src)
+	if err != nil {
+		return err
+	}
+	download, err := downloadURL(src, srcDigest, certPath, insecureSkipTLSVerify, cache)
+	if err != nil {
+		return err
+	}
+	defer download.Close()
+
+	// Figure out what to name the new content.
+	name := renameTarget
+	if name == "" {
+		name = path.Base(url.Path)
+	}
+	// Write the output archive.
+	tw := tar.NewWriter(writer)
+	defer tw.Close()
+	return writeURLFile(tw, src, download, name, chown, chmod, timestamp)
+}
+
+// getURLArchive writes a tar archive containing the contents of the archive
+// at the named location, after checking that it matches srcDigest, if one
+// was specified.  The archive can be compressed.  If the content is not an
+// archive, it is added as a file, as getURL would have done.  The cache is
+// used in the same way that getURL uses it.
+func getURLArchive(src string, chown *idtools.IDPair, writer io.Writer, chmod string, srcDigest digest.Digest, certPath string, insecureSkipTLSVerify types.OptionalBool, timestamp *time.Time, cache *downloadcache.Cache) error {
+	url, err := url.Parse(src)
+	if err != nil {
+		return err
+	}
+	download, err := downloadURL(src, srcDigest, certPath, insecureSkipTLSVerify, cache)
+	if err != nil {
+		return err
+	}
+	defer download.Close()
+
+	tw := tar.NewWriter(writer)
+	defer tw.Close()
 
-	if digester != nil {
-		if responseDigest := digester.Digest(); responseDigest != srcDigest {
-			return fmt.Errorf("unexpected response digest for %q: %s, want %s", src, responseDigest, srcDigest)
+	// If it's an archive, copy its contents.
+	if rc, _, err := compression.AutoDecompress(download); err == nil {
+		defer rc.Close()
+		// An archive with no entries is just an end-of-archive
+		// marker, which starts with a block of zeroes.
+		const blockSize = 512
+		br := bufio.NewReaderSize(rc, blockSize)
+		firstBlock, _ := br.Peek(blockSize)
+		emptyArchive := bytes.Equal(firstBlock, make([]byte, blockSize))
+		tr := tar.NewReader(br)
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) && emptyArchive {
+			return nil
+		}
+		if err == nil {
+			for err == nil {
+				if timestamp != nil {
+					hdr.ModTime = timestamp.UTC()
+					if !hdr.AccessTime.IsZero() {
+						hdr.AccessTime = timestamp.UTC()
+					}
+					if !hdr.ChangeTime.IsZero() {
+						hdr.ChangeTime = timestamp.UTC()
+					}
+				}
+				if err = tw.WriteHeader(hdr); err != nil {
+					return fmt.Errorf("writing header for %q from %q: %w", hdr.Name, src, err)
+				}
+				if _, err = io.Copy(tw, tr); err != nil {
+					return fmt.Errorf("extracting %q from %q: %w", hdr.Name, src, err)
+				}
+				hdr, err = tr.Next()
+			}
+			if !errors.Is(err, io.EOF) {
+				return fmt.Errorf("extracting contents of %q: %w", src, err)
+			}
+			return nil
 		}
 	}
 
-	return nil
+	// It's not an archive, so add it as a file.
+	if _, err := download.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewinding %q: %w", src, err)
+	}
+	return writeURLFile(tw, src, download, path.Base(url.Path), chown, chmod, timestamp)
 }
 
 // includeDirectoryAnyway returns true if "path" is a prefix for an exception
@@ -307,9 +453,38 @@ func getParentsPrefixToRemoveAndParentsToSkip(pattern string, contextDir string)
 	return prefix, out
 }
 
+// addSource tracks one of the sources passed to Add().
+type addSource struct {
+	src             string
+	localSourceStat *copier.StatsForGlob // for local sources, what the glob matched
+	items           []*addItem
+	itemsCopied     int
+}
+
+// addItem is a remote source, or an item which a local source's glob matched.
+// Add() can read items ahead of when it writes them into the container, but
+// always writes them in order.
+type addItem struct {
+	src            string
+	contentType    string // passed to the ContentDigester
+	get            func(writer io.WriteCloser, passthrough *copier.Passthrough) []error
+	putOptions     copier.PutOptions
+	usePassthrough bool
+	passthrough    *copier.Passthrough
+	reader         io.Reader
+	writer         io.WriteCloser
+	closeReader    func() error
+	started        chan struct{} // closed once the passthrough is set up
+	done           chan struct{} // closed once get() returns
+	errs           []error       // from get()
+	itemsCopied    int           // archive entries that get() produced
+}
+
 // Add copies the contents of the specified sources into the container's root
 // filesystem, optionally extracting contents of local files that look like
-// non-empty archives.
+// non-empty archives.  Content which is downloaded using HTTP(S) is kept in a
+// cache in the Builder's store, and is only downloaded again if the server
+// reports that it has changed.
 func (b *Builder) Add(destination string, extract bool, options AddAndCopyOptions, sources ...string) error {
 	mountPoint, err := b.Mount(b.MountLabel)
 	if err != nil {
@@ -368,13 +543,23 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 	// should have matched at least one item, otherwise we consider it an
 	// error.
 	var localSourceStats []*copier.StatsForGlob
+	var contextSession *copier.Session
 	if len(localSources) > 0 {
 		statOptions := copier.StatOptions{
 			CheckForArchives:   extract,
 			DisallowWildcard:   options.AllowWildcard == types.OptionalBoolFalse,
 			AllowEmptyWildcard: options.AllowEmptyWildcard == types.OptionalBoolTrue,
 		}
-		localSourceStats, err = copier.Stat(contextDir, contextDir, statOptions, localSources)
+		contextSession, err = copier.NewSession(contextDir)
+		if err != nil {
+			return fmt.Errorf("preparing to read from %q: %w", contextDir, err)
+		}
+		defer func() {
+			if err := contextSession.Close(); err != nil {
+				logrus.Debugf("closing copier session for %q: %v", contextDir, err)
+			}
+		}()
+		localSourceStats, err = contextSession.Stat(contextDir, statOptions, localSources)
 		if err != nil {
 			return fmt.Errorf("checking on sources under %q: %w", contextDir, err)
 		}
@@ -442,7 +627,8 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 	destCanBeFile := false
 	if len(sources) == 1 {
 		if len(remoteSources) == 1 {
-			destCanBeFile = urlsource.IsRemote(sources[0])
+			destCanBeFile = urlsource.IsRemote(sources[0]) && !options.ExtractRemoteArchives
+			destMustBeDirectory = destMustBeDirectory || options.ExtractRemoteArchives
 		}
 		if len(localSources) == 1 {
 			item := localSourceStats[0].Results[localSourceStats[0].Globbed[0]]
@@ -578,32 +764,114 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 		putDir = extractDirectory
 	}
 
-	// Copy each source in turn.
+	// Start a session for writing everything into the container.
+	var putSession *copier.Session
+	if !options.DryRun {
+		if putSession, err = copier.NewSession(putRoot); err != nil {
+			return fmt.Errorf("preparing to write to %q: %w", putRoot, err)
+		}
+		defer func() {
+			if err := putSession.Close(); err != nil {
+				logrus.Debugf("closing copier session for %q: %v", putRoot, err)
+			}
+		}()
+	}
+
+	// If we're going to be downloading anything, keep a copy of it, so
+	// that we won't need to download it again if it doesn't change.
+	var downloadCache *downloadcache.Cache
+	if len(remoteSources) > len(gitSources) {
+		if downloadCache, err = downloadcache.Open(downloadcache.Directory(b.store.GraphRoot())); err != nil {
+			logrus.Debugf("not caching downloads: %v", err)
+		}
+	}
+
+	// Work out what we're going to copy, in order.
+	var addSources []*addSource
+	var items []*addItem
+	newItem := func(src, contentType string, putOptions copier.PutOptions, get func(writer io.WriteCloser, passthrough *copier.Passthrough) []error) *addItem {
+		item := &addItem{
+			src:         src,
+			contentType: contentType,
+			putOptions:  putOptions,
+			get:         get,
+			started:     make(chan struct{}),
+			done:        make(chan struct{}),
+		}
+		if options.Jobs > 1 {
+			spooled := spool.New(tmpdir.GetTempDir(), 0)
+			item.reader, item.writer, item.closeReader = spooled, spooled, spooled.CloseReader
+		} else {
+			pipeReader, pipeWriter := io.Pipe()
+			item.reader, item.writer, item.closeReader = pipeReader, pipeWriter, pipeReader.Close
+		}
+		items = append(items, item)
+		return item
+	}
 	for _, src := range sources {
-		var multiErr *multierror.Error
-		var getErr, closeErr, renameErr, putErr error
-		var wg sync.WaitGroup
+		source := &addSource{src: src}
+		addSources = append(addSources, source)
 		if urlsource.IsRemote(src) || urlsource.IsGit(src) {
-			pipeReader, pipeWriter := io.Pipe()
 			var srcDigest digest.Digest
 			if options.Checksum != "" {
-				srcDigest, err = digest.Parse(options.Checksum)
+				if urlsource.IsGit(src) {
+					err = gitsource.ValidateChecksum(options.Checksum)
+				} else {
+					srcDigest, err = digest.Parse(options.Checksum)
+				}
 				if err != nil {
 					return fmt.Errorf("invalid checksum flag: %w", err)
 				}
 			}
-
-			wg.Add(1)
+			putOptions := copier.PutOptions{
+				UIDMap:        destUIDMap,
+				GIDMap:        destGIDMap,
+				ChownDirs:     nil,
+				ChmodDirs:     nil,
+				ChownFiles:    nil,
+				ChmodFiles:    nil,
+				IgnoreDevices: userns.RunningInUserNS(),
+				Timestamp:     options.Timestamp,
+			}
+			var get func(io.WriteCloser, *copier.Passthrough) []error
 			if urlsource.IsGit(src) {
-				go func() {
-					defer wg.Done()
-					defer pipeWriter.Close()
-					var cloneDir, subdir string
-					cloneDir, subdir, getErr = define.TempDirForURL(tmpdir.GetTempDir(), "", src)
-					if getErr != nil {
-						return
+				get = func(writer io.WriteCloser, _ *copier.Passthrough) []error {
+					defer writer.Close()
+					cloneDir, err := os.MkdirTemp(tmpdir.GetTempDir(), "buildah-git")
+					if err != nil {
+						return []error{fmt.Errorf("creating temporary directory for %q: %w", src, err)}
 					}
 					defer os.RemoveAll(cloneDir)
+					cloneOptions := gitsource.Options{
+						Checksum:   options.Checksum,
+						Submodules: true,
+						AuthToken:  options.GitAuthToken,
+					}
+					if options.GitSSHSource != nil && urlsource.IsGitSSH(src) {
+						agent, err := sshagent.NewAgentServer(options.GitSSHSource)
+						if err != nil {
+							return []error{fmt.Errorf("starting SSH agent for %q: %w", src, err)}
+						}
+						if cloneOptions.SSHAuthSock, err = agent.Serve(""); err != nil {
+							return []error{fmt.Errorf("starting SSH agent for %q: %w", src, err)}
+						}
+						defer func() {
+							if err := agent.Shutdown(); err != nil {
+								logrus.Errorf("shutting down SSH agent: %v", err)
+							}
+						}()
+					}
+					combinedOutput, subdir, err := gitsource.Clone(src, cloneDir, cloneOptions)
+					if err != nil {
+						if len(combinedOutput) > 0 {
+							return []error{fmt.Errorf("cloning %q:\n%s: %w", src, string(combinedOutput), err)}
+						}
+						return []error{fmt.Errorf("cloning %q: %w", src, err)}
+					}
+					repositoryDir, err := securejoin.SecureJoin(cloneDir, subdir)
+					if err != nil {
+						return []error{fmt.Errorf("resolving subdirectory %q in %q: %w", subdir, src, err)}
+					}
 					getOptions := copier.GetOptions{
 						UIDMap:             srcUIDMap,
 						GIDMap:             srcGIDMap,
@@ -619,62 +887,41 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 						StripStickyBit:     options.StripStickyBit,
 						Timestamp:          options.Timestamp,
 					}
-					writer := io.WriteCloser(pipeWriter)
-					repositoryDir := filepath.Join(cloneDir, subdir)
-					getErr = copier.Get(repositoryDir, repositoryDir, getOptions, []string{"."}, writer)
-				}()
-			} else {
-				go func() {
-					getErr = retry.IfNecessary(context.TODO(), func() error {
-						return getURL(src, chownFiles, mountPoint, renameTarget, pipeWriter, options.Chmod, srcDigest, options.CertPath, options.InsecureSkipTLSVerify, options.Timestamp)
+					if err := copier.Get(repositoryDir, repositoryDir, getOptions, []string{"."}, writer); err != nil {
+						return []error{fmt.Errorf("reading %q: %w", src, err)}
+					}
+					return nil
+				}
+			} else if options.ExtractRemoteArchives {
+				get = func(writer io.WriteCloser, _ *copier.Passthrough) []error {
+					defer writer.Close()
+					err := retry.IfNecessary(context.TODO(), func() error {
+						return getURLArchive(src, chownFiles, writer, options.Chmod, srcDigest, options.CertPath, options.InsecureSkipTLSVerify, options.Timestamp, downloadCache)
 					}, &retry.Options{
 						MaxRetry: options.MaxRetries,
 						Delay:    options.RetryDelay,
 					})
-					pipeWriter.Close()
-					wg.Done()
-				}()
-			}
-
-			wg.Go(func() {
-				b.ContentDigester.Start("")
-				hashCloser := b.ContentDigester.Hash()
-				hasher := io.Writer(hashCloser)
-				if options.Hasher != nil {
-					hasher = io.MultiWriter(hasher, options.Hasher)
-				}
-				if options.DryRun {
-					_, putErr = io.Copy(hasher, pipeReader)
-				} else {
-					putOptions := copier.PutOptions{
-						UIDMap:        destUIDMap,
-						GIDMap:        destGIDMap,
-						ChownDirs:     nil,
-						ChmodDirs:     nil,
-						ChownFiles:    nil,
-						ChmodFiles:    nil,
-						IgnoreDevices: userns.RunningInUserNS(),
-						Timestamp:     options.Timestamp,
+					if err != nil {
+						return []error{fmt.Errorf("reading %q: %w", src, err)}
 					}
-					putErr = copier.Put(putRoot, putDir, putOptions, io.TeeReader(pipeReader, hasher))
+					return nil
 				}
-				hashCloser.Close()
-				pipeReader.Close()
-			})
-			wg.Wait()
-			if getErr != nil {
-				getErr = fmt.Errorf("reading %q: %w", src, getErr)
-			}
-			if putErr != nil {
-				putErr = fmt.Errorf("storing %q: %w", src, putErr)
-			}
-			multiErr = multierror.Append(getErr, putErr)
-			if multiErr != nil && multiErr.ErrorOrNil() != nil {
-				if len(multiErr.Errors) > 1 {
-					return multiErr.ErrorOrNil()
+			} else {
+				get = func(writer io.WriteCloser, _ *copier.Passthrough) []error {
+					defer writer.Close()
+					err := retry.IfNecessary(context.TODO(), func() error {
+						return getURL(src, chownFiles, renameTarget, writer, options.Chmod, srcDigest, options.CertPath, options.InsecureSkipTLSVerify, options.Timestamp, downloadCache)
+					}, &retry.Options{
+						MaxRetry: options.MaxRetries,
+						Delay:    options.RetryDelay,
+					})
+					if err != nil {
+						return []error{fmt.Errorf("reading %q: %w", src, err)}
+					}
+					return nil
 				}
-				return multiErr.Errors[0]
 			}
+			source.items = append(source.items, newItem(src, "", putOptions, get))
 			continue
 		}
 
@@ -683,19 +930,17 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 		}
 
 		// Dig out the result of running glob+stat on this source spec.
-		var localSourceStat *copier.StatsForGlob
 		for _, st := range localSourceStats {
 			if st.Glob == src {
-				localSourceStat = st
+				source.localSourceStat = st
 				break
 			}
 		}
-		if localSourceStat == nil {
+		if source.localSourceStat == nil {
 			continue
 		}
 		// Iterate through every item that matched the glob.
-		itemsCopied := 0
-		for _, globbed := range localSourceStat.Globbed {
+		for _, globbed := range source.localSourceStat.Globbed {
 			rel := globbed
 			if filepath.IsAbs(globbed) {
 				if rel, err = filepath.Rel(contextDir, globbed); err != nil {
@@ -715,14 +960,14 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 					// non-directories that are excluded are excluded, no question, but
 					// directories can only be skipped if we don't have to allow for the
 					// possibility of finding things to include under them
-					globInfo := localSourceStat.Results[globbed]
+					globInfo := source.localSourceStat.Results[globbed]
 					if !globInfo.IsDir || !includeDirectoryAnyway(rel, pm) {
 						continue
 					}
 				} else {
 					// if the destination is a directory that doesn't yet exist, and is not excluded, let's copy it.
 					if newDestDirFound {
-						itemsCopied++
+						source.itemsCopied++
 					}
 				}
 			} else {
@@ -730,16 +975,31 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 				// directory if we were told to copy the context directory itself.  We won't
 				// actually copy it, but we need to make sure that we don't produce an error
 				// due to potentially not having anything in the tarstream that we passed.
-				itemsCopied++
+				source.itemsCopied++
 			}
-			st := localSourceStat.Results[globbed]
+			st := source.localSourceStat.Results[globbed]
 			if options.Link && st.ModTime.After(latestTimestamp) {
 				latestTimestamp = st.ModTime
 			}
-			pipeReader, pipeWriter := io.Pipe()
-			wg.Go(func() {
+			contentType := "file"
+			if st.IsDir {
+				contentType = "dir"
+			}
+			putOptions := copier.PutOptions{
+				UIDMap:          destUIDMap,
+				GIDMap:          destGIDMap,
+				DefaultDirOwner: chownDirs,
+				DefaultDirMode:  nil,
+				ChownDirs:       nil,
+				ChmodDirs:       nil,
+				ChownFiles:      nil,
+				ChmodFiles:      nil,
+				IgnoreDevices:   userns.RunningInUserNS(),
+				Timestamp:       options.Timestamp,
+			}
+			var item *addItem
+			item = newItem(src, contentType, putOptions, func(writer io.WriteCloser, passthrough *copier.Passthrough) []error {
 				renamedItems := 0
-				writer := io.WriteCloser(pipeWriter)
 				if renameTarget != "" {
 					writer = newTarFilterer(writer, func(hdr *tar.Header) (bool, bool, io.Reader) {
 						hdr.Name = renameTarget
@@ -767,7 +1027,7 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 					})
 				}
 				writer = newTarFilterer(writer, func(_ *tar.Header) (bool, bool, io.Reader) {
-					itemsCopied++
+					item.itemsCopied++
 					return false, false, nil
 				})
 				getOptions := copier.GetOptions{
@@ -787,74 +1047,168 @@ func (b *Builder) Add(destination string, extract bool, options AddAndCopyOption
 					DisallowWildcard:   options.AllowWildcard == types.OptionalBoolFalse,
 					AllowEmptyWildcard: options.AllowEmptyWildcard == types.OptionalBoolTrue,
 					NoDerefSymlinks:    options.FollowSymlink == types.OptionalBoolFalse,
+					Passthrough:        passthrough,
 				}
-				getErr = copier.Get(contextDir, contextDir, getOptions, []string{globbedToGlobbable(globbed)}, writer)
-				closeErr = writer.Close()
-				if renameTarget != "" && renamedItems > 1 {
-					renameErr = fmt.Errorf("internal error: renamed %d items when we expected to only rename 1", renamedItems)
+				var errs []error
+				if err := contextSession.Get(contextDir, getOptions, []string{globbedToGlobbable(globbed)}, writer); err != nil {
+					errs = append(errs, fmt.Errorf("reading %q: %w", src, err))
 				}
-			})
-			wg.Go(func() {
-				if st.IsDir {
-					b.ContentDigester.Start("dir")
-				} else {
-					b.ContentDigester.Start("file")
+				if err := writer.Close(); err != nil {
+					errs = append(errs, fmt.Errorf("closing %q: %w", src, err))
 				}
-				hashCloser := b.ContentDigester.Hash()
-				hasher := io.Writer(hashCloser)
-				if options.Hasher != nil {
-					hasher = io.MultiWriter(hasher, options.Hasher)
+				if renameTarget != "" && renamedItems > 1 {
+					errs = append(errs, fmt.Errorf("renaming %q: %w", src, fmt.Errorf("internal error: renamed %d items when we expected to only rename 1", renamedItems)))
 				}
-				if options.DryRun {
-					_, putErr = io.Copy(hasher, pipeReader)
+				return errs
+			})
+			item.usePassthrough = !options.DryRun
+			source.items = append(source.items, item)
+		}
+	}
+
+	// Start reading items, up to options.Jobs at a time, in order.  An
+	// item's slot is freed when we're done writing it into the container,
+	// so we never read too far ahead of where we're writing.
+	ctx, cancel := context.WithCancel(context.TODO())
+	jobs := semaphore.NewWeighted(int64(max(options.Jobs, 1)))
+	var readers sync.WaitGroup
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		for _, item := range items {
+			if err := jobs.Acquire(ctx, 1); err != nil {
+				return
+			}
+			if item.usePassthrough {
+				passthrough, err := copier.NewPassthrough()
+				if err != nil {
+					logrus.Debugf("not passing file descriptors from %q: %v", item.src, err)
 				} else {
-					putOptions := copier.PutOptions{
-						UIDMap:          destUIDMap,
-						GIDMap:          destGIDMap,
-						DefaultDirOwner: chownDirs,
-						DefaultDirMode:  nil,
-						ChownDirs:       nil,
-						ChmodDirs:       nil,
-						ChownFiles:      nil,
-						ChmodFiles:      nil,
-						IgnoreDevices:   userns.RunningInUserNS(),
-						Timestamp:       options.Timestamp,
-					}
-					putErr = copier.Put(putRoot, putDir, putOptions, io.TeeReader(pipeReader, hasher))
+					item.passthrough = passthrough
+					item.putOptions.Passthrough = passthrough
 				}
-				hashCloser.Close()
-				pipeReader.Close()
+			}
+			close(item.started)
+			readers.Go(func() {
+				item.errs = item.get(item.writer, item.passthrough)
+				close(item.done)
 			})
+		}
+	}()
+	defer func() {
+		// Stop reading anything that we won't be writing.
+		cancel()
+		for _, item := range items {
+			item.closeReader()
+		}
+		<-schedulerDone
+		readers.Wait()
+		for _, item := range items {
+			if err := item.passthrough.Close(); err != nil {
+				logrus.Debugf("closing file descriptor passthrough for %q: %v", item.src, err)
+			}
+		}
+	}()
 
-			wg.Wait()
-			if getErr != nil {
-				getErr = fmt.Errorf("reading %q: %w", src, getErr)
+	// If we're recording what we write, figure out where it'll be in the
+	// container's filesystem.
+	var contentManifest *ContentManifest
+	var manifestDirectory string
+	manifestIndex := make(map[string]int)
+	if options.ContentManifest && !options.DryRun {
+		command := "ADD"
+		if !extract {
+			command = "COPY"
+		}
+		contentManifest = &ContentManifest{
+			Command:     command,
+			Destination: destination,
+		}
+		for _, src := range sources {
+			if options.ContextDir != "" && !urlsource.IsRemote(src) && !urlsource.IsGit(src) {
+				// Record local sources relative to the context directory.
+				if rel, err := filepath.Rel(contextDir, src); err == nil && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+					src = rel
+				}
 			}
-			if closeErr != nil {
-				closeErr = fmt.Errorf("closing %q: %w", src, closeErr)
+			contentManifest.Sources = append(contentManifest.Sources, src)
+		}
+		manifestRoot := mountPoint
+		if options.Link {
+			manifestRoot = stagingDir
+		}
+		rel, err := filepath.Rel(manifestRoot, putDir)
+		if err != nil {
+			return fmt.Errorf("computing path of %q relative to %q: %w", putDir, manifestRoot, err)
+		}
+		manifestDirectory = filepath.ToSlash(rel)
+	}
+
+	// Write each item into the container in turn.
+	for _, source := range addSources {
+		for _, item := range source.items {
+			<-item.started
+			b.ContentDigester.Start(item.contentType)
+			hashCloser := b.ContentDigester.Hash()
+			hasher := io.Writer(hashCloser)
+			if options.Hasher != nil {
+				hasher = io.MultiWriter(hasher, options.Hasher)
+			}
+			var manifestWriter *copier.ManifestWriter
+			if contentManifest != nil {
+				manifestWriter = copier.NewManifestWriter(manifestDirectory)
+				hasher = io.MultiWriter(hasher, manifestWriter)
 			}
-			if renameErr != nil {
-				renameErr = fmt.Errorf("renaming %q: %w", src, renameErr)
+			var putErr error
+			if options.DryRun {
+				_, putErr = io.Copy(hasher, item.reader)
+			} else {
+				putErr = putSession.Put(putDir, item.putOptions, io.TeeReader(item.reader, hasher))
+			}
+			hashCloser.Close()
+			if manifestWriter != nil {
+				if err := manifestWriter.Close(); err != nil && putErr == nil {
+					putErr = fmt.Errorf("recording manifest: %w", err)
+				}
+				// Later items replace earlier ones with the same name.
+				for _, entry := range manifestWriter.Entries() {
+					if i, ok := manifestIndex[entry.Path]; ok {
+						contentManifest.Items[i] = entry
+						continue
+					}
+					manifestIndex[entry.Path] = len(contentManifest.Items)
+					contentManifest.Items = append(contentManifest.Items, entry)
+				}
+			}
+			item.closeReader()
+			<-item.done
+			jobs.Release(1)
+			if err := item.passthrough.Close(); err != nil {
+				logrus.Debugf("closing file descriptor passthrough for %q: %v", item.src, err)
 			}
 			if putErr != nil {
-				putErr = fmt.Errorf("storing %q: %w", src, putErr)
+				putErr = fmt.Errorf("storing %q: %w", item.src, putErr)
 			}
-			multiErr = multierror.Append(getErr, closeErr, renameErr, putErr)
-			if multiErr != nil && multiErr.ErrorOrNil() != nil {
+			multiErr := multierror.Append(nil, append(item.errs, putErr)...)
+			if multiErr.ErrorOrNil() != nil {
 				if len(multiErr.Errors) > 1 {
 					return multiErr.ErrorOrNil()
 				}
 				return multiErr.Errors[0]
 			}
+			source.itemsCopied += item.itemsCopied
 		}
-		if itemsCopied == 0 {
+		if source.localSourceStat != nil && source.itemsCopied == 0 {
 			excludesFile := ""
 			if options.IgnoreFile != "" {
 				excludesFile = " using " + options.IgnoreFile
 			}
-			return fmt.Errorf("no items matching glob %q copied (%d filtered out%s): %w", localSourceStat.Glob, len(localSourceStat.Globbed), excludesFile, syscall.ENOENT)
+			return fmt.Errorf("no items matching glob %q copied (%d filtered out%s): %w", source.localSourceStat.Glob, len(source.localSourceStat.Globbed), excludesFile, syscall.ENOENT)
 		}
 	}
+	if contentManifest != nil {
+		b.ContentManifests = append(b.ContentManifests, *contentManifest)
+	}
 
 	if options.Link {
 		if !latestTimestamp.IsZero() {
@@ -1,9 +1,18 @@
 package buildah
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	digest "github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.podman.io/buildah/internal/downloadcache"
 	"go.podman.io/image/v5/types"
 )
 
@@ -36,3 +45,80 @@ func TestDirCopyContentsToKeepDirectoryNames(t *testing.T) {
 		})
 	}
 }
+
+func TestGetURLArchive(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"a": "first", "b": "second"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0o644}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	var emptyArchive bytes.Buffer
+	require.NoError(t, tar.NewWriter(&emptyArchive).Close())
+
+	content := map[string][]byte{
+		"/archive.tar.gz": archive.Bytes(),
+		"/empty.tar":      emptyArchive.Bytes(),
+		"/file.txt":       []byte("not an archive"),
+		"/empty.txt":      {},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"unchanging"`)
+		if r.Header.Get("If-None-Match") == `"unchanging"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(content[r.URL.Path])
+	}))
+	defer server.Close()
+
+	read := func(src string, srcDigest digest.Digest, cache *downloadcache.Cache) (map[string]string, error) {
+		var output bytes.Buffer
+		if err := getURLArchive(server.URL+src, nil, &output, "", srcDigest, "", types.OptionalBoolUndefined, nil, cache); err != nil {
+			return nil, err
+		}
+		items := make(map[string]string)
+		tr := tar.NewReader(&output)
+		hdr, err := tr.Next()
+		for err == nil {
+			data, readErr := io.ReadAll(tr)
+			require.NoError(t, readErr)
+			items[hdr.Name] = string(data)
+			hdr, err = tr.Next()
+		}
+		assert.ErrorIs(t, err, io.EOF)
+		return items, nil
+	}
+
+	cache, err := downloadcache.Open(t.TempDir())
+	require.NoError(t, err)
+	items, err := read("/archive.tar.gz", digest.FromBytes(archive.Bytes()), cache)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "first", "b": "second"}, items)
+
+	items, err = read("/file.txt", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"file.txt": "not an archive"}, items)
+
+	items, err = read("/empty.tar", "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, items, "an archive with no entries should be unpacked to nothing")
+
+	items, err = read("/empty.txt", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"empty.txt": ""}, items)
+
+	_, err = read("/archive.tar.gz", digest.FromString("something else"), nil)
+	assert.ErrorContains(t, err, "unexpected response digest")
+
+	// the server says it hasn't changed, so we should use the cached copy
+	content["/archive.tar.gz"] = []byte("changed")
+	items, err = read("/archive.tar.gz", "", cache)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "first", "b": "second"}, items)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"time"
 
@@ -198,6 +199,9 @@ type Builder struct {
 	// to PrependedEmptyLayers and AppendedEmptyLayers in the committed
 	// image is not guaranteed.
 	PrependedLinkedLayers, AppendedLinkedLayers []LinkedLayer
+	// ContentManifests describe the items written by calls to Add() for
+	// which AddAndCopyOptions.ContentManifest was set.
+	ContentManifests []ContentManifest `json:"content-manifests,omitempty"`
 }
 
 // BuilderInfo are used as objects to display container information
@@ -232,6 +236,7 @@ type BuilderInfo struct {
 	Devices          define.ContainerDevices
 	DeviceSpecs      []string
 	CDIConfigDir     string
+	ContentManifests []ContentManifest `json:",omitempty"`
 }
 
 // GetBuildInfo gets a pointer to a Builder object and returns a BuilderInfo object from it.
@@ -268,6 +273,7 @@ func GetBuildInfo(b *Builder) BuilderInfo {
 		Devices:               b.Devices,
 		DeviceSpecs:           b.DeviceSpecs,
 		CDIConfigDir:          b.CDIConfigDir,
+		ContentManifests:      slices.Clone(b.ContentManifests),
 	}
 }
 
@@ -314,6 +320,10 @@ type BuilderOptions struct {
 	// ReportWriter is an io.Writer which will be used to log the reading
 	// of the source image from a registry, if we end up pulling the image.
 	ReportWriter io.Writer
+	// Progress, if set, will be sent updates on the progress of copying
+	// each of the source image's blobs, if we end up pulling the image.
+	// It is not closed.
+	Progress chan types.ProgressProperties
 	// github.com/containers/image/types SystemContext to hold credentials
 	// and other authentication/authorization information.
 	SystemContext *types.SystemContext
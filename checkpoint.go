@@ -0,0 +1,257 @@
+package buildah
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"go.podman.io/storage"
+	"go.podman.io/storage/pkg/archive"
+	"go.podman.io/storage/pkg/fileutils"
+	"go.podman.io/storage/pkg/ioutils"
+)
+
+const (
+	checkpointsDir      = "checkpoints"
+	checkpointLayerFile = "layer.tar"
+)
+
+var checkpointNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func (b *Builder) checkpointDirectory(name string) (string, error) {
+	if name != "" && !checkpointNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid checkpoint name %q: names must match %s", name, checkpointNameRegexp.String())
+	}
+	cdir, err := b.store.ContainerDirectory(b.ContainerID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cdir, checkpointsDir, name), nil
+}
+
+// Checkpoint saves a copy of the contents of the working container's
+// read-write layer, along with its configuration and history, under the
+// specified name, so that they can be restored later using Rollback.  Any
+// checkpoint which was previously saved using the same name is replaced.
+func (b *Builder) Checkpoint(name string) error {
+	dir, err := b.checkpointDirectory(name)
+	if err != nil {
+		return err
+	}
+	container, err := b.store.Container(b.ContainerID)
+	if err != nil {
+		return fmt.Errorf("locating build container %q: %w", b.ContainerID, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o700); err != nil {
+		return fmt.Errorf("creating checkpoint directory: %w", err)
+	}
+	tmpdir, err := os.MkdirTemp(filepath.Dir(dir), ".checkpoint")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpdir); err != nil {
+			logrus.Debugf("removing %q: %v", tmpdir, err)
+		}
+	}()
+
+	// Save the changes that have been made to the container's filesystem.
+	f, err := os.OpenFile(filepath.Join(tmpdir, checkpointLayerFile), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("creating checkpoint: %w", err)
+	}
+	defer f.Close()
+	noCompression := archive.Uncompressed
+	rc, err := b.store.Diff("", container.LayerID, &storage.DiffOptions{Compression: &noCompression})
+	if err != nil {
+		return fmt.Errorf("reading changes to build container %q: %w", b.ContainerID, err)
+	}
+	_, err = io.Copy(f, rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("saving changes to build container %q: %w", b.ContainerID, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("saving changes to build container %q: %w", b.ContainerID, err)
+	}
+
+	// Save the configuration and history.
+	buildstate, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if err := ioutils.AtomicWriteFile(filepath.Join(tmpdir, stateFile), buildstate, 0o600); err != nil {
+		return fmt.Errorf("saving builder state: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("replacing checkpoint %q: %w", name, err)
+	}
+	if err := os.Rename(tmpdir, dir); err != nil {
+		return fmt.Errorf("saving checkpoint %q: %w", name, err)
+	}
+	return nil
+}
+
+// Checkpoints returns the sorted list of the names of checkpoints which have
+// been saved for the working container.
+func (b *Builder) Checkpoints() ([]string, error) {
+	dir, err := b.checkpointDirectory("")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && checkpointNameRegexp.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// RemoveCheckpoint removes a checkpoint which was saved using Checkpoint.
+func (b *Builder) RemoveCheckpoint(name string) error {
+	dir, err := b.checkpointDirectory(name)
+	if err != nil {
+		return err
+	}
+	if err := fileutils.Exists(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("build container %q has no checkpoint named %q: %w", b.Container, name, fs.ErrNotExist)
+		}
+		return fmt.Errorf("checkpoint %q: %w", name, err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing checkpoint %q: %w", name, err)
+	}
+	return nil
+}
+
+// Rollback restores the contents of the working container's read-write layer,
+// along with its configuration and history, to the state that they were in
+// when the named checkpoint was saved using Checkpoint.  Because a container's
+// layer can't be replaced, a new container is created in place of the working
+// container, and given its names, so the Builder's ContainerID will change.
+// The working container's checkpoints are kept.
+func (b *Builder) Rollback(name string) error {
+	dir, err := b.checkpointDirectory(name)
+	if err != nil {
+		return err
+	}
+	buildstate, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("build container %q has no checkpoint named %q: %w", b.Container, name, fs.ErrNotExist)
+		}
+		return fmt.Errorf("reading checkpoint %q: %w", name, err)
+	}
+	restored := &Builder{}
+	if err := json.Unmarshal(buildstate, restored); err != nil {
+		return fmt.Errorf("parsing checkpoint %q: %w", name, err)
+	}
+	container, err := b.store.Container(b.ContainerID)
+	if err != nil {
+		return fmt.Errorf("locating build container %q: %w", b.ContainerID, err)
+	}
+	if restored.FromImageID != container.ImageID {
+		return fmt.Errorf("checkpoint %q was not saved for build container %q", name, b.ContainerID)
+	}
+
+	// Create a new container with the same base image, ID mappings, and
+	// SELinux labels, and apply the saved changes to its layer.
+	var flags map[string]any
+	if b.ProcessLabel != "" && b.MountLabel != "" {
+		flags = map[string]any{
+			"ProcessLabel": b.ProcessLabel,
+			"MountLabel":   b.MountLabel,
+		}
+	}
+	coptions := storage.ContainerOptions{
+		IDMappingOptions: storage.IDMappingOptions{
+			HostUIDMapping: len(container.UIDMap) == 0,
+			HostGIDMapping: len(container.GIDMap) == 0,
+			UIDMap:         container.UIDMap,
+			GIDMap:         container.GIDMap,
+		},
+		Flags:    flags,
+		Volatile: true,
+	}
+	replacement, err := b.store.CreateContainer("", nil, container.ImageID, "", "", &coptions)
+	if err != nil {
+		return fmt.Errorf("creating replacement for build container %q: %w", b.ContainerID, err)
+	}
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			if err := b.store.DeleteContainer(replacement.ID); err != nil {
+				logrus.Debugf("removing replacement container %q: %v", replacement.ID, err)
+			}
+		}
+	}()
+	f, err := os.Open(filepath.Join(dir, checkpointLayerFile))
+	if err != nil {
+		return fmt.Errorf("reading checkpoint %q: %w", name, err)
+	}
+	defer f.Close()
+	if _, err := b.store.ApplyDiff(replacement.LayerID, f); err != nil {
+		return fmt.Errorf("restoring checkpoint %q: %w", name, err)
+	}
+
+	// Bring the checkpoints along, and record the restored state as the
+	// replacement container's state.
+	rdir, err := b.store.ContainerDirectory(replacement.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Dir(dir), filepath.Join(rdir, checkpointsDir)); err != nil {
+		return fmt.Errorf("moving checkpoints to replacement container: %w", err)
+	}
+	restored.store = b.store
+	restored.ContainerID = replacement.ID
+	restored.Container = b.Container
+	restored.MountPoint = ""
+	restored.ProcessLabel = replacement.ProcessLabel()
+	restored.MountLabel = replacement.MountLabel()
+	if err := restored.Save(); err != nil {
+		if err2 := os.Rename(filepath.Join(rdir, checkpointsDir), filepath.Dir(dir)); err2 != nil {
+			logrus.Errorf("moving checkpoints back to build container %q: %v", b.ContainerID, err2)
+		}
+		return err
+	}
+
+	// Swap the containers.
+	if _, err := b.store.Unmount(b.ContainerID, true); err != nil {
+		logrus.Debugf("unmounting build container %q: %v", b.ContainerID, err)
+	}
+	if err := b.store.DeleteContainer(b.ContainerID); err != nil {
+		if err2 := os.Rename(filepath.Join(rdir, checkpointsDir), filepath.Dir(dir)); err2 != nil {
+			logrus.Errorf("moving checkpoints back to build container %q: %v", b.ContainerID, err2)
+		}
+		return fmt.Errorf("removing build container %q: %w", b.ContainerID, err)
+	}
+	succeeded = true
+	if err := b.store.AddNames(replacement.ID, container.Names); err != nil {
+		return fmt.Errorf("renaming replacement container %q: %w", replacement.ID, err)
+	}
+	restoredBuilder, err := OpenBuilder(b.store, replacement.ID)
+	if err != nil {
+		return err
+	}
+	*b = *restoredBuilder
+	return nil
+}
@@ -0,0 +1,98 @@
+package buildah
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.podman.io/storage"
+	storageTypes "go.podman.io/storage/types"
+)
+
+func TestCheckpointRollback(t *testing.T) {
+	ctx := context.TODO()
+	graphDriverName := os.Getenv("STORAGE_DRIVER")
+	if graphDriverName == "" {
+		graphDriverName = "vfs"
+	}
+	t.Logf("using storage driver %q", graphDriverName)
+	store, err := storage.GetStore(storageTypes.StoreOptions{
+		RunRoot:         t.TempDir(),
+		GraphRoot:       t.TempDir(),
+		GraphDriverName: graphDriverName,
+	})
+	require.NoError(t, err, "initializing storage")
+	t.Cleanup(func() { _, err := store.Shutdown(true); assert.NoError(t, err) })
+
+	b, err := NewBuilder(ctx, store, BuilderOptions{
+		FromImage: "scratch",
+		NamespaceOptions: []NamespaceOption{{
+			Name: string(rspec.NetworkNamespace),
+			Host: true,
+		}},
+		SystemContext: &testSystemContext,
+	})
+	require.NoError(t, err, "creating builder")
+	t.Cleanup(func() { assert.NoError(t, b.Delete()) })
+
+	contentDir := t.TempDir()
+	write := func(name string) string {
+		filename := filepath.Join(contentDir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(name), 0o644))
+		return filename
+	}
+	exists := func(name string) bool {
+		mountPoint, err := b.Mount("")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, b.Unmount()) }()
+		_, err = os.Stat(filepath.Join(mountPoint, name))
+		return err == nil
+	}
+
+	require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, write("one")))
+	b.SetEnv("A", "1")
+	require.NoError(t, b.Save())
+	require.NoError(t, b.Checkpoint("first"))
+
+	require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, write("two")))
+	b.SetEnv("A", "2")
+	require.NoError(t, b.Save())
+	require.NoError(t, b.Checkpoint("second"))
+
+	names, err := b.Checkpoints()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, names)
+
+	originalID, originalName := b.ContainerID, b.Container
+	require.NoError(t, b.Rollback("first"))
+	assert.NotEqual(t, originalID, b.ContainerID, "expected the container to be replaced")
+	assert.Equal(t, originalName, b.Container, "expected the container's name to be kept")
+	assert.Equal(t, []string{"A=1"}, b.Env())
+	assert.True(t, exists("one"))
+	assert.False(t, exists("two"))
+	_, err = store.Container(originalID)
+	assert.ErrorIs(t, err, storage.ErrContainerUnknown, "expected the old container to be removed")
+
+	reopened, err := OpenBuilder(store, originalName)
+	require.NoError(t, err)
+	assert.Equal(t, b.ContainerID, reopened.ContainerID)
+
+	names, err = b.Checkpoints()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, names, "expected checkpoints to be kept")
+
+	require.NoError(t, b.Rollback("second"))
+	assert.Equal(t, []string{"A=2"}, b.Env())
+	assert.True(t, exists("one"))
+	assert.True(t, exists("two"))
+
+	require.NoError(t, b.RemoveCheckpoint("second"))
+	assert.ErrorIs(t, b.Rollback("second"), fs.ErrNotExist)
+	assert.ErrorIs(t, b.RemoveCheckpoint("second"), fs.ErrNotExist)
+	assert.Error(t, b.Checkpoint("../escape"), "expected invalid name to be rejected")
+}
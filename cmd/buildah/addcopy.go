@@ -46,6 +46,7 @@ type addCopyResults struct {
 	allowWildcard      bool
 	allowEmptyWildcard bool
 	noFollowSymlinks   bool
+	contentManifest    bool
 }
 
 func createCommand(addCopy string, desc string, short string, opts *addCopyResults) *cobra.Command {
@@ -79,6 +80,7 @@ func applyFlagVars(flags *pflag.FlagSet, opts *addCopyResults) {
 	flags.StringVar(&opts.checksum, "checksum", "", "checksum the HTTP source content")
 	flags.StringVar(&opts.chown, "chown", "", "set the user and group ownership of the destination content")
 	flags.StringVar(&opts.chmod, "chmod", "", "set the access permissions of the destination content")
+	flags.BoolVar(&opts.contentManifest, "content-manifest", false, "record the path, type, ownership, and digest of each item that is added")
 	flags.StringVar(&opts.creds, "creds", "", "use `[username[:password]]` for accessing registries when pulling images")
 	flags.BoolVar(&opts.link, "link", false, "enable layer caching for this operation (creates an independent layer)")
 	flags.BoolVar(&opts.noFollowSymlinks, "no-follow-symlinks", false, "do not follow symlinks when copying content (copy the symlink itself)")
@@ -297,6 +299,7 @@ func addAndCopyCmd(c *cobra.Command, args []string, verb string, iopts addCopyRe
 		Timestamp:             timestamp,
 		Link:                  iopts.link,
 		FollowSymlink:         followSymlink,
+		ContentManifest:       iopts.contentManifest,
 	}
 	if iopts.contextdir != "" {
 		var excludes []string
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"go.podman.io/buildah/internal/volumes"
+	buildahcli "go.podman.io/buildah/pkg/cli"
+	"go.podman.io/common/pkg/formats"
+	"golang.org/x/term"
+)
+
+var cacheHeader = map[string]string{
+	"Name":     "NAME",
+	"ID":       "ID",
+	"Owner":    "OWNER",
+	"Size":     "SIZE",
+	"Created":  "CREATED",
+	"LastUsed": "LAST USED",
+}
+
+type cacheLsResults struct {
+	format    string
+	noheading bool
+	quiet     bool
+}
+
+type cacheRmResults struct {
+	all bool
+}
+
+type cacheExportResults struct {
+	output string
+}
+
+type cacheImportResults struct {
+	uid uint64
+	gid uint64
+}
+
+type cacheOutputParams struct {
+	Name     string
+	ID       string
+	Owner    string
+	Size     string
+	Created  string
+	LastUsed string
+}
+
+func cacheInit() {
+	var (
+		cacheDescription        = "\n  Manages the host directories which are used for RUN --mount=type=cache."
+		cacheLsDescription      = "\n  Lists the caches which were created for RUN --mount=type=cache."
+		cacheInspectDescription = "\n  Displays information about caches which were created for RUN --mount=type=cache."
+		cacheRmDescription      = "\n  Removes caches which were created for RUN --mount=type=cache."
+		cacheExportDescription  = "\n  Writes the contents of a cache to a tar archive."
+		cacheImportDescription  = "\n  Creates a cache for RUN --mount=type=cache from the contents of a tar archive."
+		lsOpts                  cacheLsResults
+		rmOpts                  cacheRmResults
+		exportOpts              cacheExportResults
+		importOpts              cacheImportResults
+	)
+	cacheCommand := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage caches used by RUN --mount=type=cache",
+		Long:  cacheDescription,
+		Example: `buildah cache ls
+  buildah cache inspect go-build
+  buildah cache rm go-build
+  buildah cache export -o go-build.tar go-build
+  buildah cache import go-build go-build.tar`,
+		GroupID: groupSystem,
+	}
+	cacheCommand.SetUsageTemplate(UsageTemplate())
+	rootCmd.AddCommand(cacheCommand)
+
+	cacheLsCommand := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List caches",
+		Long:    cacheLsDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cacheLsCmd(cmd, args, lsOpts)
+		},
+		Example: `buildah cache ls
+  buildah cache ls --format json`,
+		Args: cobra.NoArgs,
+	}
+	cacheLsCommand.SetUsageTemplate(UsageTemplate())
+	flags := cacheLsCommand.Flags()
+	flags.StringVar(&lsOpts.format, "format", "", "use `format` as a Go template to format the output, or \"json\" to output JSON")
+	flags.BoolVarP(&lsOpts.noheading, "noheading", "n", false, "do not print column headings")
+	flags.BoolVarP(&lsOpts.quiet, "quiet", "q", false, "display only cache IDs")
+	cacheCommand.AddCommand(cacheLsCommand)
+
+	cacheInspectCommand := &cobra.Command{
+		Use:   "inspect",
+		Short: "Display information about caches",
+		Long:  cacheInspectDescription,
+		RunE:  cacheInspectCmd,
+		Example: `buildah cache inspect go-build
+  buildah cache inspect /root/.cache`,
+		Args: cobra.MinimumNArgs(1),
+	}
+	cacheInspectCommand.SetUsageTemplate(UsageTemplate())
+	cacheCommand.AddCommand(cacheInspectCommand)
+
+	cacheRmCommand := &cobra.Command{
+		Use:   "rm",
+		Short: "Remove caches",
+		Long:  cacheRmDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cacheRmCmd(cmd, args, rmOpts)
+		},
+		Example: `buildah cache rm go-build
+  buildah cache rm --all`,
+	}
+	cacheRmCommand.SetUsageTemplate(UsageTemplate())
+	cacheRmCommand.Flags().BoolVarP(&rmOpts.all, "all", "a", false, "remove all caches")
+	cacheCommand.AddCommand(cacheRmCommand)
+
+	cacheExportCommand := &cobra.Command{
+		Use:   "export",
+		Short: "Write the contents of a cache to a tar archive",
+		Long:  cacheExportDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cacheExportCmd(cmd, args, exportOpts)
+		},
+		Example: `buildah cache export -o go-build.tar go-build
+  buildah cache export go-build | gzip > go-build.tar.gz`,
+		Args: cobra.ExactArgs(1),
+	}
+	cacheExportCommand.SetUsageTemplate(UsageTemplate())
+	cacheExportCommand.Flags().StringVarP(&exportOpts.output, "output", "o", "", "write the archive to `file` instead of stdout")
+	cacheCommand.AddCommand(cacheExportCommand)
+
+	cacheImportCommand := &cobra.Command{
+		Use:   "import",
+		Short: "Create a cache from the contents of a tar archive",
+		Long:  cacheImportDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cacheImportCmd(cmd, args, importOpts)
+		},
+		Example: `buildah cache import go-build go-build.tar
+  gunzip -c go-build.tar.gz | buildah cache import --uid 1000 --gid 1000 go-build`,
+		Args: cobra.RangeArgs(1, 2),
+	}
+	cacheImportCommand.SetUsageTemplate(UsageTemplate())
+	flags = cacheImportCommand.Flags()
+	flags.Uint64Var(&importOpts.uid, "uid", 0, "the `uid` setting of the RUN --mount=type=cache instructions which will use the cache")
+	flags.Uint64Var(&importOpts.gid, "gid", 0, "the `gid` setting of the RUN --mount=type=cache instructions which will use the cache")
+	cacheCommand.AddCommand(cacheImportCommand)
+}
+
+func cacheLsCmd(c *cobra.Command, args []string, iopts cacheLsResults) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	if c.Flag("quiet").Changed && c.Flag("format").Changed {
+		return errors.New("quiet and format are mutually exclusive")
+	}
+	caches, err := volumes.ListCaches(volumes.CacheParent())
+	if err != nil {
+		return err
+	}
+
+	if iopts.format == "json" {
+		return writeCacheJSON(caches)
+	}
+	if iopts.quiet {
+		for _, cache := range caches {
+			fmt.Println(cacheDisplayID(cache))
+		}
+		return nil
+	}
+	format := "table {{.ID}}\t{{.Owner}}\t{{.Size}}\t{{.LastUsed}}"
+	if iopts.noheading {
+		format = strings.TrimPrefix(format, "table ")
+	}
+	if iopts.format != "" {
+		format = strings.ReplaceAll(iopts.format, `\t`, "\t")
+	}
+	output := make([]any, 0, len(caches))
+	for _, cache := range caches {
+		output = append(output, cacheOutputParams{
+			Name:     cache.Name,
+			ID:       cacheDisplayID(cache),
+			Owner:    fmt.Sprintf("%d:%d", cache.UID, cache.GID),
+			Size:     formattedSize(cache.Size),
+			Created:  units.HumanDuration(time.Since(cache.Created)) + " ago",
+			LastUsed: units.HumanDuration(time.Since(cache.LastUsed)) + " ago",
+		})
+	}
+	out := formats.StdoutTemplateArray{Output: output, Template: format, Fields: cacheHeader}
+	return formats.Writer(out).Out()
+}
+
+// cacheDisplayID returns the ID of a cache, or the name of its directory if
+// we don't know its ID, which can also be used to refer to it.
+func cacheDisplayID(cache volumes.CacheInfo) string {
+	if cache.ID == "" {
+		return cache.Name
+	}
+	return cache.ID
+}
+
+func writeCacheJSON(caches []volumes.CacheInfo) error {
+	if caches == nil {
+		caches = []volumes.CacheInfo{}
+	}
+	data, err := json.MarshalIndent(caches, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
+func cacheInspectCmd(_ *cobra.Command, args []string) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	var caches []volumes.CacheInfo
+	for _, arg := range args {
+		matches, err := volumes.FindCaches(volumes.CacheParent(), arg)
+		if err != nil {
+			return err
+		}
+		caches = append(caches, matches...)
+	}
+	return writeCacheJSON(caches)
+}
+
+func cacheRmCmd(_ *cobra.Command, args []string, iopts cacheRmResults) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	if iopts.all && len(args) > 0 {
+		return errors.New("when using the --all switch, you may not pass any cache IDs")
+	}
+	if !iopts.all && len(args) == 0 {
+		return errors.New("cache ID must be specified")
+	}
+	cacheParent := volumes.CacheParent()
+	var caches []volumes.CacheInfo
+	if iopts.all {
+		var err error
+		if caches, err = volumes.ListCaches(cacheParent); err != nil {
+			return err
+		}
+	}
+	for _, arg := range args {
+		matches, err := volumes.FindCaches(cacheParent, arg)
+		if err != nil {
+			return err
+		}
+		caches = append(caches, matches...)
+	}
+	for _, cache := range caches {
+		if err := volumes.RemoveCache(cacheParent, cache.Name); err != nil {
+			return err
+		}
+		fmt.Println(cacheDisplayID(cache))
+	}
+	return nil
+}
+
+// findCache finds the one cache that idOrName refers to.
+func findCache(idOrName string) (*volumes.CacheInfo, error) {
+	matches, err := volumes.FindCaches(volumes.CacheParent(), idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 1 {
+		names := make([]string, 0, len(matches))
+		for _, match := range matches {
+			names = append(names, match.Name)
+		}
+		return nil, fmt.Errorf("%d caches with different owners have ID %q, specify one of %s by name", len(matches), idOrName, strings.Join(names, ", "))
+	}
+	return &matches[0], nil
+}
+
+func cacheExportCmd(_ *cobra.Command, args []string, iopts cacheExportResults) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	cache, err := findCache(args[0])
+	if err != nil {
+		return err
+	}
+	if iopts.output == "" || iopts.output == "-" {
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			return errors.New("refusing to write an archive to a terminal, use --output or redirect stdout")
+		}
+		return volumes.ExportCache(volumes.CacheParent(), cache.Name, os.Stdout)
+	}
+	f, err := os.Create(iopts.output)
+	if err != nil {
+		return err
+	}
+	if err := volumes.ExportCache(volumes.CacheParent(), cache.Name, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func cacheImportCmd(_ *cobra.Command, args []string, iopts cacheImportResults) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	var input io.Reader = os.Stdin
+	if len(args) > 1 && args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	cache, err := volumes.ImportCache(volumes.CacheParent(), args[0], iopts.uid, iopts.gid, input)
+	if err != nil {
+		return err
+	}
+	fmt.Println(cache.Name)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	buildahcli "go.podman.io/buildah/pkg/cli"
+)
+
+type checkpointResults struct {
+	list   bool
+	remove bool
+}
+
+func checkpointInit() {
+	var (
+		opts                  checkpointResults
+		checkpointDescription = "\n  Saves the contents of a working container's root filesystem, along with its\n  configuration and history, so that they can be restored using\n  \"buildah rollback\"."
+	)
+
+	checkpointCommand := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Save the state of a working container",
+		Long:  checkpointDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return checkpointCmd(cmd, args, opts)
+		},
+		Example: `buildah checkpoint containerID before-upgrade
+  buildah checkpoint --list containerID
+  buildah checkpoint --rm containerID before-upgrade`,
+		Args:    cobra.RangeArgs(1, 2),
+		GroupID: groupContainers,
+	}
+	checkpointCommand.SetUsageTemplate(UsageTemplate())
+
+	flags := checkpointCommand.Flags()
+	flags.SetInterspersed(false)
+	flags.BoolVarP(&opts.list, "list", "l", false, "list the container's checkpoints")
+	flags.BoolVar(&opts.remove, "rm", false, "remove the named checkpoint")
+
+	rootCmd.AddCommand(checkpointCommand)
+}
+
+func checkpointCmd(c *cobra.Command, args []string, iopts checkpointResults) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	if iopts.list && iopts.remove {
+		return errors.New("--list and --rm are mutually exclusive")
+	}
+	if iopts.list && len(args) != 1 {
+		return errors.New("--list requires only a container name or ID")
+	}
+	if !iopts.list && len(args) != 2 {
+		return errors.New("container name or ID and checkpoint name must be specified")
+	}
+
+	store, err := getStore(c)
+	if err != nil {
+		return err
+	}
+	builder, err := openBuilder(getContext(), store, args[0])
+	if err != nil {
+		return fmt.Errorf("reading build container %q: %w", args[0], err)
+	}
+
+	switch {
+	case iopts.list:
+		names, err := builder.Checkpoints()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	case iopts.remove:
+		return builder.RemoveCheckpoint(args[1])
+	default:
+		return builder.Checkpoint(args[1])
+	}
+}
@@ -65,6 +65,7 @@ type commitInputOptions struct {
 	signaturePolicy        string
 	signBy                 string
 	squash                 bool
+	squashFrom             string
 	tlsVerify              bool
 	identityLabel          bool
 	encryptionKeys         []string
@@ -201,6 +202,7 @@ func commitListFlagSet(cmd *cobra.Command, opts *commitInputOptions) {
 	}
 
 	flags.BoolVar(&opts.squash, "squash", false, "produce an image with only one layer")
+	flags.StringVar(&opts.squashFrom, "squash-from", "", "merge base image layers starting with this `index or history comment` into the new layer")
 	flags.BoolVar(&opts.tlsVerify, "tls-verify", true, "require HTTPS and verify certificates when accessing the registry. TLS verification cannot be used when talking to an insecure registry.")
 
 	flags.StringSliceVar(&opts.unsetenvs, "unsetenv", nil, "unset env from final image")
@@ -335,6 +337,7 @@ func commitCmd(c *cobra.Command, args []string, iopts commitInputOptions) error
 		SystemContext:          systemContext,
 		IIDFile:                iopts.iidfile,
 		Squash:                 iopts.squash,
+		SquashFrom:             iopts.squashFrom,
 		BlobDirectory:          iopts.blobCache,
 		OmitHistory:            iopts.omitHistory,
 		SignBy:                 iopts.signBy,
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.podman.io/buildah"
+	buildahcli "go.podman.io/buildah/pkg/cli"
+	"go.podman.io/buildah/pkg/parse"
+	"go.podman.io/common/pkg/formats"
+	"go.podman.io/image/v5/types"
+	"go.podman.io/storage"
+)
+
+var diffHeader = map[string]string{
+	"Kind":    "KIND",
+	"Change":  "CHANGE",
+	"Path":    "PATH",
+	"Details": "DETAILS",
+}
+
+type diffResults struct {
+	digests bool
+	format  string
+}
+
+type diffOutputParams struct {
+	Kind      string
+	Change    string
+	Path      string
+	Details   string
+	OldDigest string
+	NewDigest string
+}
+
+func diffInit() {
+	var (
+		opts            diffResults
+		diffDescription = "\n  Lists the files which were added, removed, or modified in the second of two\n  working containers or images, compared to the first.  If only a working\n  container is specified, it is compared to the image it was created from."
+	)
+
+	diffCommand := &cobra.Command{
+		Use:   "diff",
+		Short: "List the differences between the filesystems of two containers or images",
+		Long:  diffDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return diffCmd(cmd, args, opts)
+		},
+		Example: `buildah diff containerID
+  buildah diff imageID containerID
+  buildah diff --digests --format json oldImage newImage`,
+		Args:    cobra.RangeArgs(1, 2),
+		GroupID: groupImages,
+	}
+	diffCommand.SetUsageTemplate(UsageTemplate())
+
+	flags := diffCommand.Flags()
+	flags.SetInterspersed(false)
+	flags.BoolVar(&opts.digests, "digests", false, "compute and compare digests of the contents of regular files")
+	flags.StringVarP(&opts.format, "format", "f", "", "use `format` as a Go template to format the output, or \"json\" to output JSON")
+
+	rootCmd.AddCommand(diffCommand)
+}
+
+// openDiffTarget opens a working container or, failing that, an image.
+func openDiffTarget(ctx context.Context, sc *types.SystemContext, store storage.Store, name string) (*buildah.Builder, error) {
+	builder, err := openBuilder(ctx, store, name)
+	if err != nil {
+		if builder, err = openImage(ctx, sc, store, name); err != nil {
+			return nil, fmt.Errorf("%q is neither a working container nor an image: %w", name, err)
+		}
+	}
+	return builder, nil
+}
+
+func diffCmd(c *cobra.Command, args []string, iopts diffResults) error {
+	if err := buildahcli.VerifyFlagsArgsOrder(args); err != nil {
+		return err
+	}
+	systemContext, err := parse.SystemContextFromOptions(c)
+	if err != nil {
+		return fmt.Errorf("building system context: %w", err)
+	}
+	store, err := getStore(c)
+	if err != nil {
+		return err
+	}
+	ctx := getContext()
+
+	var from, to *buildah.Builder
+	if len(args) == 1 {
+		if to, err = openBuilder(ctx, store, args[0]); err != nil {
+			return fmt.Errorf("reading build container %q: %w", args[0], err)
+		}
+		if to.FromImageID == "" {
+			return fmt.Errorf("build container %q was not created from an image", args[0])
+		}
+		if from, err = openImage(ctx, systemContext, store, to.FromImageID); err != nil {
+			return fmt.Errorf("reading base image of build container %q: %w", args[0], err)
+		}
+	} else {
+		if from, err = openDiffTarget(ctx, systemContext, store, args[0]); err != nil {
+			return err
+		}
+		if to, err = openDiffTarget(ctx, systemContext, store, args[1]); err != nil {
+			return err
+		}
+	}
+
+	entries, err := buildah.Diff(store, from, to, buildah.DiffOptions{Digests: iopts.digests})
+	if err != nil {
+		return err
+	}
+
+	if iopts.format == "json" {
+		output := make([]any, 0, len(entries))
+		for _, entry := range entries {
+			output = append(output, entry)
+		}
+		return formats.Writer(formats.JSONStructArray{Output: output}).Out()
+	}
+	format := "{{.Kind}} {{.Path}}{{if .Details}}  {{.Details}}{{end}}"
+	if iopts.format != "" {
+		format = strings.ReplaceAll(iopts.format, `\t`, "\t")
+	}
+	output := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		output = append(output, diffOutput(entry))
+	}
+	if len(output) == 0 {
+		return nil
+	}
+	out := formats.StdoutTemplateArray{Output: output, Template: format, Fields: diffHeader}
+	return formats.Writer(out).Out()
+}
+
+// diffOutput converts a DiffEntry into the values that we make available to
+// templates, including a readable summary of what changed.
+func diffOutput(entry buildah.DiffEntry) diffOutputParams {
+	params := diffOutputParams{
+		Change: string(entry.Change),
+		Path:   entry.Path,
+	}
+	var details []string
+	switch entry.Change {
+	case buildah.DiffAdded:
+		params.Kind = "A"
+	case buildah.DiffRemoved:
+		params.Kind = "D"
+	case buildah.DiffModified:
+		params.Kind = "M"
+		before, after := entry.Old, entry.New
+		for _, difference := range entry.Differences {
+			switch difference {
+			case "type", "mode":
+				details = append(details, fmt.Sprintf("%s %s -> %s", difference, before.Mode, after.Mode))
+			case "owner":
+				details = append(details, fmt.Sprintf("owner %d:%d -> %d:%d", before.UID, before.GID, after.UID, after.GID))
+			case "size":
+				details = append(details, fmt.Sprintf("size %d -> %d", before.Size, after.Size))
+			case "modtime":
+				details = append(details, fmt.Sprintf("modtime %s -> %s", before.ModTime.UTC().Format(time.RFC3339), after.ModTime.UTC().Format(time.RFC3339)))
+			case "linkname":
+				details = append(details, fmt.Sprintf("linkname %q -> %q", before.Linkname, after.Linkname))
+			case "xattrs":
+				details = append(details, "xattrs")
+			case "content":
+				details = append(details, fmt.Sprintf("content %s -> %s", before.Digest, after.Digest))
+			}
+		}
+	}
+	if entry.Old != nil {
+		params.OldDigest = entry.Old.Digest.String()
+	}
+	if entry.New != nil {
+		params.NewDigest = entry.New.Digest.String()
+	}
+	if entry.Change != buildah.DiffModified {
+		if digest := params.NewDigest + params.OldDigest; digest != "" {
+			details = append(details, digest)
+		}
+	}
+	params.Details = strings.Join(details, ", ")
+	return params
+}
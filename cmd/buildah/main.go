@@ -262,9 +262,12 @@ func main() {
 
 	addcopyInit()
 	buildInit()
+	cacheInit()
+	checkpointInit()
 	commitInit()
 	configInit()
 	containersInit()
+	diffInit()
 	dumpboltInit()
 	fromInit()
 	imagesInit()
@@ -281,8 +284,10 @@ func main() {
 	renameInit()
 	rmiInit()
 	rmInit()
+	rollbackInit()
 	rpcInit()
 	runInit()
+	serveInit()
 	sftpInit()
 	sourceInit()
 	tagInit()
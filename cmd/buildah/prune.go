@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
+	"go.podman.io/buildah/internal/downloadcache"
 	buildahcli "go.podman.io/buildah/pkg/cli"
 	"go.podman.io/buildah/pkg/parse"
 	"go.podman.io/buildah/pkg/volumes"
@@ -20,12 +21,12 @@ type pruneOptions struct {
 func pruneInit() {
 	var (
 		pruneDescription = `
-Cleanup intermediate images as well as build and mount cache.`
+Cleanup intermediate images as well as build, mount, and download cache.`
 		opts pruneOptions
 	)
 	pruneCommand := &cobra.Command{
 		Use:   "prune",
-		Short: "Cleanup intermediate images as well as build and mount cache",
+		Short: "Cleanup intermediate images as well as build, mount, and download cache",
 		Long:  pruneDescription,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return pruneCmd(cmd, args, opts)
@@ -69,6 +70,10 @@ func pruneCmd(c *cobra.Command, args []string, iopts pruneOptions) error {
 		return err
 	}
 
+	if err := downloadcache.Remove(downloadcache.Directory(store.GraphRoot())); err != nil {
+		return err
+	}
+
 	options := &libimage.RemoveImagesOptions{
 		Filters: []string{"readonly=false"},
 	}
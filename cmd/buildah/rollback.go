@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackDescription = "\n  Restores a working container's root filesystem, configuration, and history to\n  the state they were in when a checkpoint was saved using \"buildah checkpoint\".\n  The container is replaced with a new one which has the same name."
+	rollbackCommand     = &cobra.Command{
+		Use:     "rollback",
+		Short:   "Restore a working container to a checkpoint",
+		Long:    rollbackDescription,
+		RunE:    rollbackCmd,
+		Example: `buildah rollback containerName before-upgrade`,
+		Args:    cobra.ExactArgs(2),
+		GroupID: groupContainers,
+	}
+)
+
+func rollbackInit() {
+	rollbackCommand.SetUsageTemplate(UsageTemplate())
+	rootCmd.AddCommand(rollbackCommand)
+}
+
+func rollbackCmd(c *cobra.Command, args []string) error {
+	name := args[0]
+	checkpoint := args[1]
+
+	store, err := getStore(c)
+	if err != nil {
+		return err
+	}
+
+	builder, err := openBuilder(getContext(), store, name)
+	if err != nil {
+		return fmt.Errorf("reading build container %q: %w", name, err)
+	}
+
+	if err := builder.Rollback(checkpoint); err != nil {
+		return err
+	}
+	fmt.Println(builder.ContainerID)
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -8,18 +9,23 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.podman.io/buildah/internal/rpc/build"
 	"go.podman.io/buildah/internal/rpc/listen"
 	"go.podman.io/buildah/internal/rpc/noop"
+	"go.podman.io/buildah/pkg/parse"
+	"go.podman.io/common/pkg/auth"
+	"go.podman.io/storage"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
 var (
-	rpcDescription = "\n  Runs a command with a rudimentary RPC server available."
+	rpcDescription = "\n  Runs a command with an RPC server which can be used to build images available."
 	rpcCommand     = &cobra.Command{
 		Use:     "rpc",
-		Short:   "Run a command with a rudimentary RPC server available",
+		Short:   "Run a command with an RPC server available",
 		Long:    rpcDescription,
 		RunE:    rpcCmd,
 		Hidden:  true,
@@ -33,6 +39,10 @@ func rpcCmd(c *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	options, err := buildServiceOptions(c)
+	if err != nil {
+		return err
+	}
 
 	socketPath := c.Flag("listen").Value.String()
 	if socketPath == "" {
@@ -58,9 +68,7 @@ func rpcCmd(c *cobra.Command, args []string) error {
 		}
 	}()
 
-	s := grpc.NewServer()
-	noop.Register(s)
-	reflection.Register(s)
+	s := newRPCServer(store, options)
 
 	var errgroup errgroup.Group
 	errgroup.Go(func() error {
@@ -86,6 +94,56 @@ func rpcCmd(c *cobra.Command, args []string) error {
 	return cmd.Run()
 }
 
+// buildServiceOptions reads the flags which were added by
+// addBuildServiceFlags.
+func buildServiceOptions(c *cobra.Command) (build.Options, error) {
+	systemContext, err := parse.SystemContextFromOptions(c)
+	if err != nil {
+		return build.Options{}, fmt.Errorf("building system context: %w", err)
+	}
+	isolation, err := parse.IsolationOption(c.Flag("isolation").Value.String())
+	if err != nil {
+		return build.Options{}, err
+	}
+	return build.Options{
+		SystemContext:         systemContext,
+		SignaturePolicyPath:   c.Flag("signature-policy").Value.String(),
+		DefaultMountsFilePath: globalFlagResults.DefaultMountsFile,
+		Isolation:             isolation,
+	}, nil
+}
+
+// newRPCServer creates a server with all of our services registered.
+func newRPCServer(store storage.Store, options build.Options, serverOptions ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(serverOptions...)
+	noop.Register(s)
+	build.Register(s, store, options)
+	reflection.Register(s)
+	return s
+}
+
+// addBuildServiceFlags adds flags which control how the build service pulls,
+// writes, and pushes images, and runs commands.
+func addBuildServiceFlags(flags *pflag.FlagSet) {
+	var buildServiceOptions struct {
+		authfile        string
+		certDir         string
+		creds           string
+		isolation       string
+		signaturePolicy string
+		tlsVerify       bool
+	}
+	flags.StringVar(&buildServiceOptions.authfile, "authfile", auth.GetDefaultAuthFile(), "path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override")
+	flags.StringVar(&buildServiceOptions.certDir, "cert-dir", "", "use certificates at the specified path to access the registry")
+	flags.StringVar(&buildServiceOptions.creds, "creds", "", "use `[username[:password]]` for accessing the registry")
+	flags.StringVar(&buildServiceOptions.isolation, "isolation", "", "`type` of process isolation to use. Use BUILDAH_ISOLATION environment variable to override.")
+	flags.StringVar(&buildServiceOptions.signaturePolicy, "signature-policy", "", "`pathname` of signature policy file (not usually used)")
+	if err := flags.MarkHidden("signature-policy"); err != nil {
+		panic(fmt.Sprintf("error marking signature-policy as hidden: %v", err))
+	}
+	flags.BoolVar(&buildServiceOptions.tlsVerify, "tls-verify", true, "require HTTPS and verify certificates when accessing the registry. TLS verification cannot be used when talking to an insecure registry.")
+}
+
 func rpcInit() {
 	var rpcOptions struct {
 		envVar     string
@@ -96,5 +154,6 @@ func rpcInit() {
 	flags.SetInterspersed(false)
 	flags.StringVarP(&rpcOptions.envVar, "env", "e", "", "set environment `variable` to point to listening socket path")
 	flags.StringVarP(&rpcOptions.listenPath, "listen", "l", "", "listening socket `path`")
+	addBuildServiceFlags(flags)
 	rootCmd.AddCommand(rpcCommand)
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go.podman.io/buildah/internal/rpc/listen"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/grpc"
+)
+
+type serveOptions struct {
+	listenPath  string
+	maxRequests int64
+}
+
+var (
+	serveDescription = `
+  Serves requests to create, modify, and commit working containers, and to
+  build and push images, on a socket until it is interrupted.  The storage
+  library, registry configuration, and network configuration are initialized
+  once and shared by all requests, which are handled concurrently.`
+	serveCommand = &cobra.Command{
+		Use:     "serve",
+		Short:   "Serve build requests on a socket",
+		Long:    serveDescription,
+		RunE:    serveCmd,
+		Example: `buildah serve --listen /run/buildah/build.sock`,
+		Args:    cobra.NoArgs,
+		GroupID: groupSystem,
+	}
+)
+
+func serveCmd(c *cobra.Command, _ []string) error {
+	store, err := getStore(c)
+	if err != nil {
+		return err
+	}
+	options, err := buildServiceOptions(c)
+	if err != nil {
+		return err
+	}
+	if options.NetworkInterface, err = serveNetworkInterface(store); err != nil {
+		return fmt.Errorf("initializing network interface: %w", err)
+	}
+
+	maxRequests, err := c.Flags().GetInt64("max-requests")
+	if err != nil {
+		return err
+	}
+	var serverOptions []grpc.ServerOption
+	if maxRequests > 0 {
+		serverOptions = append(serverOptions, limitRequests(maxRequests)...)
+	}
+
+	listener, cleanup, err := listen.Listen(c.Flag("listen").Value.String())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cleanup(); err != nil {
+			logrus.Errorf("cleaning up: %v", err)
+		}
+	}()
+	fmt.Printf("unix://%s\n", listener.Addr().String())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := newRPCServer(store, options, serverOptions...)
+	var errgroup errgroup.Group
+	errgroup.Go(func() error {
+		defer stop()
+		return s.Serve(listener)
+	})
+	errgroup.Go(func() error {
+		<-ctx.Done()
+		logrus.Debugf("shutting down rpc service")
+		s.GracefulStop() // closes the listening socket
+		return nil
+	})
+	if err := errgroup.Wait(); err != nil && err != grpc.ErrServerStopped {
+		return fmt.Errorf("serving rpc requests: %w", err)
+	}
+	return nil
+}
+
+// limitRequests returns server options which cause requests to wait for one
+// of a limited number of slots before they are handled.
+func limitRequests(maxRequests int64) []grpc.ServerOption {
+	slots := semaphore.NewWeighted(maxRequests)
+	unary := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := slots.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		defer slots.Release(1)
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := slots.Acquire(ss.Context(), 1); err != nil {
+			return err
+		}
+		defer slots.Release(1)
+		return handler(srv, ss)
+	}
+	return []grpc.ServerOption{grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream)}
+}
+
+func serveInit() {
+	var opts serveOptions
+	serveCommand.SetUsageTemplate(UsageTemplate())
+	flags := serveCommand.Flags()
+	flags.StringVarP(&opts.listenPath, "listen", "l", "", "listening socket `path`")
+	flags.Int64Var(&opts.maxRequests, "max-requests", 0, "maximum `number` of requests to handle at the same time (0 for no limit)")
+	addBuildServiceFlags(flags)
+	rootCmd.AddCommand(serveCommand)
+}
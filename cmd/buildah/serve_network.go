@@ -0,0 +1,19 @@
+//go:build linux || freebsd
+
+package main
+
+import (
+	"go.podman.io/common/libnetwork/network"
+	nettypes "go.podman.io/common/libnetwork/types"
+	"go.podman.io/storage"
+)
+
+// serveNetworkInterface initializes the network interface which the service
+// will use for every container which it creates.
+func serveNetworkInterface(store storage.Store) (nettypes.ContainerNetwork, error) {
+	_, netInt, err := network.NetworkBackend(store, defaultContainerConfig, false)
+	if err != nil {
+		return nil, err
+	}
+	return netInt, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !freebsd
+
+package main
+
+import (
+	nettypes "go.podman.io/common/libnetwork/types"
+	"go.podman.io/storage"
+)
+
+func serveNetworkInterface(storage.Store) (nettypes.ContainerNetwork, error) {
+	return nil, nil
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -92,6 +93,14 @@ type CommitOptions struct {
 	// Squash tells the builder to produce an image with a single layer
 	// instead of with possibly more than one layer.
 	Squash bool
+	// SquashFrom, if set, tells the builder to keep some of the base
+	// image's layers as they are, and to merge the rest of them into the
+	// new layer.  It is either the index of the first base image layer to
+	// merge, counting from 0 at the bottom of the image, or a history
+	// marker: the comment of the history entry for the first layer to
+	// merge.  An index of 0 is the same as setting Squash.  Ignored if
+	// Squash is set.
+	SquashFrom string
 	// OmitHistory tells the builder to ignore the history of build layers and
 	// base while preparing image-spec, setting this to true will ensure no history
 	// is added to the image-spec. (default false)
@@ -326,6 +335,7 @@ type CommitResults struct {
 	ImageManifest []byte              // raw image manifest, always returned
 	Digest        digest.Digest       // digest of the manifest, always returned
 	Metadata      map[string]any      // always returned, format is flexible
+	ReusedLayers  []string            // IDs of layers in local storage which were reused instead of being written again
 }
 
 // Commit writes the contents of the container, along with its updated
@@ -350,6 +360,7 @@ func (b *Builder) Commit(ctx context.Context, dest types.ImageReference, options
 func (b *Builder) CommitResults(ctx context.Context, dest types.ImageReference, options CommitOptions) (*CommitResults, error) {
 	var (
 		imgID                string
+		reusedLayers         []string
 		src                  types.ImageReference
 		destinationTimestamp *time.Time
 	)
@@ -455,10 +466,11 @@ func (b *Builder) CommitResults(ctx context.Context, dest types.ImageReference,
 	}
 
 	// Build an image reference from which we can copy the finished image.
-	src, err = b.makeContainerImageRef(options)
+	imageRef, err := b.makeContainerImageRef(options)
 	if err != nil {
 		return nil, fmt.Errorf("computing layer digests and building metadata for container %q: %w", b.ContainerID, err)
 	}
+	src = imageRef
 	// In case we're using caching, decide how to handle compression for a cache.
 	// If we're using blob caching, set it up for the source.
 	maybeCachedSrc := src
@@ -536,6 +548,24 @@ func (b *Builder) CommitResults(ctx context.Context, dest types.ImageReference,
 		}
 		dest = dest2
 		imgID = img.ID
+		// Only report the layers that we offered for reuse if the
+		// image that was written actually ended up using them.
+		for layerID := img.TopLayer; layerID != ""; {
+			layer, err := b.store.Layer(layerID)
+			if err != nil {
+				return nil, fmt.Errorf("locating layer %q of image %q: %w", layerID, imgID, err)
+			}
+			if slices.Contains(imageRef.reusedLayers, layer.ID) {
+				reusedLayers = append(reusedLayers, layer.ID)
+			}
+			layerID = layer.Parent
+		}
+		slices.Reverse(reusedLayers)
+		if len(b.ContentManifests) > 0 {
+			if err := saveImageContentManifests(b.store, imgID, b.ContentManifests); err != nil {
+				return nil, err
+			}
+		}
 		toPruneNames := make([]string, 0, len(img.Names))
 		for _, name := range img.Names {
 			if nameToRemove != "" && strings.Contains(name, nameToRemove) {
@@ -615,10 +645,13 @@ func (b *Builder) CommitResults(ctx context.Context, dest types.ImageReference,
 		Digest:    manifestDigest,
 		Size:      int64(len(manifestBytes)),
 	}
-	metadata, err := metadata.Build(configInfo.Digest, descriptor)
+	imageMetadata, err := metadata.Build(configInfo.Digest, descriptor)
 	if err != nil {
 		return nil, fmt.Errorf("building metadata map for image: %w", err)
 	}
+	if len(b.ContentManifests) > 0 {
+		imageMetadata[metadata.ContentManifestKey] = slices.Clone(b.ContentManifests)
+	}
 
 	results := CommitResults{
 		ImageID:       imgID,
@@ -626,7 +659,8 @@ func (b *Builder) CommitResults(ctx context.Context, dest types.ImageReference,
 		MediaType:     descriptor.MediaType,
 		ImageManifest: manifestBytes,
 		Digest:        manifestDigest,
-		Metadata:      metadata,
+		Metadata:      imageMetadata,
+		ReusedLayers:  reusedLayers,
 	}
 	return &results, nil
 }
@@ -660,3 +660,152 @@ func TestCommitChmod(t *testing.T) {
 		require.ErrorIs(t, err, io.EOF)
 	}
 }
+
+func TestCommitReusesLayers(t *testing.T) {
+	ctx := context.TODO()
+	graphDriverName := os.Getenv("STORAGE_DRIVER")
+	if graphDriverName == "" {
+		graphDriverName = "vfs"
+	}
+	t.Logf("using storage driver %q", graphDriverName)
+	store, err := storage.GetStore(storageTypes.StoreOptions{
+		RunRoot:         t.TempDir(),
+		GraphRoot:       t.TempDir(),
+		GraphDriverName: graphDriverName,
+	})
+	require.NoError(t, err, "initializing storage")
+	t.Cleanup(func() { _, err := store.Shutdown(true); assert.NoError(t, err) })
+
+	payload := makeFile(t, "payload", 4096)
+	timestamp := time.Unix(1700000000, 0)
+	require.NoError(t, os.Chtimes(payload, timestamp, timestamp))
+
+	// Commit two unrelated containers that have the same contents.
+	commit := func(name string) (*CommitResults, *storage.Image) {
+		builderOptions := BuilderOptions{
+			FromImage: "scratch",
+			NamespaceOptions: []NamespaceOption{{
+				Name: string(rspec.NetworkNamespace),
+				Host: true,
+			}},
+			SystemContext: &testSystemContext,
+		}
+		b, err := NewBuilder(ctx, store, builderOptions)
+		require.NoError(t, err, "creating builder")
+		t.Cleanup(func() { assert.NoError(t, b.Delete()) })
+		b.SetLabel("name", name)
+		require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, payload), "adding payload")
+		ref, err := imageStorage.Transport.ParseStoreReference(store, name)
+		require.NoError(t, err, "parsing reference for to-be-committed image", name)
+		results, err := b.CommitResults(ctx, ref, CommitOptions{SystemContext: &testSystemContext})
+		require.NoError(t, err, "committing", name)
+		img, err := store.Image(results.ImageID)
+		require.NoError(t, err, "locating committed image", name)
+		return results, img
+	}
+	firstResults, firstImage := commit("first")
+	assert.Empty(t, firstResults.ReusedLayers, "nothing to reuse yet")
+	layerCount := func() int {
+		layers, err := store.Layers()
+		require.NoError(t, err)
+		return len(layers)
+	}
+	before := layerCount()
+
+	secondResults, secondImage := commit("second")
+	assert.NotEqual(t, firstImage.ID, secondImage.ID, "images should have different configurations")
+	assert.Equal(t, []string{firstImage.TopLayer}, secondResults.ReusedLayers)
+	assert.Equal(t, firstImage.TopLayer, secondImage.TopLayer)
+	// the second builder's container layer is the only new one
+	assert.Equal(t, before+1, layerCount())
+}
+
+func TestCommitSquashFrom(t *testing.T) {
+	ctx := context.TODO()
+	graphDriverName := os.Getenv("STORAGE_DRIVER")
+	if graphDriverName == "" {
+		graphDriverName = "vfs"
+	}
+	t.Logf("using storage driver %q", graphDriverName)
+	store, err := storage.GetStore(storageTypes.StoreOptions{
+		RunRoot:         t.TempDir(),
+		GraphRoot:       t.TempDir(),
+		GraphDriverName: graphDriverName,
+	})
+	require.NoError(t, err, "initializing storage")
+	t.Cleanup(func() { _, err := store.Shutdown(true); assert.NoError(t, err) })
+
+	newBuilder := func(from string) *Builder {
+		builderOptions := BuilderOptions{
+			FromImage: from,
+			NamespaceOptions: []NamespaceOption{{
+				Name: string(rspec.NetworkNamespace),
+				Host: true,
+			}},
+			SystemContext: &testSystemContext,
+		}
+		b, err := NewBuilder(ctx, store, builderOptions)
+		require.NoError(t, err, "creating builder")
+		t.Cleanup(func() { assert.NoError(t, b.Delete()) })
+		return b
+	}
+	commit := func(b *Builder, name string, options CommitOptions) (string, error) {
+		ref, err := imageStorage.Transport.ParseStoreReference(store, name)
+		require.NoError(t, err, "parsing reference for to-be-committed image", name)
+		options.SystemContext = &testSystemContext
+		imageID, _, _, err := b.Commit(ctx, ref, options)
+		return imageID, err
+	}
+
+	// Build a base image with three layers, marking the history entry for
+	// the third one.
+	base := "scratch"
+	for i, comment := range []string{"", "", "marker"} {
+		b := newBuilder(base)
+		b.SetHistoryComment(comment)
+		require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, makeFile(t, fmt.Sprintf("base%d", i), 64)))
+		base = fmt.Sprintf("base%d", i)
+		_, err := commit(b, base, CommitOptions{})
+		require.NoError(t, err, "committing", base)
+	}
+
+	testCases := []struct {
+		squashFrom string
+		layers     int
+		fail       bool
+	}{
+		{squashFrom: "0", layers: 1},
+		{squashFrom: "1", layers: 2},
+		{squashFrom: "marker", layers: 3},
+		{squashFrom: "3", layers: 4},
+		{squashFrom: "4", fail: true},
+		{squashFrom: "no-such-marker", fail: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.squashFrom, func(t *testing.T) {
+			b := newBuilder(base)
+			require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, makeFile(t, "new", 64)))
+			imageID, err := commit(b, "squashed", CommitOptions{SquashFrom: testCase.squashFrom})
+			if testCase.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			squashed := newBuilder(imageID)
+			assert.Len(t, squashed.OCIv1.RootFS.DiffIDs, testCase.layers)
+			nonEmpty := 0
+			for _, history := range squashed.OCIv1.History {
+				if !history.EmptyLayer {
+					nonEmpty++
+				}
+			}
+			assert.Equal(t, testCase.layers, nonEmpty, "history should describe the layers")
+			mountPoint, err := squashed.Mount("")
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, squashed.Unmount()) })
+			for _, name := range []string{"base0", "base1", "base2", "new"} {
+				assert.FileExists(t, filepath.Join(mountPoint, name))
+			}
+		})
+	}
+}
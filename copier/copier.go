@@ -33,8 +33,9 @@ import (
 )
 
 const (
-	copierCommand    = "buildah-copier"
-	maxLoopsFollowed = 64
+	copierCommand        = "buildah-copier"
+	copierSessionCommand = "buildah-copier-session"
+	maxLoopsFollowed     = 64
 	// See http://pubs.opengroup.org/onlinepubs/9699919799/utilities/pax.html#tag_20_92_13_06, from archive/tar
 	cISUID = 0o4000 // Set uid, from archive/tar
 	cISGID = 0o2000 // Set gid, from archive/tar
@@ -45,6 +46,7 @@ const (
 
 func init() {
 	reexec.Register(copierCommand, copierMain)
+	reexec.Register(copierSessionCommand, copierSessionMain)
 }
 
 // extendedGlob calls filepath.Glob() on the passed-in patterns.  If there is a
@@ -171,6 +173,7 @@ type request struct {
 	RemoveOptions            RemoveOptions
 	EnsureOptions            EnsureOptions
 	ConditionalRemoveOptions ConditionalRemoveOptions
+	Passthrough              bool `json:",omitempty"` // the subprocess was passed our end of a Passthrough as descriptor 5
 }
 
 func (req *request) Excludes() []string {
@@ -365,14 +368,7 @@ type StatOptions struct {
 // Relative names in the glob list are treated as being relative to the
 // directory.
 func Stat(root string, directory string, options StatOptions, globs []string) ([]*StatsForGlob, error) {
-	req := request{
-		Request:     requestStat,
-		Root:        root,
-		Directory:   directory,
-		Globs:       slices.Clone(globs),
-		StatOptions: options,
-	}
-	resp, err := copier(nil, nil, req)
+	resp, err := copier(nil, nil, newStatRequest(root, directory, options, globs))
 	if err != nil {
 		return nil, err
 	}
@@ -382,6 +378,16 @@ func Stat(root string, directory string, options StatOptions, globs []string) ([
 	return resp.Stat.Globs, nil
 }
 
+func newStatRequest(root string, directory string, options StatOptions, globs []string) request {
+	return request{
+		Request:     requestStat,
+		Root:        root,
+		Directory:   directory,
+		Globs:       slices.Clone(globs),
+		StatOptions: options,
+	}
+}
+
 // GetOptions controls parts of Get()'s behavior.
 type GetOptions struct {
 	UIDMap, GIDMap     []idtools.IDMap   // map from hostIDs to containerIDs in the output archive
@@ -405,6 +411,7 @@ type GetOptions struct {
 	Timestamp          *time.Time        // timestamp to force on all contents
 	DisallowWildcard   bool              // reject glob patterns in source paths
 	AllowEmptyWildcard bool              // don't error when glob patterns match nothing
+	Passthrough        *Passthrough      `json:"-"` // pass descriptors for regular files to a Put() which is using the same Passthrough
 }
 
 // Get produces an archive containing items that match the specified glob
@@ -420,7 +427,18 @@ type GetOptions struct {
 // Relative names in the glob list are treated as being relative to the
 // directory.
 func Get(root string, directory string, options GetOptions, globs []string, bulkWriter io.Writer) error {
-	req := request{
+	resp, err := copier(nil, bulkWriter, newGetRequest(root, directory, options, globs))
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+func newGetRequest(root string, directory string, options GetOptions, globs []string) request {
+	return request{
 		Request:   requestGet,
 		Root:      root,
 		Directory: directory,
@@ -432,14 +450,6 @@ func Get(root string, directory string, options GetOptions, globs []string, bulk
 		},
 		GetOptions: options,
 	}
-	resp, err := copier(nil, bulkWriter, req)
-	if err != nil {
-		return err
-	}
-	if resp.Error != "" {
-		return errors.New(resp.Error)
-	}
-	return nil
 }
 
 // PutOptions controls parts of Put()'s behavior.
@@ -463,6 +473,7 @@ type PutOptions struct {
 	Rename               map[string]string  // rename items with the specified names, or under the specified names
 	Timestamp            *time.Time         // override timestamps on all extracted content
 	CreateDestPath       types.OptionalBool // create the destination path if it doesn't already exist, default is true
+	Passthrough          *Passthrough       `json:"-"` // clone or copy regular files using descriptors from a Get() which is using the same Passthrough
 }
 
 // Put extracts an archive from the bulkReader at the specified directory.
@@ -475,13 +486,7 @@ type PutOptions struct {
 // Otherwise, the directory is treated as a path relative to the root
 // directory.
 func Put(root string, directory string, options PutOptions, bulkReader io.Reader) error {
-	req := request{
-		Request:    requestPut,
-		Root:       root,
-		Directory:  directory,
-		PutOptions: options,
-	}
-	resp, err := copier(bulkReader, nil, req)
+	resp, err := copier(bulkReader, nil, newPutRequest(root, directory, options))
 	if err != nil {
 		return err
 	}
@@ -491,6 +496,15 @@ func Put(root string, directory string, options PutOptions, bulkReader io.Reader
 	return nil
 }
 
+func newPutRequest(root string, directory string, options PutOptions) request {
+	return request{
+		Request:    requestPut,
+		Root:       root,
+		Directory:  directory,
+		PutOptions: options,
+	}
+}
+
 // MkdirOptions controls parts of Mkdir()'s behavior.
 type MkdirOptions struct {
 	UIDMap, GIDMap []idtools.IDMap    // map from containerIDs to hostIDs when creating directories
@@ -712,11 +726,27 @@ func looksLikeAbs(candidate string) bool {
 }
 
 func copier(bulkReader io.Reader, bulkWriter io.Writer, req request) (*response, error) {
+	if err := req.setDefaults(); err != nil {
+		return nil, err
+	}
+	isAlreadyRoot, err := isVolumeRoot(req.Root)
+	if err != nil {
+		return nil, fmt.Errorf("checking if %q is a root directory: %w", req.Root, err)
+	}
+	if !isAlreadyRoot && canChroot {
+		return copierWithSubprocess(bulkReader, bulkWriter, req)
+	}
+	return copierWithoutSubprocess(bulkReader, bulkWriter, req)
+}
+
+// setDefaults fills in the request's root and directory if they weren't
+// specified, and checks that the directory is under the root.
+func (req *request) setDefaults() error {
 	if req.Directory == "" {
 		if req.Root == "" {
 			wd, err := os.Getwd()
 			if err != nil {
-				return nil, fmt.Errorf("getting current working directory: %w", err)
+				return fmt.Errorf("getting current working directory: %w", err)
 			}
 			req.Directory = wd
 		} else {
@@ -726,24 +756,17 @@ func copier(bulkReader io.Reader, bulkWriter io.Writer, req request) (*response,
 	if req.Root == "" {
 		root, err := currentVolumeRoot()
 		if err != nil {
-			return nil, fmt.Errorf("determining root of current volume: %w", err)
+			return fmt.Errorf("determining root of current volume: %w", err)
 		}
 		req.Root = root
 	}
 	if filepath.IsAbs(req.Directory) {
 		_, err := convertToRelSubdirectory(req.Root, req.Directory)
 		if err != nil {
-			return nil, fmt.Errorf("rewriting %q to be relative to %q: %w", req.Directory, req.Root, err)
+			return fmt.Errorf("rewriting %q to be relative to %q: %w", req.Directory, req.Root, err)
 		}
 	}
-	isAlreadyRoot, err := isVolumeRoot(req.Root)
-	if err != nil {
-		return nil, fmt.Errorf("checking if %q is a root directory: %w", req.Root, err)
-	}
-	if !isAlreadyRoot && canChroot {
-		return copierWithSubprocess(bulkReader, bulkWriter, req)
-	}
-	return copierWithoutSubprocess(bulkReader, bulkWriter, req)
+	return nil
 }
 
 func copierWithoutSubprocess(bulkReader io.Reader, bulkWriter io.Writer, req request) (*response, error) {
@@ -832,6 +855,14 @@ func copierWithSubprocess(bulkReader io.Reader, bulkWriter io.Writer, req reques
 	cmd.Stdout = stdoutWrite
 	cmd.Stderr = &errorBuffer
 	cmd.ExtraFiles = []*os.File{bulkReaderRead, bulkWriterWrite}
+	switch {
+	case req.Request == requestGet && req.GetOptions.Passthrough != nil && req.GetOptions.Passthrough.get != nil:
+		cmd.ExtraFiles = append(cmd.ExtraFiles, req.GetOptions.Passthrough.get)
+		req.Passthrough = true
+	case req.Request == requestPut && req.PutOptions.Passthrough != nil && req.PutOptions.Passthrough.put != nil:
+		cmd.ExtraFiles = append(cmd.ExtraFiles, req.PutOptions.Passthrough.put)
+		req.Passthrough = true
+	}
 	if err = cmd.Start(); err != nil {
 		return nil, fmt.Errorf("starting subprocess: %w", err)
 	}
@@ -926,12 +957,8 @@ func copierWithSubprocess(bulkReader io.Reader, bulkWriter io.Writer, req reques
 	return resp, nil
 }
 
-func copierMain() {
-	var chrooted bool
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
-	previousRequestRoot := ""
-
+// setupSubprocess prepares a copier subprocess to handle requests.
+func setupSubprocess() {
 	// Attempt a user and host lookup to force libc (glibc, and possibly others that use dynamic
 	// modules to handle looking up user and host information) to load modules that match the libc
 	// our binary is currently using.  Hopefully they're loaded on first use, so that they won't
@@ -947,10 +974,108 @@ func copierMain() {
 			logrus.SetLevel(logrus.Level(ll))
 		}
 	}
+}
+
+// subprocessRoot tracks the root directory that a copier subprocess has
+// chrooted into.
+type subprocessRoot struct {
+	previousRequestRoot string
+	chrooted            bool
+}
+
+// enter chroots into the root directory named in the first request that it's
+// called for, checks that later requests name the same root directory, and
+// then rewrites the request's directory and globs to be relative to the new
+// root directory, if it chrooted.
+func (r *subprocessRoot) enter(req *request) error {
+	// Multiple requests should list the same root, because we
+	// can't un-chroot to chroot to some other location.
+	if r.previousRequestRoot != "" {
+		// Check that we got the same input value for
+		// where-to-chroot-to.
+		if req.Root != r.previousRequestRoot {
+			return fmt.Errorf("can't change location of chroot from %q to %q", r.previousRequestRoot, req.Root)
+		}
+	} else {
+		// Figure out where to chroot to, if we weren't told.
+		if req.Root == "" {
+			root, err := currentVolumeRoot()
+			if err != nil {
+				return fmt.Errorf("determining root of current volume: %w", err)
+			}
+			req.Root = root
+		}
+		// Change to the specified root directory.
+		chrooted, err := chroot(req.Root)
+		if err != nil {
+			return err
+		}
+		r.chrooted = chrooted
+		r.previousRequestRoot = req.Root
+	}
+
+	req.preservedRoot = req.Root
+	req.rootPrefix = string(os.PathSeparator)
+	req.preservedDirectory = req.Directory
+	req.preservedGlobs = slices.Clone(req.Globs)
+	if r.chrooted {
+		// We'll need to adjust some things now that the root
+		// directory isn't what it was.  Make the directory and
+		// globs absolute paths for simplicity's sake.
+		absoluteDirectory := req.Directory
+		if !filepath.IsAbs(req.Directory) {
+			absoluteDirectory = filepath.Join(req.Root, cleanerReldirectory(req.Directory))
+		}
+		relativeDirectory, err := convertToRelSubdirectory(req.preservedRoot, absoluteDirectory)
+		if err != nil {
+			return fmt.Errorf("rewriting %q to be relative to %q: %w", absoluteDirectory, req.preservedRoot, err)
+		}
+		req.Directory = filepath.Clean(string(os.PathSeparator) + relativeDirectory)
+		absoluteGlobs := make([]string, 0, len(req.Globs))
+		for i, glob := range req.preservedGlobs {
+			if filepath.IsAbs(glob) {
+				relativeGlob, err := convertToRelSubdirectory(req.preservedRoot, glob)
+				if err != nil {
+					return fmt.Errorf("rewriting %q to be relative to %q: %w", glob, req.preservedRoot, err)
+				}
+				absoluteGlobs = append(absoluteGlobs, filepath.Clean(string(os.PathSeparator)+relativeGlob))
+			} else {
+				absoluteGlobs = append(absoluteGlobs, filepath.Join(req.Directory, cleanerReldirectory(req.Globs[i])))
+			}
+		}
+		req.Globs = absoluteGlobs
+		req.rootPrefix = req.Root
+		req.Root = string(os.PathSeparator)
+	} else {
+		// Make the directory and globs absolute paths for
+		// simplicity's sake.
+		if !filepath.IsAbs(req.Directory) {
+			req.Directory = filepath.Join(req.Root, cleanerReldirectory(req.Directory))
+		}
+		absoluteGlobs := make([]string, 0, len(req.Globs))
+		for i, glob := range req.preservedGlobs {
+			if filepath.IsAbs(glob) {
+				absoluteGlobs = append(absoluteGlobs, req.Globs[i])
+			} else {
+				absoluteGlobs = append(absoluteGlobs, filepath.Join(req.Directory, cleanerReldirectory(req.Globs[i])))
+			}
+		}
+		req.Globs = absoluteGlobs
+	}
+	return nil
+}
+
+func copierMain() {
+	decoder := json.NewDecoder(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	var root subprocessRoot
+
+	setupSubprocess()
 
 	// Set up descriptors for receiving and sending tarstreams.
 	bulkReader := os.NewFile(3, "bulk-reader")
 	bulkWriter := os.NewFile(4, "bulk-writer")
+	var passthrough *Passthrough
 
 	for {
 		// Read a request.
@@ -962,89 +1087,27 @@ func copierMain() {
 		if req.Request == requestQuit {
 			// Making Quit a specific request means that we could
 			// run Stat() at a caller's behest before using the
-			// same process for Get() or Put().  Maybe later.
+			// same process for Get() or Put().  Sessions do that.
 			break
 		}
 
-		// Multiple requests should list the same root, because we
-		// can't un-chroot to chroot to some other location.
-		if previousRequestRoot != "" {
-			// Check that we got the same input value for
-			// where-to-chroot-to.
-			if req.Root != previousRequestRoot {
-				fmt.Fprintf(os.Stderr, "error: can't change location of chroot from %q to %q", previousRequestRoot, req.Root)
-				os.Exit(1)
-			}
-		} else {
-			// Figure out where to chroot to, if we weren't told.
-			if req.Root == "" {
-				root, err := currentVolumeRoot()
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "error determining root of current volume: %v", err)
-					os.Exit(1)
-				}
-				req.Root = root
-			}
-			// Change to the specified root directory.
-			var err error
-			chrooted, err = chroot(req.Root)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "%v", err)
-				os.Exit(1)
-			}
-
-			previousRequestRoot = req.Root
+		if err := root.enter(req); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v", err)
+			os.Exit(1)
 		}
-
-		req.preservedRoot = req.Root
-		req.rootPrefix = string(os.PathSeparator)
-		req.preservedDirectory = req.Directory
-		req.preservedGlobs = slices.Clone(req.Globs)
-		if chrooted {
-			// We'll need to adjust some things now that the root
-			// directory isn't what it was.  Make the directory and
-			// globs absolute paths for simplicity's sake.
-			absoluteDirectory := req.Directory
-			if !filepath.IsAbs(req.Directory) {
-				absoluteDirectory = filepath.Join(req.Root, cleanerReldirectory(req.Directory))
-			}
-			relativeDirectory, err := convertToRelSubdirectory(req.preservedRoot, absoluteDirectory)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "error rewriting %q to be relative to %q: %v", absoluteDirectory, req.preservedRoot, err)
-				os.Exit(1)
-			}
-			req.Directory = filepath.Clean(string(os.PathSeparator) + relativeDirectory)
-			absoluteGlobs := make([]string, 0, len(req.Globs))
-			for i, glob := range req.preservedGlobs {
-				if filepath.IsAbs(glob) {
-					relativeGlob, err := convertToRelSubdirectory(req.preservedRoot, glob)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "error rewriting %q to be relative to %q: %v", glob, req.preservedRoot, err)
-						os.Exit(1)
-					}
-					absoluteGlobs = append(absoluteGlobs, filepath.Clean(string(os.PathSeparator)+relativeGlob))
-				} else {
-					absoluteGlobs = append(absoluteGlobs, filepath.Join(req.Directory, cleanerReldirectory(req.Globs[i])))
+		if req.Passthrough {
+			// Our parent gave us one end of a Passthrough.
+			if passthrough == nil {
+				passthrough = &Passthrough{}
+				switch req.Request {
+				case requestGet:
+					passthrough.get = os.NewFile(5, "passthrough-get")
+				case requestPut:
+					passthrough.put = os.NewFile(5, "passthrough-put")
 				}
 			}
-			req.Globs = absoluteGlobs
-			req.rootPrefix = req.Root
-			req.Root = string(os.PathSeparator)
-		} else {
-			// Make the directory and globs absolute paths for
-			// simplicity's sake.
-			if !filepath.IsAbs(req.Directory) {
-				req.Directory = filepath.Join(req.Root, cleanerReldirectory(req.Directory))
-			}
-			absoluteGlobs := make([]string, 0, len(req.Globs))
-			for i, glob := range req.preservedGlobs {
-				if filepath.IsAbs(glob) {
-					absoluteGlobs = append(absoluteGlobs, req.Globs[i])
-				} else {
-					absoluteGlobs = append(absoluteGlobs, filepath.Join(req.Directory, cleanerReldirectory(req.Globs[i])))
-				}
-			}
-			req.Globs = absoluteGlobs
+			req.GetOptions.Passthrough = passthrough
+			req.PutOptions.Passthrough = passthrough
 		}
 		resp, cb, err := copierHandler(bulkReader, bulkWriter, *req)
 		if err != nil {
@@ -1820,6 +1883,10 @@ func copierHandlerGetOne(srcfi os.FileInfo, symlinkTarget, name, contentPath str
 						hdr.ChangeTime = timestamp
 					}
 				}
+				if hdr.Typeflag == tar.TypeReg {
+					// we don't have a descriptor for this one
+					options.Passthrough.offer(nil, hdr)
+				}
 				if err = tw.WriteHeader(hdr); err != nil {
 					return fmt.Errorf("writing tar header from %q to pipe: %w", contentPath, err)
 				}
@@ -1914,6 +1981,10 @@ func copierHandlerGetOne(srcfi os.FileInfo, symlinkTarget, name, contentPath str
 			hdr.ChangeTime = timestamp
 		}
 	}
+	// offer the file to the Put() that's reading the archive, if we can
+	if hdr.Typeflag == tar.TypeReg {
+		options.Passthrough.offer(f, hdr)
+	}
 	// output the header
 	if err = tw.WriteHeader(hdr); err != nil {
 		return fmt.Errorf("writing header for %s (%s): %w", contentPath, hdr.Name, err)
@@ -2039,7 +2110,7 @@ func copierHandlerPut(bulkReader io.Reader, req request, idMappings *idtools.IDM
 		}
 		return nil
 	}
-	createFile := func(path string, tr *tar.Reader) (int64, error) {
+	createFile := func(path string, tr *tar.Reader, size int64, src *os.File) (int64, error) {
 		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_EXCL, 0o600)
 		if err != nil && errors.Is(err, os.ErrExist) {
 			if req.PutOptions.NoOverwriteDirNonDir {
@@ -2070,6 +2141,11 @@ func copierHandlerPut(bulkReader io.Reader, req request, idMappings *idtools.IDM
 			return 0, fmt.Errorf("copier: put: error opening file %q for writing: %w", path, err)
 		}
 		defer f.Close()
+		// if we were handed the source file, try to clone it or
+		// have the kernel copy it, and skip its archived contents
+		if src != nil && cloneFileContents(f, src, size) {
+			return size, nil
+		}
 		n, err := io.Copy(f, tr)
 		if err != nil {
 			return n, fmt.Errorf("copier: put: error writing file %q: %w", path, err)
@@ -2181,7 +2257,11 @@ func copierHandlerPut(bulkReader io.Reader, req request, idMappings *idtools.IDM
 				return fmt.Errorf("unrecognized Typeflag %c", hdr.Typeflag)
 			case tar.TypeReg:
 				var written int64
-				written, err = createFile(path, tr)
+				src := req.PutOptions.Passthrough.take(hdr)
+				written, err = createFile(path, tr, hdr.Size, src)
+				if src != nil {
+					src.Close()
+				}
 				// only check the length if there wasn't an error, which we'll
 				// check along with errors for other types of entries
 				if err == nil && written != hdr.Size {
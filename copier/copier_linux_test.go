@@ -193,3 +193,87 @@ func TestGetNoCrossDevice(t *testing.T) {
 		t.Logf("got unexpected entry for %q", th.Name)
 	}
 }
+
+func TestPassthroughNoChroot(t *testing.T) {
+	couldChroot := canChroot
+	canChroot = false
+	testPassthrough(t)
+	canChroot = couldChroot
+}
+
+func TestPassthroughChroot(t *testing.T) {
+	if uid != 0 {
+		t.Skipf("chroot() requires root privileges, skipping")
+	}
+	couldChroot := canChroot
+	canChroot = true
+	testPassthrough(t)
+	canChroot = couldChroot
+}
+
+func testPassthrough(t *testing.T) {
+	src := t.TempDir()
+	big := make([]byte, 4*1024*1024+17)
+	for i := range big {
+		big[i] = byte(i % 251)
+	}
+	contents := map[string][]byte{
+		"small":          []byte("hello, world\n"),
+		"big":            big,
+		"empty":          {},
+		"subdir/nested":  []byte("nested\n"),
+		"subdir/another": []byte("another\n"),
+	}
+	for name, content := range contents {
+		require.NoError(t, os.MkdirAll(filepath.Join(src, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), content, 0o640))
+	}
+	require.NoError(t, os.Link(filepath.Join(src, "small"), filepath.Join(src, "hardlink")))
+	contents["hardlink"] = contents["small"]
+
+	for _, putUsesPassthrough := range []bool{true, false} {
+		t.Run(fmt.Sprintf("put=%v", putUsesPassthrough), func(t *testing.T) {
+			passthrough, err := NewPassthrough()
+			require.NoError(t, err)
+			defer passthrough.Close()
+			putOptions := PutOptions{
+				Rename:    map[string]string{"subdir/another": "renamed"},
+				ChmodDirs: &[]os.FileMode{0o755}[0],
+			}
+			if putUsesPassthrough {
+				putOptions.Passthrough = passthrough
+			}
+			dest := t.TempDir()
+			pipeReader, pipeWriter := io.Pipe()
+			var getErr error
+			go func() {
+				getErr = Get(src, src, GetOptions{Passthrough: passthrough}, []string{"."}, pipeWriter)
+				pipeWriter.Close()
+			}()
+			err = Put(dest, dest, putOptions, pipeReader)
+			pipeReader.Close()
+			require.NoError(t, err)
+			require.NoError(t, getErr)
+			for name, content := range contents {
+				if name == "subdir/another" {
+					name = "renamed"
+				}
+				actual, err := os.ReadFile(filepath.Join(dest, name))
+				require.NoError(t, err)
+				assert.Equalf(t, content, actual, "contents of %q", name)
+				st, err := os.Stat(filepath.Join(dest, name))
+				require.NoError(t, err)
+				assert.Equalf(t, os.FileMode(0o640), st.Mode().Perm(), "permissions of %q", name)
+			}
+			if !canChroot {
+				// the hard link is archived as a link, so only the
+				// other files should have been passed through
+				expected := 0
+				if putUsesPassthrough {
+					expected = len(contents) - 1
+				}
+				assert.Equal(t, expected, passthrough.taken, "number of files passed through")
+			}
+		})
+	}
+}
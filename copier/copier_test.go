@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
@@ -23,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	digest "github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -3350,3 +3352,113 @@ func testSymlink(t *testing.T) {
 		assert.Equal(t, "new-target", got)
 	})
 }
+
+func TestSessionNoChroot(t *testing.T) {
+	couldChroot := canChroot
+	canChroot = false
+	testSession(t)
+	canChroot = couldChroot
+}
+
+func testSession(t *testing.T) {
+	src, dest := t.TempDir(), t.TempDir()
+	contents := map[string]string{
+		"a": "first\n",
+		"b": "second\n",
+		"c": "third\n",
+		"d": "fourth\n",
+	}
+	names := slices.Sorted(maps.Keys(contents))
+	for name, content := range contents {
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), []byte(content), 0o644))
+	}
+
+	getSession, err := NewSession(src)
+	require.NoError(t, err)
+	defer getSession.Close()
+	putSession, err := NewSession(dest)
+	require.NoError(t, err)
+	defer putSession.Close()
+	assert.Equal(t, canChroot, getSession.cmd != nil, "whether or not the session started a subprocess")
+
+	stats, err := getSession.Stat(src, StatOptions{}, []string{"*"})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Len(t, stats[0].Globbed, len(contents))
+
+	// several Get() requests at once, followed by a failed one
+	archives := make([]bytes.Buffer, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Go(func() {
+			errs[i] = getSession.Get(src, GetOptions{}, []string{name}, &archives[i])
+		})
+	}
+	wg.Wait()
+	for i := range names {
+		require.NoErrorf(t, errs[i], "reading %q", names[i])
+	}
+	assert.Error(t, getSession.Get(src, GetOptions{}, []string{"missing"}, io.Discard))
+
+	// the sessions should still be usable
+	for i := range names {
+		require.NoErrorf(t, putSession.Put(dest, PutOptions{}, &archives[i]), "writing %q", names[i])
+	}
+	for name, content := range contents {
+		actual, err := os.ReadFile(filepath.Join(dest, name))
+		require.NoError(t, err)
+		assert.Equalf(t, content, string(actual), "contents of %q", name)
+	}
+
+	require.NoError(t, getSession.Close())
+	require.NoError(t, putSession.Close())
+	if canChroot {
+		assert.Error(t, getSession.Get(src, GetOptions{}, []string{"a"}, io.Discard), "closed session should not accept requests")
+	}
+}
+
+func TestManifestWriterNoChroot(t *testing.T) {
+	couldChroot := canChroot
+	canChroot = false
+	defer func() { canChroot = couldChroot }()
+
+	src := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(src, "subdir"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "subdir", "file"), []byte("contents\n"), 0o640))
+	require.NoError(t, os.Link(filepath.Join(src, "subdir", "file"), filepath.Join(src, "subdir", "hardlink")))
+	require.NoError(t, os.Symlink("file", filepath.Join(src, "subdir", "symlink")))
+	require.NoError(t, os.Mkdir(filepath.Join(src, "subdir", "nested"), 0o750))
+
+	var archive bytes.Buffer
+	require.NoError(t, Get(src, src, GetOptions{}, []string{"subdir"}, &archive))
+	m := NewManifestWriter("/dest/subdir")
+	_, err := io.Copy(m, &archive)
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	entries := make(map[string]ManifestEntry)
+	for _, entry := range m.Entries() {
+		entries[entry.Path] = entry
+	}
+	require.Contains(t, entries, "/dest/subdir/nested")
+	assert.Equal(t, "dir", entries["/dest/subdir/nested"].Type)
+	assert.Equal(t, "0750", entries["/dest/subdir/nested"].Mode)
+	require.Contains(t, entries, "/dest/subdir/file")
+	assert.Equal(t, "file", entries["/dest/subdir/file"].Type)
+	assert.Equal(t, "0640", entries["/dest/subdir/file"].Mode)
+	assert.Equal(t, int64(len("contents\n")), entries["/dest/subdir/file"].Size)
+	assert.Equal(t, digest.FromString("contents\n"), entries["/dest/subdir/file"].Digest)
+	require.Contains(t, entries, "/dest/subdir/hardlink")
+	assert.Equal(t, "hardlink", entries["/dest/subdir/hardlink"].Type)
+	assert.Equal(t, "/dest/subdir/file", entries["/dest/subdir/hardlink"].Linkname)
+	require.Contains(t, entries, "/dest/subdir/symlink")
+	assert.Equal(t, "symlink", entries["/dest/subdir/symlink"].Type)
+	assert.Equal(t, "file", entries["/dest/subdir/symlink"].Linkname)
+
+	// garbage should be reported when the writer is closed, not when it's written
+	m = NewManifestWriter("/")
+	_, err = m.Write(bytes.Repeat([]byte("garbage"), 1024))
+	require.NoError(t, err)
+	assert.Error(t, m.Close())
+}
@@ -199,3 +199,13 @@ func TestSymlinkChroot(t *testing.T) {
 	testSymlink(t)
 	canChroot = couldChroot
 }
+
+func TestSessionChroot(t *testing.T) {
+	if uid != 0 {
+		t.Skip("chroot() requires root privileges, skipping")
+	}
+	couldChroot := canChroot
+	canChroot = true
+	testSession(t)
+	canChroot = couldChroot
+}
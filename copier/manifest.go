@@ -0,0 +1,134 @@
+package copier
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// ManifestEntry describes one item in an archive which was produced by Get()
+// or which was passed to Put().
+type ManifestEntry struct {
+	Path     string            `json:"path"`
+	Type     string            `json:"type"` // "file", "dir", "symlink", "hardlink", "char", "block", or "fifo"
+	Mode     string            `json:"mode"` // permissions, including setuid/setgid/sticky bits, in octal
+	UID      int               `json:"uid"`
+	GID      int               `json:"gid"`
+	Size     int64             `json:"size"`
+	Digest   digest.Digest     `json:"digest,omitempty"`   // for regular files
+	Linkname string            `json:"linkname,omitempty"` // for symbolic and hard links
+	Xattrs   map[string][]byte `json:"xattrs,omitempty"`
+}
+
+// ManifestWriter reads an archive which is written to it, and builds a list
+// of ManifestEntry structures describing its contents, including digests of
+// the contents of regular files.  It is meant to be written to alongside
+// Put(), using an io.MultiWriter or io.TeeReader.  Writes to it don't fail if
+// the archive can't be parsed, but Close() returns the error.
+type ManifestWriter struct {
+	directory  string
+	pipeWriter *io.PipeWriter
+	done       chan struct{}
+	entries    []ManifestEntry
+	err        error
+}
+
+// NewManifestWriter creates a ManifestWriter which treats the names of items
+// in the archive as being relative to the specified directory.
+func NewManifestWriter(directory string) *ManifestWriter {
+	pipeReader, pipeWriter := io.Pipe()
+	m := &ManifestWriter{
+		directory:  path.Join("/", directory),
+		pipeWriter: pipeWriter,
+		done:       make(chan struct{}),
+	}
+	go func() {
+		defer close(m.done)
+		m.err = m.read(pipeReader)
+		// Consume anything that's left over, so that writes
+		// don't block.
+		_, _ = io.Copy(io.Discard, pipeReader)
+		pipeReader.Close()
+	}()
+	return m
+}
+
+func (m *ManifestWriter) read(reader io.Reader) error {
+	tr := tar.NewReader(reader)
+	hdr, err := tr.Next()
+	for err == nil {
+		entry := ManifestEntry{
+			Path: path.Join(m.directory, hdr.Name),
+			Mode: fmt.Sprintf("%04o", hdr.Mode&0o7777),
+			UID:  hdr.Uid,
+			GID:  hdr.Gid,
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			entry.Type = "file"
+			entry.Size = hdr.Size
+			digester := digest.Canonical.Digester()
+			if _, err := io.Copy(digester.Hash(), tr); err != nil {
+				return fmt.Errorf("digesting %q: %w", hdr.Name, err)
+			}
+			entry.Digest = digester.Digest()
+		case tar.TypeDir:
+			entry.Type = "dir"
+		case tar.TypeSymlink:
+			entry.Type = "symlink"
+			entry.Linkname = hdr.Linkname
+		case tar.TypeLink:
+			entry.Type = "hardlink"
+			entry.Linkname = path.Join(m.directory, hdr.Linkname)
+		case tar.TypeChar:
+			entry.Type = "char"
+		case tar.TypeBlock:
+			entry.Type = "block"
+		case tar.TypeFifo:
+			entry.Type = "fifo"
+		default:
+			entry.Type = string(hdr.Typeflag)
+		}
+		for key, value := range hdr.PAXRecords {
+			if name, ok := strings.CutPrefix(key, xattrPAXRecordNamespace); ok {
+				if entry.Xattrs == nil {
+					entry.Xattrs = make(map[string][]byte)
+				}
+				entry.Xattrs[name] = []byte(value)
+			}
+		}
+		m.entries = append(m.entries, entry)
+		hdr, err = tr.Next()
+	}
+	if !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	return nil
+}
+
+// Write passes more of the archive to the ManifestWriter.
+func (m *ManifestWriter) Write(p []byte) (int, error) {
+	if _, err := m.pipeWriter.Write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close signals the end of the archive, and returns any error that was
+// encountered while reading it.
+func (m *ManifestWriter) Close() error {
+	m.pipeWriter.Close()
+	<-m.done
+	return m.err
+}
+
+// Entries returns descriptions of the items in the archive, in the order in
+// which they appeared.  It should only be called after Close().
+func (m *ManifestWriter) Entries() []ManifestEntry {
+	return m.entries
+}
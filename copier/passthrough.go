@@ -0,0 +1,55 @@
+package copier
+
+import (
+	"os"
+)
+
+// Passthrough is a channel between a Get() and a Put() which are called by the
+// same process, over which Get() hands Put() open descriptors for the regular
+// files that it archives.  When Put() can clone the files (using reflinks on
+// filesystems which support them) or have the kernel copy their contents, it
+// does that instead of writing the contents that it reads from the archive.
+// The archive which Get() produces is unchanged, so it can still be digested,
+// and Put() can still fall back to using its contents.
+//
+// The archive which is passed from Get() to Put() can be filtered along the
+// way, so long as regular file entries are neither dropped nor reordered.
+// A Passthrough should only be used for one Get() and one Put().
+type Passthrough struct {
+	get, put *os.File // ends of the socket pair, for Get() and Put()
+	sent     int      // number of regular files Get() has produced
+	received int      // number of regular files Put() has consumed
+	taken    int      // number of descriptors Put() has used
+	pending  *passthroughFile
+}
+
+// passthroughFile is a descriptor which Put() has received from Get(), along
+// with the header fields that Get() produced for it.
+type passthroughFile struct {
+	Sequence int   // Get()'s count of regular files, starting at 1
+	Size     int64 // from the header
+	ModTime  int64 // from the header, in seconds since the epoch
+	file     *os.File
+}
+
+// Close closes both ends of the Passthrough, along with any descriptors which
+// were sent but not used.
+func (p *Passthrough) Close() error {
+	if p == nil {
+		return nil
+	}
+	if p.pending != nil && p.pending.file != nil {
+		p.pending.file.Close()
+		p.pending = nil
+	}
+	var err error
+	for _, f := range []*os.File{p.get, p.put} {
+		if f != nil {
+			if closeErr := f.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	}
+	p.get, p.put = nil, nil
+	return err
+}
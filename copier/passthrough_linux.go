@@ -0,0 +1,169 @@
+package copier
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// NewPassthrough creates a Passthrough which can be set in both the GetOptions
+// and PutOptions of a Get() and a Put() which are called to copy content
+// between directories on the local system.  The caller should Close() it
+// after both have returned.
+func NewPassthrough() (*Passthrough, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("creating socket pair: %w", err)
+	}
+	return &Passthrough{
+		get: os.NewFile(uintptr(fds[0]), "passthrough-get"),
+		put: os.NewFile(uintptr(fds[1]), "passthrough-put"),
+	}, nil
+}
+
+// offer is called by Get() before it writes the header for a regular file,
+// and sends Put() a descriptor for the file, if f isn't nil.  If the
+// descriptor can't be sent without blocking, it isn't sent.
+func (p *Passthrough) offer(f *os.File, hdr *tar.Header) {
+	if p == nil || p.get == nil {
+		return
+	}
+	p.sent++
+	if f == nil {
+		return
+	}
+	payload, err := json.Marshal(&passthroughFile{
+		Sequence: p.sent,
+		Size:     hdr.Size,
+		ModTime:  passthroughModTime(hdr),
+	})
+	if err != nil {
+		logrus.Debugf("copier: encoding passthrough message: %v", err)
+		return
+	}
+	rights := unix.UnixRights(int(f.Fd()))
+	if err := unix.Sendmsg(int(p.get.Fd()), payload, rights, nil, unix.MSG_DONTWAIT); err != nil {
+		logrus.Debugf("copier: not passing descriptor for %q: %v", hdr.Name, err)
+	}
+}
+
+// passthroughModTime returns the header's ModTime as it will be read from
+// the archive, since tar.Writer rounds it to the nearest second.
+func passthroughModTime(hdr *tar.Header) int64 {
+	return hdr.ModTime.Round(time.Second).Unix()
+}
+
+// receive reads the next descriptor that Get() sent, if one is waiting.
+func (p *Passthrough) receive() (*passthroughFile, error) {
+	payload := make([]byte, 256)
+	oob := make([]byte, unix.CmsgSpace(4))
+	n, oobn, _, _, err := unix.Recvmsg(int(p.put.Fd()), payload, oob, unix.MSG_DONTWAIT|unix.MSG_CMSG_CLOEXEC)
+	if err != nil {
+		if errors.Is(err, unix.EAGAIN) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	messages, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, fmt.Errorf("parsing control message: %w", err)
+	}
+	var fds []int
+	for _, message := range messages {
+		rights, err := unix.ParseUnixRights(&message)
+		if err != nil {
+			continue
+		}
+		fds = append(fds, rights...)
+	}
+	if len(fds) != 1 {
+		for _, fd := range fds {
+			unix.Close(fd)
+		}
+		return nil, fmt.Errorf("expected 1 descriptor, got %d", len(fds))
+	}
+	file := &passthroughFile{file: os.NewFile(uintptr(fds[0]), "passthrough-file")}
+	if err := json.Unmarshal(payload[:n], file); err != nil {
+		file.file.Close()
+		return nil, fmt.Errorf("decoding passthrough message: %w", err)
+	}
+	return file, nil
+}
+
+// take is called by Put() when it reads the header for a regular file, and
+// returns the descriptor that Get() sent for it, if there is one and it still
+// looks like it matches the header.  The caller should close the returned
+// file.
+func (p *Passthrough) take(hdr *tar.Header) *os.File {
+	if p == nil || p.put == nil {
+		return nil
+	}
+	p.received++
+	for {
+		if p.pending == nil {
+			pending, err := p.receive()
+			if err != nil {
+				logrus.Debugf("copier: receiving passthrough descriptor: %v", err)
+			}
+			if pending == nil {
+				return nil
+			}
+			p.pending = pending
+		}
+		pending := p.pending
+		if pending.Sequence > p.received {
+			// sent for a later file
+			return nil
+		}
+		p.pending = nil
+		if pending.Sequence < p.received {
+			// sent for an earlier file that we didn't get to use
+			pending.file.Close()
+			continue
+		}
+		if pending.Size != hdr.Size || pending.ModTime != passthroughModTime(hdr) {
+			logrus.Debugf("copier: passthrough descriptor for %q doesn't match its header", hdr.Name)
+			pending.file.Close()
+			return nil
+		}
+		var st unix.Stat_t
+		if err := unix.Fstat(int(pending.file.Fd()), &st); err != nil || st.Size != hdr.Size {
+			logrus.Debugf("copier: passthrough descriptor for %q changed size", hdr.Name)
+			pending.file.Close()
+			return nil
+		}
+		p.taken++
+		return pending.file
+	}
+}
+
+// cloneFileContents attempts to populate dst, an empty file, with the first
+// size bytes of src, first by cloning src, then by asking the kernel to copy
+// its contents.  It returns false if neither worked, in which case dst is
+// left empty.  The file offset of src isn't changed.
+func cloneFileContents(dst, src *os.File, size int64) bool {
+	dstFd, srcFd := int(dst.Fd()), int(src.Fd())
+	err := unix.IoctlFileClone(dstFd, srcFd)
+	if err == nil {
+		return true
+	}
+	logrus.Debugf("copier: cloning %q: %v", dst.Name(), err)
+	var srcOffset, dstOffset int64
+	for dstOffset < size {
+		n, err := unix.CopyFileRange(srcFd, &srcOffset, dstFd, &dstOffset, int(size-dstOffset), 0)
+		if err != nil || n == 0 {
+			logrus.Debugf("copier: copying %q using copy_file_range (%d bytes copied): %v", dst.Name(), dstOffset, err)
+			if err := unix.Ftruncate(dstFd, 0); err != nil {
+				logrus.Debugf("copier: truncating %q: %v", dst.Name(), err)
+			}
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,455 @@
+package copier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"go.podman.io/storage/pkg/reexec"
+)
+
+// Session is a connection to a long-lived copier subprocess which chroots into
+// a root directory once, and then handles Stat(), Get(), and Put() requests
+// for locations under it, including more than one request at a time, instead
+// of starting a new subprocess for every request.  If a subprocess wouldn't
+// be used for requests which name the root directory, requests are handled
+// in-process.  The caller should Close() the Session when it's done with it.
+type Session struct {
+	root      string
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	socket    *os.File // our end of the socket that descriptors are passed over
+	stderr    sessionStderr
+	done      chan struct{} // closed when we stop reading replies
+	closeOnce sync.Once
+	closeErr  error
+	sendLock  sync.Mutex // serializes sending requests
+	encoder   *json.Encoder
+	lock      sync.Mutex // protects the fields below
+	nextID    int
+	replies   map[int]chan sessionReply
+	err       error // set once we can't send any more requests
+}
+
+// sessionRequest is a request that's sent to a session subprocess.
+type sessionRequest struct {
+	ID      int
+	Files   int `json:",omitempty"` // the number of descriptors passed along with the request
+	Request request
+}
+
+// sessionReply is a session subprocess's reply to a request, which it sends
+// after any bulk data has been transferred.
+type sessionReply struct {
+	ID       int
+	Response *response `json:",omitempty"`
+	Error    string    `json:",omitempty"`
+}
+
+// sessionStderr collects whatever a session subprocess writes to stderr.
+type sessionStderr struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (s *sessionStderr) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buffer.Write(p)
+}
+
+func (s *sessionStderr) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return strings.TrimFunc(s.buffer.String(), unicode.IsSpace)
+}
+
+// NewSession starts a Session for handling requests under the specified root
+// directory.  If root is not specified, the current root directory is used.
+func NewSession(root string) (*Session, error) {
+	if root == "" {
+		currentRoot, err := currentVolumeRoot()
+		if err != nil {
+			return nil, fmt.Errorf("determining root of current volume: %w", err)
+		}
+		root = currentRoot
+	}
+	isAlreadyRoot, err := isVolumeRoot(root)
+	if err != nil {
+		return nil, fmt.Errorf("checking if %q is a root directory: %w", root, err)
+	}
+	s := &Session{root: root}
+	if isAlreadyRoot || !canChroot {
+		return s, nil
+	}
+	parentSocket, childSocket, err := newSessionSocketPair()
+	if err != nil {
+		return nil, fmt.Errorf("creating socket pair: %w", err)
+	}
+	defer childSocket.Close()
+	cmd := reexec.Command(copierSessionCommand)
+	cmd.Dir = "/"
+	cmd.Env = append([]string{fmt.Sprintf("LOGLEVEL=%d", logrus.GetLevel())}, os.Environ()...)
+	cmd.Stderr = &s.stderr
+	cmd.ExtraFiles = []*os.File{childSocket}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		parentSocket.Close()
+		return nil, fmt.Errorf("creating pipe for copier subprocess: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		parentSocket.Close()
+		stdin.Close()
+		return nil, fmt.Errorf("creating pipe for copier subprocess: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		parentSocket.Close()
+		return nil, fmt.Errorf("starting copier subprocess: %w", err)
+	}
+	s.cmd = cmd
+	s.stdin = stdin
+	s.socket = parentSocket
+	s.encoder = json.NewEncoder(stdin)
+	s.replies = make(map[int]chan sessionReply)
+	s.done = make(chan struct{})
+	go s.readReplies(stdout)
+	return s, nil
+}
+
+// readReplies reads replies from the subprocess and hands each of them to
+// the request which is waiting for it.
+func (s *Session) readReplies(stdout io.Reader) {
+	defer close(s.done)
+	decoder := json.NewDecoder(stdout)
+	for {
+		var reply sessionReply
+		if err := decoder.Decode(&reply); err != nil {
+			if errorText := s.stderr.String(); errorText != "" {
+				err = fmt.Errorf("%s: %w", errorText, err)
+			}
+			s.fail(fmt.Errorf("reading reply from copier subprocess: %w", err))
+			return
+		}
+		s.lock.Lock()
+		replyChan, ok := s.replies[reply.ID]
+		delete(s.replies, reply.ID)
+		s.lock.Unlock()
+		if !ok {
+			logrus.Debugf("copier: discarding reply to unknown request %d", reply.ID)
+			continue
+		}
+		replyChan <- reply
+	}
+}
+
+// fail prevents any more requests from being sent, and fails any requests
+// which are still waiting for replies.
+func (s *Session) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	for id, replyChan := range s.replies {
+		replyChan <- sessionReply{ID: id, Error: err.Error()}
+		delete(s.replies, id)
+	}
+}
+
+// send passes a request, and any descriptors which go with it, to the
+// subprocess, and returns a channel which will receive its reply.
+func (s *Session) send(req request, files []*os.File) (chan sessionReply, error) {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	s.lock.Lock()
+	if s.err != nil {
+		s.lock.Unlock()
+		return nil, s.err
+	}
+	id := s.nextID
+	s.nextID++
+	replyChan := make(chan sessionReply, 1)
+	s.replies[id] = replyChan
+	s.lock.Unlock()
+	if len(files) > 0 {
+		if err := sendSessionFiles(s.socket, id, files); err != nil {
+			err = fmt.Errorf("passing descriptors to copier subprocess: %w", err)
+			s.fail(err)
+			return nil, err
+		}
+	}
+	if err := s.encoder.Encode(&sessionRequest{ID: id, Files: len(files), Request: req}); err != nil {
+		err = fmt.Errorf("encoding work request for copier subprocess: %w", err)
+		s.fail(err)
+		return nil, err
+	}
+	return replyChan, nil
+}
+
+// do handles a single request, either in-process or by passing it to the
+// subprocess along with descriptors for passing bulk data to and from it.
+func (s *Session) do(bulkReader io.Reader, bulkWriter io.Writer, req request) (*response, error) {
+	req.Root = s.root
+	if err := req.setDefaults(); err != nil {
+		return nil, err
+	}
+	if s.cmd == nil {
+		return copierWithoutSubprocess(bulkReader, bulkWriter, req)
+	}
+	if bulkReader == nil {
+		bulkReader = bytes.NewReader([]byte{})
+	}
+	if bulkWriter == nil {
+		bulkWriter = io.Discard
+	}
+	var files []*os.File
+	var bulkReaderRead, bulkReaderWrite, bulkWriterRead, bulkWriterWrite *os.File
+	defer closeIfNotNilYet(&bulkReaderRead, "child bulk content reader pipe, read end")
+	defer closeIfNotNilYet(&bulkReaderWrite, "child bulk content reader pipe, write end")
+	defer closeIfNotNilYet(&bulkWriterRead, "child bulk content writer pipe, read end")
+	defer closeIfNotNilYet(&bulkWriterWrite, "child bulk content writer pipe, write end")
+	if req.Request == requestGet || req.Request == requestPut {
+		var err error
+		if bulkReaderRead, bulkReaderWrite, err = os.Pipe(); err != nil {
+			return nil, fmt.Errorf("pipe: %w", err)
+		}
+		if bulkWriterRead, bulkWriterWrite, err = os.Pipe(); err != nil {
+			return nil, fmt.Errorf("pipe: %w", err)
+		}
+		files = append(files, bulkReaderRead, bulkWriterWrite)
+		switch {
+		case req.Request == requestGet && req.GetOptions.Passthrough != nil && req.GetOptions.Passthrough.get != nil:
+			files = append(files, req.GetOptions.Passthrough.get)
+			req.Passthrough = true
+		case req.Request == requestPut && req.PutOptions.Passthrough != nil && req.PutOptions.Passthrough.put != nil:
+			files = append(files, req.PutOptions.Passthrough.put)
+			req.Passthrough = true
+		}
+	}
+	replyChan, err := s.send(req, files)
+	if err != nil {
+		return nil, err
+	}
+	closeIfNotNilYet(&bulkReaderRead, "child bulk content reader pipe, read end")
+	closeIfNotNilYet(&bulkWriterWrite, "child bulk content writer pipe, write end")
+	var wg sync.WaitGroup
+	var readError, writeError error
+	if bulkWriterRead != nil {
+		wg.Go(func() {
+			_, writeError = io.Copy(bulkWriter, bulkWriterRead)
+			closeIfNotNilYet(&bulkWriterRead, "child bulk content writer pipe, read end")
+		})
+	}
+	if bulkReaderWrite != nil {
+		wg.Go(func() {
+			_, readError = io.Copy(bulkReaderWrite, bulkReader)
+			closeIfNotNilYet(&bulkReaderWrite, "child bulk content reader pipe, write end")
+		})
+	}
+	reply := <-replyChan
+	wg.Wait()
+	if reply.Error != "" {
+		return nil, errors.New(reply.Error)
+	}
+	if readError != nil {
+		return nil, fmt.Errorf("passing bulk input to subprocess: %w", readError)
+	}
+	if writeError != nil {
+		return nil, fmt.Errorf("passing bulk output from subprocess: %w", writeError)
+	}
+	if reply.Response == nil {
+		return nil, errors.New("internal error: copier subprocess didn't send a response")
+	}
+	return reply.Response, nil
+}
+
+// Stat is like the package-level Stat(), for the Session's root directory.
+func (s *Session) Stat(directory string, options StatOptions, globs []string) ([]*StatsForGlob, error) {
+	resp, err := s.do(nil, nil, newStatRequest(s.root, directory, options, globs))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Stat.Globs, nil
+}
+
+// Get is like the package-level Get(), for the Session's root directory.  It
+// can be called while other requests are being handled.
+func (s *Session) Get(directory string, options GetOptions, globs []string, bulkWriter io.Writer) error {
+	resp, err := s.do(nil, bulkWriter, newGetRequest(s.root, directory, options, globs))
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// Put is like the package-level Put(), for the Session's root directory.  It
+// can be called while other requests are being handled.
+func (s *Session) Put(directory string, options PutOptions, bulkReader io.Reader) error {
+	resp, err := s.do(bulkReader, nil, newPutRequest(s.root, directory, options))
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// Close waits for any requests which are being handled to finish, and then
+// stops the subprocess, if there is one.
+func (s *Session) Close() error {
+	if s == nil || s.cmd == nil {
+		return nil
+	}
+	s.closeOnce.Do(func() {
+		s.sendLock.Lock()
+		s.lock.Lock()
+		if s.err == nil {
+			s.err = errors.New("copier session is closed")
+			s.lock.Unlock()
+			if err := s.encoder.Encode(&sessionRequest{Request: request{Request: requestQuit}}); err != nil {
+				logrus.Debugf("copier: encoding quit request for copier subprocess: %v", err)
+			}
+		} else {
+			s.lock.Unlock()
+		}
+		s.stdin.Close()
+		s.sendLock.Unlock()
+		<-s.done
+		err := s.cmd.Wait()
+		s.socket.Close()
+		loggedOutput := s.stderr.String()
+		if err != nil {
+			if loggedOutput != "" {
+				err = fmt.Errorf("%s: %w", loggedOutput, err)
+			}
+			s.closeErr = fmt.Errorf("copier subprocess: %w", err)
+			return
+		}
+		if len(loggedOutput) > 0 {
+			for output := range strings.SplitSeq(loggedOutput, "\n") {
+				logrus.Debug(output)
+			}
+		}
+	})
+	return s.closeErr
+}
+
+// copierSessionMain is the entry point for a session subprocess.  It handles
+// requests concurrently, and replies to each of them once it's finished.
+func copierSessionMain() {
+	decoder := json.NewDecoder(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	var encoderLock sync.Mutex
+	var root subprocessRoot
+	var wg sync.WaitGroup
+
+	setupSubprocess()
+
+	socket := os.NewFile(3, "session-socket")
+	sendReply := func(reply *sessionReply) {
+		encoderLock.Lock()
+		defer encoderLock.Unlock()
+		if err := encoder.Encode(reply); err != nil {
+			fmt.Fprintf(os.Stderr, "error encoding response for copier parent process: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	for {
+		// Read a request, and any descriptors that go with it.
+		sessionReq := new(sessionRequest)
+		if err := decoder.Decode(sessionReq); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			fmt.Fprintf(os.Stderr, "error decoding request from copier parent process: %v", err)
+			os.Exit(1)
+		}
+		if sessionReq.Request.Request == requestQuit {
+			break
+		}
+		var files []*os.File
+		if sessionReq.Files > 0 {
+			id, received, err := receiveSessionFiles(socket, sessionReq.Files)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error receiving descriptors from copier parent process: %v", err)
+				os.Exit(1)
+			}
+			if id != sessionReq.ID {
+				fmt.Fprintf(os.Stderr, "error: received descriptors for request %d with request %d", id, sessionReq.ID)
+				os.Exit(1)
+			}
+			files = received
+		}
+		id, req := sessionReq.ID, sessionReq.Request
+		if err := root.enter(&req); err != nil {
+			closeSessionFiles(files)
+			sendReply(&sessionReply{ID: id, Error: err.Error()})
+			continue
+		}
+		wg.Go(func() {
+			sendReply(handleSessionRequest(id, req, files))
+		})
+	}
+	wg.Wait()
+}
+
+// handleSessionRequest handles one request in a session subprocess, closing
+// the descriptors which were passed to it before returning its reply.
+func handleSessionRequest(id int, req request, files []*os.File) *sessionReply {
+	var bulkReader io.Reader = bytes.NewReader([]byte{})
+	var bulkWriter io.Writer = io.Discard
+	if len(files) >= 2 {
+		bulkReader, bulkWriter = files[0], files[1]
+	}
+	if req.Passthrough && len(files) >= 3 {
+		// One end of a Passthrough was passed along with the request.
+		passthrough := &Passthrough{}
+		switch req.Request {
+		case requestGet:
+			passthrough.get = files[2]
+		case requestPut:
+			passthrough.put = files[2]
+		}
+		req.GetOptions.Passthrough = passthrough
+		req.PutOptions.Passthrough = passthrough
+		defer passthrough.Close()
+		files = files[:2]
+	}
+	defer closeSessionFiles(files)
+	resp, cb, err := copierHandler(bulkReader, bulkWriter, req)
+	if err != nil {
+		return &sessionReply{ID: id, Error: fmt.Sprintf("handling request: %v", err)}
+	}
+	if cb != nil {
+		if err := cb(); err != nil {
+			return &sessionReply{ID: id, Error: fmt.Sprintf("during bulk transfer: %v", err)}
+		}
+	}
+	return &sessionReply{ID: id, Response: resp}
+}
+
+// closeSessionFiles closes descriptors which were passed along with a request.
+func closeSessionFiles(files []*os.File) {
+	for _, f := range files {
+		if err := f.Close(); err != nil {
+			logrus.Debugf("copier: closing %s: %v", f.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package copier
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// newSessionSocketPair creates the socket over which descriptors are passed
+// to a session subprocess, returning our end and the subprocess's end.
+func newSessionSocketPair() (*os.File, *os.File, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	unix.CloseOnExec(fds[0])
+	unix.CloseOnExec(fds[1])
+	return os.NewFile(uintptr(fds[0]), "copier-session-parent"), os.NewFile(uintptr(fds[1]), "copier-session-child"), nil
+}
+
+// sendSessionFiles passes descriptors for a request to a session subprocess.
+func sendSessionFiles(socket *os.File, id int, files []*os.File) error {
+	fds := make([]int, 0, len(files))
+	for _, f := range files {
+		fds = append(fds, int(f.Fd()))
+	}
+	return unix.Sendmsg(int(socket.Fd()), []byte(strconv.Itoa(id)), unix.UnixRights(fds...), nil, 0)
+}
+
+// receiveSessionFiles receives the descriptors for a request, returning the
+// ID of the request that they were sent for.
+func receiveSessionFiles(socket *os.File, count int) (int, []*os.File, error) {
+	payload := make([]byte, 32)
+	oob := make([]byte, unix.CmsgSpace(count*4))
+	n, oobn, _, _, err := unix.Recvmsg(int(socket.Fd()), payload, oob, 0)
+	if err != nil {
+		return -1, nil, err
+	}
+	messages, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return -1, nil, fmt.Errorf("parsing control message: %w", err)
+	}
+	var files []*os.File
+	for _, message := range messages {
+		fds, err := unix.ParseUnixRights(&message)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			unix.CloseOnExec(fd)
+			files = append(files, os.NewFile(uintptr(fd), fmt.Sprintf("copier-session-file-%d", len(files))))
+		}
+	}
+	id, err := strconv.Atoi(string(payload[:n]))
+	if err != nil || len(files) != count {
+		closeSessionFiles(files)
+		if err != nil {
+			return -1, nil, fmt.Errorf("parsing request ID: %w", err)
+		}
+		return -1, nil, fmt.Errorf("expected %d descriptors, got %d", count, len(files))
+	}
+	return id, files, nil
+}
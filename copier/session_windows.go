@@ -0,0 +1,22 @@
+//go:build windows
+
+package copier
+
+import (
+	"errors"
+	"os"
+)
+
+var errSessionSubprocessUnsupported = errors.New("copier session subprocesses are not supported on windows")
+
+func newSessionSocketPair() (*os.File, *os.File, error) {
+	return nil, nil, errSessionSubprocessUnsupported
+}
+
+func sendSessionFiles(_ *os.File, _ int, _ []*os.File) error {
+	return errSessionSubprocessUnsupported
+}
+
+func receiveSessionFiles(_ *os.File, _ int) (int, []*os.File, error) {
+	return -1, nil, errSessionSubprocessUnsupported
+}
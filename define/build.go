@@ -14,6 +14,11 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
+// EntitlementSecurityInsecure is the entitlement which allows RUN
+// instructions to use the --security=insecure flag to run with elevated
+// privileges.
+const EntitlementSecurityInsecure = "security.insecure"
+
 // AdditionalBuildContext contains verbose details about a parsed build context from --build-context
 type AdditionalBuildContext struct {
 	// Value is the URL of an external tar archive.
@@ -158,10 +163,33 @@ type BuildOptions struct {
 	// CacheTo specifies any remote repository which can be treated as
 	// potential cache destination.
 	CacheTo []reference.Named
+	// CacheFromLocations specifies any local OCI layout directories or OCI
+	// archives which can be treated as potential cache sources, in
+	// "type=local,src=/path" or "type=oci,src=/path.tar" form.
+	CacheFromLocations []string
+	// CacheToLocations specifies any local OCI layout directories or OCI
+	// archives which can be treated as potential cache destinations, in
+	// "type=local,dest=/path" or "type=oci,dest=/path.tar" form.  Cache
+	// images in them which are older than CacheTTL are pruned at the end of
+	// a successful build.
+	CacheToLocations []string
 	// CacheTTL specifies duration, if specified using `--cache-ttl` then
 	// cache intermediate images under this duration will be considered as
 	// valid cache sources and images outside this duration will be ignored.
 	CacheTTL time.Duration
+	// CacheExplain causes the reason that no cached result was found for
+	// an instruction to be printed, along with the differences between
+	// the instruction and the cached image which came closest to matching
+	// it.  It only has an effect when Layers is set.
+	CacheExplain bool
+	// CacheKeyMode controls how images which were built previously are
+	// matched to instructions when Layers is set.  The default is
+	// CacheKeyModeHistory.
+	CacheKeyMode CacheKeyMode
+	// ContentManifest causes a manifest of the items which each ADD and
+	// COPY instruction adds to the image, including digests of the
+	// contents of files, to be recorded, and included in the MetadataFile.
+	ContentManifest bool
 	// Compression specifies the type of compression which is applied to
 	// layer blobs.  The default is to not use compression, but
 	// archive.Gzip is recommended.
@@ -176,6 +204,10 @@ type BuildOptions struct {
 	ForceCompressionFormat bool
 	// Arguments which can be interpolated into Dockerfiles
 	Args map[string]string
+	// SensitiveArgs are the names of arguments in Args whose values should
+	// be treated like those of secrets: masked in build output and the
+	// history of the built image, and never allowed into its configuration.
+	SensitiveArgs []string
 	// Map of external additional build contexts
 	AdditionalBuildContexts map[string]*AdditionalBuildContext
 	// Name of the image to write to.
@@ -204,6 +236,12 @@ type BuildOptions struct {
 	// is supplied, the message will be sent to Err (or os.Stderr, if Err
 	// is nil) by default.
 	Log func(format string, args ...any)
+	// Events is a callback that will be given a structured description
+	// of each stage and instruction as it is started and finished, of
+	// each image that is committed, and of the progress of pulling and
+	// pushing images.  Calls to it are serialized.  If no value is
+	// supplied, no events are generated.
+	Events func(event BuildEvent)
 	// In is connected to stdin for RUN instructions.
 	In io.Reader
 	// Out is a place where non-error log messages are sent.
@@ -285,6 +323,14 @@ type BuildOptions struct {
 	// possibly more than one layer, by only committing a new layer after processing the
 	// final instruction.
 	Squash bool
+	// SquashFrom, if set, tells the builder to merge the layers of the
+	// final stage's base image, starting with the one that it identifies,
+	// into the final image's new layer, while keeping the layers below it
+	// intact.  It can be a layer index or a history marker, as with
+	// buildah.CommitOptions.SquashFrom, or the name of an earlier stage,
+	// in which case the layers which were added by that stage and the
+	// ones after it are merged.  Ignored if Squash is set.
+	SquashFrom string
 	// Labels to set in a committed image.
 	Labels []string
 	// LayerLabels metadata for an intermediate image
@@ -320,6 +366,10 @@ type BuildOptions struct {
 	Target string
 	// Devices are unparsed devices to provide to RUN instructions.
 	Devices []string
+	// Entitlements are the elevated privileges which RUN instructions are
+	// allowed to request.  The only recognized value is
+	// EntitlementSecurityInsecure.
+	Entitlements []string
 	// SignBy is the fingerprint of a GPG key to use for signing images.
 	SignBy string
 	// Architecture specifies the target architecture of the image to be built.
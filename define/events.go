@@ -0,0 +1,102 @@
+package define
+
+import (
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// BuildEventType identifies the kind of thing that a BuildEvent describes.
+type BuildEventType string
+
+const (
+	// BuildEventStageStart is emitted when we begin building a stage.
+	BuildEventStageStart BuildEventType = "stage-start"
+	// BuildEventStageFinish is emitted when we finish building a stage,
+	// whether or not we succeeded.
+	BuildEventStageFinish BuildEventType = "stage-finish"
+	// BuildEventStepStart is emitted when we begin processing an
+	// instruction.
+	BuildEventStepStart BuildEventType = "step-start"
+	// BuildEventStepFinish is emitted when we finish processing an
+	// instruction, whether or not we succeeded.
+	BuildEventStepFinish BuildEventType = "step-finish"
+	// BuildEventLayerCommit is emitted when we finish committing an image.
+	BuildEventLayerCommit BuildEventType = "layer-commit"
+	// BuildEventPullProgress is emitted periodically while we're pulling
+	// blobs for an image.
+	BuildEventPullProgress BuildEventType = "pull-progress"
+	// BuildEventPushProgress is emitted periodically while we're pushing
+	// blobs for an image.
+	BuildEventPushProgress BuildEventType = "push-progress"
+)
+
+// BuildCacheDecision describes whether or not the result of processing an
+// instruction was found in the build cache.
+type BuildCacheDecision string
+
+const (
+	// BuildCacheHit indicates that a cached image was reused.
+	BuildCacheHit BuildCacheDecision = "hit"
+	// BuildCacheMiss indicates that we looked for a cached image, but
+	// didn't find a suitable one.
+	BuildCacheMiss BuildCacheDecision = "miss"
+)
+
+// BuildEvent is a structured description of something that happened during a
+// build.  Fields which don't apply to an event's Type are left empty.
+type BuildEvent struct {
+	// Type is the kind of event.
+	Type BuildEventType `json:"type"`
+	// Time is when the event occurred.
+	Time time.Time `json:"time"`
+	// Platform is the platform that the image is being built for, if
+	// images are being built for more than one platform.
+	Platform string `json:"platform,omitempty"`
+	// Stage is the position of the stage in the Containerfile, starting
+	// at 0.
+	Stage int `json:"stage"`
+	// StageName is the name of the stage, if it was given one.
+	StageName string `json:"stageName,omitempty"`
+	// Step is the position of the instruction in its stage, starting at
+	// 1.
+	Step int `json:"step,omitempty"`
+	// Instruction is the text of the instruction.
+	Instruction string `json:"instruction,omitempty"`
+	// Cache is whether or not the instruction's result was found in the
+	// build cache.  It is empty if we didn't look.
+	Cache BuildCacheDecision `json:"cache,omitempty"`
+	// CacheMiss explains why the instruction's result wasn't found in
+	// the build cache, if BuildOptions.CacheExplain was set.
+	CacheMiss string `json:"cacheMiss,omitempty"`
+	// ImageID is the ID of the image that was built, committed, or found
+	// in the cache.
+	ImageID string `json:"imageID,omitempty"`
+	// Digest is the digest of a committed image's manifest.
+	Digest digest.Digest `json:"digest,omitempty"`
+	// Layer is the diffID of a committed image's topmost layer.
+	Layer digest.Digest `json:"layer,omitempty"`
+	// Image is the name of a stage's base image, or of an image being
+	// pulled or pushed.
+	Image string `json:"image,omitempty"`
+	// Blob describes the progress of copying a blob.
+	Blob *BuildEventBlob `json:"blob,omitempty"`
+	// Duration is how long a stage, instruction, or commit took to
+	// complete, in nanoseconds.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Error is the error which caused a stage or instruction to fail.
+	Error string `json:"error,omitempty"`
+}
+
+// BuildEventBlob describes the progress of copying a blob while pulling or
+// pushing an image.
+type BuildEventBlob struct {
+	// Digest is the blob's digest.
+	Digest digest.Digest `json:"digest"`
+	// Size is the size of the blob, or -1 if it isn't known.
+	Size int64 `json:"size"`
+	// Status is one of "new", "read", "done", or "skipped".
+	Status string `json:"status"`
+	// Offset is the number of bytes of the blob which have been copied.
+	Offset uint64 `json:"offset"`
+}
@@ -0,0 +1,87 @@
+package define
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SecretDescriptors holds the values of secrets which were read from file
+// descriptors, which can only be read once, keyed by descriptor number, so
+// that they can be used more than once.  Secrets which are used together,
+// for example by a build, should share one, and it should be cleared when
+// they are no longer needed.
+type SecretDescriptors struct {
+	mu     sync.Mutex
+	values map[int][]byte
+}
+
+// Clear discards any values which have been read.
+func (d *SecretDescriptors) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, value := range d.values {
+		clear(value)
+	}
+	d.values = nil
+}
+
+// ParseSecretDescriptor parses the Source of a secret whose SourceType is
+// "fd".
+func ParseSecretDescriptor(source string) (int, error) {
+	fd, err := strconv.Atoi(source)
+	if err != nil || fd < 0 {
+		return -1, fmt.Errorf("invalid file descriptor %q", source)
+	}
+	return fd, nil
+}
+
+// resolveCommand runs the secret's command and returns what it prints.  The
+// command's error output is passed along to ours.
+func (s Secret) resolveCommand() ([]byte, error) {
+	args := strings.Fields(s.Source)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command specified for secret ID %s", s.ID)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	rv, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running command for secret ID %s: %w", s.ID, err)
+	}
+	return rv, nil
+}
+
+// resolveDescriptor reads the secret from its file descriptor the first time
+// it's needed, and, if the secret has a SecretDescriptors, remembers it there
+// for later.
+func (s Secret) resolveDescriptor() ([]byte, error) {
+	fd, err := ParseSecretDescriptor(s.Source)
+	if err != nil {
+		return nil, fmt.Errorf("secret ID %s: %w", s.ID, err)
+	}
+	if s.Descriptors != nil {
+		s.Descriptors.mu.Lock()
+		defer s.Descriptors.mu.Unlock()
+		if rv, ok := s.Descriptors.values[fd]; ok {
+			return rv, nil
+		}
+	}
+	f := os.NewFile(uintptr(fd), "secret "+s.ID)
+	rv, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading file descriptor %d for secret ID %s: %w", fd, s.ID, err)
+	}
+	if s.Descriptors != nil {
+		if s.Descriptors.values == nil {
+			s.Descriptors.values = make(map[int][]byte)
+		}
+		s.Descriptors.values[fd] = rv
+	}
+	return rv, nil
+}
@@ -0,0 +1,33 @@
+package define
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// readKeyring reads the contents of the "user" key with the specified
+// description, searching the thread, process, session, and user keyrings, in
+// that order.
+func readKeyring(description string) ([]byte, error) {
+	var id int
+	var err error
+	for _, keyring := range []int{unix.KEY_SPEC_THREAD_KEYRING, unix.KEY_SPEC_PROCESS_KEYRING, unix.KEY_SPEC_SESSION_KEYRING, unix.KEY_SPEC_USER_KEYRING} {
+		if id, err = unix.KeyctlSearch(keyring, "user", description, 0); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding key %q: %w", description, err)
+	}
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("reading key %q: %w", description, err)
+	}
+	buf := make([]byte, size)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+	if err != nil {
+		return nil, fmt.Errorf("reading key %q: %w", description, err)
+	}
+	return buf[:min(n, size)], nil
+}
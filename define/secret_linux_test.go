@@ -0,0 +1,63 @@
+package define
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestSecretResolveValueDescriptor(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString("from a pipe")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	// ResolveValue closes the descriptor after reading it, so give it a
+	// copy that r doesn't know about.
+	fd, err := unix.Dup(int(r.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	descriptors := &SecretDescriptors{}
+	secret := Secret{ID: "pipe", Source: strconv.Itoa(fd), SourceType: "fd", Descriptors: descriptors}
+	var value []byte
+	for range 2 {
+		value, err = secret.ResolveValue()
+		require.NoError(t, err)
+		assert.Equal(t, "from a pipe", string(value), "the value should be remembered after it's read")
+	}
+
+	descriptors.Clear()
+	assert.Empty(t, descriptors.values, "the value should be forgotten once it's cleared")
+	assert.Equal(t, make([]byte, len("from a pipe")), value, "the value should be zeroed once it's cleared")
+
+	secret.Source = "-1"
+	_, err = secret.ResolveValue()
+	assert.Error(t, err)
+}
+
+func TestSecretResolveValueKeyring(t *testing.T) {
+	description := "buildah-test-" + strconv.Itoa(os.Getpid())
+	id, err := unix.AddKey("user", description, []byte("from the keyring"), unix.KEY_SPEC_PROCESS_KEYRING)
+	if err != nil {
+		t.Skipf("adding a key to the process keyring: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := unix.KeyctlInt(unix.KEYCTL_REVOKE, id, 0, 0, 0); err != nil {
+			t.Logf("revoking test key: %v", err)
+		}
+	})
+
+	secret := Secret{ID: "key", Source: description, SourceType: "keyring"}
+	value, err := secret.ResolveValue()
+	require.NoError(t, err)
+	assert.Equal(t, "from the keyring", string(value))
+
+	secret.Source = description + "-missing"
+	_, err = secret.ResolveValue()
+	assert.ErrorContains(t, err, "reading key for secret ID key")
+}
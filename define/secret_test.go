@@ -0,0 +1,23 @@
+package define
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretResolveValueCommand(t *testing.T) {
+	secret := Secret{ID: "npm", Source: "sh -c 'printf token'", SourceType: "cmd"}
+	_, err := secret.ResolveValue()
+	assert.Error(t, err, "arguments aren't parsed by a shell")
+
+	secret.Source = "printf token"
+	value, err := secret.ResolveValue()
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(value))
+
+	secret.Source = "false"
+	_, err = secret.ResolveValue()
+	assert.ErrorContains(t, err, "running command for secret ID npm")
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package define
+
+import "errors"
+
+// readKeyring is not implemented on this platform.
+func readKeyring(_ string) ([]byte, error) {
+	return nil, errors.New("reading secrets from the kernel keyring is not supported on this platform")
+}
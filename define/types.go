@@ -9,7 +9,6 @@ import (
 	"net/http"
 	neturl "net/url"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -18,6 +17,7 @@ import (
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/internal/gitsource"
 	"go.podman.io/buildah/internal/urlsource"
 	"go.podman.io/image/v5/manifest"
 	"go.podman.io/storage/pkg/archive"
@@ -106,9 +106,17 @@ type IDMappingOptions struct {
 // Secret is a secret source that can be provided for a RUN instruction in a
 // build, or passed to Run(), and optionally mounted as type=secret.
 type Secret struct {
-	ID         string
-	Source     string
+	ID     string
+	Source string
+	// SourceType is one of "env" (Source is the name of an environment
+	// variable), "file" (a file's location), "cmd" (a command, with
+	// arguments separated by spaces, which prints the secret), "keyring"
+	// (the description of a "user" key in the kernel keyring), or "fd" (the
+	// number of an inherited file descriptor to read the secret from).
 	SourceType string
+	// Descriptors, if set, remembers the value of a secret whose
+	// SourceType is "fd" after it is read, since it can't be read again.
+	Descriptors *SecretDescriptors
 }
 
 // ResolveValue returns the contents of the secret, whatever its source type.
@@ -122,6 +130,16 @@ func (s Secret) ResolveValue() ([]byte, error) {
 			return nil, fmt.Errorf("reading file for secret ID %s: %w", s.ID, err)
 		}
 		return rv, nil
+	case "cmd":
+		return s.resolveCommand()
+	case "keyring":
+		rv, err := readKeyring(s.Source)
+		if err != nil {
+			return nil, fmt.Errorf("reading key for secret ID %s: %w", s.ID, err)
+		}
+		return rv, nil
+	case "fd":
+		return s.resolveDescriptor()
 	default:
 		return nil, fmt.Errorf("invalid secret type: %s for secret ID: %s", s.SourceType, s.ID)
 	}
@@ -173,6 +191,21 @@ const (
 	SBOMMergeStrategySPDXByPackageNameAndVersionInfo SBOMMergeStrategy = "merge-spdx-by-package-name-and-versioninfo"
 )
 
+// CacheKeyMode controls how the layer cache decides whether or not a
+// previously-built image can be reused as the result of an instruction.
+type CacheKeyMode string
+
+const (
+	// CacheKeyModeHistory compares the history entries which were recorded
+	// in candidate images to the ones that we would record.
+	CacheKeyModeHistory CacheKeyMode = "history"
+	// CacheKeyModeContent compares a hash of the instruction, the values of
+	// build arguments which it references, digests of content that it
+	// reads, and the cache key of its base image, which is recorded
+	// alongside images that we commit to local storage.
+	CacheKeyModeContent CacheKeyMode = "content"
+)
+
 // SBOMScanOptions encapsulates options which control whether or not we run a
 // scanner on the rootfs that we're about to commit, and how.
 type SBOMScanOptions struct {
@@ -269,61 +302,14 @@ func TempDirForURL(dir, prefix, url string) (tempDir string, relativeContextDir
 // `branch/commit`, accepts GitBuildContext in the format of
 // `repourl.git[#[branch-or-commit]:subdir]`.
 func parseGitBuildContext(url string) (string, string, string) {
-	gitSubdir := ""
-	gitBranch := ""
-	gitBranchPart := strings.Split(url, "#")
-	if len(gitBranchPart) > 1 {
-		// check if string contains path to a subdir
-		gitSubDirPart := strings.Split(gitBranchPart[1], ":")
-		if len(gitSubDirPart) > 1 {
-			gitSubdir = gitSubDirPart[1]
-		}
-		gitBranch = gitSubDirPart[0]
-	}
-	return gitBranchPart[0], gitSubdir, gitBranch
+	gitRepo, gitBranch, gitSubdir := gitsource.ParseURL(url)
+	return gitRepo, gitSubdir, gitBranch
 }
 
+// cloneToDirectory clones a Git build context, including any submodules, so
+// that their contents are part of the build context, as they are with ADD.
 func cloneToDirectory(url, dir string) ([]byte, string, error) {
-	var cmd *exec.Cmd
-	gitRepo, gitSubdir, gitRef := parseGitBuildContext(url)
-	// init repo
-	cmd = exec.Command("git", "init", dir)
-	combinedOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		// Return err.Error() instead of err as we want buildah to override error code with more predictable
-		// value.
-		return combinedOutput, gitSubdir, fmt.Errorf("failed while performing `git init`: %s", err.Error())
-	}
-	// add origin
-	cmd = exec.Command("git", "remote", "add", "origin", gitRepo)
-	cmd.Dir = dir
-	combinedOutput, err = cmd.CombinedOutput()
-	if err != nil {
-		// Return err.Error() instead of err as we want buildah to override error code with more predictable
-		// value.
-		return combinedOutput, gitSubdir, fmt.Errorf("failed while performing `git remote add`: %s", err.Error())
-	}
-
-	logrus.Debugf("fetching repo %q and branch (or commit ID) %q to %q", gitRepo, gitRef, dir)
-	args := []string{"fetch", "-u", "--depth=1", "origin", "--", gitRef}
-	cmd = exec.Command("git", args...)
-	cmd.Dir = dir
-	combinedOutput, err = cmd.CombinedOutput()
-	if err != nil {
-		// Return err.Error() instead of err as we want buildah to override error code with more predictable
-		// value.
-		return combinedOutput, gitSubdir, fmt.Errorf("failed while performing `git fetch`: %s", err.Error())
-	}
-
-	cmd = exec.Command("git", "checkout", "FETCH_HEAD")
-	cmd.Dir = dir
-	combinedOutput, err = cmd.CombinedOutput()
-	if err != nil {
-		// Return err.Error() instead of err as we want buildah to override error code with more predictable
-		// value.
-		return combinedOutput, gitSubdir, fmt.Errorf("failed while performing `git checkout`: %s", err.Error())
-	}
-	return combinedOutput, gitSubdir, nil
+	return gitsource.Clone(url, dir, gitsource.Options{Submodules: true})
 }
 
 func downloadToDirectory(url, dir string) error {
@@ -2,6 +2,7 @@ package define
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -104,3 +105,49 @@ func TestParseGitBuildContext(t *testing.T) {
 	assert.Equal(t, subdir, "mydir")
 	assert.Equal(t, branch, "main")
 }
+
+func TestCloneToDirectorySubmodules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	// submodules are normally not allowed to be fetched from the local
+	// filesystem
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, string(output))
+	}
+
+	repositories := t.TempDir()
+	submodule := filepath.Join(repositories, "submodule")
+	require.NoError(t, os.Mkdir(submodule, 0o755))
+	git(submodule, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(submodule, "nested.txt"), []byte("nested\n"), 0o644))
+	git(submodule, "add", ".")
+	git(submodule, "commit", "-q", "-m", "submodule")
+
+	repository := filepath.Join(repositories, "repository")
+	require.NoError(t, os.Mkdir(repository, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repository, "Containerfile"), []byte("FROM scratch\n"), 0o644))
+	git(repository, "init", "-q", "-b", "main")
+	git(repository, "submodule", "add", "-q", "file://"+submodule, "vendored")
+	git(repository, "add", ".")
+	git(repository, "commit", "-q", "-m", "repository")
+
+	// build contexts include the contents of submodules
+	dir := t.TempDir()
+	_, subdir, err := cloneToDirectory("file://"+repository+"#main", dir)
+	require.NoError(t, err)
+	assert.Empty(t, subdir)
+	assert.FileExists(t, filepath.Join(dir, "Containerfile"))
+	assert.FileExists(t, filepath.Join(dir, "vendored", "nested.txt"))
+}
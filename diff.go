@@ -0,0 +1,398 @@
+package buildah
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/copier"
+	"go.podman.io/storage"
+	"go.podman.io/storage/pkg/archive"
+)
+
+// DiffChange describes how an item in a filesystem changed.
+type DiffChange string
+
+const (
+	// DiffAdded items are only present in the newer filesystem.
+	DiffAdded DiffChange = "added"
+	// DiffRemoved items are only present in the older filesystem.
+	DiffRemoved DiffChange = "removed"
+	// DiffModified items are present in both filesystems, but differ.
+	DiffModified DiffChange = "modified"
+)
+
+// DiffOptions controls parts of Diff's behavior.
+type DiffOptions struct {
+	// Digests tells Diff to compute digests of the contents of regular
+	// files which it reports, and to compare them.
+	Digests bool
+}
+
+// DiffFileInfo describes one version of an item in a filesystem.
+type DiffFileInfo struct {
+	Mode     os.FileMode       `json:"mode"`
+	UID      int64             `json:"uid"`
+	GID      int64             `json:"gid"`
+	Size     int64             `json:"size"`
+	ModTime  time.Time         `json:"modtime"`
+	Linkname string            `json:"linkname,omitempty"` // target of a symbolic link
+	Xattrs   map[string]string `json:"xattrs,omitempty"`
+	Digest   digest.Digest     `json:"digest,omitempty"` // only computed for regular files, if asked for
+}
+
+// DiffEntry describes an item which differs between two filesystems.
+type DiffEntry struct {
+	Path   string     `json:"path"`
+	Change DiffChange `json:"change"`
+	// Differences lists the attributes of a modified item which differ:
+	// "type", "mode", "owner", "size", "modtime", "linkname", "xattrs",
+	// and "content".
+	Differences []string      `json:"differences,omitempty"`
+	Old         *DiffFileInfo `json:"old,omitempty"`
+	New         *DiffFileInfo `json:"new,omitempty"`
+}
+
+// Diff compares the root filesystem of the working container or image that
+// "from" describes with that of "to", and returns a list of the items which
+// were added, removed, or modified in "to", sorted by path.  "from" or "to"
+// can be a Builder that was opened for an existing working container, or one
+// that was mocked up using ImportBuilderFromImage.
+func Diff(store storage.Store, from, to *Builder, options DiffOptions) ([]DiffEntry, error) {
+	fromLayer, err := diffLayer(store, from)
+	if err != nil {
+		return nil, err
+	}
+	toLayer, err := diffLayer(store, to)
+	if err != nil {
+		return nil, err
+	}
+	if toLayer == "" {
+		return nil, fmt.Errorf("%s has no layers", diffName(to))
+	}
+	if fromLayer == "" {
+		// The storage library would compare against the parent of
+		// toLayer, which is only what we want if there isn't one.
+		layer, err := store.Layer(toLayer)
+		if err != nil {
+			return nil, fmt.Errorf("reading layer %q: %w", toLayer, err)
+		}
+		if layer.Parent != "" {
+			return nil, fmt.Errorf("%s has no layers", diffName(from))
+		}
+	}
+
+	// Mount the older filesystem so that we can examine the items in it
+	// which we find in the diff.
+	var fromRoot string
+	if fromLayer != "" {
+		if from.ContainerID != "" {
+			if fromRoot, err = store.Mount(from.ContainerID, from.MountLabel); err != nil {
+				return nil, fmt.Errorf("mounting %s: %w", diffName(from), err)
+			}
+			defer func() {
+				if _, err := store.Unmount(from.ContainerID, false); err != nil {
+					logrus.Debugf("unmounting %s: %v", diffName(from), err)
+				}
+			}()
+		} else {
+			if fromRoot, err = store.MountImage(from.FromImageID, nil, ""); err != nil {
+				return nil, fmt.Errorf("mounting %s: %w", diffName(from), err)
+			}
+			defer func() {
+				if _, err := store.UnmountImage(from.FromImageID, false); err != nil {
+					logrus.Debugf("unmounting %s: %v", diffName(from), err)
+				}
+			}()
+		}
+	}
+
+	items, paths, removed, opaque, err := readDiff(store, fromLayer, toLayer, options.Digests)
+	if err != nil {
+		return nil, fmt.Errorf("comparing %s with %s: %w", diffName(from), diffName(to), err)
+	}
+
+	// Look up everything that the diff mentions in the older filesystem,
+	// along with the contents of directories which it says were replaced.
+	var old map[string]*copier.StatForItem
+	if fromRoot != "" {
+		if old, err = diffStat(fromRoot, from, paths, removed, opaque); err != nil {
+			return nil, err
+		}
+	}
+	oldInfo := func(p string) (*DiffFileInfo, error) {
+		st, ok := old[p]
+		if !ok {
+			return nil, nil
+		}
+		return diffOldFileInfo(fromRoot, p, st, options.Digests)
+	}
+
+	var entries []DiffEntry
+	for _, p := range paths {
+		entry := DiffEntry{Path: p, Change: DiffAdded, New: items[p]}
+		if entry.Old, err = oldInfo(p); err != nil {
+			return nil, err
+		}
+		if entry.Old != nil {
+			entry.Differences = diffFileInfos(entry.Old, entry.New)
+			if len(entry.Differences) == 0 {
+				// Probably a parent directory of something
+				// that changed.
+				continue
+			}
+			entry.Change = DiffModified
+		}
+		entries = append(entries, entry)
+	}
+	for _, dir := range opaque {
+		prefix := strings.TrimSuffix(dir, "/") + "/"
+		for p := range old {
+			if strings.HasPrefix(p, prefix) && !strings.Contains(p[len(prefix):], "/") && items[p] == nil {
+				removed = append(removed, p)
+			}
+		}
+	}
+	slices.Sort(removed)
+	for _, p := range slices.Compact(removed) {
+		entry := DiffEntry{Path: p, Change: DiffRemoved}
+		if entry.Old, err = oldInfo(p); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b DiffEntry) int { return strings.Compare(a.Path, b.Path) })
+	return entries, nil
+}
+
+// diffName returns a description of a Builder for use in error messages.
+func diffName(b *Builder) string {
+	if b.ContainerID != "" {
+		return fmt.Sprintf("container %q", b.Container)
+	}
+	return fmt.Sprintf("image %q", b.FromImage)
+}
+
+// diffLayer returns the ID of the layer which holds the root filesystem of
+// the container or image that a Builder describes.
+func diffLayer(store storage.Store, b *Builder) (string, error) {
+	if b.ContainerID != "" {
+		container, err := store.Container(b.ContainerID)
+		if err != nil {
+			return "", fmt.Errorf("locating %s: %w", diffName(b), err)
+		}
+		return container.LayerID, nil
+	}
+	if b.FromImageID == "" {
+		return "", nil
+	}
+	img, err := store.Image(b.FromImageID)
+	if err != nil {
+		return "", fmt.Errorf("locating %s: %w", diffName(b), err)
+	}
+	return img.TopLayer, nil
+}
+
+// readDiff reads the changes between two layers, returning information about
+// the items that are present in the newer one, along with a sorted list of
+// their paths, and the paths of items which were removed and directories whose
+// contents were replaced.
+func readDiff(store storage.Store, fromLayer, toLayer string, digests bool) (map[string]*DiffFileInfo, []string, []string, []string, error) {
+	items := make(map[string]*DiffFileInfo)
+	var paths, removed, opaque []string
+	noCompression := archive.Uncompressed
+	rc, err := store.Diff(fromLayer, toLayer, &storage.DiffOptions{Compression: &noCompression})
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	// The diff holds a lock on the layer store until it's closed, so
+	// don't do anything else with the store until we're done with it.
+	defer rc.Close()
+	tr := tar.NewReader(rc)
+	hdr, err := tr.Next()
+	for err == nil {
+		p := path.Clean("/" + hdr.Name)
+		dir, base := path.Split(p)
+		switch {
+		case base == archive.WhiteoutOpaqueDir:
+			opaque = append(opaque, path.Clean(dir))
+		case strings.HasPrefix(base, archive.WhiteoutPrefix):
+			removed = append(removed, path.Join(dir, strings.TrimPrefix(base, archive.WhiteoutPrefix)))
+		case hdr.Typeflag == tar.TypeLink:
+			// Describe the item that it's a hard link to.
+			target, ok := items[path.Clean("/"+hdr.Linkname)]
+			if !ok {
+				return nil, nil, nil, nil, fmt.Errorf("hard link %q points to %q, which isn't in the diff", p, hdr.Linkname)
+			}
+			info := *target
+			items[p] = &info
+			paths = append(paths, p)
+		default:
+			info := &DiffFileInfo{
+				Mode:    hdr.FileInfo().Mode(),
+				UID:     int64(hdr.Uid),
+				GID:     int64(hdr.Gid),
+				Size:    hdr.Size,
+				ModTime: hdr.ModTime,
+			}
+			if hdr.Typeflag == tar.TypeSymlink {
+				info.Linkname = hdr.Linkname
+			}
+			for key, value := range hdr.PAXRecords {
+				if name, ok := strings.CutPrefix(key, archive.PaxSchilyXattr); ok {
+					if info.Xattrs == nil {
+						info.Xattrs = make(map[string]string)
+					}
+					info.Xattrs[name] = value
+				}
+			}
+			if digests && hdr.Typeflag == tar.TypeReg {
+				if info.Digest, err = digest.Canonical.FromReader(tr); err != nil {
+					return nil, nil, nil, nil, fmt.Errorf("reading %q: %w", p, err)
+				}
+			}
+			items[p] = info
+			paths = append(paths, p)
+		}
+		hdr, err = tr.Next()
+	}
+	if !errors.Is(err, io.EOF) {
+		return nil, nil, nil, nil, fmt.Errorf("reading diff: %w", err)
+	}
+	slices.Sort(paths)
+	return items, slices.Compact(paths), removed, opaque, nil
+}
+
+// escapeGlob escapes characters which copier.Stat() would otherwise treat as
+// parts of a glob pattern.
+func escapeGlob(p string) string {
+	var escaped strings.Builder
+	for _, c := range p {
+		if strings.ContainsRune(`*?[\`, c) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return escaped.String()
+}
+
+// diffStat looks up the named items, and the contents of the opaque
+// directories, in the root filesystem mounted at root.  The results are
+// indexed by absolute path, and items that don't exist are left out.
+func diffStat(root string, b *Builder, paths, removed, opaque []string) (map[string]*copier.StatForItem, error) {
+	var globs []string
+	for _, p := range slices.Concat(paths, removed) {
+		globs = append(globs, escapeGlob(strings.TrimPrefix(p, "/")))
+	}
+	for _, dir := range opaque {
+		globs = append(globs, path.Join(escapeGlob(strings.TrimPrefix(dir, "/")), "*"))
+	}
+	results := make(map[string]*copier.StatForItem)
+	if len(globs) == 0 {
+		return results, nil
+	}
+	uidMap, gidMap := convertRuntimeIDMaps(b.IDMappingOptions.UIDMap, b.IDMappingOptions.GIDMap)
+	statOptions := copier.StatOptions{
+		UIDMap:             uidMap,
+		GIDMap:             gidMap,
+		AllowEmptyWildcard: true,
+	}
+	stats, err := copier.Stat(root, root, statOptions, globs)
+	if err != nil {
+		return nil, fmt.Errorf("examining %s: %w", diffName(b), err)
+	}
+	for _, st := range stats {
+		for name, result := range st.Results {
+			if result.Error != "" {
+				continue
+			}
+			results[path.Clean("/"+filepath.ToSlash(name))] = result
+		}
+	}
+	return results, nil
+}
+
+// diffOldFileInfo fills in a DiffFileInfo for an item in the root filesystem
+// mounted at root, using the results of copier.Stat() and reading its
+// extended attributes and, if asked to, its contents.
+func diffOldFileInfo(root, p string, st *copier.StatForItem, digests bool) (*DiffFileInfo, error) {
+	info := &DiffFileInfo{
+		Mode:    st.Mode,
+		UID:     st.UID,
+		GID:     st.GID,
+		Size:    st.Size,
+		ModTime: st.ModTime,
+	}
+	if st.IsSymlink {
+		info.Linkname = st.ImmediateTarget
+	}
+	// Resolve everything but the last component, which might be a
+	// symbolic link that we don't want to follow.
+	parent, err := securejoin.SecureJoin(root, path.Dir(p))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %w", p, err)
+	}
+	hostPath := filepath.Join(parent, path.Base(p))
+	xattrs, err := copier.Lgetxattrs(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(xattrs) > 0 {
+		info.Xattrs = xattrs
+	}
+	if digests && st.Mode.IsRegular() {
+		f, err := os.Open(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if info.Digest, err = digest.Canonical.FromReader(f); err != nil {
+			return nil, fmt.Errorf("reading %q: %w", p, err)
+		}
+	}
+	return info, nil
+}
+
+// diffFileInfos returns a list of the attributes of an item which differ
+// between two versions of it.
+func diffFileInfos(before, after *DiffFileInfo) []string {
+	var differences []string
+	if before.Mode.Type() != after.Mode.Type() {
+		differences = append(differences, "type")
+	} else if before.Mode != after.Mode {
+		differences = append(differences, "mode")
+	}
+	if before.UID != after.UID || before.GID != after.GID {
+		differences = append(differences, "owner")
+	}
+	if before.Mode.IsRegular() && after.Mode.IsRegular() && before.Size != after.Size {
+		differences = append(differences, "size")
+	}
+	// Directories' timestamps change whenever their contents do, so
+	// they're not interesting.  Not every archive format preserves
+	// fractional seconds, so ignore them.
+	if !before.Mode.IsDir() && !after.Mode.IsDir() && !before.ModTime.Truncate(time.Second).Equal(after.ModTime.Truncate(time.Second)) {
+		differences = append(differences, "modtime")
+	}
+	if before.Linkname != after.Linkname {
+		differences = append(differences, "linkname")
+	}
+	if !maps.Equal(before.Xattrs, after.Xattrs) {
+		differences = append(differences, "xattrs")
+	}
+	if before.Digest != "" && after.Digest != "" && before.Digest != after.Digest {
+		differences = append(differences, "content")
+	}
+	return differences
+}
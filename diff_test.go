@@ -0,0 +1,141 @@
+package buildah
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	imageStorage "go.podman.io/image/v5/storage"
+	"go.podman.io/storage"
+	storageTypes "go.podman.io/storage/types"
+)
+
+func TestDiff(t *testing.T) {
+	ctx := context.TODO()
+	graphDriverName := os.Getenv("STORAGE_DRIVER")
+	if graphDriverName == "" {
+		graphDriverName = "vfs"
+	}
+	t.Logf("using storage driver %q", graphDriverName)
+	store, err := storage.GetStore(storageTypes.StoreOptions{
+		RunRoot:         t.TempDir(),
+		GraphRoot:       t.TempDir(),
+		GraphDriverName: graphDriverName,
+	})
+	require.NoError(t, err, "initializing storage")
+	t.Cleanup(func() { _, err := store.Shutdown(true); assert.NoError(t, err) })
+
+	newBuilder := func(from string) *Builder {
+		builderOptions := BuilderOptions{
+			FromImage: from,
+			NamespaceOptions: []NamespaceOption{{
+				Name: string(rspec.NetworkNamespace),
+				Host: true,
+			}},
+			SystemContext: &testSystemContext,
+		}
+		b, err := NewBuilder(ctx, store, builderOptions)
+		require.NoError(t, err, "creating builder")
+		t.Cleanup(func() { assert.NoError(t, b.Delete()) })
+		return b
+	}
+	commit := func(b *Builder, name string) *Builder {
+		ref, err := imageStorage.Transport.ParseStoreReference(store, name)
+		require.NoError(t, err, "parsing reference for to-be-committed image", name)
+		_, _, _, err = b.Commit(ctx, ref, CommitOptions{SystemContext: &testSystemContext})
+		require.NoError(t, err, "committing", name)
+		image, err := ImportBuilderFromImage(ctx, store, ImportFromImageOptions{Image: name, SystemContext: &testSystemContext})
+		require.NoError(t, err, "reading", name)
+		return image
+	}
+	contentDir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(contentDir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o644))
+		timestamp := time.Unix(1700000000, 0)
+		require.NoError(t, os.Chtimes(filename, timestamp, timestamp))
+		return filename
+	}
+	unchanged := write("unchanged", "unchanged")
+	modeChanged := write("mode-changed", "mode-changed")
+	removed := write("removed", "removed")
+	grown := write("grown", "short")
+
+	b := newBuilder("scratch")
+	require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, unchanged, modeChanged, removed, grown))
+	first := commit(b, "first")
+
+	b = newBuilder("scratch")
+	require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, unchanged))
+	require.NoError(t, b.Add("/", false, AddAndCopyOptions{Chmod: "0600"}, modeChanged))
+	grown = write("grown", "longer")
+	added := write("added", "added")
+	require.NoError(t, b.Add("/", false, AddAndCopyOptions{}, grown, added))
+	second := commit(b, "second")
+
+	t.Run("images", func(t *testing.T) {
+		entries, err := Diff(store, first, second, DiffOptions{})
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+		assert.Equal(t, "/added", entries[0].Path)
+		assert.Equal(t, DiffAdded, entries[0].Change)
+		assert.Nil(t, entries[0].Old)
+		assert.Equal(t, int64(5), entries[0].New.Size)
+		assert.Equal(t, "/grown", entries[1].Path)
+		assert.Equal(t, DiffModified, entries[1].Change)
+		assert.Equal(t, []string{"size"}, entries[1].Differences)
+		assert.Equal(t, "/mode-changed", entries[2].Path)
+		assert.Equal(t, DiffModified, entries[2].Change)
+		assert.Equal(t, []string{"mode"}, entries[2].Differences)
+		assert.Equal(t, os.FileMode(0o644), entries[2].Old.Mode)
+		assert.Equal(t, os.FileMode(0o600), entries[2].New.Mode)
+		assert.Equal(t, "/removed", entries[3].Path)
+		assert.Equal(t, DiffRemoved, entries[3].Change)
+		assert.Nil(t, entries[3].New)
+		assert.Equal(t, int64(7), entries[3].Old.Size)
+		for _, entry := range entries {
+			for _, info := range []*DiffFileInfo{entry.Old, entry.New} {
+				if info != nil {
+					assert.Empty(t, info.Digest, "digests weren't requested")
+				}
+			}
+		}
+	})
+
+	t.Run("digests", func(t *testing.T) {
+		entries, err := Diff(store, first, second, DiffOptions{Digests: true})
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+		assert.Equal(t, digest.FromString("added"), entries[0].New.Digest)
+		assert.Equal(t, []string{"size", "content"}, entries[1].Differences)
+		assert.Equal(t, digest.FromString("short"), entries[1].Old.Digest)
+		assert.Equal(t, digest.FromString("longer"), entries[1].New.Digest)
+		assert.Equal(t, entries[2].Old.Digest, entries[2].New.Digest)
+		assert.Equal(t, digest.FromString("removed"), entries[3].Old.Digest)
+	})
+
+	t.Run("container", func(t *testing.T) {
+		b := newBuilder("first")
+		require.NoError(t, b.Add("/subdir/", false, AddAndCopyOptions{}, added))
+		entries, err := Diff(store, first, b, DiffOptions{})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "/subdir", entries[0].Path)
+		assert.Equal(t, DiffAdded, entries[0].Change)
+		assert.True(t, entries[0].New.Mode.IsDir())
+		assert.Equal(t, "/subdir/added", entries[1].Path)
+		assert.Equal(t, DiffAdded, entries[1].Change)
+
+		entries, err = Diff(store, b, first, DiffOptions{})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "/subdir", entries[0].Path)
+		assert.Equal(t, DiffRemoved, entries[0].Change)
+	})
+}
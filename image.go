@@ -13,6 +13,7 @@ import (
 	"path"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -123,6 +124,7 @@ type containerImageRef struct {
 	annotations           map[string]string
 	preferredManifestType string
 	squash                bool
+	squashFrom            int // if not 0, the number of base image layers to keep as they are
 	confidentialWorkload  ConfidentialWorkloadOptions
 	omitHistory           bool
 	emptyLayer            bool
@@ -146,6 +148,7 @@ type containerImageRef struct {
 	setAnnotations        []string
 	createdAnnotation     types.OptionalBool
 	os                    string
+	reusedLayers          []string // set by NewImageSource
 }
 
 type blobLayerInfo struct {
@@ -393,6 +396,22 @@ func (i *containerImageRef) newDockerSchema2ManifestBuilder() (manifestBuilder,
 		dimage.Parent = ""
 		dimage.History = []docker.V2S2History{}
 	}
+	// If we're merging some of the base image's layers into the new one,
+	// their history entries no longer describe layers of their own, and
+	// the base image is no longer our parent.
+	if i.squashFrom > 0 {
+		dimage.Parent = ""
+		nonEmpty := 0
+		for h := range dimage.History {
+			if dimage.History[h].EmptyLayer {
+				continue
+			}
+			if nonEmpty >= i.squashFrom {
+				dimage.History[h].EmptyLayer = true
+			}
+			nonEmpty++
+		}
+	}
 
 	// If we were supplied with a configuration, copy fields from it to
 	// matching fields in both formats.
@@ -613,6 +632,20 @@ func (i *containerImageRef) newOCIManifestBuilder() (manifestBuilder, error) {
 	if i.confidentialWorkload.Convert || i.squash || i.omitHistory {
 		oimage.History = []v1.History{}
 	}
+	// If we're merging some of the base image's layers into the new one,
+	// their history entries no longer describe layers of their own.
+	if i.squashFrom > 0 {
+		nonEmpty := 0
+		for h := range oimage.History {
+			if oimage.History[h].EmptyLayer {
+				continue
+			}
+			if nonEmpty >= i.squashFrom {
+				oimage.History[h].EmptyLayer = true
+			}
+			nonEmpty++
+		}
+	}
 
 	// If we were supplied with a configuration, copy fields from it to
 	// matching fields in both formats.
@@ -928,6 +961,28 @@ func (i *containerImageRef) NewImageSource(ctx context.Context, _ *types.SystemC
 	}
 	layer = nil
 
+	// If we're merging some of the base image's layers into the new one,
+	// drop them from the list, and note which layer the diff for the new
+	// one should be computed against.
+	diffBase := ""
+	if i.squashFrom > 0 {
+		var parents []string
+		for _, layerID := range layers {
+			if parentLayerIDs[layerID] {
+				parents = append(parents, layerID)
+			}
+		}
+		if i.squashFrom < len(parents) {
+			diffBase = parents[i.squashFrom-1]
+			merged := parents[i.squashFrom:]
+			layers = slices.DeleteFunc(layers, func(layerID string) bool { return slices.Contains(merged, layerID) })
+			for _, layerID := range merged {
+				delete(parentLayerIDs, layerID)
+			}
+			logrus.Debugf("merging layers %q into the new layer", merged)
+		}
+	}
+
 	// If we're slipping in a synthesized layer to hold some files, we need
 	// to add a placeholder for it to the list just after the read-write
 	// layer.  Confidential workloads and squashed images will just inline
@@ -984,6 +1039,10 @@ func (i *containerImageRef) NewImageSource(ctx context.Context, _ *types.SystemC
 	var extraImageContentDiff string
 	var extraImageContentDiffDigest digest.Digest
 	blobLayers := make(map[digest.Digest]blobLayerInfo)
+	// Keep track of the ID of the most recent layer that we know is in
+	// local storage, so that we can look for a copy of the next one.
+	parentID, parentKnown := "", true
+	i.reusedLayers = nil
 	for _, layerID := range layers {
 		what := fmt.Sprintf("layer %q", layerID)
 		if i.confidentialWorkload.Convert || i.squash {
@@ -1037,6 +1096,7 @@ func (i *containerImageRef) NewImageSource(ctx context.Context, _ *types.SystemC
 				ID:   layerID,
 				Size: layerBlobSize,
 			}
+			parentID, parentKnown = layerID, true
 			continue
 		}
 		// Figure out if we need to change the media type, in case we've changed the compression.
@@ -1096,7 +1156,11 @@ func (i *containerImageRef) NewImageSource(ctx context.Context, _ *types.SystemC
 					layerExclusions = append(layerExclusions, layerPullUps...)
 				}
 				// Extract this layer, one of possibly many.
-				rc, err = i.store.Diff("", layerID, diffOptions)
+				from := ""
+				if layerID == i.layerID {
+					from = diffBase
+				}
+				rc, err = i.store.Diff(from, layerID, diffOptions)
 				if err != nil {
 					return nil, fmt.Errorf("extracting %s: %w", what, err)
 				}
@@ -1188,16 +1252,34 @@ func (i *containerImageRef) NewImageSource(ctx context.Context, _ *types.SystemC
 		// If the layer blob is just a block of zeroes of a size that could
 		// plausibly be an empty diff (i.e., if it's several megabytes,
 		// don't bother, because it's highly unlikely), suppress it.
-		if i.emptyLayerIfEmptyDiff && layerID == i.layerID {
-			switch size {
-			case 0, 512, 1024, 2048:
-				if srcHasher.Digest() == digest.Canonical.FromBytes(make([]byte, size)) {
-					i.emptyLayer = true
-					continue
+		if i.emptyLayerIfEmptyDiff && layerID == i.layerID && isEmptyDiff(size, srcHasher.Digest()) {
+			i.emptyLayer = true
+			continue
+		}
+		// If local storage already has a layer with the same contents
+		// and the same parent, use it instead of the one we just wrote.
+		if layerID == i.layerID && parentKnown && !i.confidentialWorkload.Convert && !i.squash {
+			existing, err := i.findExistingLayer(parentID, srcHasher.Digest())
+			if err != nil {
+				return nil, fmt.Errorf("checking for an existing copy of %s: %w", what, err)
+			}
+			if existing != nil && srcHasher.Digest() == destHasher.Digest() {
+				logrus.Debugf("%s matches existing layer %q, reusing it", what, existing.ID)
+				if err := os.Remove(finalBlobName); err != nil {
+					return nil, fmt.Errorf("removing redundant copy of %s: %w", what, err)
+				}
+				mb.addLayer(srcHasher.Digest(), size, srcHasher.Digest())
+				blobLayers[srcHasher.Digest()] = blobLayerInfo{
+					ID:   existing.ID,
+					Size: size,
 				}
+				i.reusedLayers = append(i.reusedLayers, existing.ID)
+				parentID, parentKnown = existing.ID, true
+				continue
 			}
 		}
 		mb.addLayer(destHasher.Digest(), size, srcHasher.Digest())
+		parentKnown = false
 	}
 
 	// Only attempt to append history if history was not disabled explicitly.
@@ -1230,6 +1312,40 @@ func (i *containerImageRef) NewImageSource(ctx context.Context, _ *types.SystemC
 	return src, nil
 }
 
+// isEmptyDiff returns true if a layer diff with the specified size and digest
+// is just a block of zeroes that could be an empty diff.
+func isEmptyDiff(size int64, diffID digest.Digest) bool {
+	switch size {
+	case 0, 512, 1024, 2048:
+		return diffID == digest.Canonical.FromBytes(make([]byte, size))
+	}
+	return false
+}
+
+// findExistingLayer checks if local storage already has a layer, other than
+// the container's own layer, with the specified diffID and the layer with ID
+// parentID as its parent, or no parent if parentID is empty.  Layers are
+// only reused when we aren't compressing them, so that the blob which we'd
+// have written and the one we'd read from the existing layer are the same.
+func (i *containerImageRef) findExistingLayer(parentID string, diffID digest.Digest) (*storage.Layer, error) {
+	if i.compression != archive.Uncompressed || i.os == "windows" {
+		return nil, nil
+	}
+	layers, err := i.store.LayersByUncompressedDigest(diffID)
+	if err != nil {
+		if errors.Is(err, storage.ErrLayerUnknown) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("looking up layers with diffID %s: %w", diffID, err)
+	}
+	for _, layer := range layers {
+		if layer.ID != i.layerID && layer.Parent == parentID {
+			return &layer, nil
+		}
+	}
+	return nil, nil
+}
+
 func (i *containerImageRef) NewImageDestination(_ context.Context, _ *types.SystemContext) (types.ImageDestination, error) {
 	return nil, errors.New("can't write to a container")
 }
@@ -1615,6 +1731,49 @@ func (b *Builder) makeLinkedLayerInfos(layers []LinkedLayer, layerType string, l
 	return infos, nil
 }
 
+// squashFromLayerCount resolves the value of CommitOptions.SquashFrom into the
+// number of the base image's layers which should be kept as they are, and
+// also returns the number of layers that the base image has.  The container's
+// layer is the one with ID layerID.
+func (b *Builder) squashFromLayerCount(squashFrom, layerID string) (int, int, error) {
+	baseLayers := 0
+	layer, err := b.store.Layer(layerID)
+	if err != nil {
+		return -1, -1, fmt.Errorf("unable to read layer %q: %w", layerID, err)
+	}
+	for parentID := layer.Parent; parentID != ""; parentID = layer.Parent {
+		baseLayers++
+		if layer, err = b.store.Layer(parentID); err != nil {
+			return -1, -1, fmt.Errorf("unable to read layer %q: %w", parentID, err)
+		}
+	}
+	if index, err := strconv.Atoi(squashFrom); err == nil {
+		if index < 0 || index > baseLayers {
+			return -1, -1, fmt.Errorf("can't squash starting with layer %d: base image has %d layers", index, baseLayers)
+		}
+		return index, baseLayers, nil
+	}
+	// Find the last history entry with the marker as its comment.  The
+	// first history entry that we add gets a note about the base image
+	// appended to its comment, so ignore that part.
+	keep, nonEmpty, found := -1, 0, false
+	for _, history := range b.OCIv1.History {
+		if history.Comment == squashFrom || strings.HasPrefix(history.Comment, squashFrom+" FROM ") {
+			keep, found = nonEmpty, true
+		}
+		if !history.EmptyLayer {
+			nonEmpty++
+		}
+	}
+	if !found {
+		return -1, -1, fmt.Errorf("can't squash starting with %q: no history entry has that comment", squashFrom)
+	}
+	if nonEmpty != baseLayers {
+		return -1, -1, fmt.Errorf("can't squash starting with %q: base image history describes %d layers, but it has %d", squashFrom, nonEmpty, baseLayers)
+	}
+	return keep, baseLayers, nil
+}
+
 // makeContainerImageRef creates a containers/image/v5/types.ImageReference
 // which is mainly used for representing the working container as a source
 // image that can be copied, which is how we commit the container to create the
@@ -1629,6 +1788,22 @@ func (b *Builder) makeContainerImageRef(options CommitOptions) (*containerImageR
 	if err != nil {
 		return nil, fmt.Errorf("locating container %q: %w", b.ContainerID, err)
 	}
+	squash, squashFrom := options.Squash, 0
+	if options.SquashFrom != "" && !squash && !options.ConfidentialWorkloadOptions.Convert {
+		if len(options.PrependedLinkedLayers) > 0 || len(b.PrependedLinkedLayers) > 0 {
+			return nil, errors.New("can't add prebuilt layers to the bottom of an image and merge some of its layers, at the same time")
+		}
+		keep, baseLayers, err := b.squashFromLayerCount(options.SquashFrom, container.LayerID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case keep == 0:
+			squash = true
+		case keep < baseLayers:
+			squashFrom = keep
+		}
+	}
 	if len(container.Names) > 0 {
 		if parsed, err2 := reference.ParseNamed(container.Names[0]); err2 == nil {
 			name = parsed
@@ -1773,12 +1948,13 @@ func (b *Builder) makeContainerImageRef(options CommitOptions) (*containerImageR
 		setAnnotations:        slices.Clone(options.Annotations),
 		unsetAnnotations:      slices.Clone(options.UnsetAnnotations),
 		preferredManifestType: manifestType,
-		squash:                options.Squash,
+		squash:                squash,
+		squashFrom:            squashFrom,
 		confidentialWorkload:  options.ConfidentialWorkloadOptions,
 		omitHistory:           options.OmitHistory || forceOmitHistory,
-		emptyLayer:            (options.EmptyLayer || options.OmitLayerHistoryEntry) && !options.Squash && !options.ConfidentialWorkloadOptions.Convert,
-		emptyLayerIfEmptyDiff: options.EmptyLayerIfEmptyDiff && !options.Squash && !options.ConfidentialWorkloadOptions.Convert,
-		omitLayerHistoryEntry: options.OmitLayerHistoryEntry && !options.Squash && !options.ConfidentialWorkloadOptions.Convert,
+		emptyLayer:            (options.EmptyLayer || options.OmitLayerHistoryEntry) && !squash && squashFrom == 0 && !options.ConfidentialWorkloadOptions.Convert,
+		emptyLayerIfEmptyDiff: options.EmptyLayerIfEmptyDiff && !squash && squashFrom == 0 && !options.ConfidentialWorkloadOptions.Convert,
+		omitLayerHistoryEntry: options.OmitLayerHistoryEntry && !squash && squashFrom == 0 && !options.ConfidentialWorkloadOptions.Convert,
 		idMappingOptions:      &b.IDMappingOptions,
 		parent:                parent,
 		blobDirectory:         options.BlobDirectory,
@@ -214,6 +214,17 @@ func BuildDockerfiles(ctx context.Context, store storage.Store, options define.B
 		options.AdditionalBuildContexts = make(map[string]*define.AdditionalBuildContext)
 	}
 
+	caches, err := openLayerCaches(options)
+	if err != nil {
+		return "", nil, err
+	}
+	defer caches.close(false)
+
+	// Secrets read from file descriptors can only be read once, so keep
+	// their values around for the builds for every platform, but no longer.
+	secretDescriptors := &define.SecretDescriptors{}
+	defer secretDescriptors.Clear()
+
 	if len(options.Platforms) == 0 {
 		options.Platforms = append(options.Platforms, struct{ OS, Arch, Variant string }{
 			OS:   options.SystemContext.OSChoice,
@@ -228,6 +239,18 @@ func BuildDockerfiles(ctx context.Context, store storage.Store, options define.B
 		}
 	}
 
+	if options.Events != nil {
+		// Stages, and builds for multiple platforms, can run in
+		// parallel, but the caller's callback shouldn't have to care.
+		var eventsLock sync.Mutex
+		events := options.Events
+		options.Events = func(event define.BuildEvent) {
+			eventsLock.Lock()
+			defer eventsLock.Unlock()
+			events(event)
+		}
+	}
+
 	if sourceDateEpoch, ok := options.Args[internal.SourceDateEpochName]; ok && options.SourceDateEpoch == nil {
 		sde, err := strconv.ParseInt(sourceDateEpoch, 10, 64)
 		if err != nil {
@@ -278,6 +301,13 @@ func BuildDockerfiles(ctx context.Context, store storage.Store, options define.B
 		}
 		// Deep copy args to prevent concurrent read/writes over Args.
 		platformOptions.Args = maps.Clone(options.Args)
+		if options.Events != nil && len(options.Platforms) > 1 {
+			platformName := platforms.Format(platformSpec)
+			platformOptions.Events = func(event define.BuildEvent) {
+				event.Platform = platformName
+				options.Events(event)
+			}
+		}
 
 		if options.SourceDateEpoch != nil {
 			if options.Timestamp != nil {
@@ -313,7 +343,7 @@ func BuildDockerfiles(ctx context.Context, store storage.Store, options define.B
 				platformOptions.ReportWriter = reporter
 				platformOptions.Err = stderr
 			}
-			thisID, thisRef, err := buildDockerfilesOnce(ctx, store, loggerPerPlatform, logPrefix, platformOptions, paths, files, processLabel, mountLabel, usingContextOverlay)
+			thisID, thisRef, err := buildDockerfilesOnce(ctx, store, loggerPerPlatform, logPrefix, platformOptions, paths, files, processLabel, mountLabel, usingContextOverlay, caches, secretDescriptors)
 			if err != nil {
 				if errorContext := strings.TrimSpace(logPrefix); errorContext != "" {
 					return fmt.Errorf("%s: %w", errorContext, err)
@@ -338,6 +368,10 @@ func BuildDockerfiles(ctx context.Context, store storage.Store, options define.B
 		return "", nil, merr.ErrorOrNil()
 	}
 
+	if err := caches.finish(ctx, options.SystemContext, options.CacheTTL); err != nil {
+		return "", nil, fmt.Errorf("saving layer cache: %w", err)
+	}
+
 	// Reasons for this id, ref assignment w.r.t to use-case:
 	//
 	// * Single-platform build: On single platform build we only
@@ -424,7 +458,7 @@ func BuildDockerfiles(ctx context.Context, store storage.Store, options define.B
 	return id, ref, nil
 }
 
-func buildDockerfilesOnce(ctx context.Context, store storage.Store, logger *logrus.Logger, logPrefix string, options define.BuildOptions, containerFiles []string, dockerfilecontents [][]byte, processLabel, mountLabel string, usingContextOverlay bool) (string, reference.Canonical, error) {
+func buildDockerfilesOnce(ctx context.Context, store storage.Store, logger *logrus.Logger, logPrefix string, options define.BuildOptions, containerFiles []string, dockerfilecontents [][]byte, processLabel, mountLabel string, usingContextOverlay bool, caches *layerCaches, secretDescriptors *define.SecretDescriptors) (string, reference.Canonical, error) {
 	mainNode, err := imagebuilder.ParseDockerfile(bytes.NewReader(dockerfilecontents[0]))
 	if err != nil {
 		return "", nil, fmt.Errorf("parsing main Dockerfile: %s: %w", containerFiles[0], err)
@@ -465,7 +499,7 @@ func buildDockerfilesOnce(ctx context.Context, store storage.Store, logger *logr
 		mainNode.Children = append(mainNode.Children, additionalNode.Children...)
 	}
 
-	exec, err := newExecutor(logger, logPrefix, store, options, mainNode, containerFiles, processLabel, mountLabel, usingContextOverlay)
+	exec, err := newExecutor(logger, logPrefix, store, options, mainNode, containerFiles, processLabel, mountLabel, usingContextOverlay, caches, secretDescriptors)
 	if err != nil {
 		return "", nil, fmt.Errorf("creating build executor: %w", err)
 	}
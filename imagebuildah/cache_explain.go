@@ -0,0 +1,119 @@
+package imagebuildah
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createdByComponent identifies a part of the history entry that we record
+// for an instruction, and which is compared when looking for a cached result
+// of the instruction.  Its value describes a difference in that part.
+type createdByComponent string
+
+const (
+	createdByInstruction createdByComponent = "the instruction differs"
+	createdByBuildArgs   createdByComponent = "build arguments differ"
+	createdByMounts      createdByComponent = "checksums of mounted content differ"
+	createdByContent     createdByComponent = "the digest of added content differs"
+	createdByMetadata    createdByComponent = "build metadata differs"
+)
+
+// createdBySegment is a piece of the history entry that we record for an
+// instruction.
+type createdBySegment struct {
+	component createdByComponent
+	text      string
+}
+
+// joinCreatedBy assembles the history entry for an instruction from its
+// pieces.
+func joinCreatedBy(segments []createdBySegment) string {
+	var createdBy strings.Builder
+	for _, segment := range segments {
+		createdBy.WriteString(segment.text)
+	}
+	return createdBy.String()
+}
+
+// How close a cacheMismatch came to being a match, in increasing order.
+const (
+	cacheMismatchPlatform = iota
+	cacheMismatchHistory
+	cacheMismatchCreatedBy // plus the index of the differing createdBySegment
+)
+
+// cacheMismatch describes why an image couldn't be used as the cached result
+// of an instruction.
+type cacheMismatch struct {
+	imageID   string
+	created   time.Time
+	closeness int
+	reason    string
+	expected  string
+	found     string
+}
+
+// closerThan returns true if m came closer to matching than other did, or
+// if they came equally close and m is newer, since newer images are preferred
+// when more than one image matches.
+func (m *cacheMismatch) closerThan(other *cacheMismatch) bool {
+	if m.closeness != other.closeness {
+		return m.closeness > other.closeness
+	}
+	return m.created.After(other.created)
+}
+
+// explain returns a human-readable description of the mismatch.  A nil
+// mismatch indicates that no image was built from the same base.
+func (m *cacheMismatch) explain() string {
+	if m == nil {
+		return "no cached images were built on top of this step's base image"
+	}
+	imageID := m.imageID
+	if len(imageID) > 12 {
+		imageID = imageID[:12]
+	}
+	return fmt.Sprintf("%s in closest cached image %s", m.reason, imageID)
+}
+
+// compareCreatedBy compares the history entry that we would record for an
+// instruction to the one that was recorded in a candidate image, returning
+// a description of the first part of it which differs, or nil if it doesn't.
+func compareCreatedBy(segments []createdBySegment, found string) *cacheMismatch {
+	remainder := found
+	for i, segment := range segments {
+		// Figure out where the part of the remainder that corresponds
+		// to this segment ends by looking for the next segment that
+		// isn't empty.  If there isn't one, this segment has to match
+		// the rest of the entry.
+		matched := strings.HasPrefix(remainder, segment.text)
+		searchFrom := 0
+		if matched {
+			searchFrom = len(segment.text)
+		}
+		end := len(remainder)
+		for _, next := range segments[i+1:] {
+			if next.text != "" {
+				if index := strings.Index(remainder[searchFrom:], next.text); index != -1 {
+					end = searchFrom + index
+				} else if matched {
+					// the next segment differs
+					end = len(segment.text)
+				}
+				break
+			}
+		}
+		if matched && end == len(segment.text) {
+			remainder = remainder[end:]
+			continue
+		}
+		return &cacheMismatch{
+			closeness: cacheMismatchCreatedBy + i,
+			reason:    string(segment.component),
+			expected:  segment.text,
+			found:     remainder[:end],
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package imagebuildah
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareCreatedBy(t *testing.T) {
+	t.Parallel()
+	runEntry := []createdBySegment{
+		{createdByBuildArgs, "|1 FOO=bar "},
+		{createdByInstruction, "/bin/sh -c make"},
+		{createdByMounts, ""},
+		{createdByMetadata, ""},
+	}
+	copyEntry := []createdBySegment{
+		{createdByInstruction, "/bin/sh -c #(nop) COPY "},
+		{createdByContent, "file:aaa"},
+		{createdByInstruction, " in /dest "},
+		{createdByMetadata, "buildah-metadata"},
+	}
+	testCases := []struct {
+		name     string
+		segments []createdBySegment
+		found    string
+		reason   createdByComponent
+		expected string
+		actual   string
+	}{
+		{
+			name:     "run-args",
+			segments: runEntry,
+			found:    "|1 FOO=baz /bin/sh -c make",
+			reason:   createdByBuildArgs,
+			expected: "|1 FOO=bar ",
+			actual:   "|1 FOO=baz ",
+		},
+		{
+			name:     "run-command",
+			segments: runEntry,
+			found:    "|1 FOO=bar /bin/sh -c make install",
+			reason:   createdByInstruction,
+			expected: "/bin/sh -c make",
+			actual:   "/bin/sh -c make install",
+		},
+		{
+			name:     "run-args-added",
+			segments: runEntry[1:],
+			found:    "|1 FOO=bar /bin/sh -c make",
+			reason:   createdByInstruction,
+			expected: "/bin/sh -c make",
+			actual:   "|1 FOO=bar /bin/sh -c make",
+		},
+		{
+			name:     "copy-content",
+			segments: copyEntry,
+			found:    "/bin/sh -c #(nop) COPY file:bbb in /dest buildah-metadata",
+			reason:   createdByContent,
+			expected: "file:aaa",
+			actual:   "file:bbb",
+		},
+		{
+			name:     "copy-metadata",
+			segments: copyEntry,
+			found:    "/bin/sh -c #(nop) COPY file:aaa in /dest ",
+			reason:   createdByMetadata,
+			expected: "buildah-metadata",
+			actual:   "",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Nil(t, compareCreatedBy(testCase.segments, joinCreatedBy(testCase.segments)), "entry should match itself")
+			mismatch := compareCreatedBy(testCase.segments, testCase.found)
+			require.NotNil(t, mismatch, "entries should not have matched")
+			assert.Equal(t, string(testCase.reason), mismatch.reason)
+			assert.Equal(t, testCase.expected, mismatch.expected)
+			assert.Equal(t, testCase.actual, mismatch.found)
+		})
+	}
+}
+
+func TestCacheMismatchCloserThan(t *testing.T) {
+	t.Parallel()
+	platform := &cacheMismatch{closeness: cacheMismatchPlatform}
+	history := &cacheMismatch{closeness: cacheMismatchHistory}
+	content := compareCreatedBy([]createdBySegment{{createdByInstruction, "COPY "}, {createdByContent, "file:aaa"}}, "COPY file:bbb")
+	require.NotNil(t, content)
+	assert.True(t, history.closerThan(platform))
+	assert.True(t, content.closerThan(history))
+	assert.False(t, platform.closerThan(content))
+	assert.Contains(t, (*cacheMismatch)(nil).explain(), "no cached images")
+}
@@ -0,0 +1,294 @@
+package imagebuildah
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	buildkitparser "github.com/moby/buildkit/frontend/dockerfile/parser"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/openshift/imagebuilder/dockerfile/parser"
+	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/internal/redact"
+	"go.podman.io/buildah/pkg/parse"
+)
+
+// contentCacheKeyVersion is recorded in every content-addressed cache key, so
+// that changing what goes into them doesn't cause us to match images whose
+// keys were computed differently.
+const contentCacheKeyVersion = 3
+
+// sensitiveArgsKeyFile is the name of the file, in the store's graph root,
+// which holds the key that we use to compute digests of the values of
+// sensitive build arguments.
+const sensitiveArgsKeyFile = "buildah-sensitive-args.key"
+
+// sensitiveArgsBigDataKey is the key under which the digest of the values of
+// the sensitive build arguments which affected an instruction is recorded for
+// an image that was committed for it, when the cache is keyed on history.
+const sensitiveArgsBigDataKey = "buildah-sensitive-args"
+
+// contentCacheKeyInputs is the set of things which, when hashed, produce the
+// content-addressed cache key for an instruction.
+type contentCacheKeyInputs struct {
+	Version int `json:"version"`
+	// Parent is the cache key of the base image, if it has one, or its
+	// ID, if it doesn't.
+	Parent        string          `json:"parent"`
+	ParentDiffIDs []digest.Digest `json:"parentDiffIDs,omitempty"`
+	Format        string          `json:"format"`
+	OS            string          `json:"os,omitempty"`
+	Architecture  string          `json:"architecture,omitempty"`
+	AddsLayer     bool            `json:"addsLayer"`
+	Instruction   instructionKey  `json:"instruction"`
+	// BuildArgs are "name=value" pairs for build arguments which are
+	// referenced by the instruction, or for a RUN instruction, which are
+	// set in its environment.
+	BuildArgs []string `json:"buildArgs,omitempty"`
+	// SensitiveArgs is a keyed digest of the values of sensitive build
+	// arguments, which are masked in BuildArgs.
+	SensitiveArgs string `json:"sensitiveArgs,omitempty"`
+	// Inputs are digests of content that the instruction adds or mounts.
+	Inputs   []string `json:"inputs,omitempty"`
+	Metadata string   `json:"metadata,omitempty"`
+}
+
+// instructionKey is a parsed instruction, stripped of the formatting details
+// that don't affect what it does.
+type instructionKey struct {
+	Command  string                   `json:"command"`
+	JSON     bool                     `json:"json,omitempty"`
+	Flags    []string                 `json:"flags,omitempty"`
+	Args     []string                 `json:"args,omitempty"`
+	Heredocs []buildkitparser.Heredoc `json:"heredocs,omitempty"`
+}
+
+// buildArgReference matches references to variables in instructions.
+var buildArgReference = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// newInstructionKey returns the parts of a parsed instruction which are
+// included in its content-addressed cache key.  The order of the name=value
+// pairs in a LABEL instruction doesn't change the resulting image, so they
+// are sorted.
+func newInstructionKey(node *parser.Node) instructionKey {
+	key := instructionKey{
+		Command:  strings.ToLower(node.Value),
+		JSON:     node.Attributes["json"],
+		Flags:    slices.Clone(node.Flags),
+		Heredocs: slices.Clone(node.Heredocs),
+	}
+	for arg := node.Next; arg != nil; arg = arg.Next {
+		key.Args = append(key.Args, arg.Value)
+	}
+	if key.Command == "label" && len(key.Args)%2 == 0 {
+		pairs := make([]string, 0, len(key.Args)/2)
+		for i := 0; i < len(key.Args); i += 2 {
+			pairs = append(pairs, key.Args[i]+"="+key.Args[i+1])
+		}
+		slices.Sort(pairs)
+		key.Args = pairs
+	}
+	return key
+}
+
+// referencedBuildArgs returns sorted "name=value" pairs for the build
+// arguments in args which are referenced in the text of an instruction or
+// in any heredocs attached to it.
+func referencedBuildArgs(node *parser.Node, args map[string]string) []string {
+	texts := []string{node.Original}
+	for _, heredoc := range node.Heredocs {
+		texts = append(texts, heredoc.Content)
+	}
+	var referenced []string
+	for _, text := range texts {
+		for _, match := range buildArgReference.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if value, ok := args[name]; ok {
+				arg := name + "=" + value
+				if !slices.Contains(referenced, arg) {
+					referenced = append(referenced, arg)
+				}
+			}
+		}
+	}
+	slices.Sort(referenced)
+	return referenced
+}
+
+// targetPlatform returns the OS and architecture that we're building for.
+func (s *stageExecutor) targetPlatform() (string, string) {
+	currentArch := s.executor.architecture
+	currentOS := s.executor.os
+	if currentArch == "" && currentOS == "" {
+		var err error
+		currentOS, currentArch, _, err = parse.Platform(s.stage.Builder.Platform)
+		if err != nil {
+			logrus.Debugf("unable to parse default OS and Arch for the current build: %v", err)
+		}
+	}
+	return currentOS, currentArch
+}
+
+// contentCacheKey computes a cache key for the instruction which doesn't
+// depend on the text of the history entries that we'd record for it or for
+// earlier instructions, only on the inputs which affect its result.
+func (s *stageExecutor) contentCacheKey(ctx context.Context, currNode *parser.Node, addedContentDigest string, buildAddsLayer bool, lastInstruction bool) (string, error) {
+	inputs := contentCacheKeyInputs{
+		Version:   contentCacheKeyVersion,
+		Parent:    "scratch",
+		Format:    s.executor.outputFormat,
+		AddsLayer: buildAddsLayer,
+	}
+	inputs.OS, inputs.Architecture = s.targetPlatform()
+	if s.builder.FromImageID != "" {
+		_, _, _, _, diffIDs, err := s.executor.getImageTypeAndHistoryAndDiffIDs(ctx, s.builder.FromImageID)
+		if err != nil {
+			return "", fmt.Errorf("getting history of base image %q: %w", s.builder.FromImageID, err)
+		}
+		parentKey, err := s.executor.getImageCacheKey(s.builder.FromImageID)
+		if err != nil {
+			return "", fmt.Errorf("getting cache key of base image %q: %w", s.builder.FromImageID, err)
+		}
+		inputs.Parent = "image:" + s.builder.FromImageID
+		if parentKey != "" {
+			inputs.Parent = "key:" + parentKey
+		}
+		inputs.ParentDiffIDs = diffIDs
+	}
+	if currNode != nil && strings.EqualFold(currNode.Value, "RUN") {
+		inputs.Instruction = newInstructionKey(currNode)
+		// Every allowed build argument is set in a RUN instruction's
+		// environment, where a script that it runs can read it, so
+		// all of them are inputs.
+		inputs.BuildArgs = s.buildArgsResolvedForRun()
+	} else if currNode != nil {
+		inputs.Instruction = newInstructionKey(currNode)
+		args := make(map[string]string)
+		for name, value := range s.stage.Builder.Args {
+			if _, ok := s.stage.Builder.AllowedArgs[name]; ok {
+				if _, sensitive := s.executor.sensitiveArgs[name]; sensitive {
+					// as in the history, the value isn't part of the key
+					value = redact.Placeholder
+				}
+				args[name] = value
+			}
+		}
+		inputs.BuildArgs = referencedBuildArgs(currNode, args)
+	}
+	inputs.SensitiveArgs = s.sensitiveArgsDigest(currNode)
+	segments, err := s.getCreatedBySegments(currNode, addedContentDigest, lastInstruction)
+	if err != nil {
+		return "", err
+	}
+	for _, segment := range segments {
+		switch segment.component {
+		case createdByContent, createdByMounts:
+			if segment.text != "" {
+				inputs.Inputs = append(inputs.Inputs, segment.text)
+			}
+		case createdByMetadata:
+			inputs.Metadata += segment.text
+		}
+	}
+	encoded, err := json.Marshal(&inputs)
+	if err != nil {
+		return "", fmt.Errorf("encoding cache key inputs: %w", err)
+	}
+	logrus.Debugf("content cache key inputs: %s", s.executor.redactor.String(string(encoded)))
+	return fmt.Sprintf("%x", sha256.Sum256(encoded)), nil
+}
+
+// loadSensitiveArgsKey reads the key that we use to compute digests of the
+// values of sensitive build arguments from the store's graph root, creating
+// it if there isn't one yet.
+func loadSensitiveArgsKey(graphRoot string) ([]byte, error) {
+	path := filepath.Join(graphRoot, sensitiveArgsKeyFile)
+	key, err := os.ReadFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading key for sensitive build arguments: %w", err)
+	}
+	f, err := os.CreateTemp(graphRoot, sensitiveArgsKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("creating key for sensitive build arguments: %w", err)
+	}
+	defer os.Remove(f.Name())
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("generating key for sensitive build arguments: %w", err)
+	}
+	_, err = f.Write(key)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing key for sensitive build arguments: %w", err)
+	}
+	// If another build created one first, use that one instead.
+	if err := os.Link(f.Name(), path); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("saving key for sensitive build arguments: %w", err)
+	}
+	if key, err = os.ReadFile(path); err != nil {
+		return nil, fmt.Errorf("reading key for sensitive build arguments: %w", err)
+	}
+	return key, nil
+}
+
+// sensitiveArgsDigest returns a keyed digest of the values of the sensitive
+// build arguments which affect the instruction, so that its cache key can
+// change when they do without them being recorded in its history.  It
+// returns an empty string if none of them affect the instruction.
+func (s *stageExecutor) sensitiveArgsDigest(node *parser.Node) string {
+	if len(s.executor.sensitiveArgs) == 0 || node == nil {
+		return ""
+	}
+	args := make(map[string]string)
+	for name, value := range s.stage.Builder.Args {
+		if _, ok := s.stage.Builder.AllowedArgs[name]; !ok {
+			continue
+		}
+		if _, sensitive := s.executor.sensitiveArgs[name]; sensitive {
+			args[name] = value
+		}
+	}
+	var used []string
+	if strings.EqualFold(node.Value, "RUN") {
+		// As in buildArgsResolvedForRun(), a value in the image's
+		// configuration takes precedence.
+		configured := make(map[string]struct{})
+		for _, env := range s.stage.Builder.Config().Env {
+			if name, _, ok := strings.Cut(env, "="); ok {
+				configured[name] = struct{}{}
+			}
+		}
+		for name, value := range args {
+			if _, ok := configured[name]; !ok {
+				used = append(used, name+"="+value)
+			}
+		}
+		slices.Sort(used)
+	} else {
+		used = referencedBuildArgs(node, args)
+	}
+	if len(used) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.executor.sensitiveArgsKey)
+	for _, arg := range used {
+		fmt.Fprintf(mac, "%d:%s", len(arg), arg)
+	}
+	return fmt.Sprintf("hmac-sha256:%x", mac.Sum(nil))
+}
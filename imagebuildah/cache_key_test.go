@@ -0,0 +1,115 @@
+package imagebuildah
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/openshift/imagebuilder"
+	"github.com/openshift/imagebuilder/dockerfile/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseInstruction(t *testing.T, instruction string) *parser.Node {
+	t.Helper()
+	node, err := imagebuilder.ParseDockerfile(strings.NewReader(instruction))
+	require.NoError(t, err)
+	require.Len(t, node.Children, 1)
+	return node.Children[0]
+}
+
+func TestNewInstructionKey(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{a: "LABEL a=1 b=2", b: "LABEL b=2 a=1", equal: true},
+		{a: "LABEL a=1 b=2", b: "label  a=1 \\\n  b=2", equal: true},
+		{a: "LABEL a=1 b=2", b: "LABEL a=1 b=3", equal: false},
+		{a: "COPY a b /dest/", b: "copy   a b   /dest/", equal: true},
+		{a: "COPY a b /dest/", b: "COPY b a /dest/", equal: false},
+		{a: "COPY --chmod=0755 a /dest/", b: "COPY a /dest/", equal: false},
+		{a: "RUN make", b: "RUN [\"make\"]", equal: false},
+		{a: "ENV A=1 B=2", b: "ENV B=2 A=1", equal: false},
+		{a: "RUN <<EOF\nmake\nEOF", b: "RUN <<EOF\nmake install\nEOF", equal: false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.a+"/"+testCase.b, func(t *testing.T) {
+			t.Parallel()
+			a := newInstructionKey(parseInstruction(t, testCase.a))
+			b := newInstructionKey(parseInstruction(t, testCase.b))
+			if testCase.equal {
+				assert.Equal(t, a, b)
+			} else {
+				assert.NotEqual(t, a, b)
+			}
+		})
+	}
+}
+
+func TestReferencedBuildArgs(t *testing.T) {
+	t.Parallel()
+	args := map[string]string{
+		"VERSION": "1.0",
+		"NAME":    "thing",
+		"UNUSED":  "ignored",
+	}
+	testCases := []struct {
+		instruction string
+		referenced  []string
+	}{
+		{instruction: "RUN make", referenced: nil},
+		{instruction: "RUN make VERSION=$VERSION", referenced: []string{"VERSION=1.0"}},
+		{instruction: "COPY ${NAME}-${VERSION}.tar /$NAME/", referenced: []string{"NAME=thing", "VERSION=1.0"}},
+		{instruction: "RUN echo $VERSIONS $UNDEFINED", referenced: nil},
+		{instruction: "RUN <<EOF\necho ${NAME}\nEOF", referenced: []string{"NAME=thing"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.instruction, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.referenced, referencedBuildArgs(parseInstruction(t, testCase.instruction), args))
+		})
+	}
+}
+
+func TestLoadSensitiveArgsKey(t *testing.T) {
+	t.Parallel()
+	graphRoot := t.TempDir()
+	key, err := loadSensitiveArgsKey(graphRoot)
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+	again, err := loadSensitiveArgsKey(graphRoot)
+	require.NoError(t, err)
+	assert.Equal(t, key, again, "the key should be reused")
+	entries, err := os.ReadDir(graphRoot)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files should be cleaned up")
+	assert.Equal(t, sensitiveArgsKeyFile, entries[0].Name())
+}
+
+func TestSensitiveArgsDigest(t *testing.T) {
+	t.Parallel()
+	digest := func(key, instruction, token string) string {
+		builder := imagebuilder.NewBuilder(map[string]string{"TOKEN": token, "OTHER": "plain"})
+		builder.AllowedArgs["TOKEN"] = true
+		builder.AllowedArgs["OTHER"] = true
+		s := &stageExecutor{
+			executor: &executor{
+				sensitiveArgs:    map[string]struct{}{"TOKEN": {}},
+				sensitiveArgsKey: []byte(key),
+			},
+			stage: &imagebuilder.Stage{Builder: builder},
+		}
+		return s.sensitiveArgsDigest(parseInstruction(t, instruction))
+	}
+	first := digest("key", "RUN make", "first")
+	assert.NotEmpty(t, first, "every build argument is set for RUN")
+	assert.NotContains(t, first, "first")
+	assert.Equal(t, first, digest("key", "RUN make", "first"))
+	assert.NotEqual(t, first, digest("key", "RUN make", "second"), "the digest should change with the value")
+	assert.NotEqual(t, first, digest("other key", "RUN make", "first"), "the digest should depend on the key")
+	assert.NotEmpty(t, digest("key", "COPY file /$TOKEN/", "first"))
+	assert.Empty(t, digest("key", "COPY file /$OTHER/", "first"), "sensitive build arguments which aren't referenced don't matter")
+}
@@ -0,0 +1,117 @@
+package imagebuildah
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah"
+	"go.podman.io/buildah/define"
+	"go.podman.io/image/v5/docker/reference"
+	"go.podman.io/image/v5/transports"
+	"go.podman.io/image/v5/types"
+)
+
+// emit passes an event to the Events callback, if one was supplied.
+func (b *executor) emit(event define.BuildEvent) {
+	if b.events == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	event.Instruction = b.redactor.String(event.Instruction)
+	event.CacheMiss = b.redactor.String(event.CacheMiss)
+	event.Error = b.redactor.String(event.Error)
+	b.events(event)
+}
+
+// event returns an event of the specified type which describes this stage.
+func (s *stageExecutor) event(eventType define.BuildEventType) define.BuildEvent {
+	event := define.BuildEvent{
+		Type:  eventType,
+		Stage: s.index,
+	}
+	// stages which aren't given names are named after their positions
+	if s.name != strconv.Itoa(s.index) {
+		event.StageName = s.name
+	}
+	return event
+}
+
+// emitCommit emits an event describing an image which we just committed.
+func (s *stageExecutor) emitCommit(results *buildah.CommitResults, started time.Time) {
+	if s.executor.events == nil {
+		return
+	}
+	event := s.event(define.BuildEventLayerCommit)
+	event.ImageID = results.ImageID
+	event.Digest = results.Digest
+	event.Duration = time.Since(started)
+	if img, err := s.executor.store.Image(results.ImageID); err == nil && img.TopLayer != "" {
+		if layer, err := s.executor.store.Layer(img.TopLayer); err == nil {
+			event.Layer = layer.UncompressedDigest
+		} else {
+			logrus.Debugf("looking up top layer of image %q: %v", results.ImageID, err)
+		}
+	}
+	s.executor.emit(event)
+}
+
+// blobProgress returns a channel which can be used as the Progress channel
+// when pulling or pushing the named image, and a function to call after the
+// pull or push is finished.  Updates which are sent to the channel are
+// emitted as events of the specified type.  If no Events callback was
+// supplied, the returned channel is nil.
+func (s *stageExecutor) blobProgress(eventType define.BuildEventType, image string) (chan types.ProgressProperties, func()) {
+	if s.executor.events == nil {
+		return nil, func() {}
+	}
+	progress := make(chan types.ProgressProperties)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for update := range progress {
+			event := s.event(eventType)
+			event.Image = image
+			event.Blob = &define.BuildEventBlob{
+				Digest: update.Artifact.Digest,
+				Size:   update.Artifact.Size,
+				Status: progressStatus(update.Event),
+				Offset: update.Offset,
+			}
+			s.executor.emit(event)
+		}
+	}()
+	return progress, func() {
+		close(progress)
+		<-done
+	}
+}
+
+// push pushes an image using buildah.Push, emitting events which describe
+// its progress.
+func (s *stageExecutor) push(ctx context.Context, image string, dest types.ImageReference, options buildah.PushOptions) (reference.Canonical, digest.Digest, error) {
+	var pushFinished func()
+	options.Progress, pushFinished = s.blobProgress(define.BuildEventPushProgress, transports.ImageName(dest))
+	defer pushFinished()
+	return buildah.Push(ctx, image, dest, options)
+}
+
+// progressStatus returns the name that we use for a progress event type in
+// the BuildEventBlob structure.
+func progressStatus(event types.ProgressEvent) string {
+	switch event {
+	case types.ProgressEventNewArtifact:
+		return "new"
+	case types.ProgressEventRead:
+		return "read"
+	case types.ProgressEventDone:
+		return "done"
+	case types.ProgressEventSkipped:
+		return "skipped"
+	}
+	return strconv.FormatUint(uint64(event), 10)
+}
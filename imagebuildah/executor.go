@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"slices"
 	"strconv"
 	"strings"
@@ -22,7 +23,9 @@ import (
 	"go.podman.io/buildah"
 	"go.podman.io/buildah/define"
 	"go.podman.io/buildah/internal"
+	"go.podman.io/buildah/internal/layercache"
 	"go.podman.io/buildah/internal/metadata"
+	"go.podman.io/buildah/internal/redact"
 	internalUtil "go.podman.io/buildah/internal/util"
 	"go.podman.io/buildah/pkg/parse"
 	"go.podman.io/buildah/pkg/sourcepolicy"
@@ -77,7 +80,12 @@ var builtinAllowedBuildArgs = map[string]struct{}{
 type executor struct {
 	cacheFrom                      []reference.Named
 	cacheTo                        []reference.Named
+	cacheFromLayouts               []*layercache.Cache
+	cacheToLayouts                 []*layercache.Cache
 	cacheTTL                       time.Duration
+	cacheExplain                   bool
+	cacheKeyMode                   define.CacheKeyMode
+	contentManifest                bool
 	containerSuffix                string
 	logger                         *logrus.Logger
 	stages                         map[int]*stageExecutor // Maps from stage indexes to their stageExecutors, serialized by stagesLock.
@@ -100,6 +108,7 @@ type executor struct {
 	outputFormat                   string
 	additionalTags                 []string
 	log                            func(format string, args ...any) // can be nil
+	events                         func(event define.BuildEvent)    // can be nil
 	in                             io.Reader
 	inheritLabels                  types.OptionalBool
 	inheritAnnotations             types.OptionalBool
@@ -107,6 +116,8 @@ type executor struct {
 	err                            io.Writer
 	signaturePolicyPath            string
 	sourcePolicy                   *sourcepolicy.Policy
+	sourcePolicyReport             []sourcePolicyReportEntry // serialized by sourcePolicyReportLock
+	sourcePolicyReportLock         sync.Mutex
 	skipUnusedStages               types.OptionalBool
 	systemContext                  *types.SystemContext
 	reportWriter                   io.Writer
@@ -121,6 +132,7 @@ type executor struct {
 	iidfile                                 string
 	iidfileRaw                              string
 	squash                                  bool
+	squashFrom                              string
 	labels                                  []string
 	layerLabels                             []string
 	annotations                             []string
@@ -148,6 +160,7 @@ type executor struct {
 	capabilities                            []string
 	devices                                 define.ContainerDevices
 	deviceSpecs                             []string
+	entitlements                            []string
 	signBy                                  string
 	architecture                            string
 	timestamp                               *time.Time
@@ -163,14 +176,20 @@ type executor struct {
 	terminatedStage                         map[int]error // maps from stage indexes to error results, serialized by stagesLock
 	stagesLock                              sync.Mutex    // serializes stages, stageImageIDs, imageMap, terminatedStage
 	stagesSemaphore                         *semaphore.Weighted
+	copyJobs                                int // sources an ADD or COPY can read at once
 	logRusage                               bool
 	rusageLogFile                           io.Writer
+	rusageLogCloser                         io.Closer // set if we opened rusageLogFile ourselves
 	imageInfoLock                           sync.Mutex
 	imageInfoCache                          map[string]imageTypeAndHistoryAndDiffIDs
 	fromOverride                            string
 	additionalBuildContexts                 map[string]*additionalBuildContext
 	manifest                                string
 	secrets                                 map[string]define.Secret
+	sensitiveArgs                           map[string]struct{}
+	sensitiveArgsKey                        []byte           // used for digests of sensitiveArgs values
+	redactor                                *redact.Redactor // nil unless there are secrets or sensitive args
+	redactWriters                           []*redact.Writer
 	sshsources                              map[string]*sshagent.Source
 	logPrefix                               string
 	unsetEnvs                               []string
@@ -205,8 +224,12 @@ type imageTypeAndHistoryAndDiffIDs struct {
 	os           string
 }
 
+// cacheKeyBigDataKey is the key under which the content-addressed cache key
+// of an image is recorded alongside it in local storage.
+const cacheKeyBigDataKey = "buildah-cache-key"
+
 // newExecutor creates a new instance of the imagebuilder.Executor interface.
-func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, options define.BuildOptions, mainNode *parser.Node, containerFiles []string, processLabel, mountLabel string, contextWritesDiscarded bool) (*executor, error) {
+func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, options define.BuildOptions, mainNode *parser.Node, containerFiles []string, processLabel, mountLabel string, contextWritesDiscarded bool, caches *layerCaches, secretDescriptors *define.SecretDescriptors) (*executor, error) {
 	defaultContainerConfig, err := config.Default()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container config: %w", err)
@@ -238,6 +261,12 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 	if err != nil {
 		return nil, err
 	}
+	for id, secret := range secrets {
+		if secret.SourceType == "fd" {
+			secret.Descriptors = secretDescriptors
+			secrets[id] = secret
+		}
+	}
 	sshsources, err := parse.SSH(options.CommonBuildOpts.SSHSources)
 	if err != nil {
 		return nil, err
@@ -258,15 +287,25 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 	}
 
 	var rusageLogFile io.Writer
+	var rusageLogCloser io.Closer
 
 	if options.LogRusage && !options.Quiet {
 		if options.RusageLogFile == "" {
 			rusageLogFile = options.Out
 		} else {
-			rusageLogFile, err = os.OpenFile(options.RusageLogFile, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
+			f, err := os.OpenFile(options.RusageLogFile, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
 			if err != nil {
 				return nil, fmt.Errorf("creating file to store rusage logs: %w", err)
 			}
+			rusageLogFile, rusageLogCloser = f, f
+		}
+	}
+
+	copyJobs := 1
+	if options.Jobs != nil {
+		copyJobs = *options.Jobs
+		if copyJobs == 0 {
+			copyJobs = runtime.NumCPU()
 		}
 	}
 
@@ -282,9 +321,14 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 
 	exec := executor{
 		args:                                    options.Args,
-		cacheFrom:                               options.CacheFrom,
-		cacheTo:                                 options.CacheTo,
+		cacheFrom:                               slices.Concat(options.CacheFrom, caches.fromRepos),
+		cacheTo:                                 slices.Concat(options.CacheTo, caches.toRepos),
+		cacheFromLayouts:                        caches.fromLayouts,
+		cacheToLayouts:                          caches.toLayouts,
 		cacheTTL:                                options.CacheTTL,
+		cacheExplain:                            options.CacheExplain,
+		cacheKeyMode:                            options.CacheKeyMode,
+		contentManifest:                         options.ContentManifest,
 		containerSuffix:                         options.ContainerSuffix,
 		logger:                                  logger,
 		stages:                                  make(map[int]*stageExecutor),
@@ -314,6 +358,7 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 		skipUnusedStages:                        options.SkipUnusedStages,
 		systemContext:                           options.SystemContext,
 		log:                                     options.Log,
+		events:                                  options.Events,
 		in:                                      options.In,
 		out:                                     options.Out,
 		err:                                     options.Err,
@@ -330,6 +375,7 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 		iidfile:                                 options.IIDFile,
 		iidfileRaw:                              options.IIDFileRaw,
 		squash:                                  options.Squash,
+		squashFrom:                              options.SquashFrom,
 		labels:                                  slices.Clone(options.Labels),
 		layerLabels:                             slices.Clone(options.LayerLabels),
 		processLabel:                            processLabel,
@@ -353,6 +399,7 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 		unusedArgs:                              make(map[string]struct{}),
 		capabilities:                            capabilities,
 		deviceSpecs:                             options.Devices,
+		entitlements:                            slices.Clone(options.Entitlements),
 		signBy:                                  options.SignBy,
 		architecture:                            options.Architecture,
 		timestamp:                               options.Timestamp,
@@ -366,8 +413,10 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 		ociDecryptConfig:                        options.OciDecryptConfig,
 		terminatedStage:                         make(map[int]error),
 		stagesSemaphore:                         options.JobSemaphore,
+		copyJobs:                                copyJobs,
 		logRusage:                               options.LogRusage,
 		rusageLogFile:                           rusageLogFile,
+		rusageLogCloser:                         rusageLogCloser,
 		imageInfoCache:                          make(map[string]imageTypeAndHistoryAndDiffIDs),
 		fromOverride:                            options.From,
 		additionalBuildContexts:                 wrappedAdditionalBuildContexts,
@@ -406,6 +455,42 @@ func newExecutor(logger *logrus.Logger, logPrefix string, store storage.Store, o
 	if exec.out == nil {
 		exec.out = os.Stdout
 	}
+	if len(secrets) > 0 || len(options.SensitiveArgs) > 0 {
+		exec.redactor = redact.New()
+		exec.sensitiveArgs = make(map[string]struct{}, len(options.SensitiveArgs))
+		for _, arg := range options.SensitiveArgs {
+			exec.sensitiveArgs[arg] = struct{}{}
+			if value, ok := options.Args[arg]; ok {
+				exec.redactor.Add([]byte(value))
+			}
+		}
+		if len(exec.sensitiveArgs) > 0 {
+			if exec.sensitiveArgsKey, err = loadSensitiveArgsKey(store.GraphRoot()); err != nil {
+				return nil, err
+			}
+		}
+		// Secrets are added to the redactor as they're read, so mask
+		// them in everything that we, or a RUN instruction, print.
+		wrapped := make(map[io.Writer]*redact.Writer)
+		wrap := func(w io.Writer) io.Writer {
+			if w == nil || w == io.Discard {
+				return w
+			}
+			if rw, ok := wrapped[w]; ok {
+				return rw
+			}
+			rw := redact.NewWriter(exec.redactor, w)
+			wrapped[w] = rw
+			exec.redactWriters = append(exec.redactWriters, rw)
+			return rw
+		}
+		exec.out, exec.err, exec.reportWriter = wrap(exec.out), wrap(exec.err), wrap(exec.reportWriter)
+		if exec.rusageLogCloser == nil {
+			// keep usage logged to our output in order with the
+			// rest of it
+			exec.rusageLogFile = wrap(exec.rusageLogFile)
+		}
+	}
 
 	for arg := range options.Args {
 		if _, isBuiltIn := builtinAllowedBuildArgs[arg]; !isBuiltIn {
@@ -467,6 +552,18 @@ func (b *executor) startStage(ctx context.Context, stage *imagebuilder.Stage, st
 	return stageExec
 }
 
+// hasCacheSources returns true if any registries or OCI layouts were
+// specified as sources of cache images.
+func (b *executor) hasCacheSources() bool {
+	return len(b.cacheFrom) != 0 || len(b.cacheFromLayouts) != 0
+}
+
+// hasCacheDestinations returns true if any registries or OCI layouts were
+// specified as destinations for cache images.
+func (b *executor) hasCacheDestinations() bool {
+	return len(b.cacheTo) != 0 || len(b.cacheToLayouts) != 0
+}
+
 // resolveNameToImageRef creates a types.ImageReference for the output name in local storage
 func (b *executor) resolveNameToImageRef(output string) (types.ImageReference, error) {
 	if imageRef, err := alltransports.ParseImageName(output); err == nil {
@@ -582,6 +679,57 @@ func (b *executor) getImageTypeAndHistoryAndDiffIDs(ctx context.Context, imageID
 	return oci.OS, oci.Architecture, manifestFormat, oci.History, oci.RootFS.DiffIDs, nil
 }
 
+// getImageCacheKey returns the content-addressed cache key which was recorded
+// for an image, if it has one.
+func (b *executor) getImageCacheKey(imageID string) (string, error) {
+	keys, err := b.store.ListImageBigData(imageID)
+	if err != nil {
+		return "", fmt.Errorf("listing data items for image %q: %w", imageID, err)
+	}
+	if !slices.Contains(keys, cacheKeyBigDataKey) {
+		return "", nil
+	}
+	cacheKey, err := b.store.ImageBigData(imageID, cacheKeyBigDataKey)
+	if err != nil {
+		return "", fmt.Errorf("reading cache key of image %q: %w", imageID, err)
+	}
+	return string(cacheKey), nil
+}
+
+// setImageCacheKey records the content-addressed cache key for an image.
+func (b *executor) setImageCacheKey(imageID, cacheKey string) error {
+	if err := b.store.SetImageBigData(imageID, cacheKeyBigDataKey, []byte(cacheKey), nil); err != nil {
+		return fmt.Errorf("recording cache key of image %q: %w", imageID, err)
+	}
+	return nil
+}
+
+// getImageSensitiveArgsDigest returns the digest of the values of sensitive
+// build arguments which was recorded for an image, if it has one.
+func (b *executor) getImageSensitiveArgsDigest(imageID string) (string, error) {
+	keys, err := b.store.ListImageBigData(imageID)
+	if err != nil {
+		return "", fmt.Errorf("listing data items for image %q: %w", imageID, err)
+	}
+	if !slices.Contains(keys, sensitiveArgsBigDataKey) {
+		return "", nil
+	}
+	sensitiveArgsDigest, err := b.store.ImageBigData(imageID, sensitiveArgsBigDataKey)
+	if err != nil {
+		return "", fmt.Errorf("reading sensitive build argument digest of image %q: %w", imageID, err)
+	}
+	return string(sensitiveArgsDigest), nil
+}
+
+// setImageSensitiveArgsDigest records the digest of the values of sensitive
+// build arguments for an image.
+func (b *executor) setImageSensitiveArgsDigest(imageID, sensitiveArgsDigest string) error {
+	if err := b.store.SetImageBigData(imageID, sensitiveArgsBigDataKey, []byte(sensitiveArgsDigest), nil); err != nil {
+		return fmt.Errorf("recording sensitive build argument digest of image %q: %w", imageID, err)
+	}
+	return nil
+}
+
 func (b *executor) buildStage(ctx context.Context, cleanupStages map[int]*stageExecutor, stages imagebuilder.Stages, stageIndex int, afterDependency map[int]int) (imageID string, commitResults *buildah.CommitResults, onlyBaseImage bool, err error) {
 	var prependInstructions, appendInstructions []string
 	stage := stages[stageIndex]
@@ -709,7 +857,19 @@ func (b *executor) buildStage(ctx context.Context, cleanupStages map[int]*stageE
 	}
 
 	// Build this stage.
-	if imageID, commitResults, onlyBaseImage, err = stageExecutor.execute(ctx, base); err != nil {
+	started := time.Now()
+	stageStart := stageExecutor.event(define.BuildEventStageStart)
+	stageStart.Image = base
+	b.emit(stageStart)
+	imageID, commitResults, onlyBaseImage, err = stageExecutor.execute(ctx, base)
+	stageFinish := stageExecutor.event(define.BuildEventStageFinish)
+	stageFinish.ImageID = imageID
+	stageFinish.Duration = time.Since(started)
+	if err != nil {
+		stageFinish.Error = err.Error()
+	}
+	b.emit(stageFinish)
+	if err != nil {
 		return "", nil, onlyBaseImage, err
 	}
 
@@ -893,16 +1053,22 @@ func (b *executor) Build(ctx context.Context, stages imagebuilder.Stages) (image
 			lastErr = err
 		}
 
-		if b.rusageLogFile != nil && b.rusageLogFile != b.out {
+		if b.rusageLogCloser != nil {
 			// we deliberately ignore the error here, as this
 			// function can be called multiple times
-			if closer, ok := b.rusageLogFile.(interface{ Close() error }); ok {
-				closer.Close()
-			}
+			b.rusageLogCloser.Close()
 		}
 		return lastErr
 	}
 
+	defer func() {
+		err = b.redactor.Error(err)
+		for _, w := range b.redactWriters {
+			if flushErr := w.Flush(); flushErr != nil {
+				logrus.Debugf("writing build output: %v", flushErr)
+			}
+		}
+	}()
 	defer func() {
 		if cleanupErr := cleanup(); cleanupErr != nil {
 			if err == nil {
@@ -1310,7 +1476,7 @@ func (b *executor) Build(ctx context.Context, stages imagebuilder.Stages) (image
 				return imageID, ref, fmt.Errorf("coercing image ID into a digest structure: %w", err)
 			}
 		}
-		metadata, err := metadata.Build(cdigest, v1.Descriptor{
+		buildMetadata, err := metadata.Build(cdigest, v1.Descriptor{
 			MediaType: commitResults.MediaType,
 			Digest:    commitResults.Digest,
 			Size:      int64(len(commitResults.ImageManifest)),
@@ -1318,10 +1484,19 @@ func (b *executor) Build(ctx context.Context, stages imagebuilder.Stages) (image
 		if err != nil {
 			return imageID, ref, fmt.Errorf("building metadata for metadata file: %w", err)
 		}
-		metadataBytes, err := json.Marshal(metadata)
+		if contentManifests, ok := commitResults.Metadata[metadata.ContentManifestKey]; ok {
+			buildMetadata[metadata.ContentManifestKey] = contentManifests
+		}
+		b.sourcePolicyReportLock.Lock()
+		if len(b.sourcePolicyReport) > 0 {
+			buildMetadata[metadata.SourcePolicyKey] = b.sourcePolicyReport
+		}
+		b.sourcePolicyReportLock.Unlock()
+		metadataBytes, err := json.Marshal(buildMetadata)
 		if err != nil {
 			return imageID, ref, fmt.Errorf("encoding metadata for metadata file: %w", err)
 		}
+		metadataBytes = b.redactor.Bytes(metadataBytes)
 		if err = os.WriteFile(b.metadataFile, metadataBytes, 0o644); err != nil {
 			return imageID, ref, fmt.Errorf("failed to write image metadata to file %q: %w", b.metadataFile, err)
 		}
@@ -0,0 +1,95 @@
+package imagebuildah
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.podman.io/buildah/define"
+	"go.podman.io/buildah/internal/layercache"
+	"go.podman.io/buildah/pkg/parse"
+	"go.podman.io/image/v5/docker/reference"
+	"go.podman.io/image/v5/types"
+)
+
+// layerCaches holds the cache locations which were parsed from the
+// CacheFromLocations and CacheToLocations build options.  OCI layouts are
+// opened once per call to BuildDockerfiles, so that builds for multiple
+// platforms add to the same layout.
+type layerCaches struct {
+	fromRepos   []reference.Named
+	toRepos     []reference.Named
+	fromLayouts []*layercache.Cache
+	toLayouts   []*layercache.Cache
+}
+
+// openLayerCaches parses the CacheFromLocations and CacheToLocations build
+// options and opens any OCI layouts that they refer to.
+func openLayerCaches(options define.BuildOptions) (_ *layerCaches, err error) {
+	caches := &layerCaches{}
+	defer func() {
+		if err != nil {
+			caches.close(false)
+		}
+	}()
+	open := func(values []string, export bool) ([]reference.Named, []*layercache.Cache, error) {
+		var repos []reference.Named
+		var layouts []*layercache.Cache
+		for _, value := range values {
+			location, err := layercache.GetLocation(value, export)
+			if err != nil {
+				return nil, nil, err
+			}
+			if location.Type == layercache.LocationRegistry {
+				named, err := parse.RepoNamesToNamedReferences([]string{location.Ref})
+				if err != nil {
+					return nil, nil, err
+				}
+				repos = append(repos, named...)
+				continue
+			}
+			layout, err := layercache.Open(location)
+			if err != nil {
+				return nil, nil, err
+			}
+			layouts = append(layouts, layout)
+		}
+		return repos, layouts, nil
+	}
+	if caches.fromRepos, caches.fromLayouts, err = open(options.CacheFromLocations, false); err != nil {
+		return nil, fmt.Errorf("parsing cache source locations: %w", err)
+	}
+	if caches.toRepos, caches.toLayouts, err = open(options.CacheToLocations, true); err != nil {
+		return nil, fmt.Errorf("parsing cache destination locations: %w", err)
+	}
+	return caches, nil
+}
+
+// finish prunes cache images which are older than ttl from OCI layouts which
+// were used as cache destinations, and then writes out any which are stored
+// as OCI archives.
+func (l *layerCaches) finish(ctx context.Context, sys *types.SystemContext, ttl time.Duration) error {
+	for _, layout := range l.toLayouts {
+		if err := layout.Prune(ctx, sys, ttl); err != nil {
+			return err
+		}
+	}
+	return l.close(true)
+}
+
+// close releases any temporary directories used by the OCI layouts, saving
+// the ones used as cache destinations first if save is true.
+func (l *layerCaches) close(save bool) error {
+	var lastErr error
+	for _, layout := range l.fromLayouts {
+		if err := layout.Close(false); err != nil {
+			lastErr = err
+		}
+	}
+	for _, layout := range l.toLayouts {
+		if err := layout.Close(save); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
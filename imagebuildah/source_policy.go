@@ -0,0 +1,76 @@
+package imagebuildah
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/pkg/sourcepolicy"
+)
+
+// sourcePolicyReportEntry records a source policy rule which matched a source
+// that the build used.  The list of them is included in the metadata file.
+type sourcePolicyReportEntry struct {
+	Source   string              `json:"source"`
+	Usage    string              `json:"usage"`
+	Action   sourcepolicy.Action `json:"action"`
+	Target   string              `json:"target,omitempty"`
+	Rule     int                 `json:"rule"`
+	Selector string              `json:"selector"`
+}
+
+// evaluateSourcePolicy evaluates the source policy, if there is one, for a
+// source identifier, and reports the rule which matched, if one did.  The
+// usage describes the instruction or flag which referred to the source.
+func (b *executor) evaluateSourcePolicy(source, sourceID, usage string) (sourcepolicy.Decision, bool, error) {
+	if b.sourcePolicy == nil {
+		return sourcepolicy.Decision{}, false, nil
+	}
+	decision, matched, err := b.sourcePolicy.Evaluate(sourceID)
+	if err != nil {
+		return sourcepolicy.Decision{}, false, fmt.Errorf("evaluating source policy for %q: %w", source, err)
+	}
+	if !matched {
+		return decision, false, nil
+	}
+	entry := sourcePolicyReportEntry{
+		Source:   source,
+		Usage:    usage,
+		Action:   decision.Action,
+		Rule:     decision.Rule,
+		Selector: b.sourcePolicy.Rules[decision.Rule].Selector.Identifier,
+	}
+	if decision.Action == sourcepolicy.ActionConvert {
+		entry.Target = decision.TargetRef
+	}
+	b.sourcePolicyReportLock.Lock()
+	b.sourcePolicyReport = append(b.sourcePolicyReport, entry)
+	b.sourcePolicyReportLock.Unlock()
+	logrus.Debugf("source policy: %s %q for %s (%s)", decision.Action, source, usage, decision.Reason)
+	if !b.quiet {
+		switch decision.Action {
+		case sourcepolicy.ActionConvert:
+			fmt.Fprintf(b.out, "--> Source policy rule %d (%s) converted %s %q to %q\n", entry.Rule, entry.Selector, usage, source, entry.Target)
+		default:
+			fmt.Fprintf(b.out, "--> Source policy rule %d (%s) matched %s %q: %s\n", entry.Rule, entry.Selector, usage, source, decision.Action)
+		}
+	}
+	return decision, true, nil
+}
+
+// applySourcePolicyToImage evaluates the source policy, if there is one, for
+// an image which is about to be used, and returns the name of the image which
+// should be used in its place.  The usage describes the instruction or flag
+// which referred to the image.
+func (b *executor) applySourcePolicyToImage(image, usage string) (string, error) {
+	decision, matched, err := b.evaluateSourcePolicy(image, sourcepolicy.ImageSourceIdentifier(image), usage)
+	if err != nil || !matched {
+		return image, err
+	}
+	switch decision.Action {
+	case sourcepolicy.ActionDeny:
+		return "", fmt.Errorf("source %q %w: %s", image, sourcepolicy.ErrDenied, decision.Reason)
+	case sourcepolicy.ActionConvert:
+		return sourcepolicy.ExtractImageRef(decision.TargetRef), nil
+	}
+	return image, nil
+}
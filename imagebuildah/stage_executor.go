@@ -3,9 +3,12 @@ package imagebuildah
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"os"
 	"path"
 	"path/filepath"
@@ -28,8 +31,10 @@ import (
 	"go.podman.io/buildah/define"
 	buildahdocker "go.podman.io/buildah/docker"
 	"go.podman.io/buildah/internal"
+	"go.podman.io/buildah/internal/layercache"
 	"go.podman.io/buildah/internal/metadata"
 	"go.podman.io/buildah/internal/output"
+	"go.podman.io/buildah/internal/redact"
 	"go.podman.io/buildah/internal/sanitize"
 	"go.podman.io/buildah/internal/tmpdir"
 	"go.podman.io/buildah/internal/urlsource"
@@ -38,16 +43,21 @@ import (
 	"go.podman.io/buildah/pkg/rusage"
 	"go.podman.io/buildah/pkg/sourcepolicy"
 	"go.podman.io/buildah/util"
+	"go.podman.io/common/libnetwork/pasta"
 	config "go.podman.io/common/pkg/config"
 	cp "go.podman.io/image/v5/copy"
 	imagedocker "go.podman.io/image/v5/docker"
+	dockerarchive "go.podman.io/image/v5/docker/archive"
 	"go.podman.io/image/v5/docker/reference"
 	"go.podman.io/image/v5/image"
 	"go.podman.io/image/v5/manifest"
+	ociarchive "go.podman.io/image/v5/oci/archive"
+	"go.podman.io/image/v5/pkg/compression"
 	is "go.podman.io/image/v5/storage"
 	"go.podman.io/image/v5/transports"
 	"go.podman.io/image/v5/types"
 	"go.podman.io/storage"
+	"go.podman.io/storage/pkg/archive"
 	"go.podman.io/storage/pkg/chrootarchive"
 	"go.podman.io/storage/pkg/unshare"
 )
@@ -72,6 +82,7 @@ type stageExecutor struct {
 	stages                imagebuilder.Stages
 	name                  string
 	builder               *buildah.Builder
+	baseImageID           string // the ID of the image that the stage started from, if it didn't start from scratch
 	preserved             int
 	volumes               imagebuilder.VolumeSet // list of directories which are volumes
 	volumeCache           map[string]string      // mapping from volume directories to cache archives (used by vfs method)
@@ -83,7 +94,11 @@ type stageExecutor struct {
 	didExecute            bool
 	argsFromContainerfile []string
 	hasLink               bool
+	unpack                types.OptionalBool // the value of the current ADD instruction's --unpack flag
+	runSecurity           string             // the value of the current RUN instruction's --security flag
+	runDevices            []string           // the values of the current RUN instruction's --device flags
 	isLastStep            bool
+	cacheMiss             *cacheMismatch // the closest candidate found by the most recent cache search, if we're explaining cache misses
 }
 
 // Preserve informs the stage executor that from this point on, it needs to
@@ -549,7 +564,7 @@ func (s *stageExecutor) performCopy(excludes []string, copies ...imagebuilder.Co
 				}
 			} else if additionalBuildContext.IsImage {
 				// Image was selected as additionalContext so only process image.
-				mountPoint, err := s.getImageRootfs(s.ctx, copy.From)
+				mountPoint, err := s.getImageRootfs(s.ctx, copy.From, fmt.Sprintf("COPY --from=%s", from))
 				if err != nil {
 					return err
 				}
@@ -573,10 +588,22 @@ func (s *stageExecutor) performCopy(excludes []string, copies ...imagebuilder.Co
 
 		var gitSources []string
 		var nonGitSources []string
+		var pinnedSources []pinnedURLSource
 		for _, src := range copy.Src {
-			if urlsource.IsHTTPOrHTTPS(src) {
+			if urlsource.IsHTTPOrHTTPS(src) || urlsource.IsGitSSH(src) {
 				// Source is a URL, allowed for ADD but not COPY.
 				if copy.Download {
+					src, checksum, err := s.applySourcePolicyToURL(src)
+					if err != nil {
+						return err
+					}
+					if checksum != "" && checksum != copy.Checksum {
+						if copy.Checksum != "" {
+							return fmt.Errorf("checksum %q for %q does not match checksum %q required by source policy", copy.Checksum, src, checksum)
+						}
+						pinnedSources = append(pinnedSources, pinnedURLSource{url: src, checksum: checksum})
+						continue
+					}
 					if urlsource.IsGit(src) {
 						gitSources = append(gitSources, src)
 					} else {
@@ -622,6 +649,8 @@ func (s *stageExecutor) performCopy(excludes []string, copies ...imagebuilder.Co
 			Parents:               copy.Parents,
 			Link:                  s.hasLink,
 			BuildMetadata:         labelsAndAnnotations,
+			Jobs:                  s.executor.copyJobs,
+			ContentManifest:       s.executor.contentManifest,
 		}
 		if len(copy.Files) > 0 {
 			// If we are copying heredoc files, we need to temporary place
@@ -633,8 +662,23 @@ func (s *stageExecutor) performCopy(excludes []string, copies ...imagebuilder.Co
 			options.IgnoreFile = ""
 		}
 
+		// ADD extracts local archives unless --unpack=false is used,
+		// and only extracts remote archives if --unpack=true is used.
+		extract := copy.Download && s.unpack != types.OptionalBoolFalse
+		options.ExtractRemoteArchives = copy.Download && s.unpack == types.OptionalBoolTrue
+
 		if len(nonGitSources) > 0 {
-			if err := s.builder.Add(copy.Dest, copy.Download, options, nonGitSources...); err != nil {
+			if err := s.builder.Add(copy.Dest, extract, options, nonGitSources...); err != nil {
+				return err
+			}
+		}
+
+		// URLs which the source policy pinned to checksums are added one
+		// at a time, since each can have a different checksum.
+		for _, pinned := range pinnedSources {
+			pinnedOptions := options
+			pinnedOptions.Checksum = pinned.checksum
+			if err := s.builder.Add(copy.Dest, extract, pinnedOptions, pinned.url); err != nil {
 				return err
 			}
 		}
@@ -644,7 +688,16 @@ func (s *stageExecutor) performCopy(excludes []string, copies ...imagebuilder.Co
 			gitOptions := options
 			gitOptions.Excludes = copyExcludesWithoutContainerIgnore
 			gitOptions.IgnoreFile = ""
-			if err := s.builder.Add(copy.Dest, copy.Download, gitOptions, gitSources...); err != nil {
+			if secret, ok := s.executor.secrets[gitAuthTokenSecret]; ok {
+				token, err := secret.ResolveValue()
+				if err != nil {
+					return fmt.Errorf("reading secret %q: %w", gitAuthTokenSecret, err)
+				}
+				s.executor.redactor.Add(token)
+				gitOptions.GitAuthToken = strings.TrimSpace(string(token))
+			}
+			gitOptions.GitSSHSource = s.executor.sshsources[gitSSHSource]
+			if err := s.builder.Add(copy.Dest, extract, gitOptions, gitSources...); err != nil {
 				return err
 			}
 		}
@@ -695,8 +748,11 @@ func (s *stageExecutor) runStageMountPoints(mountList []string) (map[string]inte
 					// stage which might have the value as its name.
 					if additionalBuildContext, ok := s.executor.additionalBuildContexts[from]; ok {
 						if additionalBuildContext.IsImage {
-							mountPoint, err := s.getImageRootfs(s.ctx, additionalBuildContext.Value)
+							mountPoint, err := s.getImageRootfs(s.ctx, additionalBuildContext.Value, "RUN --mount from="+from)
 							if err != nil {
+								if errors.Is(err, sourcepolicy.ErrDenied) {
+									return nil, err
+								}
 								return nil, fmt.Errorf("%s from=%s: image not found with that name", flag, from)
 							}
 							// The `from` in stageMountPoints should point
@@ -761,8 +817,11 @@ func (s *stageExecutor) runStageMountPoints(mountList []string) (map[string]inte
 						break
 					}
 					// Otherwise, treat the source's name as the name of an image.
-					mountPoint, err := s.getImageRootfs(s.ctx, from)
+					mountPoint, err := s.getImageRootfs(s.ctx, from, "RUN --mount from="+from)
 					if err != nil {
+						if errors.Is(err, sourcepolicy.ErrDenied) {
+							return nil, err
+						}
 						return nil, fmt.Errorf("%s from=%s: no stage or image found with that name", flag, from)
 					}
 					stageMountPoints[from] = internal.StageMountDetails{
@@ -813,6 +872,146 @@ func parseSheBang(data string) string {
 	return ""
 }
 
+const (
+	// gitAuthTokenSecret is the ID of the build secret which is used as
+	// the password when ADD fetches a Git repository using HTTP(S).
+	gitAuthTokenSecret = "GIT_AUTH_TOKEN"
+	// gitSSHSource is the ID of the --ssh source which is used when ADD
+	// fetches a Git repository using SSH.
+	gitSSHSource = "default"
+)
+
+// takeUnpackFlag removes any --unpack flag, which imagebuilder doesn't
+// recognize, from an ADD instruction, and returns its value.
+func (s *stageExecutor) takeUnpackFlag(step *imagebuilder.Step) (types.OptionalBool, error) {
+	unpack := types.OptionalBoolUndefined
+	if step.Command != command.Add {
+		return unpack, nil
+	}
+	flags := make([]string, 0, len(step.Flags))
+	for _, flag := range step.Flags {
+		arg, err := imagebuilder.ProcessWord(flag, s.stage.Builder.Arguments())
+		if err != nil {
+			return unpack, fmt.Errorf("unable to resolve argument %q: %w", flag, err)
+		}
+		value, ok := strings.CutPrefix(arg, "--unpack")
+		if !ok || (value != "" && !strings.HasPrefix(value, "=")) {
+			flags = append(flags, flag)
+			continue
+		}
+		enabled := true
+		if value != "" {
+			if enabled, err = strconv.ParseBool(value[1:]); err != nil {
+				return unpack, fmt.Errorf("ADD: invalid value for --unpack: %q", value[1:])
+			}
+		}
+		unpack = types.NewOptionalBool(enabled)
+	}
+	step.Flags = flags
+	return unpack, nil
+}
+
+// takeRunFlags removes any --security and --device flags, which imagebuilder
+// doesn't recognize, from a RUN instruction, and returns their values.
+func (s *stageExecutor) takeRunFlags(step *imagebuilder.Step) (string, []string, error) {
+	var security string
+	var devices []string
+	if step.Command != command.Run {
+		return security, devices, nil
+	}
+	flags := make([]string, 0, len(step.Flags))
+	for _, flag := range step.Flags {
+		arg, err := imagebuilder.ProcessWord(flag, s.stage.Builder.Arguments())
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to resolve argument %q: %w", flag, err)
+		}
+		if value, ok := strings.CutPrefix(arg, "--security="); ok {
+			switch value {
+			case "sandbox", "insecure":
+				security = value
+			default:
+				return "", nil, fmt.Errorf(`RUN: invalid value for --security: %q, must be "sandbox" or "insecure"`, value)
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(arg, "--device="); ok {
+			if value == "" {
+				return "", nil, errors.New("RUN: --device requires a value")
+			}
+			devices = append(devices, value)
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	if security == "insecure" && !slices.Contains(s.executor.entitlements, define.EntitlementSecurityInsecure) {
+		return "", nil, fmt.Errorf(`RUN --security=insecure requires the %q entitlement, which can be granted using "--allow %s"`, define.EntitlementSecurityInsecure, define.EntitlementSecurityInsecure)
+	}
+	step.Flags = flags
+	return security, devices, nil
+}
+
+// pinnedURLSource is an ADD source which the source policy requires to have a
+// specific checksum.
+type pinnedURLSource struct {
+	url      string
+	checksum string
+}
+
+// applySourcePolicyToURL evaluates the source policy, if there is one, for an
+// ADD source which is an HTTP(S) URL or a Git repository.  It returns the
+// location to read the source from, and the checksum that the source policy
+// requires its contents to have, if it requires one.
+func (s *stageExecutor) applySourcePolicyToURL(src string) (string, string, error) {
+	if s.executor.sourcePolicy == nil {
+		return src, "", nil
+	}
+	isGit := urlsource.IsGit(src)
+	sourceID := sourcepolicy.HTTPSourceIdentifier(src)
+	if isGit {
+		sourceID = sourcepolicy.GitSourceIdentifier(src)
+	}
+	decision, matched, err := s.executor.evaluateSourcePolicy(src, sourceID, "ADD")
+	if err != nil || !matched {
+		return src, "", err
+	}
+	switch decision.Action {
+	case sourcepolicy.ActionDeny:
+		return "", "", fmt.Errorf("source %q %w: %s", src, sourcepolicy.ErrDenied, decision.Reason)
+	case sourcepolicy.ActionConvert:
+		newSrc := decision.TargetRef
+		if isGit {
+			newSrc = sourcepolicy.ExtractGitURL(newSrc)
+		}
+		if !urlsource.IsHTTPOrHTTPS(newSrc) {
+			return "", "", fmt.Errorf("source policy converted %q to unsupported source %q: %s", src, decision.TargetRef, decision.Reason)
+		}
+		checksum := decision.Attrs[sourcepolicy.AttrHTTPChecksum]
+		if checksum != "" && urlsource.IsGit(newSrc) {
+			return "", "", fmt.Errorf("source policy requires checksum for Git repository %q, which is not supported: %s", newSrc, decision.Reason)
+		}
+		return newSrc, checksum, nil
+	}
+	return src, "", nil
+}
+
+// checkRunNetwork checks that a "RUN --network" value which isn't one of the
+// keywords we recognize is either "pasta", possibly with options, or a
+// comma-separated list of networks that we know about.
+func (s *stageExecutor) checkRunNetwork(network string) error {
+	if name, _, _ := strings.Cut(network, ":"); name == pasta.BinaryName {
+		return nil
+	}
+	if s.builder.NetworkInterface == nil {
+		return fmt.Errorf(`unsupported value %q for "RUN --network": no networks are configured`, network)
+	}
+	for name := range strings.SplitSeq(network, ",") {
+		if _, err := s.builder.NetworkInterface.NetworkInspect(name); err != nil {
+			return fmt.Errorf(`unsupported value %q for "RUN --network", must be "host", "none", "private", or the name of a network: %w`, network, err)
+		}
+	}
+	return nil
+}
+
 // Run executes a RUN instruction using the stage's current working container
 // as a root directory.
 func (s *stageExecutor) Run(run imagebuilder.Run, config docker.Config) error {
@@ -879,7 +1078,10 @@ func (s *stageExecutor) Run(run imagebuilder.Run, config docker.Config) error {
 		NoHosts:              s.executor.noHosts,
 		NoPivot:              os.Getenv("BUILDAH_NOPIVOT") != "" || s.executor.noPivotRoot,
 		Quiet:                s.executor.quiet,
+		RecordSecret:         s.executor.redactor.Add,
 		CompatBuiltinVolumes: types.OptionalBoolFalse,
+		DeviceSpecs:          slices.Clone(s.runDevices),
+		Insecure:             s.runSecurity == "insecure",
 		RunMounts:            slices.Concat(run.Mounts, s.executor.transientRunMounts),
 		Runtime:              s.executor.runtime,
 		Secrets:              s.executor.secrets,
@@ -901,10 +1103,17 @@ func (s *stageExecutor) Run(run imagebuilder.Run, config docker.Config) error {
 		options.ConfigureNetwork = define.NetworkEnabled
 	case "none":
 		options.ConfigureNetwork = define.NetworkDisabled
+	case "private", "bridge":
+		options.NamespaceOptions.AddOrReplace(define.NamespaceOption{Name: "network"})
+		options.ConfigureNetwork = define.NetworkEnabled
 	case "", "default":
 		// do nothing
 	default:
-		return fmt.Errorf(`unsupported value %q for "RUN --network", must be either "host" or "none"`, run.Network)
+		if err := s.checkRunNetwork(run.Network); err != nil {
+			return err
+		}
+		options.NamespaceOptions.AddOrReplace(define.NamespaceOption{Name: "network", Path: run.Network})
+		options.ConfigureNetwork = define.NetworkEnabled
 	}
 
 	if config.NetworkDisabled {
@@ -1003,7 +1212,10 @@ func (s *stageExecutor) sanitizeFrom(from, tmpdir string) (newFrom string, err e
 // prepare creates a working container based on the specified image, or if one
 // isn't specified, the first argument passed to the first FROM instruction we
 // can find in the stage's parsed tree.
-func (s *stageExecutor) prepare(ctx context.Context, from string, initializeIBConfig, rebase, preserveBaseImageAnnotations bool, pullPolicy define.PullPolicy) (builder *buildah.Builder, err error) {
+//
+// If sourcePolicyUsage is not empty, the source policy is applied to the image
+// first, and the rule which matched, if any, is reported along with it.
+func (s *stageExecutor) prepare(ctx context.Context, from string, initializeIBConfig, rebase, preserveBaseImageAnnotations bool, pullPolicy define.PullPolicy, sourcePolicyUsage string) (builder *buildah.Builder, err error) {
 	stage := s.stage
 	ib := stage.Builder
 	node := stage.Node
@@ -1020,7 +1232,7 @@ func (s *stageExecutor) prepare(ctx context.Context, from string, initializeIBCo
 	// Apply source policy if one is configured and this is not "scratch" or a stage reference.
 	// Stage references are handled separately and don't need policy evaluation since they
 	// refer to images built within this same build.
-	if s.executor.sourcePolicy != nil && from != "scratch" {
+	if s.executor.sourcePolicy != nil && sourcePolicyUsage != "" && from != "scratch" {
 		// Check if 'from' references a previous stage by name, index, or image ID
 		isStageRef := false
 		if stageIndex, _ := s.executor.stageIndex(from, s.stages[:s.index]); stageIndex != -1 {
@@ -1040,24 +1252,8 @@ func (s *stageExecutor) prepare(ctx context.Context, from string, initializeIBCo
 		}
 
 		if !isStageRef {
-			sourceID := sourcepolicy.ImageSourceIdentifier(from)
-			decision, matched, err := s.executor.sourcePolicy.Evaluate(sourceID)
-			if err != nil {
-				return nil, fmt.Errorf("evaluating source policy for %q: %w", from, err)
-			}
-
-			if matched {
-				switch decision.Action {
-				case sourcepolicy.ActionDeny:
-					return nil, fmt.Errorf("source %q denied by source policy: %s", from, decision.Reason)
-				case sourcepolicy.ActionConvert:
-					// Extract the new image reference from the policy decision
-					newFrom := sourcepolicy.ExtractImageRef(decision.TargetRef)
-					logrus.Debugf("source policy: converting %q to %q (%s)", from, newFrom, decision.Reason)
-					from = newFrom
-				case sourcepolicy.ActionAllow:
-					logrus.Debugf("source policy: allowing %q (%s)", from, decision.Reason)
-				}
+			if from, err = s.executor.applySourcePolicyToImage(from, sourcePolicyUsage); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -1136,7 +1332,10 @@ func (s *stageExecutor) prepare(ctx context.Context, from string, initializeIBCo
 		CompatScratchConfig:   s.executor.compatScratchConfig,
 	}
 
+	var pullFinished func()
+	builderOptions.Progress, pullFinished = s.blobProgress(define.BuildEventPullProgress, sanitizedFrom)
 	builder, err = buildah.NewBuilder(ctx, s.executor.store, builderOptions)
+	pullFinished()
 	if err != nil {
 		return nil, fmt.Errorf("creating build container: %w", err)
 	}
@@ -1214,6 +1413,9 @@ func (s *stageExecutor) prepare(ctx context.Context, from string, initializeIBCo
 		// Make this our "current" working container.
 		s.mountPoint = mountPoint
 		s.builder = builder
+		if initializeIBConfig {
+			s.baseImageID = builder.FromImageID
+		}
 		// Now that the rootfs is mounted, set up handling of volumes from the base image.
 		s.volumes = make([]string, 0, len(s.volumes))
 		s.volumeCache = make(map[string]string)
@@ -1250,12 +1452,13 @@ func (*stageExecutor) stepRequiresLayer(step *imagebuilder.Step) bool {
 // getImageRootfs checks for an image matching the passed-in name in local
 // storage.  If it isn't found, it pulls down a copy.  Then, if we don't have a
 // working container root filesystem based on the image, it creates one.  Then
-// it returns that root filesystem's location.
-func (s *stageExecutor) getImageRootfs(ctx context.Context, image string) (mountPoint string, err error) {
+// it returns that root filesystem's location.  The usage describes the
+// instruction which referred to the image, for source policy reporting.
+func (s *stageExecutor) getImageRootfs(ctx context.Context, image, usage string) (mountPoint string, err error) {
 	if builder, ok := s.executor.containerMap[image]; ok {
 		return builder.MountPoint, nil
 	}
-	builder, err := s.prepare(ctx, image, false, false, false, s.executor.pullPolicy)
+	builder, err := s.prepare(ctx, image, false, false, false, s.executor.pullPolicy, usage)
 	if err != nil {
 		return "", err
 	}
@@ -1340,7 +1543,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 	// Create the (first) working container for this stage.  Reinitializing
 	// the imagebuilder configuration may alter the list of steps we have,
 	// so take a snapshot of them *after* that.
-	if _, err := s.prepare(ctx, base, true, true, preserveBaseImageAnnotationsAtStageStart, pullPolicy); err != nil {
+	if _, err := s.prepare(ctx, base, true, true, preserveBaseImageAnnotationsAtStageStart, pullPolicy, "FROM"); err != nil {
 		return "", nil, false, err
 	}
 	children := stage.Node.Children
@@ -1363,10 +1566,10 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 	}
 	// logCachePulled produces build log for cases when `--cache-from`
 	// is used and a valid intermediate image is pulled from remote source.
-	logCachePulled := func(cacheKey string, remote reference.Named) {
+	logCachePulled := func(cacheKey string, remote string) {
 		if !s.executor.quiet {
 			cachePullMessage := "--> Cache pulled from remote"
-			fmt.Fprintf(s.executor.out, "%s %s\n", cachePullMessage, fmt.Sprintf("%s:%s", remote.String(), cacheKey))
+			fmt.Fprintf(s.executor.out, "%s %s\n", cachePullMessage, fmt.Sprintf("%s:%s", remote, cacheKey))
 		}
 	}
 	// logCachePush produces build log for cases when `--cache-to`
@@ -1374,7 +1577,24 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 	logCachePush := func(cacheKey string) {
 		if !s.executor.quiet {
 			cachePushMessage := "--> Pushing cache"
-			fmt.Fprintf(s.executor.out, "%s %s\n", cachePushMessage, fmt.Sprintf("%s:%s", s.executor.cacheTo, cacheKey))
+			destinations := make([]string, 0, len(s.executor.cacheTo)+len(s.executor.cacheToLayouts))
+			for _, repo := range s.executor.cacheTo {
+				destinations = append(destinations, repo.String())
+			}
+			for _, layout := range s.executor.cacheToLayouts {
+				destinations = append(destinations, layout.String())
+			}
+			fmt.Fprintf(s.executor.out, "%s %s\n", cachePushMessage, fmt.Sprintf("%s:%s", destinations, cacheKey))
+		}
+	}
+	// logCacheMiss explains why we didn't find a cached result for an
+	// instruction.
+	logCacheMiss := func(mismatch *cacheMismatch) {
+		if !s.executor.quiet {
+			fmt.Fprintf(s.executor.out, "--> Cache miss: %s\n", mismatch.explain())
+			if mismatch != nil {
+				fmt.Fprintf(s.executor.out, "    expected: %q\n    found:    %q\n", mismatch.expected, mismatch.found)
+			}
 		}
 	}
 	logCacheHit := func(cacheID string) {
@@ -1407,7 +1627,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 
 	if len(children) == 0 {
 		// There are no steps.
-		if s.builder.FromImageID == "" || s.executor.squash || s.executor.confidentialWorkload.Convert || len(s.executor.annotations) > 0 || len(s.executor.unsetEnvs) > 0 || len(s.executor.unsetLabels) > 0 || len(s.executor.sbomScanOptions) > 0 || len(s.executor.unsetAnnotations) > 0 || s.executor.inheritLabels == types.OptionalBoolFalse || s.executor.inheritAnnotations == types.OptionalBoolFalse {
+		if s.builder.FromImageID == "" || s.executor.squash || (lastStage && s.executor.squashFrom != "") || s.executor.confidentialWorkload.Convert || len(s.executor.annotations) > 0 || len(s.executor.unsetEnvs) > 0 || len(s.executor.unsetLabels) > 0 || len(s.executor.sbomScanOptions) > 0 || len(s.executor.unsetAnnotations) > 0 || s.executor.inheritLabels == types.OptionalBoolFalse || s.executor.inheritAnnotations == types.OptionalBoolFalse {
 			// We either don't have a base image, or we need to
 			// transform the contents of the base image, or we need
 			// to make some changes to just the config blob.  Whichever
@@ -1424,7 +1644,11 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			if err != nil {
 				return "", nil, false, fmt.Errorf("unable to get createdBy for the node: %w", err)
 			}
-			if imgID, commitResults, err = s.commit(ctx, createdBy, emptyLayer, s.output, s.executor.squash || s.executor.confidentialWorkload.Convert, lastStage); err != nil {
+			squashFrom := ""
+			if lastStage {
+				squashFrom = s.executor.squashFrom
+			}
+			if imgID, commitResults, err = s.commit(ctx, createdBy, "", "", emptyLayer, s.output, s.executor.squash || s.executor.confidentialWorkload.Convert, squashFrom, lastStage); err != nil {
 				return "", nil, false, fmt.Errorf("committing base container: %w", err)
 			}
 		} else {
@@ -1440,14 +1664,39 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 		}
 		// Generate build output from the new image, or the preexisting
 		// one if we didn't actually do anything, if needed.
-		for _, buildOutputOption := range buildOutputOptions {
-			if err := s.generateBuildOutput(buildOutputOption); err != nil {
-				return "", nil, onlyBaseImage, err
-			}
+		if err := s.generateBuildOutputs(ctx, buildOutputOptions, imgID); err != nil {
+			return "", nil, onlyBaseImage, err
 		}
 		logImageID(imgID)
 	}
 
+	// When building with layers, we need to commit the final image a
+	// second time if it's going to be squashed, converted, or scanned.
+	finalCommitPending := s.executor.squash || s.executor.squashFrom != "" || s.executor.confidentialWorkload.Convert || len(s.executor.sbomScanOptions) != 0
+
+	// Keep track of the instruction that we're processing, so that we can
+	// report when we're done with it, or why we failed.
+	var (
+		stepFinish  *define.BuildEvent
+		stepStarted time.Time
+	)
+	finishStep := func(imageID string, cache define.BuildCacheDecision, stepErr error) {
+		if stepFinish == nil {
+			return
+		}
+		stepFinish.ImageID = imageID
+		stepFinish.Cache = cache
+		stepFinish.Duration = time.Since(stepStarted)
+		if stepErr != nil {
+			stepFinish.Error = stepErr.Error()
+		}
+		s.executor.emit(*stepFinish)
+		stepFinish = nil
+	}
+	defer func() {
+		finishStep("", "", err)
+	}()
+
 	executedLayerStep := false
 	for i, node := range children {
 		logRusage()
@@ -1460,6 +1709,12 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 		if err := step.Resolve(node); err != nil {
 			return "", nil, false, fmt.Errorf("resolving step %+v: %w", *node, err)
 		}
+		if s.unpack, err = s.takeUnpackFlag(step); err != nil {
+			return "", nil, false, err
+		}
+		if s.runSecurity, s.runDevices, err = s.takeRunFlags(step); err != nil {
+			return "", nil, false, err
+		}
 		logrus.Debugf("Parsed Step: %+v", *step)
 		if !s.executor.quiet {
 			logMsg := step.Original
@@ -1481,6 +1736,12 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			}
 			s.log("%s", logMsg)
 		}
+		stepEvent := s.event(define.BuildEventStepStart)
+		stepEvent.Step = i + 1
+		stepEvent.Instruction = step.Original
+		s.executor.emit(stepEvent)
+		stepEvent.Type = define.BuildEventStepFinish
+		stepFinish, stepStarted = &stepEvent, time.Now()
 
 		// Check if there's a --from if the step command is COPY.
 		// Also check the chmod and the chown flags for validity.
@@ -1522,8 +1783,12 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 						break
 					}
 					// replace with image set in build context
+					usage := fmt.Sprintf("%s --from=%s", strings.ToUpper(step.Command), from)
 					from = additionalBuildContext.Value
-					if _, err := s.getImageRootfs(ctx, from); err != nil {
+					if _, err := s.getImageRootfs(ctx, from, usage); err != nil {
+						if errors.Is(err, sourcepolicy.ErrDenied) {
+							return "", nil, false, err
+						}
 						return "", nil, false, fmt.Errorf("%s --from=%s: no stage or image found with that name", strings.ToUpper(step.Command), from)
 					}
 					break
@@ -1537,7 +1802,10 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 				}
 				if otherStageIndex, _ := s.executor.stageIndex(from, s.stages[:s.index]); otherStageIndex != -1 {
 					break
-				} else if _, err = s.getImageRootfs(ctx, from); err != nil {
+				} else if _, err = s.getImageRootfs(ctx, from, fmt.Sprintf("%s --from=%s", strings.ToUpper(step.Command), from)); err != nil {
+					if errors.Is(err, sourcepolicy.ErrDenied) {
+						return "", nil, false, err
+					}
 					return "", nil, false, fmt.Errorf("%s --from=%s: no stage or image found with that name", strings.ToUpper(step.Command), from)
 				}
 				break
@@ -1581,6 +1849,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 					return "", nil, false, fmt.Errorf("unable to get createdBy for the node: %w", err)
 				}
 				s.builder.AddPrependedEmptyLayer(&timestamp, createdBy, "", "")
+				finishStep("", "", nil)
 				continue
 			}
 			// This is the last instruction for this stage, so we
@@ -1600,21 +1869,24 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 				if !executedLayerStep {
 					emptyLayer = types.OptionalBoolTrue
 				}
-				imgID, commitResults, err = s.commit(ctx, createdBy, emptyLayer, s.output, s.executor.squash, lastStage && lastInstruction)
+				squashFrom := ""
+				if lastStage && lastInstruction {
+					squashFrom = s.executor.squashFrom
+				}
+				imgID, commitResults, err = s.commit(ctx, createdBy, "", "", emptyLayer, s.output, s.executor.squash, squashFrom, lastStage && lastInstruction)
 				if err != nil {
 					return "", nil, false, fmt.Errorf("committing container for step %+v: %w", *step, err)
 				}
 				logImageID(imgID)
 				// Generate build output if needed.
-				for _, buildOutputOption := range buildOutputOptions {
-					if err := s.generateBuildOutput(buildOutputOption); err != nil {
-						return "", nil, false, err
-					}
+				if err := s.generateBuildOutputs(ctx, buildOutputOptions, imgID); err != nil {
+					return "", nil, false, err
 				}
 			} else {
 				imgID = ""
 				commitResults = &buildah.CommitResults{}
 			}
+			finishStep(imgID, "", nil)
 			break
 		}
 
@@ -1629,6 +1901,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			rebase                    bool
 			addedContentSummary       string
 			canMatchCacheOnlyAfterRun bool
+			lookedForCache            bool
 		)
 
 		// Only attempt to find cache if it's needed, this part is needed
@@ -1660,7 +1933,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			}
 		}
 
-		needsCacheKey := (len(s.executor.cacheFrom) != 0 && !avoidLookingCache) || len(s.executor.cacheTo) != 0
+		needsCacheKey := (s.executor.hasCacheSources() && !avoidLookingCache) || s.executor.hasCacheDestinations()
 
 		// If we have to commit for this instruction, only assign the
 		// stage's configured output name to the last layer.
@@ -1708,19 +1981,21 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 				}
 			}
 			cacheID, err = s.intermediateImageExists(ctx, node, addedContentSummary, s.stepRequiresLayer(step), lastInstruction && lastStage)
+			lookedForCache = true
 			if err != nil {
 				return "", nil, false, fmt.Errorf("checking if cached image exists from a previous build: %w", err)
 			}
 			// All the best efforts to find a suitable cache hit in local storage have
 			// failed; try pulling cache from a remote repo if `--cache-from` was
 			// configured.
-			if cacheID == "" && len(s.executor.cacheFrom) != 0 {
+			if cacheID == "" && s.executor.hasCacheSources() {
 				// only attempt to use cache again if pulling was successful
 				// otherwise do nothing and attempt to run the step, err != nil
 				// is ignored and will be automatically logged for --log-level debug
-				if ref, id, err := s.pullCache(ctx, cacheKey); ref != nil && id != "" && err == nil {
+				if ref, id, err := s.pullCache(ctx, cacheKey); ref != "" && id != "" && err == nil {
 					logCachePulled(cacheKey, ref)
 					cacheID, err = s.intermediateImageExists(ctx, node, addedContentSummary, s.stepRequiresLayer(step), lastInstruction && lastStage)
+					lookedForCache = true
 					if err != nil {
 						return "", nil, false, fmt.Errorf("checking if cached image exists from a previous build: %w", err)
 					}
@@ -1762,19 +2037,21 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			// has the same change that we just made.
 			if checkForLayers && !avoidLookingCache {
 				cacheID, err = s.intermediateImageExists(ctx, node, addedContentSummary, s.stepRequiresLayer(step), lastInstruction && lastStage)
+				lookedForCache = true
 				if err != nil {
 					return "", nil, false, fmt.Errorf("checking if cached image exists from a previous build: %w", err)
 				}
 				// All the best effort to find cache on localstorage have failed try pulling
 				// cache from remote repo if `--cache-from` was configured and cacheKey was
 				// generated again after adding content summary.
-				if cacheID == "" && len(s.executor.cacheFrom) != 0 {
+				if cacheID == "" && s.executor.hasCacheSources() {
 					// only attempt to use cache again if pulling was successful
 					// otherwise do nothing and attempt to run the step, err != nil
 					// is ignored and will be automatically logged for --log-level debug
-					if ref, id, err := s.pullCache(ctx, cacheKey); ref != nil && id != "" && err == nil {
+					if ref, id, err := s.pullCache(ctx, cacheKey); ref != "" && id != "" && err == nil {
 						logCachePulled(cacheKey, ref)
 						cacheID, err = s.intermediateImageExists(ctx, node, addedContentSummary, s.stepRequiresLayer(step), lastInstruction && lastStage)
+						lookedForCache = true
 						if err != nil {
 							return "", nil, false, fmt.Errorf("checking if cached image exists from a previous build: %w", err)
 						}
@@ -1826,6 +2103,9 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			}
 		} else {
 			logrus.Debugf("No longer searching cache due to miss")
+			if lookedForCache && s.executor.cacheExplain {
+				logCacheMiss(s.cacheMiss)
+			}
 			// We're not going to find any more cache hits, so we
 			// can stop looking for them.
 			checkForLayers = false
@@ -1833,6 +2113,16 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			if err != nil {
 				return "", nil, false, fmt.Errorf("unable to get createdBy for the node: %w", err)
 			}
+			// Record the cache key for this step so that later
+			// builds can find the image using it.
+			contentKey, sensitiveArgsDigest := "", ""
+			if s.executor.cacheKeyMode == define.CacheKeyModeContent {
+				if contentKey, err = s.contentCacheKey(ctx, node, addedContentSummary, s.stepRequiresLayer(step), lastStage && lastInstruction); err != nil {
+					return "", nil, false, fmt.Errorf("computing cache key: %w", err)
+				}
+			} else {
+				sensitiveArgsDigest = s.sensitiveArgsDigest(node)
+			}
 			// Create a new image, maybe with a new layer, with the
 			// name for this stage if it's the last instruction.
 			logCommit(s.output, i)
@@ -1841,13 +2131,15 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			// layers even if its a squashed build so that they
 			// can be part of the build cache.
 			emptyLayer := types.NewOptionalBool(!s.stepRequiresLayer(step))
-			imgID, commitResults, err = s.commit(ctx, createdBy, emptyLayer, commitName, false, lastStage && lastInstruction)
+			imgID, commitResults, err = s.commit(ctx, createdBy, contentKey, sensitiveArgsDigest, emptyLayer, commitName, false, "", lastStage && lastInstruction)
 			if err != nil {
 				return "", nil, false, fmt.Errorf("committing container for step %+v: %w", *step, err)
 			}
-			// Generate build output if needed.
-			for _, buildOutputOption := range buildOutputOptions {
-				if err := s.generateBuildOutput(buildOutputOption); err != nil {
+			// Generate build output if needed, unless this isn't
+			// the final image, or we're about to commit the final
+			// image again below.
+			if lastInstruction && lastStage && !finalCommitPending {
+				if err := s.generateBuildOutputs(ctx, buildOutputOptions, imgID); err != nil {
 					return "", nil, false, err
 				}
 			}
@@ -1863,7 +2155,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 		// Try to push this cache to remote repository only
 		// if cache was present on local storage and not
 		// pulled from remote source while processing this
-		if len(s.executor.cacheTo) != 0 && (!pulledAndUsedCacheImage || cacheID == "") && needsCacheKey {
+		if s.executor.hasCacheDestinations() && (!pulledAndUsedCacheImage || cacheID == "") && needsCacheKey {
 			logCachePush(cacheKey)
 			if err = s.pushCache(ctx, imgID, cacheKey); err != nil {
 				return "", nil, false, err
@@ -1871,7 +2163,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 		}
 
 		if lastInstruction && lastStage {
-			if s.executor.squash || s.executor.confidentialWorkload.Convert || len(s.executor.sbomScanOptions) != 0 {
+			if finalCommitPending {
 				createdBy, err := s.getCreatedBy(node, addedContentSummary, lastStage && lastInstruction)
 				if err != nil {
 					return "", nil, false, fmt.Errorf("unable to get createdBy for the node: %w", err)
@@ -1882,15 +2174,13 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 				// or a normal one if we need to scan the image while
 				// committing it.
 				emptyLayer := types.NewOptionalBool(!s.stepRequiresLayer(step))
-				imgID, commitResults, err = s.commit(ctx, createdBy, emptyLayer, commitName, s.executor.squash || s.executor.confidentialWorkload.Convert, lastStage && lastInstruction)
+				imgID, commitResults, err = s.commit(ctx, createdBy, "", "", emptyLayer, commitName, s.executor.squash || s.executor.confidentialWorkload.Convert, s.executor.squashFrom, lastStage && lastInstruction)
 				if err != nil {
 					return "", nil, false, fmt.Errorf("committing final squash step %+v: %w", *step, err)
 				}
 				// Generate build output if needed.
-				for _, buildOutputOption := range buildOutputOptions {
-					if err := s.generateBuildOutput(buildOutputOption); err != nil {
-						return "", nil, false, err
-					}
+				if err := s.generateBuildOutputs(ctx, buildOutputOptions, imgID); err != nil {
+					return "", nil, false, err
 				}
 			} else if cacheID != "" {
 				// If we found a valid cache hit and this is lastStage
@@ -1902,15 +2192,23 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 				// then generate output manually since there is no opportunity
 				// for us to perform `commit` anywhere in the code.
 				// Generate build output if needed.
-				for _, buildOutputOption := range buildOutputOptions {
-					if err := s.generateBuildOutput(buildOutputOption); err != nil {
-						return "", nil, false, err
-					}
+				if err := s.generateBuildOutputs(ctx, buildOutputOptions, imgID); err != nil {
+					return "", nil, false, err
 				}
 			}
 		}
 
 		logImageID(imgID)
+		var cacheDecision define.BuildCacheDecision
+		if cacheID != "" {
+			cacheDecision = define.BuildCacheHit
+		} else if lookedForCache {
+			cacheDecision = define.BuildCacheMiss
+			if s.executor.cacheExplain {
+				stepFinish.CacheMiss = s.cacheMiss.explain()
+			}
+		}
+		finishStep(imgID, cacheDecision, nil)
 
 		// Update our working container to be based off of the cached
 		// image, if we might need to use it as a basis for the next
@@ -1934,7 +2232,7 @@ func (s *stageExecutor) execute(ctx context.Context, base string) (imgID string,
 			// Enforce pull "never" since we already have an image
 			// ID that we really should not be pulling anymore (see
 			// containers/podman/issues/10307).
-			if _, err := s.prepare(ctx, imgID, false, true, true, define.PullNever); err != nil {
+			if _, err := s.prepare(ctx, imgID, false, true, true, define.PullNever, ""); err != nil {
 				return "", nil, false, fmt.Errorf("preparing container for next step: %w", err)
 			}
 		}
@@ -1970,56 +2268,60 @@ func historyEntriesEqual(base, derived v1.History) bool {
 	return true
 }
 
-// historyAndDiffIDsMatch returns true if a candidate history matches the
-// history of our base image (if we have one), plus the current instruction,
-// and if the list of diff IDs for the images do for the part of the history
-// that we're comparing.
+// historyAndDiffIDsMatch checks if a candidate history matches the history of
+// our base image (if we have one), plus the current instruction, and if the
+// list of diff IDs for the images do for the part of the history that we're
+// comparing.  It returns nil if they do, or a description of the first
+// difference that it finds.
 // Used to verify whether a cache of the intermediate image exists and whether
 // to run the build again.
-func (s *stageExecutor) historyAndDiffIDsMatch(baseHistory []v1.History, baseDiffIDs []digest.Digest, child *parser.Node, history []v1.History, diffIDs []digest.Digest, addedContentSummary string, buildAddsLayer bool, lastInstruction bool) (bool, error) {
+func (s *stageExecutor) historyAndDiffIDsMatch(baseHistory []v1.History, baseDiffIDs []digest.Digest, child *parser.Node, history []v1.History, diffIDs []digest.Digest, addedContentSummary string, buildAddsLayer bool, lastInstruction bool) (*cacheMismatch, error) {
+	historyMismatch := func(reason, expected, found string) *cacheMismatch {
+		return &cacheMismatch{closeness: cacheMismatchHistory, reason: reason, expected: expected, found: found}
+	}
 	// our history should be as long as the base's, plus one entry for what
 	// we're doing
 	if len(history) != len(baseHistory)+1 {
-		return false, nil
+		return historyMismatch("the number of history entries differs", strconv.Itoa(len(baseHistory)+1), strconv.Itoa(len(history))), nil
 	}
 	// check that each entry in the base history corresponds to an entry in
 	// our history, and count how many of them add a layer diff
 	expectedDiffIDs := 0
 	for i := range baseHistory {
 		if !historyEntriesEqual(baseHistory[i], history[i]) {
-			return false, nil
+			return historyMismatch(fmt.Sprintf("base image history entry %d differs", i+1), baseHistory[i].CreatedBy, history[i].CreatedBy), nil
 		}
 		if !baseHistory[i].EmptyLayer {
 			expectedDiffIDs++
 		}
 	}
 	if len(baseDiffIDs) != expectedDiffIDs {
-		return false, nil
+		return historyMismatch("the number of base image layers differs", strconv.Itoa(expectedDiffIDs), strconv.Itoa(len(baseDiffIDs))), nil
 	}
 	if buildAddsLayer {
 		// we're adding a layer, so we should have exactly one more
 		// layer than the base image
 		if len(diffIDs) != expectedDiffIDs+1 {
-			return false, nil
+			return historyMismatch("the number of layers differs", strconv.Itoa(expectedDiffIDs+1), strconv.Itoa(len(diffIDs))), nil
 		}
 	} else {
 		// we're not adding a layer, so we should have exactly the same
 		// layers as the base image
 		if len(diffIDs) != expectedDiffIDs {
-			return false, nil
+			return historyMismatch("the number of layers differs", strconv.Itoa(expectedDiffIDs), strconv.Itoa(len(diffIDs))), nil
 		}
 	}
 	// compare the diffs for the layers that we should have in common
 	for i := range baseDiffIDs {
 		if diffIDs[i] != baseDiffIDs[i] {
-			return false, nil
+			return historyMismatch(fmt.Sprintf("base image layer %d differs", i+1), baseDiffIDs[i].String(), diffIDs[i].String()), nil
 		}
 	}
-	createdBy, err := s.getCreatedBy(child, addedContentSummary, lastInstruction)
+	createdBy, err := s.getCreatedBySegments(child, addedContentSummary, lastInstruction)
 	if err != nil {
-		return false, fmt.Errorf("unable to get createdBy for the node: %w", err)
+		return nil, fmt.Errorf("unable to get createdBy for the node: %w", err)
 	}
-	return history[len(baseHistory)].CreatedBy == createdBy, nil
+	return compareCreatedBy(createdBy, history[len(baseHistory)].CreatedBy), nil
 }
 
 // getCreatedBy returns the value to store in the history entry for the node.
@@ -2034,8 +2336,25 @@ func (s *stageExecutor) historyAndDiffIDsMatch(baseHistory []v1.History, baseDif
 // were made so that a future build won't mistake this result for a cache hit
 // unless the same flags are being used at that time.
 func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary string, isLastStep bool) (string, error) {
+	segments, err := s.getCreatedBySegments(node, addedContentSummary, isLastStep)
+	if err != nil {
+		return "", err
+	}
+	return joinCreatedBy(segments), nil
+}
+
+// getCreatedBySegments returns the pieces of the value that getCreatedBy
+// returns, labeled with the parts of the cache key that they represent.
+func (s *stageExecutor) getCreatedBySegments(node *parser.Node, addedContentSummary string, isLastStep bool) (segments []createdBySegment, err error) {
+	// Keep the values of secrets out of the history, and since we compare
+	// these values to history entries, mask them in the same way here.
+	defer func() {
+		for i := range segments {
+			segments[i].text = s.executor.redactor.String(segments[i].text)
+		}
+	}()
 	if node == nil {
-		return "/bin/sh", nil
+		return []createdBySegment{{createdByInstruction, "/bin/sh"}}, nil
 	}
 
 	command := strings.ToUpper(node.Value)
@@ -2051,7 +2370,11 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 			}
 		}
 		buildArgs := s.getBuildArgsKey()
-		return "/bin/sh -c #(nop) ARG " + buildArgs + labelsAndAnnotations, nil
+		return []createdBySegment{
+			{createdByInstruction, "/bin/sh -c #(nop) ARG "},
+			{createdByBuildArgs, buildArgs},
+			{createdByMetadata, labelsAndAnnotations},
+		}, nil
 	case "RUN":
 		shArg := ""
 		buildArgs := s.getBuildArgsResolvedForRun()
@@ -2069,7 +2392,7 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 				mountOptionSource = mountInfo.Source
 				mountOptionSource, err = imagebuilder.ProcessWord(mountOptionSource, s.stage.Builder.Arguments())
 				if err != nil {
-					return "", fmt.Errorf("getCreatedBy: while replacing arg variables with values for format %q: %w", mountOptionSource, err)
+					return nil, fmt.Errorf("getCreatedBy: while replacing arg variables with values for format %q: %w", mountOptionSource, err)
 				}
 				mountOptionFrom = mountInfo.From
 				// If source is not specified then default is '.'
@@ -2103,7 +2426,7 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 							}
 							mountCheckSum, err = generatePathChecksum(filepath.Join(basePath, mountOptionSource))
 							if err != nil {
-								return "", fmt.Errorf("generating checksum for directory %q in %q: %w", mountOptionSource, basePath, err)
+								return nil, fmt.Errorf("generating checksum for directory %q in %q: %w", mountOptionSource, basePath, err)
 							}
 						}
 					}
@@ -2112,7 +2435,7 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 				if mountOptionSource != "" {
 					mountCheckSum, err = generatePathChecksum(filepath.Join(s.executor.contextDir, mountOptionSource))
 					if err != nil {
-						return "", fmt.Errorf("generating checksum for directory %q in %q: %w", mountOptionSource, s.executor.contextDir, err)
+						return nil, fmt.Errorf("generating checksum for directory %q in %q: %w", mountOptionSource, s.executor.contextDir, err)
 					}
 				}
 			}
@@ -2126,7 +2449,7 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 		}
 
 		heredoc := ""
-		result := ""
+		argsPrefix := ""
 		if len(node.Heredocs) > 0 {
 			for _, doc := range node.Heredocs {
 				heredocContent := strings.TrimSpace(doc.Content)
@@ -2134,10 +2457,14 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 			}
 		}
 		if buildArgs != "" {
-			result = result + "|" + strconv.Itoa(len(strings.Split(buildArgs, " "))) + " " + buildArgs + " "
-		}
-		result = result + "/bin/sh -c " + shArg + heredoc + appendCheckSum.String() + labelsAndAnnotations
-		return result, nil
+			argsPrefix = "|" + strconv.Itoa(len(strings.Split(buildArgs, " "))) + " " + buildArgs + " "
+		}
+		return []createdBySegment{
+			{createdByBuildArgs, argsPrefix},
+			{createdByInstruction, "/bin/sh -c " + shArg + heredoc},
+			{createdByMounts, appendCheckSum.String()},
+			{createdByMetadata, labelsAndAnnotations},
+		}, nil
 	case "ADD", "COPY":
 		destination := node
 		for destination.Next != nil {
@@ -2147,9 +2474,17 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 		if s.hasLink {
 			hasLink = " --link"
 		}
-		return "/bin/sh -c #(nop) " + strings.ToUpper(node.Value) + hasLink + " " + addedContentSummary + " in " + destination.Value + " " + labelsAndAnnotations, nil
+		return []createdBySegment{
+			{createdByInstruction, "/bin/sh -c #(nop) " + strings.ToUpper(node.Value) + hasLink + " "},
+			{createdByContent, addedContentSummary},
+			{createdByInstruction, " in " + destination.Value + " "},
+			{createdByMetadata, labelsAndAnnotations},
+		}, nil
 	default:
-		return "/bin/sh -c #(nop) " + node.Original + labelsAndAnnotations, nil
+		return []createdBySegment{
+			{createdByInstruction, "/bin/sh -c #(nop) " + node.Original},
+			{createdByMetadata, labelsAndAnnotations},
+		}, nil
 	}
 }
 
@@ -2158,6 +2493,12 @@ func (s *stageExecutor) getCreatedBy(node *parser.Node, addedContentSummary stri
 // values for args are overridden by the values specified using ENV.
 // Reason: Values from ENV will always override values specified arg.
 func (s *stageExecutor) getBuildArgsResolvedForRun() string {
+	return strings.Join(s.buildArgsResolvedForRun(), " ")
+}
+
+// buildArgsResolvedForRun returns the sorted "name=value" pairs which
+// getBuildArgsResolvedForRun joins together.
+func (s *stageExecutor) buildArgsResolvedForRun() []string {
 	var envs []string
 	configuredEnvs := make(map[string]string)
 	dockerConfig := s.stage.Builder.Config()
@@ -2174,7 +2515,13 @@ func (s *stageExecutor) getBuildArgsResolvedForRun() string {
 			// if value was in image it will be given higher priority
 			// so please embed that into build history
 			_, inImage := configuredEnvs[key]
-			if inImage {
+			if _, sensitive := s.executor.sensitiveArgs[key]; sensitive && !inImage {
+				// Mask the value, here and everywhere else.  The
+				// cache is keyed on a digest of the value instead;
+				// see sensitiveArgsDigest().
+				s.executor.redactor.Add([]byte(value))
+				envs = append(envs, fmt.Sprintf("%s=%s", key, redact.Placeholder))
+			} else if inImage {
 				envs = append(envs, fmt.Sprintf("%s=%s", key, configuredEnvs[key]))
 			} else {
 				// By default everything must be added to history.
@@ -2206,7 +2553,7 @@ func (s *stageExecutor) getBuildArgsResolvedForRun() string {
 		}
 	}
 	slices.Sort(envs)
-	return strings.Join(envs, " ")
+	return envs
 }
 
 // getBuildArgs key returns the set of args which were specified during the
@@ -2301,7 +2648,7 @@ func (s *stageExecutor) tagExistingImage(ctx context.Context, cacheID, output st
 		return "", nil, fmt.Errorf("computing digest of manifest for image %q: %w", cacheID, err)
 	}
 	// Reconstruct the metadata that would be returned if we were committing it fresh.
-	metadata, err := metadata.Build(parsedManifest.ConfigInfo().Digest, v1.Descriptor{
+	imageMetadata, err := metadata.Build(parsedManifest.ConfigInfo().Digest, v1.Descriptor{
 		MediaType: manifestType,
 		Digest:    manifestDigest,
 		Size:      int64(len(manifestBytes)),
@@ -2309,6 +2656,13 @@ func (s *stageExecutor) tagExistingImage(ctx context.Context, cacheID, output st
 	if err != nil {
 		return "", nil, fmt.Errorf("reconstructing metadata for image %q: %w", cacheID, err)
 	}
+	contentManifests, err := buildah.ImageContentManifests(s.executor.store, cacheID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(contentManifests) > 0 {
+		imageMetadata[metadata.ContentManifestKey] = contentManifests
+	}
 	// If we had a name, generate the canonical reference for it.
 	if dref != nil {
 		if ref, err = reference.WithDigest(dref, manifestDigest); err != nil {
@@ -2322,7 +2676,7 @@ func (s *stageExecutor) tagExistingImage(ctx context.Context, cacheID, output st
 		MediaType:     manifestType,
 		ImageManifest: manifestBytes,
 		Digest:        manifestDigest,
-		Metadata:      metadata,
+		Metadata:      imageMetadata,
 	}
 	return cacheID, &commitResults, nil
 }
@@ -2333,6 +2687,9 @@ func (s *stageExecutor) tagExistingImage(ctx context.Context, cacheID, output st
 // tag for the intermediate image which can be pushed and pulled to/from
 // the remote repository.
 func (s *stageExecutor) generateCacheKey(ctx context.Context, currNode *parser.Node, addedContentDigest string, buildAddsLayer bool, lastInstruction bool) (string, error) {
+	if s.executor.cacheKeyMode == define.CacheKeyModeContent {
+		return s.contentCacheKey(ctx, currNode, addedContentDigest, buildAddsLayer, lastInstruction)
+	}
 	hash := sha256.New()
 	var baseHistory []v1.History
 	var diffIDs []digest.Digest
@@ -2353,6 +2710,9 @@ func (s *stageExecutor) generateCacheKey(ctx context.Context, currNode *parser.N
 	}
 	fmt.Fprintf(hash, "%t", buildAddsLayer)
 	fmt.Fprintln(hash, createdBy)
+	if sensitiveArgsDigest := s.sensitiveArgsDigest(currNode); sensitiveArgsDigest != "" {
+		fmt.Fprintln(hash, sensitiveArgsDigest)
+	}
 	fmt.Fprintln(hash, manifestType)
 	for _, element := range baseHistory {
 		fmt.Fprintln(hash, element.CreatedBy)
@@ -2366,8 +2726,8 @@ func (s *stageExecutor) generateCacheKey(ctx context.Context, currNode *parser.N
 }
 
 // cacheImageReference is internal function which generates ImageReference from Named repo sources
-// and a tag.
-func cacheImageReferences(repos []reference.Named, cachekey string) ([]types.ImageReference, error) {
+// and OCI layouts, and a tag.
+func cacheImageReferences(repos []reference.Named, layouts []*layercache.Cache, cachekey string) ([]types.ImageReference, error) {
 	var result []types.ImageReference
 	for _, repo := range repos {
 		tagged, err := reference.WithTag(repo, cachekey)
@@ -2380,18 +2740,34 @@ func cacheImageReferences(repos []reference.Named, cachekey string) ([]types.Ima
 		}
 		result = append(result, dest)
 	}
+	for _, layout := range layouts {
+		dest, err := layout.Reference(cachekey)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dest)
+	}
 	return result, nil
 }
 
+// cacheLayoutForReference returns the OCI layout which a reference returned by
+// cacheImageReferences points into, or nil if it points to a registry.
+func cacheLayoutForReference(layouts []*layercache.Cache, repoCount int, index int) *layercache.Cache {
+	if index < repoCount {
+		return nil
+	}
+	return layouts[index-repoCount]
+}
+
 // pushCache takes the image id of intermediate image and attempts
 // to perform push at the remote repository with cacheKey as the tag.
 // Returns error if fails otherwise returns nil.
 func (s *stageExecutor) pushCache(ctx context.Context, src, cacheKey string) error {
-	destList, err := cacheImageReferences(s.executor.cacheTo, cacheKey)
+	destList, err := cacheImageReferences(s.executor.cacheTo, s.executor.cacheToLayouts, cacheKey)
 	if err != nil {
 		return err
 	}
-	for _, dest := range destList {
+	for i, dest := range destList {
 		logrus.Debugf("trying to push cache to dest: %+v from src:%+v", dest, src)
 		options := buildah.PushOptions{
 			Compression:            s.executor.compression,
@@ -2412,9 +2788,18 @@ func (s *stageExecutor) pushCache(ctx context.Context, src, cacheKey string) err
 		if s.executor.cachePushDestinationLookupReferenceFunc != nil {
 			options.DestinationLookupReferenceFunc = s.executor.cachePushDestinationLookupReferenceFunc
 		}
-		ref, digest, err := buildah.Push(ctx, src, dest, options)
+		layout := cacheLayoutForReference(s.executor.cacheToLayouts, len(s.executor.cacheTo), i)
+		if layout != nil {
+			// Writing to an OCI layout updates its index, and
+			// stages can be committing layers in parallel.
+			layout.Lock()
+		}
+		ref, digest, err := s.push(ctx, src, dest, options)
+		if layout != nil {
+			layout.Unlock()
+		}
 		if err != nil {
-			return fmt.Errorf("failed pushing cache to %q: %w", dest, err)
+			return fmt.Errorf("failed pushing cache to %q: %w", transports.ImageName(dest), err)
 		}
 		logrus.Debugf("successfully pushed cache to dest: %+v with ref:%+v and digest: %v", dest, ref, digest)
 	}
@@ -2427,14 +2812,27 @@ func (s *stageExecutor) pushCache(ctx context.Context, src, cacheKey string) err
 // or a newer version of cache was found in the upstream repo. If new
 // image was pulled function returns image id otherwise returns empty
 // string "" or error if any error was encontered while pulling the cache.
-func (s *stageExecutor) pullCache(ctx context.Context, cacheKey string) (reference.Named, string, error) {
-	srcList, err := cacheImageReferences(s.executor.cacheFrom, cacheKey)
+// The first returned value describes the location that the cache was pulled
+// from.
+func (s *stageExecutor) pullCache(ctx context.Context, cacheKey string) (string, string, error) {
+	srcList, err := cacheImageReferences(s.executor.cacheFrom, s.executor.cacheFromLayouts, cacheKey)
 	if err != nil {
-		return nil, "", err
-	}
-	for _, src := range srcList {
-		srcDockerRef := src.DockerReference()
-		logrus.Debugf("trying to pull cache from remote repo: %+v", srcDockerRef)
+		return "", "", err
+	}
+	for i, src := range srcList {
+		// Registry references are pulled using their plain names,
+		// while references into OCI layouts need their transport.
+		srcName, description := "", ""
+		if layout := cacheLayoutForReference(s.executor.cacheFromLayouts, len(s.executor.cacheFrom), i); layout != nil {
+			if !layout.Has() {
+				continue
+			}
+			srcName, description = transports.ImageName(src), layout.String()
+		} else {
+			srcName = src.DockerReference().String()
+			description = srcName
+		}
+		logrus.Debugf("trying to pull cache from remote repo: %+v", srcName)
 		options := buildah.PullOptions{
 			SignaturePolicyPath: s.executor.signaturePolicyPath,
 			Store:               s.executor.store,
@@ -2453,16 +2851,26 @@ func (s *stageExecutor) pullCache(ctx context.Context, cacheKey string) (referen
 			options.DestinationLookupReferenceFunc = s.executor.cachePullDestinationLookupReferenceFunc(src)
 		}
 
-		id, err := buildah.Pull(ctx, srcDockerRef.String(), options)
+		var pullFinished func()
+		options.Progress, pullFinished = s.blobProgress(define.BuildEventPullProgress, srcName)
+		id, err := buildah.Pull(ctx, srcName, options)
+		pullFinished()
 		if err != nil {
-			logrus.Debugf("failed pulling cache from source %s: %v", src, err)
+			logrus.Debugf("failed pulling cache from source %s: %v", srcName, err)
 			continue // failed pulling this one try next
 			// return "", fmt.Errorf("failed while pulling cache from %q: %w", src, err)
 		}
-		logrus.Debugf("successfully pulled cache from repo %s: %s", src, id)
-		return src.DockerReference(), id, nil
+		logrus.Debugf("successfully pulled cache from repo %s: %s", srcName, id)
+		if s.executor.cacheKeyMode == define.CacheKeyModeContent {
+			// The image was found using its key, so we know what
+			// it is.
+			if err := s.executor.setImageCacheKey(id, cacheKey); err != nil {
+				return "", "", err
+			}
+		}
+		return description, id, nil
 	}
-	return nil, "", fmt.Errorf("failed pulling cache from all available sources %q", srcList)
+	return "", "", fmt.Errorf("failed pulling cache from all available sources %q", srcList)
 }
 
 // intermediateImageExists returns image ID if an intermediate image of currNode exists in the image store from a previous build.
@@ -2470,6 +2878,18 @@ func (s *stageExecutor) pullCache(ctx context.Context, cacheKey string) (referen
 // If more than one image matches as potential candidates then priority is given to the most recently built image.
 func (s *stageExecutor) intermediateImageExists(ctx context.Context, currNode *parser.Node, addedContentDigest string, buildAddsLayer bool, lastInstruction bool) (string, error) {
 	cacheCandidates := []storage.Image{}
+	// If we're explaining cache misses, keep track of the image that came
+	// closest to being a match.
+	var closest *cacheMismatch
+	noteMismatch := func(image storage.Image, mismatch *cacheMismatch) {
+		if !s.executor.cacheExplain {
+			return
+		}
+		mismatch.imageID, mismatch.created = image.ID, image.Created
+		if closest == nil || mismatch.closerThan(closest) {
+			closest = mismatch
+		}
+	}
 	// Get the list of images available in the image store
 	images, err := s.executor.store.Images()
 	if err != nil {
@@ -2483,6 +2903,17 @@ func (s *stageExecutor) intermediateImageExists(ctx context.Context, currNode *p
 			return "", fmt.Errorf("getting history of base image %q: %w", s.builder.FromImageID, err)
 		}
 	}
+	var contentKey, sensitiveArgsDigest string
+	if s.executor.cacheKeyMode == define.CacheKeyModeContent {
+		if contentKey, err = s.contentCacheKey(ctx, currNode, addedContentDigest, buildAddsLayer, lastInstruction); err != nil {
+			return "", fmt.Errorf("computing cache key: %w", err)
+		}
+	} else {
+		// The values of sensitive build arguments are masked in the
+		// history, so compare digests of them separately.
+		sensitiveArgsDigest = s.sensitiveArgsDigest(currNode)
+	}
+	currentOS, currentArch := s.targetPlatform()
 	for _, image := range images {
 		// If s.executor.cacheTTL was specified
 		// then ignore processing image if it
@@ -2540,50 +2971,77 @@ func (s *stageExecutor) intermediateImageExists(ctx context.Context, currNode *p
 		// some format-specific information that a building-without-cache run wouldn't lose.
 		if manifestType != s.executor.outputFormat {
 			logrus.Debugf("image %q manifest type %q does not match output format %q", image.ID, manifestType, s.executor.outputFormat)
+			noteMismatch(image, &cacheMismatch{closeness: cacheMismatchPlatform, reason: "the image format differs", expected: s.executor.outputFormat, found: manifestType})
 			continue
 		}
 
 		// Compare the cached image's platform with the current build's target platform
-		currentArch := s.executor.architecture
-		currentOS := s.executor.os
-		if currentArch == "" && currentOS == "" {
-			currentOS, currentArch, _, err = parse.Platform(s.stage.Builder.Platform)
-			if err != nil {
-				logrus.Debugf("unable to parse default OS and Arch for the current build: %v", err)
-			}
-		}
 		if currentArch != "" && imageArchitecture != currentArch {
 			logrus.Debugf("cached image %q has architecture %q but current build targets %q, ignoring it", image.ID, imageArchitecture, currentArch)
+			noteMismatch(image, &cacheMismatch{closeness: cacheMismatchPlatform, reason: "the architecture differs", expected: currentArch, found: imageArchitecture})
 			continue
 		}
 		if currentOS != "" && imageOS != currentOS {
 			logrus.Debugf("cached image %q has OS %q but current build targets %q, ignoring it", image.ID, imageOS, currentOS)
+			noteMismatch(image, &cacheMismatch{closeness: cacheMismatchPlatform, reason: "the OS differs", expected: currentOS, found: imageOS})
+			continue
+		}
+
+		// If we're using content-addressed cache keys, compare the one
+		// that was recorded for the image to the one we computed.
+		if contentKey != "" {
+			imageKey, err := s.executor.getImageCacheKey(image.ID)
+			if err != nil {
+				logrus.Debugf("error getting cache key of %q (%v), ignoring it", image.ID, err)
+				continue
+			}
+			if imageKey == contentKey {
+				cacheCandidates = append(cacheCandidates, image)
+			} else {
+				logrus.Debugf("image %q cache key %q does not match %q", image.ID, imageKey, contentKey)
+				noteMismatch(image, &cacheMismatch{closeness: cacheMismatchHistory, reason: "the cache key differs", expected: contentKey, found: imageKey})
+			}
 			continue
 		}
 
 		// children + currNode is the point of the Dockerfile we are currently at.
-		foundMatch, err := s.historyAndDiffIDsMatch(baseHistory, baseDiffIDs, currNode, history, diffIDs, addedContentDigest, buildAddsLayer, lastInstruction)
+		mismatch, err := s.historyAndDiffIDsMatch(baseHistory, baseDiffIDs, currNode, history, diffIDs, addedContentDigest, buildAddsLayer, lastInstruction)
 		if err != nil {
 			return "", err
 		}
-		if foundMatch {
-			cacheCandidates = append(cacheCandidates, image)
-		} else {
-			logrus.Debugf("historyAndDiffIDsMatch indicated mismatch for image %q", image.ID)
+		if mismatch != nil {
+			logrus.Debugf("historyAndDiffIDsMatch indicated mismatch for image %q: %s", image.ID, mismatch.reason)
+			noteMismatch(image, mismatch)
+			continue
+		}
+		imageSensitiveArgsDigest, err := s.executor.getImageSensitiveArgsDigest(image.ID)
+		if err != nil {
+			logrus.Debugf("error getting sensitive build argument digest of %q (%v), ignoring it", image.ID, err)
+			continue
+		}
+		if imageSensitiveArgsDigest != sensitiveArgsDigest {
+			logrus.Debugf("image %q sensitive build argument digest %q does not match %q", image.ID, imageSensitiveArgsDigest, sensitiveArgsDigest)
+			// everything else matched, so this is as close as it gets
+			noteMismatch(image, &cacheMismatch{closeness: math.MaxInt, reason: "the values of sensitive build arguments differ"})
+			continue
 		}
+		cacheCandidates = append(cacheCandidates, image)
 	}
 	if len(cacheCandidates) > 0 {
+		s.cacheMiss = nil
 		slices.SortFunc(cacheCandidates, func(a, b storage.Image) int { return a.Created.Compare(b.Created) })
 		return cacheCandidates[len(cacheCandidates)-1].ID, nil
 	}
 	logrus.Debugf("no cache candidates found")
+	s.cacheMiss = closest
 	return "", nil
 }
 
 // commit writes the container's contents to an image, using a passed-in tag as
 // the name if there is one, generating a unique ID-based one otherwise.
-// or commit via any custom exporter if specified.
-func (s *stageExecutor) commit(ctx context.Context, createdBy string, emptyLayer types.OptionalBool, output string, squash, finalInstruction bool) (string, *buildah.CommitResults, error) {
+// or commit via any custom exporter if specified.  A non-empty cacheKey or
+// sensitiveArgsDigest is recorded with the image in local storage.
+func (s *stageExecutor) commit(ctx context.Context, createdBy, cacheKey, sensitiveArgsDigest string, emptyLayer types.OptionalBool, output string, squash bool, squashFrom string, finalInstruction bool) (string, *buildah.CommitResults, error) {
 	ib := s.stage.Builder
 	var imageRef types.ImageReference
 	if output != "" {
@@ -2699,6 +3157,9 @@ func (s *stageExecutor) commit(ctx context.Context, createdBy string, emptyLayer
 			s.builder.UnsetAnnotation(key)
 		}
 	}
+	if err := s.checkConfigForSecrets(); err != nil {
+		return "", nil, err
+	}
 	if imageRef != nil {
 		logName := transports.ImageName(imageRef)
 		logrus.Debugf("COMMIT %q", logName)
@@ -2747,17 +3208,309 @@ func (s *stageExecutor) commit(ctx context.Context, createdBy string, emptyLayer
 			options.ForceCompressionFormat = s.executor.forceCompressionFormat
 		}
 	}
+	if squashFrom != "" && !squash {
+		boundary, err := s.squashFromBoundary(squashFrom)
+		if err != nil {
+			return "", nil, err
+		}
+		options.SquashFrom = boundary
+	}
+	started := time.Now()
 	results, err := s.builder.CommitResults(ctx, imageRef, options)
 	if err != nil {
 		return "", nil, err
 	}
+	if !s.executor.quiet {
+		for _, layerID := range results.ReusedLayers {
+			if len(layerID) > 12 {
+				layerID = layerID[:12]
+			}
+			fmt.Fprintf(s.executor.out, "--> Reusing layer %s\n", layerID)
+		}
+	}
+	if imageRef == nil || imageRef.Transport().Name() == is.Transport.Name() {
+		if cacheKey != "" {
+			if err := s.executor.setImageCacheKey(results.ImageID, cacheKey); err != nil {
+				return "", nil, err
+			}
+		}
+		if sensitiveArgsDigest != "" {
+			if err := s.executor.setImageSensitiveArgsDigest(results.ImageID, sensitiveArgsDigest); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	s.emitCommit(results, started)
 	return results.ImageID, results, nil
 }
 
-func (s *stageExecutor) generateBuildOutput(buildOutputOpts output.BuildOutputOption) error {
-	forceTimestamp := s.executor.timestamp
+// checkConfigForSecrets returns an error if the configuration that we're about
+// to commit would include the value of a secret or a sensitive build argument.
+func (s *stageExecutor) checkConfigForSecrets() error {
+	if s.executor.redactor == nil {
+		return nil
+	}
+	ociConfig := s.builder.OCIv1.Config
+	for _, env := range ociConfig.Env {
+		if s.executor.redactor.Contains(env) {
+			key, _, _ := strings.Cut(env, "=")
+			return fmt.Errorf("refusing to commit an image with the value of a secret in environment variable %q", key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(ociConfig.Labels)) {
+		if s.executor.redactor.Contains(key + "=" + ociConfig.Labels[key]) {
+			return fmt.Errorf("refusing to commit an image with the value of a secret in label %q", key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(s.builder.ImageAnnotations)) {
+		if s.executor.redactor.Contains(key + "=" + s.builder.ImageAnnotations[key]) {
+			return fmt.Errorf("refusing to commit an image with the value of a secret in annotation %q", key)
+		}
+	}
+	for _, config := range []any{ociConfig, s.builder.Docker.Config} {
+		encoded, err := json.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("encoding image configuration: %w", err)
+		}
+		if s.executor.redactor.Contains(string(encoded)) {
+			return errors.New("refusing to commit an image with the value of a secret in its configuration")
+		}
+	}
+	return nil
+}
+
+// generateBuildOutputs writes the custom build outputs for the image that we
+// just finished building.  Outputs which write the image itself are copied
+// from local storage.  Outputs which write the image's rootfs are grouped so
+// that the rootfs is only read once for each group.
+func (s *stageExecutor) generateBuildOutputs(ctx context.Context, buildOutputOptions []output.BuildOutputOption, imageID string) error {
+	var forceTimestampOutputs, preserveTimestampOutputs, imageOutputs []output.BuildOutputOption
+	for _, buildOutputOption := range buildOutputOptions {
+		buildOutputOption = buildOutputOption.ForPlatform(s.builder.OS(), s.builder.Architecture(), s.builder.Variant())
+		switch {
+		case buildOutputOption.Type.IsImage():
+			imageOutputs = append(imageOutputs, buildOutputOption)
+		case buildOutputOption.RewriteTimestamp == types.OptionalBoolFalse:
+			preserveTimestampOutputs = append(preserveTimestampOutputs, buildOutputOption)
+		default:
+			forceTimestampOutputs = append(forceTimestampOutputs, buildOutputOption)
+		}
+	}
+	if len(forceTimestampOutputs) > 0 {
+		if err := s.generateBuildOutput(false, forceTimestampOutputs...); err != nil {
+			return err
+		}
+	}
+	if len(preserveTimestampOutputs) > 0 {
+		if err := s.generateBuildOutput(true, preserveTimestampOutputs...); err != nil {
+			return err
+		}
+	}
+	for _, buildOutputOption := range imageOutputs {
+		if err := s.generateImageBuildOutput(ctx, buildOutputOption, imageID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageBuildOutputReference returns the location to which an image build
+// output should be written.
+func (s *stageExecutor) imageBuildOutputReference(buildOutputOpts output.BuildOutputOption) (types.ImageReference, error) {
+	switch buildOutputOpts.Type {
+	case output.BuildOutputImage:
+		return s.executor.resolveNameToImageRef(buildOutputOpts.Name)
+	case output.BuildOutputRegistry:
+		named, err := reference.ParseNormalizedNamed(buildOutputOpts.Name)
+		if err != nil {
+			return nil, fmt.Errorf("parsing image name %q: %w", buildOutputOpts.Name, err)
+		}
+		return imagedocker.NewReference(reference.TagNameOnly(named))
+	case output.BuildOutputOCIArchive:
+		return ociarchive.NewReference(buildOutputOpts.Path, buildOutputOpts.Name)
+	case output.BuildOutputDockerArchive:
+		var tagged reference.NamedTagged
+		if buildOutputOpts.Name != "" {
+			named, err := reference.ParseNormalizedNamed(buildOutputOpts.Name)
+			if err != nil {
+				return nil, fmt.Errorf("parsing image name %q: %w", buildOutputOpts.Name, err)
+			}
+			var ok bool
+			if tagged, ok = reference.TagNameOnly(named).(reference.NamedTagged); !ok {
+				return nil, fmt.Errorf("image name %q for docker archive must not include a digest", buildOutputOpts.Name)
+			}
+		}
+		return dockerarchive.NewReference(buildOutputOpts.Path, tagged)
+	}
+	return nil, fmt.Errorf("internal error: build output type %v does not write an image", buildOutputOpts.Type)
+}
+
+// imageBuildOutputCompression returns the compression settings to use when
+// writing an image build output, which default to the ones for the build.
+func (s *stageExecutor) imageBuildOutputCompression(buildOutputOpts output.BuildOutputOption) (archive.Compression, *compression.Algorithm, error) {
+	switch buildOutputOpts.Compression {
+	case "":
+		return s.executor.compression, s.executor.compressionFormat, nil
+	case "uncompressed":
+		return archive.Uncompressed, nil, nil
+	}
+	algorithm, err := compression.AlgorithmByName(buildOutputOpts.Compression)
+	if err != nil {
+		return archive.Uncompressed, nil, fmt.Errorf("parsing build output compression %q: %w", buildOutputOpts.Compression, err)
+	}
+	return archive.Gzip, &algorithm, nil
+}
+
+// generateImageBuildOutput writes the image that we just finished building to
+// the location specified by an image, registry, oci, or docker build output.
+// The image is copied from local storage, with any annotations or timestamp
+// changes that are specific to this output applied as it is copied.
+func (s *stageExecutor) generateImageBuildOutput(ctx context.Context, buildOutputOpts output.BuildOutputOption, imageID string) error {
+	dest, err := s.imageBuildOutputReference(buildOutputOpts)
+	if err != nil {
+		return fmt.Errorf("parsing build output destination: %w", err)
+	}
+	archiveCompression, compressionFormat, err := s.imageBuildOutputCompression(buildOutputOpts)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("writing %s build output to %q", buildOutputOpts.Type, transports.ImageName(dest))
+	pushOptions := buildah.PushOptions{
+		Compression:            archiveCompression,
+		CompressionFormat:      compressionFormat,
+		CompressionLevel:       s.executor.compressionLevel,
+		ForceCompressionFormat: s.executor.forceCompressionFormat,
+		SignaturePolicyPath:    s.executor.signaturePolicyPath,
+		ReportWriter:           s.executor.reportWriter,
+		Store:                  s.executor.store,
+		SystemContext:          s.systemContext,
+		BlobDirectory:          s.executor.blobDirectory,
+		SignBy:                 s.executor.signBy,
+		MaxRetries:             s.executor.maxPullPushRetries,
+		RetryDelay:             s.executor.retryPullPushDelay,
+		Annotations:            buildOutputOpts.Annotations,
+	}
+	// The image's layers were written using the build's
+	// --rewrite-timestamp setting.  We can clamp timestamps in the ones
+	// that the build added, but we can't put back any that were clamped.
 	if s.executor.sourceDateEpoch != nil {
-		forceTimestamp = s.executor.sourceDateEpoch
+		switch buildOutputOpts.RewriteTimestamp {
+		case types.OptionalBoolTrue:
+			if !s.executor.rewriteTimestamp {
+				preservedLayers, err := s.baseImageLayerCount()
+				if err != nil {
+					return err
+				}
+				pushOptions.LatestTimestamp = s.executor.sourceDateEpoch
+				pushOptions.PreservedLayers = preservedLayers
+			}
+		case types.OptionalBoolFalse:
+			if s.executor.rewriteTimestamp {
+				return fmt.Errorf("writing build output to %q: rewrite-timestamp=false can not be used with --rewrite-timestamp", transports.ImageName(dest))
+			}
+		}
+	}
+	if _, _, err := s.push(ctx, imageID, dest, pushOptions); err != nil {
+		return fmt.Errorf("writing build output to %q: %w", transports.ImageName(dest), err)
+	}
+	if buildOutputOpts.Type == output.BuildOutputImage && buildOutputOpts.Push {
+		named, err := reference.ParseNormalizedNamed(buildOutputOpts.Name)
+		if err != nil {
+			return fmt.Errorf("parsing image name %q: %w", buildOutputOpts.Name, err)
+		}
+		pushDest, err := imagedocker.NewReference(reference.TagNameOnly(named))
+		if err != nil {
+			return fmt.Errorf("parsing image name %q: %w", buildOutputOpts.Name, err)
+		}
+		if _, _, err := s.push(ctx, imageID, pushDest, pushOptions); err != nil {
+			return fmt.Errorf("pushing build output to %q: %w", transports.ImageName(pushDest), err)
+		}
+	}
+	return nil
+}
+
+// baseImageLayerCount returns the number of layers in the image that the
+// working container was created from, none of which are included in the
+// image that we commit if we're squashing it.
+func (s *stageExecutor) baseImageLayerCount() (int, error) {
+	if s.builder.FromImageID == "" || s.executor.squash || s.executor.confidentialWorkload.Convert {
+		return 0, nil
+	}
+	img, err := s.executor.store.Image(s.builder.FromImageID)
+	if err != nil {
+		return 0, fmt.Errorf("locating base image %q: %w", s.builder.FromImageID, err)
+	}
+	count := 0
+	for layerID := img.TopLayer; layerID != ""; count++ {
+		layer, err := s.executor.store.Layer(layerID)
+		if err != nil {
+			return 0, fmt.Errorf("reading layer %q of base image %q: %w", layerID, s.builder.FromImageID, err)
+		}
+		layerID = layer.Parent
+	}
+	return count, nil
+}
+
+// squashFromBoundary converts a --squash-from value which names an earlier
+// stage into the number of layers in that stage's base image, so that the
+// layers that stage added, along with any added by the stages between it and
+// this one, will be merged.  Other values are returned unchanged.
+func (s *stageExecutor) squashFromBoundary(squashFrom string) (string, error) {
+	if _, err := strconv.Atoi(squashFrom); err == nil {
+		return squashFrom, nil
+	}
+	_, stage := s.executor.stageIndex(squashFrom, s.stages)
+	if stage == nil || stage.builder == nil {
+		return squashFrom, nil
+	}
+	if stage.baseImageID == "" {
+		// The stage started from scratch, so everything is
+		// going to be merged.
+		return "0", nil
+	}
+	img, err := s.executor.store.Image(stage.baseImageID)
+	if err != nil {
+		return "", fmt.Errorf("locating base image of stage %q: %w", squashFrom, err)
+	}
+	if img.TopLayer == "" {
+		return "0", nil
+	}
+	baseLayers := 0
+	for layerID := img.TopLayer; layerID != ""; baseLayers++ {
+		layer, err := s.executor.store.Layer(layerID)
+		if err != nil {
+			return "", fmt.Errorf("reading layer %q of base image of stage %q: %w", layerID, squashFrom, err)
+		}
+		layerID = layer.Parent
+	}
+	container, err := s.executor.store.Container(s.builder.ContainerID)
+	if err != nil {
+		return "", fmt.Errorf("locating container %q: %w", s.builder.ContainerID, err)
+	}
+	for layerID := container.LayerID; layerID != img.TopLayer; {
+		layer, err := s.executor.store.Layer(layerID)
+		if err != nil {
+			return "", fmt.Errorf("reading layer %q: %w", layerID, err)
+		}
+		if layer.Parent == "" {
+			return "", fmt.Errorf("squashing starting with stage %q: the final stage is not based on it", squashFrom)
+		}
+		layerID = layer.Parent
+	}
+	return strconv.Itoa(baseLayers), nil
+}
+
+// generateBuildOutput extracts the working container's rootfs once and writes
+// it to each of the local, tar, and stdout build outputs.  Unless
+// preserveTimestamps is set, timestamps on the contents are set to the
+// --timestamp or --source-date-epoch value, if one was specified.
+func (s *stageExecutor) generateBuildOutput(preserveTimestamps bool, buildOutputOpts ...output.BuildOutputOption) error {
+	var forceTimestamp *time.Time
+	if !preserveTimestamps {
+		forceTimestamp = s.executor.timestamp
+		if s.executor.sourceDateEpoch != nil {
+			forceTimestamp = s.executor.sourceDateEpoch
+		}
 	}
 	extractRootfsOpts := buildah.ExtractRootfsOptions{
 		ForceTimestamp: forceTimestamp,
@@ -2785,7 +3538,7 @@ func (s *stageExecutor) generateBuildOutput(buildOutputOpts output.BuildOutputOp
 		return fmt.Errorf("failed to extract rootfs from given container image: %w", err)
 	}
 	defer rc.Close()
-	err = internalUtil.ExportFromReader(rc, buildOutputOpts)
+	err = internalUtil.ExportFromReaderMulti(rc, buildOutputOpts)
 	if err != nil {
 		return fmt.Errorf("failed to export build output: %w", err)
 	}
@@ -2821,6 +3574,7 @@ func (s *stageExecutor) buildMetadata(isLastStep bool, isAddOrCopy bool) string
 	inheritAnnotations := ""
 	newAnnotations := ""
 	layerMutations := ""
+	contentManifest := ""
 
 	// If --inherit-label was manually set to false then update history.
 	if s.executor.inheritLabels == types.OptionalBoolFalse {
@@ -2866,7 +3620,12 @@ func (s *stageExecutor) buildMetadata(isLastStep bool, isAddOrCopy bool) string
 	}
 
 	if isAddOrCopy {
-		return unsetLabels.String() + " " + inheritLabels + " " + unsetAnnotations.String() + " " + inheritAnnotations + " " + layerMutations + " " + newAnnotations
+		// If we're recording content manifests, make a note of it, so
+		// that we don't use a cached image which doesn't have one.
+		if s.executor.contentManifest {
+			contentManifest = "|contentManifest"
+		}
+		return unsetLabels.String() + " " + inheritLabels + " " + unsetAnnotations.String() + " " + inheritAnnotations + " " + layerMutations + " " + newAnnotations + contentManifest
 	}
 	return unsetLabels.String() + inheritLabels + unsetAnnotations.String() + inheritAnnotations + layerMutations + newAnnotations
 }
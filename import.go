@@ -116,6 +116,10 @@ func importBuilderDataFromImage(ctx context.Context, store storage.Store, system
 		return nil, fmt.Errorf("preparing image configuration: %w", err)
 	}
 
+	if builder.ContentManifests, err = ImageContentManifests(store, imageID); err != nil {
+		return nil, err
+	}
+
 	return builder, nil
 }
 
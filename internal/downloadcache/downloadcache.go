@@ -0,0 +1,346 @@
+// Package downloadcache keeps copies of content which was downloaded for ADD
+// instructions, so that repeated builds don't need to download it again.
+//
+// Content is stored under its digest, and is found using a record which is
+// named after the URL it was downloaded from.  The record also holds the
+// validators (ETag and Last-Modified) that the server sent with the content,
+// so that a later download can be made conditional on the content having
+// changed since it was cached.
+package downloadcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"go.podman.io/storage/pkg/ioutils"
+	"go.podman.io/storage/pkg/lockfile"
+)
+
+const (
+	entriesDir = "entries"
+	blobsDir   = "blobs"
+)
+
+// Directory returns the location of the download cache for a store with the
+// specified graph root.
+func Directory(graphRoot string) string {
+	return filepath.Join(graphRoot, "buildah-downloads")
+}
+
+// Cache is a directory of downloaded files.
+type Cache struct {
+	dir      string
+	lock     *lockfile.LockFile // nil if the cache is temporary
+	remember bool
+}
+
+// getLock returns the lock which keeps the cache in dir from being removed
+// while it's being used.  It's kept next to the directory, so that removing
+// the directory doesn't remove it.
+func getLock(dir string) (*lockfile.LockFile, error) {
+	lock, err := lockfile.GetLockFile(dir + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("opening download cache lock: %w", err)
+	}
+	return lock, nil
+}
+
+// Open returns a Cache which stores its contents in dir, creating dir if it
+// doesn't already exist.
+func Open(dir string) (*Cache, error) {
+	lock, err := getLock(dir)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{dir: dir, lock: lock, remember: true}
+	lock.RLock()
+	defer lock.Unlock()
+	if err := c.createDirectories(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// createDirectories creates the cache's directory, if it doesn't already
+// exist.
+func (c *Cache) createDirectories() error {
+	for _, subdir := range []string{entriesDir, blobsDir} {
+		if err := os.MkdirAll(filepath.Join(c.dir, subdir), 0o700); err != nil {
+			return fmt.Errorf("creating download cache directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// OpenTemporary returns a Cache which never finds anything, and which keeps
+// the content it downloads in unnamed files in dir, which must already exist.
+func OpenTemporary(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Remove removes the download cache in dir, if there is one, after waiting
+// for any downloads which are using it to finish.
+func Remove(dir string) error {
+	lock, err := getLock(dir)
+	if err != nil {
+		return err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing download cache: %w", err)
+	}
+	return nil
+}
+
+// entry is the record that we keep for a URL.
+type entry struct {
+	URL          string        `json:"url"`
+	ETag         string        `json:"etag,omitempty"`
+	LastModified string        `json:"last-modified,omitempty"`
+	Digest       digest.Digest `json:"digest"`
+	Fetched      time.Time     `json:"fetched"`
+}
+
+// Download is content which was either downloaded or found in the cache.
+type Download struct {
+	// File is positioned at the beginning of the content.  The caller
+	// is expected to close it.
+	*os.File
+	// LastModified is the value of the Last-Modified header that the
+	// server sent along with the content, if it sent one.
+	LastModified string
+	// Cached is true if the content was read from the cache instead of
+	// being downloaded.
+	Cached bool
+}
+
+func (c *Cache) entryPath(url string) string {
+	return filepath.Join(c.dir, entriesDir, digest.FromString(url).Encoded())
+}
+
+func (c *Cache) blobPath(d digest.Digest) string {
+	return filepath.Join(c.dir, blobsDir, d.Algorithm().String(), d.Encoded())
+}
+
+// lookup returns the record for url and opens the content that it refers to.
+func (c *Cache) lookup(url string) (*entry, *os.File, error) {
+	if !c.remember {
+		return nil, nil, fs.ErrNotExist
+	}
+	encoded, err := os.ReadFile(c.entryPath(url))
+	if err != nil {
+		return nil, nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(encoded, &e); err != nil {
+		return nil, nil, fmt.Errorf("decoding download cache record for %q: %w", url, err)
+	}
+	if e.URL != url {
+		return nil, nil, fs.ErrNotExist
+	}
+	if err := e.Digest.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("download cache record for %q: %w", url, err)
+	}
+	f, err := os.Open(c.blobPath(e.Digest))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &e, f, nil
+}
+
+// matches checks if the content in f matches checksum, and rewinds f.
+func matches(e *entry, f *os.File, checksum digest.Digest) (bool, error) {
+	if checksum.Algorithm() == e.Digest.Algorithm() {
+		return checksum == e.Digest, nil
+	}
+	if !checksum.Algorithm().Available() {
+		return false, nil
+	}
+	actual, err := checksum.Algorithm().FromReader(f)
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	return actual == checksum, nil
+}
+
+// Get downloads the content at url using client, after checking the cache for
+// a copy.  If checksum is set and a cached copy matches it, the cached copy
+// is returned without contacting the server.  Otherwise, if there is a cached
+// copy, the request asks the server to only send the content if it has
+// changed since the copy was made.  Content that the server sends is checked
+// against checksum, if one is set, and is added to the cache if it can be
+// validated later, either because the server provided an ETag or
+// Last-Modified header, or because a checksum was supplied.
+func (c *Cache) Get(client *http.Client, url string, checksum digest.Digest) (*Download, error) {
+	if checksum != "" {
+		if err := checksum.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.lock != nil {
+		c.lock.RLock()
+		defer c.lock.Unlock()
+		// The cache may have been removed since we opened it.
+		if err := c.createDirectories(); err != nil {
+			return nil, err
+		}
+	}
+	e, f, err := c.lookup(url)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logrus.Debugf("ignoring cached copy of %q: %v", url, err)
+		}
+		e, f = nil, nil
+	}
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	if f != nil && checksum != "" {
+		ok, err := matches(e, f, checksum)
+		if err != nil {
+			return nil, fmt.Errorf("checking cached copy of %q: %w", url, err)
+		}
+		if ok {
+			logrus.Debugf("using cached copy of %q", url)
+			download := &Download{File: f, LastModified: e.LastModified, Cached: true}
+			f = nil
+			return download, nil
+		}
+	}
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		if e.ETag != "" {
+			request.Header.Set("If-None-Match", e.ETag)
+		}
+		if e.LastModified != "" {
+			request.Header.Set("If-Modified-Since", e.LastModified)
+		}
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && f != nil {
+		if checksum != "" {
+			// The content hasn't changed, but it isn't what we
+			// were told to expect.
+			return nil, fmt.Errorf("unexpected response digest: %s, want %s", e.Digest, checksum)
+		}
+		logrus.Debugf("using cached copy of %q", url)
+		download := &Download{File: f, LastModified: e.LastModified, Cached: true}
+		f = nil
+		return download, nil
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("invalid response status %d", response.StatusCode)
+	}
+
+	etag := response.Header.Get("ETag")
+	lastModified := response.Header.Get("Last-Modified")
+	remember := c.remember && (etag != "" || lastModified != "" || checksum != "") &&
+		!strings.Contains(strings.ToLower(response.Header.Get("Cache-Control")), "no-store")
+	newFile, d, err := c.store(response.Body, checksum, remember)
+	if err != nil {
+		return nil, err
+	}
+	if remember {
+		e := entry{
+			URL:          url,
+			ETag:         etag,
+			LastModified: lastModified,
+			Digest:       d,
+			Fetched:      time.Now().UTC(),
+		}
+		encoded, err := json.Marshal(&e)
+		if err == nil {
+			err = ioutils.AtomicWriteFile(c.entryPath(url), encoded, 0o600)
+		}
+		if err != nil {
+			logrus.Debugf("not recording download of %q in cache: %v", url, err)
+		}
+	}
+	return &Download{File: newFile, LastModified: lastModified}, nil
+}
+
+// store reads content from reader, checks that it matches checksum if one is
+// provided, and, if remember is set, adds it to the set of blobs.  It returns
+// a handle for reading the content, positioned at its beginning, and the
+// content's digest.
+func (c *Cache) store(reader io.Reader, checksum digest.Digest, remember bool) (*os.File, digest.Digest, error) {
+	tempDir := c.dir
+	if remember {
+		tempDir = filepath.Join(c.dir, blobsDir)
+	}
+	f, err := os.CreateTemp(tempDir, ".download")
+	if err != nil {
+		return nil, "", fmt.Errorf("creating temporary file in download cache: %w", err)
+	}
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	digester := digest.Canonical.Digester()
+	writers := []io.Writer{f, digester.Hash()}
+	var checksumDigester digest.Digester
+	if checksum != "" && checksum.Algorithm() != digest.Canonical {
+		checksumDigester = checksum.Algorithm().Digester()
+		writers = append(writers, checksumDigester.Hash())
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), reader); err != nil {
+		return nil, "", fmt.Errorf("writing to download cache: %w", err)
+	}
+	d := digester.Digest()
+	if checksum != "" {
+		actual := d
+		if checksumDigester != nil {
+			actual = checksumDigester.Digest()
+		}
+		if actual != checksum {
+			return nil, "", fmt.Errorf("unexpected response digest: %s, want %s", actual, checksum)
+		}
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, "", fmt.Errorf("rewinding download: %w", err)
+	}
+	if !remember {
+		// We'll keep using the file, but it doesn't need a name.
+		if err := os.Remove(f.Name()); err != nil {
+			return nil, "", fmt.Errorf("removing temporary file: %w", err)
+		}
+	} else {
+		blobPath := c.blobPath(d)
+		if err := os.MkdirAll(filepath.Dir(blobPath), 0o700); err != nil {
+			return nil, "", fmt.Errorf("adding download to cache: %w", err)
+		}
+		if err := os.Rename(f.Name(), blobPath); err != nil {
+			return nil, "", fmt.Errorf("adding download to cache: %w", err)
+		}
+	}
+	succeeded = true
+	return f, d, nil
+}
@@ -0,0 +1,231 @@
+package downloadcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testServer struct {
+	content      string
+	etag         string
+	lastModified string
+	requests     int
+	fullRequests int
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+		if r.Header.Get("If-None-Match") == s.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	if s.lastModified != "" {
+		w.Header().Set("Last-Modified", s.lastModified)
+		if s.etag == "" && r.Header.Get("If-Modified-Since") == s.lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	s.fullRequests++
+	_, _ = io.WriteString(w, s.content)
+}
+
+func read(t *testing.T, cache *Cache, url string, checksum digest.Digest) (string, bool, error) {
+	t.Helper()
+	download, err := cache.Get(http.DefaultClient, url, checksum)
+	if err != nil {
+		return "", false, err
+	}
+	defer download.Close()
+	content, err := io.ReadAll(download)
+	require.NoError(t, err)
+	return string(content), download.Cached, nil
+}
+
+func TestGetETag(t *testing.T) {
+	ts := &testServer{content: "first", etag: `"1"`}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	cache, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	content, cached, err := read(t, cache, server.URL+"/file", "")
+	require.NoError(t, err)
+	assert.Equal(t, "first", content)
+	assert.False(t, cached)
+
+	content, cached, err = read(t, cache, server.URL+"/file", "")
+	require.NoError(t, err)
+	assert.Equal(t, "first", content)
+	assert.True(t, cached)
+	assert.Equal(t, 2, ts.requests)
+	assert.Equal(t, 1, ts.fullRequests)
+
+	// a different URL is a different entry
+	_, cached, err = read(t, cache, server.URL+"/other", "")
+	require.NoError(t, err)
+	assert.False(t, cached)
+
+	ts.content, ts.etag = "second", `"2"`
+	content, cached, err = read(t, cache, server.URL+"/file", "")
+	require.NoError(t, err)
+	assert.Equal(t, "second", content)
+	assert.False(t, cached)
+}
+
+func TestGetLastModified(t *testing.T) {
+	ts := &testServer{content: "first", lastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	cache, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	_, _, err = read(t, cache, server.URL+"/file", "")
+	require.NoError(t, err)
+	download, err := cache.Get(http.DefaultClient, server.URL+"/file", "")
+	require.NoError(t, err)
+	assert.True(t, download.Cached)
+	assert.Equal(t, ts.lastModified, download.LastModified)
+	require.NoError(t, download.Close())
+	assert.Equal(t, 1, ts.fullRequests)
+}
+
+func TestGetChecksum(t *testing.T) {
+	ts := &testServer{content: "content"}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	cache, err := Open(t.TempDir())
+	require.NoError(t, err)
+	checksum := digest.FromString("content")
+
+	// content that doesn't match the checksum isn't kept
+	_, _, err = read(t, cache, server.URL+"/file", digest.FromString("something else"))
+	assert.ErrorContains(t, err, "unexpected response digest")
+
+	content, cached, err := read(t, cache, server.URL+"/file", checksum)
+	require.NoError(t, err)
+	assert.Equal(t, "content", content)
+	assert.False(t, cached)
+
+	// a matching copy is used without asking the server, even with a
+	// different algorithm
+	requests := ts.requests
+	for _, checksum := range []digest.Digest{checksum, digest.SHA512.FromString("content")} {
+		content, cached, err = read(t, cache, server.URL+"/file", checksum)
+		require.NoError(t, err)
+		assert.Equal(t, "content", content)
+		assert.True(t, cached)
+	}
+	assert.Equal(t, requests, ts.requests)
+
+	// a copy that doesn't match is downloaded again
+	_, _, err = read(t, cache, server.URL+"/file", digest.FromString("something else"))
+	assert.ErrorContains(t, err, "unexpected response digest")
+	assert.Equal(t, requests+1, ts.requests)
+}
+
+func TestGetUncacheable(t *testing.T) {
+	ts := &testServer{content: "content"}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+	dir := t.TempDir()
+	cache, err := Open(dir)
+	require.NoError(t, err)
+
+	// without validators or a checksum, nothing can be reused
+	for range 2 {
+		content, cached, err := read(t, cache, server.URL+"/file", "")
+		require.NoError(t, err)
+		assert.Equal(t, "content", content)
+		assert.False(t, cached)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, entriesDir))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// a temporary cache doesn't keep anything
+	tempDir := t.TempDir()
+	ts.etag = `"1"`
+	for range 2 {
+		content, cached, err := read(t, OpenTemporary(tempDir), server.URL+"/file", "")
+		require.NoError(t, err)
+		assert.Equal(t, "content", content)
+		assert.False(t, cached)
+	}
+	entries, err = os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRemove(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "downloads")
+	assert.NoError(t, Remove(dir))
+	_, err := Open(dir)
+	require.NoError(t, err)
+	assert.NoError(t, Remove(dir))
+	assert.NoDirExists(t, dir)
+}
+
+func TestRemoveWaitsForGet(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "downloads")
+	cache, err := Open(dir)
+	require.NoError(t, err)
+
+	requested := make(chan struct{}, 1)
+	respond := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requested <- struct{}{}
+		<-respond
+		w.Header().Set("ETag", `"1"`)
+		_, _ = io.WriteString(w, "content")
+	}))
+	defer server.Close()
+
+	got := make(chan error, 1)
+	go func() {
+		download, err := cache.Get(http.DefaultClient, server.URL, "")
+		if err != nil {
+			got <- err
+			return
+		}
+		defer download.Close()
+		content, err := io.ReadAll(download)
+		if err == nil && string(content) != "content" {
+			err = fmt.Errorf("unexpected content %q", content)
+		}
+		got <- err
+	}()
+	<-requested
+	removed := make(chan error, 1)
+	go func() {
+		removed <- Remove(dir)
+	}()
+	select {
+	case err := <-removed:
+		t.Fatalf("cache was removed while a download was using it: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(respond)
+	require.NoError(t, <-got)
+	require.NoError(t, <-removed)
+	assert.NoDirExists(t, dir)
+
+	// the cache can still be used after it's been removed
+	content, cached, err := read(t, cache, server.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, "content", content)
+	assert.False(t, cached)
+}
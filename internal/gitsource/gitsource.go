@@ -0,0 +1,219 @@
+// Package gitsource fetches the contents of remote Git repositories which are
+// used as build contexts or as sources for ADD instructions.
+package gitsource
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/internal/urlsource"
+)
+
+// Options controls how Clone fetches a repository.
+type Options struct {
+	// Checksum, if set, is the ID of the commit, or a prefix of at least
+	// seven characters of it, which the requested reference must resolve
+	// to.  For annotated tags, the ID of the tag object is also accepted.
+	Checksum string
+	// Submodules causes submodules to be checked out, recursively.
+	Submodules bool
+	// AuthToken, if set, is used as the password when fetching a
+	// repository using HTTP(S).
+	AuthToken string
+	// SSHAuthSock, if set, is the path of an SSH agent socket which is
+	// used when fetching a repository using SSH.
+	SSHAuthSock string
+}
+
+// ErrGitNotFound is returned by Clone when the git command isn't available.
+var ErrGitNotFound = errors.New("fetching Git repositories requires git, which was not found in $PATH")
+
+// ParseURL parses a URL of the form `repository[#[ref][:subdir]]` into the
+// URL of the repository, the branch, tag, or commit ID to fetch, and the
+// subdirectory of the repository that is of interest.
+func ParseURL(url string) (repository, ref, subdir string) {
+	repository, fragment, _ := strings.Cut(url, "#")
+	ref, subdir, _ = strings.Cut(fragment, ":")
+	return repository, ref, subdir
+}
+
+// ValidateChecksum checks that a checksum is a plausible commit ID or prefix
+// of one.
+func ValidateChecksum(checksum string) error {
+	if len(checksum) < 7 || len(checksum) > 64 || strings.Trim(strings.ToLower(checksum), "0123456789abcdef") != "" {
+		return fmt.Errorf("checksum %q for a Git repository is not a commit ID", checksum)
+	}
+	return nil
+}
+
+type gitCommand struct {
+	path string
+	dir  string
+	env  []string
+}
+
+func (g *gitCommand) run(description string, args ...string) ([]byte, error) {
+	cmd := exec.Command(g.path, args...)
+	cmd.Dir = g.dir
+	cmd.Env = g.env
+	combinedOutput, err := cmd.CombinedOutput()
+	if err != nil {
+		// Return err.Error() instead of err as we want buildah to override error code with more predictable
+		// value.
+		return combinedOutput, fmt.Errorf("failed while performing `git %s`: %s", description, err.Error())
+	}
+	return combinedOutput, nil
+}
+
+// authEnvironment returns environment variables which cause git to use the
+// credentials in options, and no others, when fetching the repository.
+func authEnvironment(repository string, options Options) []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if options.SSHAuthSock != "" {
+		env = append(env, "SSH_AUTH_SOCK="+options.SSHAuthSock)
+	}
+	if options.AuthToken != "" && urlsource.IsHTTPOrHTTPS(repository) {
+		if u, err := neturl.Parse(repository); err == nil {
+			// Scope the header to the repository's server, so that
+			// it isn't sent to servers that host submodules.
+			scope := u.Scheme + "://" + u.Host + "/"
+			credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + options.AuthToken))
+			// Pass the setting using the environment, so that it
+			// doesn't show up in the process list.
+			env = append(env,
+				"GIT_CONFIG_COUNT=1",
+				"GIT_CONFIG_KEY_0=http."+scope+".extraheader",
+				"GIT_CONFIG_VALUE_0=Authorization: basic "+credentials,
+			)
+		}
+	}
+	return env
+}
+
+// sparseCheckoutPattern returns a pattern for .git/info/sparse-checkout which
+// matches subdir and everything under it.
+func sparseCheckoutPattern(subdir string) string {
+	var pattern strings.Builder
+	pattern.WriteString("/")
+	for _, c := range strings.Trim(filepath.ToSlash(filepath.Clean("/"+subdir)), "/") {
+		if strings.ContainsRune(`\*?[!# `, c) {
+			pattern.WriteRune('\\')
+		}
+		pattern.WriteRune(c)
+	}
+	return pattern.String()
+}
+
+// Clone fetches the repository, reference, and subdirectory named by url, in
+// the form accepted by ParseURL, and checks it out in dir.  It returns the
+// output of the last git command that it ran, and the subdirectory of dir
+// which holds the content that was asked for.  The subdirectory has not been
+// checked for symbolic links which point outside of dir.
+func Clone(url, dir string, options Options) ([]byte, string, error) {
+	gitRepo, gitRef, gitSubdir := ParseURL(url)
+	if options.Checksum != "" {
+		if err := ValidateChecksum(options.Checksum); err != nil {
+			return nil, gitSubdir, err
+		}
+	}
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return nil, gitSubdir, fmt.Errorf("%w: %v", ErrGitNotFound, err)
+	}
+	git := gitCommand{path: gitPath, dir: dir, env: append(os.Environ(), authEnvironment(gitRepo, options)...)}
+
+	// init repo
+	if combinedOutput, err := git.run("init", "init", dir); err != nil {
+		return combinedOutput, gitSubdir, err
+	}
+	// add origin
+	if combinedOutput, err := git.run("remote add", "remote", "add", "origin", gitRepo); err != nil {
+		return combinedOutput, gitSubdir, err
+	}
+
+	logrus.Debugf("fetching repo %q and branch (or commit ID) %q to %q", gitRepo, gitRef, dir)
+	if combinedOutput, err := git.run("fetch", "fetch", "-u", "--depth=1", "origin", "--", gitRef); err != nil {
+		return combinedOutput, gitSubdir, err
+	}
+
+	if options.Checksum != "" {
+		combinedOutput, err := verifyChecksum(&git, gitRepo, gitRef, options.Checksum)
+		if err != nil {
+			return combinedOutput, gitSubdir, err
+		}
+	}
+
+	// If only a subdirectory is wanted, only check out that part of the
+	// tree, along with the list of submodules.
+	sparse := strings.Trim(filepath.Clean("/"+gitSubdir), "/") != ""
+	if sparse {
+		if combinedOutput, err := git.run("config", "config", "core.sparseCheckout", "true"); err != nil {
+			return combinedOutput, gitSubdir, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".git", "info", "sparse-checkout"), []byte(sparseCheckoutPattern(gitSubdir)+"\n/.gitmodules\n"), 0o644); err != nil {
+			return nil, gitSubdir, fmt.Errorf("writing sparse checkout pattern: %w", err)
+		}
+	}
+
+	combinedOutput, err := git.run("checkout", "checkout", "FETCH_HEAD")
+	if err != nil {
+		return combinedOutput, gitSubdir, err
+	}
+
+	if sparse {
+		// The subdirectory might only be reachable through a
+		// symbolic link, which the pattern wouldn't have matched, so
+		// if it's not there, check out everything.
+		contentDir, err := securejoin.SecureJoin(dir, gitSubdir)
+		if err != nil {
+			return nil, gitSubdir, fmt.Errorf("resolving subdirectory %q in %q: %w", gitSubdir, dir, err)
+		}
+		if _, err := os.Stat(contentDir); err != nil {
+			logrus.Debugf("%q not found after sparse checkout, checking out entire tree", gitSubdir)
+			if err := os.WriteFile(filepath.Join(dir, ".git", "info", "sparse-checkout"), []byte("/*\n"), 0o644); err != nil {
+				return nil, gitSubdir, fmt.Errorf("writing sparse checkout pattern: %w", err)
+			}
+			if combinedOutput, err = git.run("read-tree", "read-tree", "-mu", "HEAD"); err != nil {
+				return combinedOutput, gitSubdir, err
+			}
+		}
+	}
+
+	if options.Submodules {
+		if _, err := os.Stat(filepath.Join(dir, ".gitmodules")); err == nil {
+			if combinedOutput, err = git.run("submodule update", "submodule", "update", "--init", "--recursive"); err != nil {
+				return combinedOutput, gitSubdir, err
+			}
+		}
+	}
+	return combinedOutput, gitSubdir, nil
+}
+
+// verifyChecksum checks that what we fetched matches the checksum.
+func verifyChecksum(git *gitCommand, gitRepo, gitRef, checksum string) ([]byte, error) {
+	checksum = strings.ToLower(checksum)
+	var ids []string
+	for _, object := range []string{"FETCH_HEAD", "FETCH_HEAD^{commit}"} {
+		combinedOutput, err := git.run("rev-parse", "rev-parse", "--verify", object)
+		if err != nil {
+			return combinedOutput, err
+		}
+		id := strings.TrimSpace(string(combinedOutput))
+		if strings.HasPrefix(id, checksum) {
+			return nil, nil
+		}
+		ids = append(ids, id)
+	}
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+	return nil, fmt.Errorf("%q in %q is commit %s, which does not match checksum %q", gitRef, gitRepo, ids[len(ids)-1], checksum)
+}
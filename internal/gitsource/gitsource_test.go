@@ -0,0 +1,161 @@
+package gitsource
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	for _, testCase := range []struct {
+		url, repository, ref, subdir string
+	}{
+		{"https://github.com/containers/repo.git", "https://github.com/containers/repo.git", "", ""},
+		{"https://github.com/containers/repo.git#main", "https://github.com/containers/repo.git", "main", ""},
+		{"https://github.com/containers/repo.git#:mydir", "https://github.com/containers/repo.git", "", "mydir"},
+		{"https://github.com/containers/repo.git#main:mydir", "https://github.com/containers/repo.git", "main", "mydir"},
+		{"git@github.com:containers/repo.git#v1.0:a/b", "git@github.com:containers/repo.git", "v1.0", "a/b"},
+	} {
+		repository, ref, subdir := ParseURL(testCase.url)
+		assert.Equal(t, testCase.repository, repository, testCase.url)
+		assert.Equal(t, testCase.ref, ref, testCase.url)
+		assert.Equal(t, testCase.subdir, subdir, testCase.url)
+	}
+}
+
+func TestValidateChecksum(t *testing.T) {
+	assert.NoError(t, ValidateChecksum("f94193d"))
+	assert.NoError(t, ValidateChecksum("F94193D34548EB58650A10A5183936D32C2D3280"))
+	assert.Error(t, ValidateChecksum("f9419"))
+	assert.Error(t, ValidateChecksum("sha256:f94193d34548eb58650a10a5183936d32c2d3280"))
+	assert.Error(t, ValidateChecksum(strings.Repeat("a", 65)))
+}
+
+func TestSparseCheckoutPattern(t *testing.T) {
+	assert.Equal(t, "/a/b", sparseCheckoutPattern("a/b/"))
+	assert.Equal(t, "/a/b", sparseCheckoutPattern("/a/../a/b"))
+	assert.Equal(t, `/\!a/\*\?/\[c\ d`, sparseCheckoutPattern("!a/*?/[c d"))
+}
+
+func TestAuthEnvironment(t *testing.T) {
+	env := authEnvironment("https://example.com/repo.git", Options{AuthToken: "token", SSHAuthSock: "/agent"})
+	assert.Contains(t, env, "GIT_TERMINAL_PROMPT=0")
+	assert.Contains(t, env, "SSH_AUTH_SOCK=/agent")
+	assert.Contains(t, env, "GIT_CONFIG_KEY_0=http.https://example.com/.extraheader")
+	assert.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: basic "+base64.StdEncoding.EncodeToString([]byte("x-access-token:token")))
+
+	env = authEnvironment("git@example.com:repo.git", Options{AuthToken: "token"})
+	for _, setting := range env {
+		assert.NotContains(t, setting, "extraheader", "token should not be used for SSH")
+	}
+}
+
+// git runs git in dir for a test, and returns its trimmed output.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoErrorf(t, err, "git %v: %s", args, string(output))
+	return strings.TrimSpace(string(output))
+}
+
+func TestClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	// submodules are normally not allowed to be fetched from the local
+	// filesystem
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	repositories := t.TempDir()
+	submodule := filepath.Join(repositories, "submodule")
+	require.NoError(t, os.Mkdir(submodule, 0o755))
+	git(t, submodule, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(submodule, "nested.txt"), []byte("nested\n"), 0o644))
+	git(t, submodule, "add", ".")
+	git(t, submodule, "commit", "-q", "-m", "submodule")
+
+	repository := filepath.Join(repositories, "repository")
+	require.NoError(t, os.MkdirAll(filepath.Join(repository, "wanted"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repository, "unwanted"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repository, "wanted", "a.txt"), []byte("a\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repository, "unwanted", "b.txt"), []byte("b\n"), 0o644))
+	require.NoError(t, os.Symlink("wanted", filepath.Join(repository, "alias")))
+	git(t, repository, "init", "-q", "-b", "main")
+	git(t, repository, "submodule", "add", "-q", "file://"+submodule, "wanted/submodule")
+	git(t, repository, "add", ".")
+	git(t, repository, "commit", "-q", "-m", "repository")
+	git(t, repository, "tag", "-a", "-m", "annotated", "v1.0")
+	commit := git(t, repository, "rev-parse", "HEAD")
+	tag := git(t, repository, "rev-parse", "v1.0")
+	url := "file://" + repository
+
+	t.Run("submodules", func(t *testing.T) {
+		dir := t.TempDir()
+		_, subdir, err := Clone(url+"#main", dir, Options{Submodules: true})
+		require.NoError(t, err)
+		assert.Empty(t, subdir)
+		assert.FileExists(t, filepath.Join(dir, "wanted", "submodule", "nested.txt"))
+		assert.FileExists(t, filepath.Join(dir, "unwanted", "b.txt"))
+
+		dir = t.TempDir()
+		_, _, err = Clone(url+"#main", dir, Options{})
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(dir, "wanted", "submodule", "nested.txt"))
+	})
+
+	t.Run("sparse", func(t *testing.T) {
+		dir := t.TempDir()
+		_, subdir, err := Clone(url+"#main:wanted", dir, Options{Submodules: true})
+		require.NoError(t, err)
+		assert.Equal(t, "wanted", subdir)
+		assert.FileExists(t, filepath.Join(dir, "wanted", "a.txt"))
+		assert.FileExists(t, filepath.Join(dir, "wanted", "submodule", "nested.txt"))
+		assert.NoDirExists(t, filepath.Join(dir, "unwanted"))
+
+		// a subdirectory that's only reachable through a symbolic
+		// link causes everything to be checked out
+		dir = t.TempDir()
+		_, subdir, err = Clone(url+"#main:alias", dir, Options{})
+		require.NoError(t, err)
+		assert.Equal(t, "alias", subdir)
+		assert.FileExists(t, filepath.Join(dir, "alias", "a.txt"))
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		for _, checksum := range []string{commit, commit[:7], strings.ToUpper(commit[:12]), tag} {
+			_, _, err := Clone(url+"#v1.0", t.TempDir(), Options{Checksum: checksum})
+			assert.NoErrorf(t, err, "checksum %q", checksum)
+		}
+		_, _, err := Clone(url+"#main", t.TempDir(), Options{Checksum: commit})
+		assert.NoError(t, err)
+		_, _, err = Clone(url+"#v1.0", t.TempDir(), Options{Checksum: "0123456789abcdef"})
+		assert.ErrorContains(t, err, "does not match checksum")
+		_, _, err = Clone(url+"#v1.0", t.TempDir(), Options{Checksum: "sha256:" + commit})
+		assert.ErrorContains(t, err, "is not a commit ID")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, _, err := Clone(url+"#nosuchbranch", t.TempDir(), Options{})
+		assert.ErrorContains(t, err, "failed while performing `git fetch`")
+	})
+}
+
+func TestCloneWithoutGit(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	_, _, err := Clone("https://example.com/repo.git", t.TempDir(), Options{})
+	assert.ErrorIs(t, err, ErrGitNotFound)
+}
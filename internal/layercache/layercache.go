@@ -0,0 +1,274 @@
+package layercache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/internal/tmpdir"
+	"go.podman.io/image/v5/oci/layout"
+	"go.podman.io/image/v5/types"
+	"go.podman.io/storage/pkg/archive"
+)
+
+// RepositoryName is the name which is given to cache images that are written
+// to an OCI layout, with the cache key used as the tag.
+const RepositoryName = "localhost/buildah-layer-cache"
+
+type LocationType int
+
+const (
+	LocationInvalid  LocationType = 0
+	LocationRegistry LocationType = 1 // a repository in a registry
+	LocationLocal    LocationType = 2 // an OCI layout directory
+	LocationOCI      LocationType = 3 // an OCI layout in a tar archive
+)
+
+func (l LocationType) String() string {
+	switch l {
+	case LocationInvalid:
+		return "invalid (unset)!"
+	case LocationRegistry:
+		return "registry"
+	case LocationLocal:
+		return "local"
+	case LocationOCI:
+		return "oci"
+	}
+	return fmt.Sprintf("unknown %d!", int(l))
+}
+
+// Location contains the outcome of parsing the value of a build --cache-from
+// or --cache-to flag.
+type Location struct {
+	Type LocationType
+	Ref  string // Only valid if Type is registry
+	Path string // Only valid if Type is local or oci
+}
+
+// IsLocation returns true if the value of a --cache-from or --cache-to flag
+// uses the "type=...,key=value" form instead of being a plain repository name.
+func IsLocation(value string) bool {
+	return strings.HasPrefix(value, "type=") || strings.Contains(value, ",type=")
+}
+
+// GetLocation is responsible for parsing the value of a build --cache-from or
+// --cache-to flag which is in the "type=...,key=value" form.  The "src" key
+// is accepted for --cache-from values, and "dest" for --cache-to values.
+func GetLocation(value string, export bool) (Location, error) {
+	pathKey := "src"
+	if export {
+		pathKey = "dest"
+	}
+	var location Location
+	for option := range strings.SplitSeq(value, ",") {
+		key, val, found := strings.Cut(option, "=")
+		if !found {
+			return Location{}, fmt.Errorf("invalid cache options %q, expected format key=value", value)
+		}
+		switch key {
+		case "type":
+			if location.Type != LocationInvalid {
+				return Location{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			switch val {
+			case "registry":
+				location.Type = LocationRegistry
+			case "local":
+				location.Type = LocationLocal
+			case "oci":
+				location.Type = LocationOCI
+			default:
+				return Location{}, fmt.Errorf("invalid type %q selected for cache options %q", val, value)
+			}
+		case "ref":
+			if location.Ref != "" {
+				return Location{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			location.Ref = val
+		case pathKey:
+			if location.Path != "" {
+				return Location{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			location.Path = val
+		default:
+			return Location{}, fmt.Errorf("unrecognized key %q in cache options: %q", key, value)
+		}
+	}
+
+	switch location.Type {
+	case LocationInvalid:
+		return Location{}, fmt.Errorf("missing required key %q in cache options: %q", "type", value)
+	case LocationRegistry:
+		if location.Ref == "" {
+			return Location{}, fmt.Errorf("missing required key %q in cache options: %q", "ref", value)
+		}
+		location.Path = ""
+	case LocationLocal, LocationOCI:
+		if location.Path == "" {
+			return Location{}, fmt.Errorf("missing required key %q in cache options: %q", pathKey, value)
+		}
+		location.Ref = ""
+	}
+	return location, nil
+}
+
+// Cache is an OCI layout which holds cache images, each of which is tagged
+// with the cache key that was computed for it.  If the Location is an OCI
+// archive, the layout is kept in a temporary directory until Close() is
+// called.
+type Cache struct {
+	location Location
+	dir      string
+	tempDir  string
+	lock     sync.Mutex // serializes writes to the layout's index
+}
+
+// Open prepares the OCI layout for a local or oci Location.  If the Location
+// is an OCI archive which already exists, its contents are extracted so that
+// they can be used or added to.
+func Open(location Location) (*Cache, error) {
+	switch location.Type {
+	case LocationLocal:
+		return &Cache{location: location, dir: location.Path}, nil
+	case LocationOCI:
+		tempDir, err := os.MkdirTemp(tmpdir.GetTempDir(), "buildah-layer-cache")
+		if err != nil {
+			return nil, fmt.Errorf("creating temporary directory for layer cache: %w", err)
+		}
+		if _, err := os.Stat(location.Path); err == nil {
+			if err := archive.UntarPath(location.Path, tempDir); err != nil {
+				os.RemoveAll(tempDir)
+				return nil, fmt.Errorf("extracting layer cache from %q: %w", location.Path, err)
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			os.RemoveAll(tempDir)
+			return nil, fmt.Errorf("checking for layer cache %q: %w", location.Path, err)
+		}
+		return &Cache{location: location, dir: tempDir, tempDir: tempDir}, nil
+	}
+	return nil, fmt.Errorf("internal error: layer cache location type %v is not backed by an OCI layout", location.Type)
+}
+
+// String returns a description of the cache's location.
+func (c *Cache) String() string {
+	return fmt.Sprintf("%s:%s", c.location.Type, c.location.Path)
+}
+
+// Reference returns a reference to the cache image with the specified key.
+func (c *Cache) Reference(cacheKey string) (types.ImageReference, error) {
+	ref, err := layout.NewReference(c.dir, RepositoryName+":"+cacheKey)
+	if err != nil {
+		return nil, fmt.Errorf("generating reference to cache image %q in %q: %w", cacheKey, c, err)
+	}
+	return ref, nil
+}
+
+// Has returns true if the cache's index is present, i.e., if it has ever been
+// written to.
+func (c *Cache) Has() bool {
+	_, err := os.Stat(filepath.Join(c.dir, "index.json"))
+	return err == nil
+}
+
+// Lock serializes writes to the cache's index.  It should be held while an
+// image is being written to the cache.
+func (c *Cache) Lock() {
+	c.lock.Lock()
+}
+
+// Unlock releases the lock obtained by Lock.
+func (c *Cache) Unlock() {
+	c.lock.Unlock()
+}
+
+// Prune removes cache images which were created more than ttl ago.  A zero
+// ttl means that nothing is removed.
+func (c *Cache) Prune(ctx context.Context, sys *types.SystemContext, ttl time.Duration) error {
+	if ttl == 0 || !c.Has() {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entries, err := layout.List(c.dir)
+	if err != nil {
+		return fmt.Errorf("listing cache images in %q: %w", c, err)
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		img, err := entry.Reference.NewImage(ctx, sys)
+		if err != nil {
+			return fmt.Errorf("opening cache image %q: %w", entry.Reference.StringWithinTransport(), err)
+		}
+		info, err := img.Inspect(ctx)
+		img.Close()
+		if err != nil {
+			return fmt.Errorf("inspecting cache image %q: %w", entry.Reference.StringWithinTransport(), err)
+		}
+		if info.Created == nil || now.Sub(*info.Created) <= ttl {
+			continue
+		}
+		logrus.Debugf("cache image %q age %v is older than cache TTL %v, removing it", entry.Reference.StringWithinTransport(), now.Sub(*info.Created), ttl)
+		if err := entry.Reference.DeleteImage(ctx, sys); err != nil {
+			return fmt.Errorf("removing expired cache image %q: %w", entry.Reference.StringWithinTransport(), err)
+		}
+	}
+	return nil
+}
+
+// Close releases any temporary resources used by the cache.  If the Location
+// is an OCI archive and save is true, the archive is (re)written from the
+// contents of the layout before they are removed.  It is safe to call Close
+// more than once.
+func (c *Cache) Close(save bool) error {
+	if c.tempDir == "" {
+		return nil
+	}
+	defer func() {
+		os.RemoveAll(c.tempDir)
+		c.tempDir = ""
+	}()
+	if !save || !c.Has() {
+		return nil
+	}
+	rc, err := archive.Tar(c.tempDir, archive.Uncompressed)
+	if err != nil {
+		return fmt.Errorf("archiving layer cache: %w", err)
+	}
+	defer rc.Close()
+	if err := os.MkdirAll(filepath.Dir(c.location.Path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for layer cache %q: %w", c.location.Path, err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(c.location.Path), filepath.Base(c.location.Path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("creating layer cache archive %q: %w", c.location.Path, err)
+	}
+	tmpName := f.Name()
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("setting permissions on layer cache archive %q: %w", c.location.Path, err)
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("writing layer cache archive %q: %w", c.location.Path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("writing layer cache archive %q: %w", c.location.Path, err)
+	}
+	if err := os.Rename(tmpName, c.location.Path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("replacing layer cache archive %q: %w", c.location.Path, err)
+	}
+	return nil
+}
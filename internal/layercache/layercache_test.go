@@ -0,0 +1,111 @@
+package layercache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLocation(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		export      bool
+		output      Location
+	}{
+		{
+			description: "registry",
+			input:       "type=registry,ref=quay.io/example/cache",
+			output: Location{
+				Type: LocationRegistry,
+				Ref:  "quay.io/example/cache",
+			},
+		},
+		{
+			description: "local-source",
+			input:       "type=local,src=/cache",
+			output: Location{
+				Type: LocationLocal,
+				Path: "/cache",
+			},
+		},
+		{
+			description: "oci-destination",
+			input:       "type=oci,dest=/cache.tar",
+			export:      true,
+			output: Location{
+				Type: LocationOCI,
+				Path: "/cache.tar",
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert.True(t, IsLocation(testCase.input))
+			result, err := GetLocation(testCase.input, testCase.export)
+			require.NoErrorf(t, err, "expected to be able to parse %q", testCase.input)
+			assert.Equal(t, testCase.output, result)
+		})
+	}
+
+	errorCases := []struct {
+		description string
+		input       string
+		export      bool
+	}{
+		{"no-type", "src=/cache", false},
+		{"bad-type", "type=s3,src=/cache", false},
+		{"dest-for-source", "type=local,dest=/cache", false},
+		{"src-for-destination", "type=local,src=/cache", true},
+		{"no-ref", "type=registry", false},
+		{"no-path", "type=oci", true},
+		{"duplicate-type", "type=oci,type=local,src=/cache", false},
+	}
+	for _, testCase := range errorCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			_, err := GetLocation(testCase.input, testCase.export)
+			assert.Errorf(t, err, "expected to not be able to parse %q", testCase.input)
+		})
+	}
+
+	assert.False(t, IsLocation("quay.io/example/cache"))
+}
+
+func TestOpenClose(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	archivePath := filepath.Join(t.TempDir(), "cache.tar")
+
+	cache, err := Open(Location{Type: LocationOCI, Path: archivePath})
+	require.NoError(t, err)
+	assert.False(t, cache.Has())
+	ref, err := cache.Reference("0123456789abcdef")
+	require.NoError(t, err)
+	assert.Contains(t, ref.StringWithinTransport(), RepositoryName+":0123456789abcdef")
+	require.NoError(t, cache.Prune(context.Background(), nil, time.Hour))
+
+	// nothing was written to the layout, so there's nothing to save
+	require.NoError(t, cache.Close(true))
+	_, err = os.Stat(archivePath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, cache.Close(true), "expected Close() to be safe to call more than once")
+
+	// a layout with an index in it gets saved
+	cache, err = Open(Location{Type: LocationOCI, Path: archivePath})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(cache.dir, "index.json"), []byte(`{"schemaVersion":2,"manifests":[]}`), 0o644))
+	require.NoError(t, cache.Close(true))
+	_, err = os.Stat(archivePath)
+	require.NoError(t, err)
+
+	// and its contents are restored when it's opened again
+	cache, err = Open(Location{Type: LocationOCI, Path: archivePath})
+	require.NoError(t, err)
+	assert.True(t, cache.Has())
+	require.NoError(t, cache.Prune(context.Background(), nil, time.Hour))
+	require.NoError(t, cache.Close(false))
+}
@@ -6,6 +6,15 @@ import (
 	"go.podman.io/buildah/docker"
 )
 
+// SourcePolicyKey is the key under which a list of the source policy rules
+// which matched sources that were used during a build is recorded.
+const SourcePolicyKey = "buildah.source-policy"
+
+// ContentManifestKey is the key under which manifests of the items which were
+// added to the image by ADD and COPY instructions are recorded, if they were
+// requested.
+const ContentManifestKey = "buildah.content-manifest"
+
 // Build constructs a map containing the passed-in information about a just-committed or reused-as-cache image.
 func Build(imageConfigDigest digest.Digest, descriptor v1.Descriptor) (map[string]any, error) {
 	metadata := make(map[string]any)
@@ -2,7 +2,11 @@ package output
 
 import (
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
+
+	"go.podman.io/image/v5/types"
 )
 
 type BuildOutputType int
@@ -12,6 +16,11 @@ const (
 	BuildOutputStdout   BuildOutputType = 1 // stream tar to stdout
 	BuildOutputLocalDir BuildOutputType = 2
 	BuildOutputTar      BuildOutputType = 3
+	// The remaining types write the image itself instead of its rootfs.
+	BuildOutputImage         BuildOutputType = 4 // commit to local storage, and optionally push
+	BuildOutputRegistry      BuildOutputType = 5 // push to a registry
+	BuildOutputOCIArchive    BuildOutputType = 6
+	BuildOutputDockerArchive BuildOutputType = 7
 )
 
 func (b BuildOutputType) String() string {
@@ -24,14 +33,74 @@ func (b BuildOutputType) String() string {
 		return "local"
 	case BuildOutputTar:
 		return "tar"
+	case BuildOutputImage:
+		return "image"
+	case BuildOutputRegistry:
+		return "registry"
+	case BuildOutputOCIArchive:
+		return "oci"
+	case BuildOutputDockerArchive:
+		return "docker"
 	}
 	return fmt.Sprintf("unknown %d!", int(b))
 }
 
+// IsImage returns true if the output type is one which writes the built
+// image instead of its rootfs.
+func (b BuildOutputType) IsImage() bool {
+	switch b {
+	case BuildOutputImage, BuildOutputRegistry, BuildOutputOCIArchive, BuildOutputDockerArchive:
+		return true
+	}
+	return false
+}
+
 // BuildOutputOptions contains the outcome of parsing the value of a build --output flag
 type BuildOutputOption struct {
 	Type BuildOutputType
-	Path string // Only valid if Type is local dir or tar
+	Path string // Only valid if Type is local dir, tar, or an archive
+	Name string // Only valid if Type is image, registry, or an archive
+	Push bool   // Only valid if Type is image or registry
+	// Compression is "uncompressed", "gzip", "zstd", or, if Type writes
+	// an image, "zstd:chunked".  Not valid if Type is local dir.
+	Compression string
+	// RewriteTimestamp overrides the build's --rewrite-timestamp setting
+	// for image types.  For other types, setting it to false preserves
+	// timestamps on contents instead of forcing them to the build's
+	// --timestamp or --source-date-epoch value.
+	RewriteTimestamp types.OptionalBool
+	// PlatformSplit adds the platform to Path, so that builds for multiple
+	// platforms write to different locations.  Only valid if Type is local
+	// dir, tar, or an archive.
+	PlatformSplit bool
+	// Annotations is a list of annotations (in the form "key=value") to
+	// add to the image.  Only valid if Type writes an image.
+	Annotations []string
+}
+
+// ForPlatform returns a copy of the option with the platform, formatted as
+// "os_arch" or "os_arch_variant", added to its Path if PlatformSplit is set.
+// Local directories get a subdirectory, and files get a suffix inserted
+// before their extension.
+func (b BuildOutputOption) ForPlatform(os, arch, variant string) BuildOutputOption {
+	if !b.PlatformSplit || b.Path == "" {
+		return b
+	}
+	platform := os + "_" + arch
+	if variant != "" {
+		platform += "_" + variant
+	}
+	if b.Type == BuildOutputLocalDir {
+		b.Path = filepath.Join(b.Path, platform)
+		return b
+	}
+	dir, base := filepath.Split(b.Path)
+	name, ext, _ := strings.Cut(base, ".")
+	if ext != "" {
+		ext = "." + ext
+	}
+	b.Path = filepath.Join(dir, name+"_"+platform+ext)
+	return b
 }
 
 // GetBuildOutput is responsible for parsing custom build output argument i.e `build --output` flag.
@@ -57,6 +126,12 @@ func GetBuildOutput(buildOutput string) (BuildOutputOption, error) {
 	// Support complex values, in the form --output type=local,dest=./mydir
 	typeSelected := BuildOutputInvalid
 	pathSelected := ""
+	nameSelected := ""
+	pushSelected := ""
+	compressionSelected := ""
+	rewriteTimestampSelected := ""
+	platformSplitSelected := ""
+	var annotations []string
 	for option := range strings.SplitSeq(buildOutput, ",") {
 		key, value, found := strings.Cut(option, "=")
 		if !found {
@@ -72,6 +147,14 @@ func GetBuildOutput(buildOutput string) (BuildOutputOption, error) {
 				typeSelected = BuildOutputLocalDir
 			case "tar":
 				typeSelected = BuildOutputTar
+			case "image":
+				typeSelected = BuildOutputImage
+			case "registry":
+				typeSelected = BuildOutputRegistry
+			case "oci":
+				typeSelected = BuildOutputOCIArchive
+			case "docker":
+				typeSelected = BuildOutputDockerArchive
 			default:
 				return BuildOutputOption{}, fmt.Errorf("invalid type %q selected for build output options %q", value, buildOutput)
 			}
@@ -80,7 +163,36 @@ func GetBuildOutput(buildOutput string) (BuildOutputOption, error) {
 				return BuildOutputOption{}, fmt.Errorf("duplicate %q not supported", key)
 			}
 			pathSelected = value
+		case "name":
+			if nameSelected != "" {
+				return BuildOutputOption{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			nameSelected = value
+		case "push":
+			if pushSelected != "" {
+				return BuildOutputOption{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			pushSelected = value
+		case "compression":
+			if compressionSelected != "" {
+				return BuildOutputOption{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			compressionSelected = value
+		case "rewrite-timestamp":
+			if rewriteTimestampSelected != "" {
+				return BuildOutputOption{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			rewriteTimestampSelected = value
+		case "platform-split":
+			if platformSplitSelected != "" {
+				return BuildOutputOption{}, fmt.Errorf("duplicate %q not supported", key)
+			}
+			platformSplitSelected = value
 		default:
+			if annotation, ok := strings.CutPrefix(key, "annotation."); ok && annotation != "" {
+				annotations = append(annotations, annotation+"="+value)
+				continue
+			}
 			return BuildOutputOption{}, fmt.Errorf("unrecognized key %q in build output option: %q", key, buildOutput)
 		}
 	}
@@ -91,15 +203,85 @@ func GetBuildOutput(buildOutput string) (BuildOutputOption, error) {
 	}
 
 	// Validate path
-	if typeSelected == BuildOutputLocalDir || typeSelected == BuildOutputTar {
+	switch typeSelected {
+	case BuildOutputLocalDir, BuildOutputTar, BuildOutputOCIArchive, BuildOutputDockerArchive:
 		if pathSelected == "" {
 			return BuildOutputOption{}, fmt.Errorf("missing required key %q in build output option: %q", "dest", buildOutput)
 		}
-	} else {
+	default:
 		// Clear path when not needed by type
 		pathSelected = ""
 	}
 
+	// Validate name and push
+	push := typeSelected == BuildOutputRegistry
+	switch typeSelected {
+	case BuildOutputImage, BuildOutputRegistry:
+		if nameSelected == "" {
+			return BuildOutputOption{}, fmt.Errorf("missing required key %q in build output option: %q", "name", buildOutput)
+		}
+		if pushSelected != "" {
+			var err error
+			if push, err = strconv.ParseBool(pushSelected); err != nil {
+				return BuildOutputOption{}, fmt.Errorf("parsing %q value %q in build output option %q: %w", "push", pushSelected, buildOutput, err)
+			}
+			if typeSelected == BuildOutputRegistry && !push {
+				return BuildOutputOption{}, fmt.Errorf(`invalid build output option %q, "type=registry" can not be used with "push=false"`, buildOutput)
+			}
+		}
+	case BuildOutputOCIArchive, BuildOutputDockerArchive:
+		if pushSelected != "" {
+			return BuildOutputOption{}, fmt.Errorf("unrecognized key %q in build output option: %q", "push", buildOutput)
+		}
+	default:
+		if nameSelected != "" {
+			return BuildOutputOption{}, fmt.Errorf("unrecognized key %q in build output option: %q", "name", buildOutput)
+		}
+		if pushSelected != "" {
+			return BuildOutputOption{}, fmt.Errorf("unrecognized key %q in build output option: %q", "push", buildOutput)
+		}
+	}
+
+	// Validate compression
+	switch compressionSelected {
+	case "":
+	case "uncompressed", "gzip", "zstd":
+		if typeSelected == BuildOutputLocalDir {
+			return BuildOutputOption{}, fmt.Errorf("unrecognized key %q in build output option: %q", "compression", buildOutput)
+		}
+	case "zstd:chunked":
+		if !typeSelected.IsImage() {
+			return BuildOutputOption{}, fmt.Errorf(`invalid build output option %q, "compression=zstd:chunked" can only be used with image types`, buildOutput)
+		}
+	default:
+		return BuildOutputOption{}, fmt.Errorf("invalid compression %q selected for build output options %q", compressionSelected, buildOutput)
+	}
+
+	// Validate rewrite-timestamp and platform-split
+	rewriteTimestamp := types.OptionalBoolUndefined
+	if rewriteTimestampSelected != "" {
+		value, err := strconv.ParseBool(rewriteTimestampSelected)
+		if err != nil {
+			return BuildOutputOption{}, fmt.Errorf("parsing %q value %q in build output option %q: %w", "rewrite-timestamp", rewriteTimestampSelected, buildOutput, err)
+		}
+		rewriteTimestamp = types.NewOptionalBool(value)
+	}
+	platformSplit := false
+	if platformSplitSelected != "" {
+		var err error
+		if platformSplit, err = strconv.ParseBool(platformSplitSelected); err != nil {
+			return BuildOutputOption{}, fmt.Errorf("parsing %q value %q in build output option %q: %w", "platform-split", platformSplitSelected, buildOutput, err)
+		}
+		if pathSelected == "" || pathSelected == "-" {
+			return BuildOutputOption{}, fmt.Errorf("unrecognized key %q in build output option: %q", "platform-split", buildOutput)
+		}
+	}
+
+	// Validate annotations
+	if len(annotations) > 0 && !typeSelected.IsImage() {
+		return BuildOutputOption{}, fmt.Errorf(`invalid build output option %q, annotations can only be used with image types`, buildOutput)
+	}
+
 	// Handle redirecting stdout for tar output
 	if pathSelected == "-" {
 		if typeSelected == BuildOutputTar {
@@ -113,5 +295,12 @@ func GetBuildOutput(buildOutput string) (BuildOutputOption, error) {
 	return BuildOutputOption{
 		Type: typeSelected,
 		Path: pathSelected,
+		Name: nameSelected,
+		Push: push,
+
+		Compression:      compressionSelected,
+		RewriteTimestamp: rewriteTimestamp,
+		PlatformSplit:    platformSplit,
+		Annotations:      annotations,
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.podman.io/image/v5/types"
 )
 
 func TestGetBuildOutput(t *testing.T) {
@@ -36,6 +37,78 @@ func TestGetBuildOutput(t *testing.T) {
 				Path: "/tmp",
 			},
 		},
+		{
+			description: "image",
+			input:       "type=image,name=localhost/example",
+			output: BuildOutputOption{
+				Type: BuildOutputImage,
+				Name: "localhost/example",
+			},
+		},
+		{
+			description: "image-push",
+			input:       "type=image,name=quay.io/example/image,push=true",
+			output: BuildOutputOption{
+				Type: BuildOutputImage,
+				Name: "quay.io/example/image",
+				Push: true,
+			},
+		},
+		{
+			description: "registry",
+			input:       "type=registry,name=quay.io/example/image:latest",
+			output: BuildOutputOption{
+				Type: BuildOutputRegistry,
+				Name: "quay.io/example/image:latest",
+				Push: true,
+			},
+		},
+		{
+			description: "oci",
+			input:       "type=oci,dest=/tmp/image.tar",
+			output: BuildOutputOption{
+				Type: BuildOutputOCIArchive,
+				Path: "/tmp/image.tar",
+			},
+		},
+		{
+			description: "docker",
+			input:       "type=docker,dest=/tmp/image.tar,name=localhost/example",
+			output: BuildOutputOption{
+				Type: BuildOutputDockerArchive,
+				Path: "/tmp/image.tar",
+				Name: "localhost/example",
+			},
+		},
+		{
+			description: "tar-options",
+			input:       "type=tar,dest=/tmp/rootfs.tar.gz,compression=gzip,rewrite-timestamp=false,platform-split=true",
+			output: BuildOutputOption{
+				Type:             BuildOutputTar,
+				Path:             "/tmp/rootfs.tar.gz",
+				Compression:      "gzip",
+				RewriteTimestamp: types.OptionalBoolFalse,
+				PlatformSplit:    true,
+			},
+		},
+		{
+			description: "oci-options",
+			input:       "type=oci,dest=/tmp/image.tar,compression=zstd:chunked,annotation.org.example.key=value,annotation.org.example.other=a=b",
+			output: BuildOutputOption{
+				Type:        BuildOutputOCIArchive,
+				Path:        "/tmp/image.tar",
+				Compression: "zstd:chunked",
+				Annotations: []string{"org.example.key=value", "org.example.other=a=b"},
+			},
+		},
+		{
+			description: "stdout-compression",
+			input:       "type=tar,dest=-,compression=zstd",
+			output: BuildOutputOption{
+				Type:        BuildOutputStdout,
+				Compression: "zstd",
+			},
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.description, func(t *testing.T) {
@@ -44,4 +117,49 @@ func TestGetBuildOutput(t *testing.T) {
 			assert.Equal(t, testCase.output, result)
 		})
 	}
+
+	errorCases := []struct {
+		description string
+		input       string
+	}{
+		{"image-no-name", "type=image"},
+		{"registry-no-name", "type=registry"},
+		{"registry-no-push", "type=registry,name=quay.io/example/image,push=false"},
+		{"oci-no-dest", "type=oci,name=localhost/example"},
+		{"oci-push", "type=oci,dest=/tmp/image.tar,push=true"},
+		{"local-name", "type=local,dest=/tmp,name=localhost/example"},
+		{"bad-push", "type=image,name=localhost/example,push=maybe"},
+		{"duplicate-name", "type=image,name=localhost/a,name=localhost/b"},
+		{"local-compression", "type=local,dest=/tmp,compression=gzip"},
+		{"tar-chunked", "type=tar,dest=/tmp/rootfs.tar,compression=zstd:chunked"},
+		{"bad-compression", "type=tar,dest=/tmp/rootfs.tar,compression=lz4"},
+		{"bad-rewrite-timestamp", "type=tar,dest=/tmp/rootfs.tar,rewrite-timestamp=maybe"},
+		{"registry-platform-split", "type=registry,name=quay.io/example/image,platform-split=true"},
+		{"stdout-platform-split", "type=tar,dest=-,platform-split=true"},
+		{"local-annotation", "type=local,dest=/tmp,annotation.key=value"},
+		{"empty-annotation", "type=oci,dest=/tmp/image.tar,annotation.=value"},
+	}
+	for _, testCase := range errorCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			_, err := GetBuildOutput(testCase.input)
+			assert.Errorf(t, err, "expected to not be able to parse %q", testCase.input)
+		})
+	}
+}
+
+func TestForPlatform(t *testing.T) {
+	testCases := []struct {
+		input  string
+		output string
+	}{
+		{"type=local,dest=/tmp/out", "/tmp/out"},
+		{"type=local,dest=/tmp/out,platform-split=true", "/tmp/out/linux_arm_v7"},
+		{"type=tar,dest=/tmp/rootfs.tar.gz,platform-split=true", "/tmp/rootfs_linux_arm_v7.tar.gz"},
+		{"type=oci,dest=image,platform-split=true", "image_linux_arm_v7"},
+	}
+	for _, testCase := range testCases {
+		option, err := GetBuildOutput(testCase.input)
+		require.NoErrorf(t, err, "expected to be able to parse %q", testCase.input)
+		assert.Equal(t, testCase.output, option.ForPlatform("linux", "arm", "v7").Path)
+	}
 }
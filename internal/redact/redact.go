@@ -0,0 +1,180 @@
+// Package redact keeps track of the values of secrets which are used during a
+// build, so that they can be masked in output which is displayed to the user
+// or recorded in logs, image histories, and metadata files.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Placeholder is what a secret value is replaced with.
+const Placeholder = "****"
+
+// minimumLength is the length of the shortest value that we'll bother trying
+// to redact.  Masking every occurrence of a one- or two-character value would
+// leave the output unreadable without hiding anything of value.
+const minimumLength = 4
+
+// Redactor masks the values that it has been told about.  A nil *Redactor
+// doesn't mask anything.
+type Redactor struct {
+	mu     sync.RWMutex
+	values [][]byte // longest first, so that a value which contains another is replaced as a whole
+}
+
+// New returns a Redactor which doesn't know about any values yet.
+func New() *Redactor {
+	return &Redactor{}
+}
+
+// Add tells the Redactor about a sensitive value.  Leading and trailing
+// whitespace is ignored, and each line of a multi-line value is also masked
+// on its own, since output is frequently processed one line at a time.  The
+// JSON-encoded forms of the value are also masked.
+func (r *Redactor) Add(value []byte) {
+	if r == nil {
+		return
+	}
+	candidates := [][]byte{bytes.TrimSpace(value)}
+	if bytes.ContainsAny(candidates[0], "\r\n") {
+		for line := range bytes.Lines(candidates[0]) {
+			candidates = append(candidates, bytes.TrimSpace(line))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, candidate := range candidates {
+		encoded, err := json.Marshal(string(candidate))
+		if err != nil {
+			continue
+		}
+		for _, v := range [][]byte{candidate, encoded[1 : len(encoded)-1]} {
+			if len(v) < minimumLength || slices.ContainsFunc(r.values, func(known []byte) bool { return bytes.Equal(known, v) }) {
+				continue
+			}
+			r.values = append(r.values, bytes.Clone(v))
+		}
+	}
+	slices.SortStableFunc(r.values, func(a, b []byte) int { return len(b) - len(a) })
+}
+
+// Bytes returns b with every known value replaced by Placeholder.
+func (r *Redactor) Bytes(b []byte) []byte {
+	if r == nil {
+		return b
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		b = bytes.ReplaceAll(b, v, []byte(Placeholder))
+	}
+	return b
+}
+
+// String returns s with every known value replaced by Placeholder.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	return string(r.Bytes([]byte(s)))
+}
+
+// Contains returns true if s includes any of the known values.
+func (r *Redactor) Contains(s string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.ContainsFunc(r.values, func(v []byte) bool { return bytes.Contains([]byte(s), v) })
+}
+
+// Error returns an error whose message is err's message with every known value
+// replaced by Placeholder, and which otherwise wraps err.
+func (r *Redactor) Error(err error) error {
+	if err == nil || !r.Contains(err.Error()) {
+		return err
+	}
+	return &redactedError{message: r.String(err.Error()), err: err}
+}
+
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// partialSuffix returns the length of the longest suffix of b which could be
+// the beginning of one of the known values.
+func (r *Redactor) partialSuffix(b []byte) int {
+	if r == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	longest := 0
+	for _, v := range r.values {
+		for n := min(len(v)-1, len(b)); n > longest; n-- {
+			if bytes.HasSuffix(b, v[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// Writer masks known values in what's written to it before passing it on.
+type Writer struct {
+	redactor *Redactor
+	mu       sync.Mutex
+	w        io.Writer
+	pending  []byte
+}
+
+// NewWriter returns a Writer which masks the values that r knows about before
+// writing to w.  The end of a write which might be the start of a value is
+// held back until more data is written, or until Flush is called.
+func NewWriter(r *Redactor, w io.Writer) *Writer {
+	return &Writer{redactor: r, w: w}
+}
+
+// Write masks known values in p and writes the result to the underlying
+// writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buf := w.redactor.Bytes(append(w.pending, p...))
+	held := w.redactor.partialSuffix(buf)
+	w.pending = bytes.Clone(buf[len(buf)-held:])
+	if len(buf) > held {
+		if _, err := w.w.Write(buf[:len(buf)-held]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes anything which is being held back to the underlying writer.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	pending := w.pending
+	w.pending = nil
+	_, err := w.w.Write(pending)
+	return err
+}
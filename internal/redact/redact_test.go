@@ -0,0 +1,57 @@
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	var unset *Redactor
+	unset.Add([]byte("ignored"))
+	assert.Equal(t, "ignored", unset.String("ignored"))
+	assert.False(t, unset.Contains("ignored"))
+
+	r := New()
+	r.Add([]byte("hunter2\n"))
+	r.Add([]byte("abc"))
+	r.Add([]byte("first line\nsecond line\n"))
+	r.Add([]byte(`quo"ted`))
+	r.Add([]byte("hunter2hunter2"))
+
+	assert.Equal(t, "password is ****.", r.String("password is hunter2."))
+	assert.Equal(t, "**** ****", r.String("hunter2hunter2 hunter2"), "a value which contains another should be replaced as a whole")
+	assert.Equal(t, "abc", r.String("abc"), "short values should not be redacted")
+	assert.Equal(t, "got ****", r.String("got first line\nsecond line"))
+	assert.Equal(t, "**** only", r.String("second line only"), "each line of a multi-line value should be redacted")
+	assert.Equal(t, `{"value":"****"}`, r.String(`{"value":"quo\"ted"}`), "JSON-encoded values should be redacted")
+	assert.True(t, r.Contains("ENV=hunter2"))
+	assert.False(t, r.Contains("ENV=hunter"))
+
+	err := fmt.Errorf("using hunter2: %w", fs.ErrPermission)
+	redacted := r.Error(err)
+	assert.EqualError(t, redacted, "using ****: "+fs.ErrPermission.Error())
+	assert.ErrorIs(t, redacted, fs.ErrPermission)
+	assert.Same(t, fs.ErrNotExist, r.Error(fs.ErrNotExist))
+	assert.NoError(t, r.Error(nil))
+}
+
+func TestWriter(t *testing.T) {
+	r := New()
+	r.Add([]byte("hunter2"))
+	var buf bytes.Buffer
+	w := NewWriter(r, &buf)
+
+	for _, chunk := range []string{"the password is hun", "ter2, not hunt", "er3", ", or hu"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, "the password is ****, not hunter3, or ", buf.String(), "the start of a possible value should be held back")
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "the password is ****, not hunter3, or hu", buf.String())
+}
@@ -0,0 +1,611 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.podman.io/buildah"
+	"go.podman.io/buildah/define"
+	"go.podman.io/buildah/imagebuildah"
+	"go.podman.io/buildah/internal/rpc/build/pb"
+	"go.podman.io/buildah/pkg/parse"
+	"go.podman.io/buildah/pkg/util"
+	nettypes "go.podman.io/common/libnetwork/types"
+	"go.podman.io/image/v5/manifest"
+	is "go.podman.io/image/v5/storage"
+	"go.podman.io/image/v5/transports/alltransports"
+	"go.podman.io/image/v5/types"
+	"go.podman.io/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options holds settings which apply to every request which the service
+// handles.
+type Options struct {
+	// SystemContext is used when pulling, writing, and pushing images.
+	SystemContext *types.SystemContext
+	// SignaturePolicyPath overrides the default signature policy.
+	SignaturePolicyPath string
+	// DefaultMountsFilePath is the location of a mounts.conf file.
+	DefaultMountsFilePath string
+	// Isolation is used for running commands in working containers and
+	// for builds, unless a request specifies a different value.
+	Isolation define.Isolation
+	// NetworkInterface, if set, is used for new working containers and for
+	// builds instead of one being initialized for each of them.
+	NetworkInterface nettypes.ContainerNetwork
+}
+
+type buildServer struct {
+	pb.UnimplementedBuildServer
+	store   storage.Store
+	options Options
+	// locks holds a lock for each working container which a request is
+	// currently using, so that requests which modify the same working
+	// container don't overwrite each other's changes.
+	locksMu sync.Mutex
+	locks   map[string]*containerLock
+}
+
+// containerLock serializes requests which use a working container.
+type containerLock struct {
+	sync.Mutex
+	users int
+}
+
+// Register adds the build service, which uses the specified store, to the
+// server.
+func Register(s grpc.ServiceRegistrar, store storage.Store, options Options) {
+	pb.RegisterBuildServer(s, &buildServer{store: store, options: options})
+}
+
+// sender serializes sending of messages on a response stream, which can be
+// written to by more than one goroutine.
+type sender[T any] struct {
+	mu       sync.Mutex
+	stream   grpc.ServerStreamingServer[T]
+	output   func(*pb.Output) *T
+	progress func(*pb.Progress) *T
+}
+
+func (s *sender[T]) send(msg *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.Send(msg)
+}
+
+// writerFunc is an io.Writer which passes a copy of what is written to it to
+// a function.
+type writerFunc func([]byte) error
+
+func (w writerFunc) Write(p []byte) (int, error) {
+	if err := w(bytes.Clone(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// outputWriter returns an io.Writer which sends what is written to it as
+// Output messages for the specified stream.
+func (s *sender[T]) outputWriter(stream pb.Output_Stream) io.Writer {
+	if s.output == nil {
+		return io.Discard
+	}
+	return writerFunc(func(p []byte) error {
+		return s.send(s.output(&pb.Output{Stream: stream, Data: p}))
+	})
+}
+
+// progressWriter returns an io.Writer which sends what is written to it as
+// Progress messages, or nil if quiet is set.
+func (s *sender[T]) progressWriter(quiet bool) io.Writer {
+	if quiet || s.progress == nil {
+		return nil
+	}
+	return writerFunc(func(p []byte) error {
+		return s.send(s.progress(&pb.Progress{Text: string(p)}))
+	})
+}
+
+func invalidArgument(format string, args ...any) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
+
+// openBuilder reads the state of a working container.
+func (b *buildServer) openBuilder(name string) (*buildah.Builder, error) {
+	if name == "" {
+		return nil, invalidArgument("a working container must be specified")
+	}
+	builder, err := buildah.OpenBuilder(b.store, name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, storage.ErrContainerUnknown) {
+			return nil, status.Errorf(codes.NotFound, "reading build container %q: %v", name, err)
+		}
+		return nil, fmt.Errorf("reading build container %q: %w", name, err)
+	}
+	return builder, nil
+}
+
+// lockBuilder waits until no other request is using a working container,
+// then reads its state.  The returned function must be called when the
+// request is done with the working container.
+func (b *buildServer) lockBuilder(name string) (*buildah.Builder, func(), error) {
+	builder, err := b.openBuilder(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	containerID := builder.ContainerID
+	b.locksMu.Lock()
+	if b.locks == nil {
+		b.locks = make(map[string]*containerLock)
+	}
+	lock, ok := b.locks[containerID]
+	if !ok {
+		lock = &containerLock{}
+		b.locks[containerID] = lock
+	}
+	lock.users++
+	b.locksMu.Unlock()
+	lock.Lock()
+	unlock := func() {
+		lock.Unlock()
+		b.locksMu.Lock()
+		defer b.locksMu.Unlock()
+		lock.users--
+		if lock.users == 0 {
+			delete(b.locks, containerID)
+		}
+	}
+	// Read the state again, in case a request that we waited for
+	// changed it.
+	if builder, err = b.openBuilder(containerID); err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	return builder, unlock, nil
+}
+
+// pullPolicy parses a pull policy, defaulting to "missing".
+func pullPolicy(policy string) (define.PullPolicy, error) {
+	if policy == "" {
+		return define.PullIfMissing, nil
+	}
+	pullPolicy, ok := define.PolicyMap[strings.ToLower(policy)]
+	if !ok {
+		return 0, invalidArgument("unrecognized pull policy %q", policy)
+	}
+	return pullPolicy, nil
+}
+
+// imageFormat parses an image format, defaulting to "oci".
+func imageFormat(format string) (string, error) {
+	switch format {
+	case "", define.OCI:
+		return define.OCIv1ImageManifest, nil
+	case define.DOCKER:
+		return define.Dockerv2ImageManifest, nil
+	default:
+		return "", invalidArgument("unrecognized image format %q", format)
+	}
+}
+
+// isolation parses an isolation type, defaulting to the one the service was
+// configured with.
+func (b *buildServer) isolation(isolation string) (define.Isolation, error) {
+	if isolation == "" {
+		return b.options.Isolation, nil
+	}
+	parsed, err := parse.IsolationOption(isolation)
+	if err != nil {
+		return 0, invalidArgument("%v", err)
+	}
+	return parsed, nil
+}
+
+// addHistory adds a history entry for an instruction which did not produce
+// its own layer to a working container.
+func addHistory(builder *buildah.Builder, createdBy string) {
+	now := time.Now().UTC()
+	builder.AddPrependedEmptyLayer(&now, createdBy, "", "")
+}
+
+func (b *buildServer) From(req *pb.FromRequest, stream grpc.ServerStreamingServer[pb.FromResponse]) error {
+	if req.GetImage() == "" {
+		return invalidArgument(`an image name (or "scratch") must be specified`)
+	}
+	s := &sender[pb.FromResponse]{
+		stream: stream,
+		progress: func(p *pb.Progress) *pb.FromResponse {
+			return &pb.FromResponse{Event: &pb.FromResponse_Progress{Progress: p}}
+		},
+	}
+	policy, err := pullPolicy(req.GetPull())
+	if err != nil {
+		return err
+	}
+	systemContext := b.systemContext()
+	if req.GetPlatform() != "" {
+		if systemContext.OSChoice, systemContext.ArchitectureChoice, systemContext.VariantChoice, err = parse.Platform(req.GetPlatform()); err != nil {
+			return invalidArgument("%v", err)
+		}
+	}
+	options := buildah.BuilderOptions{
+		FromImage:             req.GetImage(),
+		Container:             req.GetName(),
+		PullPolicy:            policy,
+		SignaturePolicyPath:   b.options.SignaturePolicyPath,
+		SystemContext:         systemContext,
+		DefaultMountsFilePath: b.options.DefaultMountsFilePath,
+		Isolation:             b.options.Isolation,
+		NetworkInterface:      b.options.NetworkInterface,
+		CommonBuildOpts:       &define.CommonBuildOptions{},
+		ReportWriter:          s.progressWriter(req.GetQuiet()),
+	}
+	builder, err := buildah.NewBuilder(stream.Context(), b.store, options)
+	if err != nil {
+		return err
+	}
+	if err := builder.Save(); err != nil {
+		return err
+	}
+	result := &pb.FromResult{
+		ContainerName: builder.Container,
+		ContainerId:   builder.ContainerID,
+		ImageId:       builder.FromImageID,
+	}
+	return s.send(&pb.FromResponse{Event: &pb.FromResponse_Result{Result: result}})
+}
+
+func (b *buildServer) Run(req *pb.RunRequest, stream grpc.ServerStreamingServer[pb.RunResponse]) error {
+	if len(req.GetArgs()) == 0 {
+		return invalidArgument("a command must be specified")
+	}
+	s := &sender[pb.RunResponse]{
+		stream: stream,
+		output: func(o *pb.Output) *pb.RunResponse { return &pb.RunResponse{Event: &pb.RunResponse_Output{Output: o}} },
+	}
+	builder, unlock, err := b.lockBuilder(req.GetContainer())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	isolation, err := b.isolation(req.GetIsolation())
+	if err != nil {
+		return err
+	}
+	options := buildah.RunOptions{
+		Isolation:  isolation,
+		User:       req.GetUser(),
+		WorkingDir: req.GetWorkdir(),
+		Env:        req.GetEnv(),
+		Terminal:   buildah.WithoutTerminal,
+		Stdin:      bytes.NewReader(req.GetStdin()),
+		Stdout:     s.outputWriter(pb.Output_STREAM_STDOUT),
+		Stderr:     s.outputWriter(pb.Output_STREAM_STDERR),
+	}
+	if err := builder.Run(req.GetArgs(), options); err != nil {
+		return err
+	}
+	if req.GetAddHistory() {
+		shell := "/bin/sh -c"
+		if len(builder.Shell()) > 0 {
+			shell = strings.Join(builder.Shell(), " ")
+		}
+		addHistory(builder, fmt.Sprintf("%s %s", shell, strings.Join(req.GetArgs(), " ")))
+	}
+	if err := builder.Save(); err != nil {
+		return err
+	}
+	return s.send(&pb.RunResponse{Event: &pb.RunResponse_Result{Result: &pb.RunResult{}}})
+}
+
+func (b *buildServer) Copy(req *pb.CopyRequest, stream grpc.ServerStreamingServer[pb.CopyResponse]) error {
+	if len(req.GetSources()) == 0 {
+		return invalidArgument("at least one source must be specified")
+	}
+	s := &sender[pb.CopyResponse]{
+		stream: stream,
+		progress: func(p *pb.Progress) *pb.CopyResponse {
+			return &pb.CopyResponse{Event: &pb.CopyResponse_Progress{Progress: p}}
+		},
+	}
+	builder, unlock, err := b.lockBuilder(req.GetContainer())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	systemContext := b.systemContext()
+	options := buildah.AddAndCopyOptions{
+		Chmod:                 req.GetChmod(),
+		Chown:                 req.GetChown(),
+		ContextDir:            req.GetContextDirectory(),
+		Excludes:              req.GetExcludes(),
+		CertPath:              systemContext.DockerCertPath,
+		InsecureSkipTLSVerify: systemContext.DockerInsecureSkipTLSVerify,
+	}
+	if options.ContextDir != "" {
+		var excludes []string
+		if excludes, options.IgnoreFile, err = parse.ContainerIgnoreFile(options.ContextDir, "", []string{}); err != nil {
+			return err
+		}
+		options.Excludes = append(excludes, options.Excludes...)
+	}
+	builder.ContentDigester.Restart()
+	if err := builder.Add(req.GetDestination(), req.GetAdd(), options, req.GetSources()...); err != nil {
+		return fmt.Errorf("adding content to container %q: %w", builder.Container, err)
+	}
+	contentType, digest := builder.ContentDigester.Digest()
+	result := &pb.CopyResult{}
+	if digest != "" {
+		result.Digest = digest.String()
+		if req.GetAddHistory() {
+			verb := "COPY"
+			if req.GetAdd() {
+				verb = "ADD"
+			}
+			if contentType != "" {
+				contentType += ":"
+			}
+			addHistory(builder, fmt.Sprintf("/bin/sh -c #(nop) %s %s%s", verb, contentType, digest.Encoded()))
+		}
+		if err := builder.Save(); err != nil {
+			return err
+		}
+	}
+	return s.send(&pb.CopyResponse{Event: &pb.CopyResponse_Result{Result: result}})
+}
+
+func (b *buildServer) Config(_ context.Context, req *pb.ConfigRequest) (*pb.ConfigResponse, error) {
+	builder, unlock, err := b.lockBuilder(req.GetContainer())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if req.Author != nil {
+		builder.SetMaintainer(req.GetAuthor())
+	}
+	if req.User != nil {
+		builder.SetUser(req.GetUser())
+	}
+	if req.Workdir != nil {
+		builder.SetWorkDir(req.GetWorkdir())
+	}
+	if req.StopSignal != nil {
+		builder.SetStopSignal(req.GetStopSignal())
+	}
+	for _, env := range req.GetEnv() {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || key == "" {
+			return nil, invalidArgument("environment variable %q is not in KEY=VALUE form", env)
+		}
+		builder.SetEnv(key, value)
+	}
+	for _, key := range req.GetUnsetEnv() {
+		builder.UnsetEnv(key)
+	}
+	for _, key := range slices.Sorted(maps.Keys(req.GetLabels())) {
+		builder.SetLabel(key, req.GetLabels()[key])
+	}
+	for _, key := range req.GetUnsetLabels() {
+		builder.UnsetLabel(key)
+	}
+	for _, key := range slices.Sorted(maps.Keys(req.GetAnnotations())) {
+		builder.SetAnnotation(key, req.GetAnnotations()[key])
+	}
+	for _, key := range req.GetUnsetAnnotations() {
+		builder.UnsetAnnotation(key)
+	}
+	if req.Entrypoint != nil {
+		builder.SetEntrypoint(req.GetEntrypoint().GetValues())
+	}
+	if req.Cmd != nil {
+		builder.SetCmd(req.GetCmd().GetValues())
+	}
+	for _, port := range req.GetPorts() {
+		builder.SetPort(port)
+	}
+	for _, volume := range req.GetVolumes() {
+		builder.AddVolume(volume)
+	}
+	if err := builder.Save(); err != nil {
+		return nil, err
+	}
+	return &pb.ConfigResponse{}, nil
+}
+
+func (b *buildServer) Commit(req *pb.CommitRequest, stream grpc.ServerStreamingServer[pb.CommitResponse]) error {
+	s := &sender[pb.CommitResponse]{
+		stream: stream,
+		progress: func(p *pb.Progress) *pb.CommitResponse {
+			return &pb.CommitResponse{Event: &pb.CommitResponse_Progress{Progress: p}}
+		},
+	}
+	builder, unlock, err := b.lockBuilder(req.GetContainer())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	format, err := imageFormat(req.GetFormat())
+	if err != nil {
+		return err
+	}
+	var dest types.ImageReference
+	if req.GetImage() != "" {
+		if dest, err = alltransports.ParseImageName(req.GetImage()); err != nil {
+			if dest, err = is.Transport.ParseStoreReference(b.store, req.GetImage()); err != nil {
+				return invalidArgument("parsing target image name %q: %v", req.GetImage(), err)
+			}
+		}
+	}
+	options := buildah.CommitOptions{
+		PreferredManifestType: format,
+		SignaturePolicyPath:   b.options.SignaturePolicyPath,
+		SystemContext:         b.systemContext(),
+		Squash:                req.GetSquash(),
+		ReportWriter:          s.progressWriter(req.GetQuiet()),
+	}
+	results, err := builder.CommitResults(stream.Context(), dest, options)
+	if err != nil {
+		return err
+	}
+	if req.GetRm() {
+		if err := builder.Delete(); err != nil {
+			return err
+		}
+	}
+	result := &pb.CommitResult{
+		ImageId:        results.ImageID,
+		ManifestDigest: results.Digest.String(),
+	}
+	return s.send(&pb.CommitResponse{Event: &pb.CommitResponse_Result{Result: result}})
+}
+
+func (b *buildServer) Build(req *pb.BuildRequest, stream grpc.ServerStreamingServer[pb.BuildResponse]) error {
+	if req.GetContextDirectory() == "" {
+		return invalidArgument("a build context directory must be specified")
+	}
+	s := &sender[pb.BuildResponse]{
+		stream: stream,
+		output: func(o *pb.Output) *pb.BuildResponse {
+			return &pb.BuildResponse{Event: &pb.BuildResponse_Output{Output: o}}
+		},
+		progress: func(p *pb.Progress) *pb.BuildResponse {
+			return &pb.BuildResponse{Event: &pb.BuildResponse_Progress{Progress: p}}
+		},
+	}
+	policy, err := pullPolicy(req.GetPull())
+	if err != nil {
+		return err
+	}
+	format, err := imageFormat(req.GetFormat())
+	if err != nil {
+		return err
+	}
+	containerfiles := req.GetContainerfiles()
+	if len(containerfiles) == 0 {
+		containerfile, err := util.DiscoverContainerfile(req.GetContextDirectory())
+		if err != nil {
+			return invalidArgument("%v", err)
+		}
+		containerfiles = []string{containerfile}
+	}
+	var labels, annotations []string
+	for _, key := range slices.Sorted(maps.Keys(req.GetLabels())) {
+		labels = append(labels, key+"="+req.GetLabels()[key])
+	}
+	for _, key := range slices.Sorted(maps.Keys(req.GetAnnotations())) {
+		annotations = append(annotations, key+"="+req.GetAnnotations()[key])
+	}
+	options := define.BuildOptions{
+		ContextDirectory:        req.GetContextDirectory(),
+		PullPolicy:              policy,
+		Args:                    req.GetBuildArgs(),
+		Target:                  req.GetTarget(),
+		Layers:                  req.GetLayers(),
+		NoCache:                 req.GetNoCache(),
+		OutputFormat:            format,
+		Labels:                  labels,
+		Annotations:             annotations,
+		Isolation:               b.options.Isolation,
+		NetworkInterface:        b.options.NetworkInterface,
+		CommonBuildOpts:         &define.CommonBuildOptions{},
+		SignaturePolicyPath:     b.options.SignaturePolicyPath,
+		SystemContext:           b.systemContext(),
+		DefaultMountsFilePath:   b.options.DefaultMountsFilePath,
+		RemoveIntermediateCtrs:  true,
+		ForceRmIntermediateCtrs: true,
+		Quiet:                   req.GetQuiet(),
+		Out:                     s.outputWriter(pb.Output_STREAM_STDOUT),
+		Err:                     s.outputWriter(pb.Output_STREAM_STDERR),
+		ReportWriter:            s.progressWriter(req.GetQuiet()),
+	}
+	if tags := req.GetTags(); len(tags) > 0 {
+		options.Output = tags[0]
+		options.AdditionalTags = tags[1:]
+	}
+	imageID, ref, err := imagebuildah.BuildDockerfiles(stream.Context(), b.store, options, containerfiles...)
+	if err != nil {
+		return err
+	}
+	result := &pb.BuildResult{ImageId: imageID}
+	if ref != nil {
+		result.Reference = ref.String()
+	}
+	return s.send(&pb.BuildResponse{Event: &pb.BuildResponse_Result{Result: result}})
+}
+
+func (b *buildServer) Push(req *pb.PushRequest, stream grpc.ServerStreamingServer[pb.PushResponse]) error {
+	if req.GetImage() == "" {
+		return invalidArgument("an image must be specified")
+	}
+	s := &sender[pb.PushResponse]{
+		stream: stream,
+		progress: func(p *pb.Progress) *pb.PushResponse {
+			return &pb.PushResponse{Event: &pb.PushResponse_Progress{Progress: p}}
+		},
+	}
+	destSpec := req.GetDestination()
+	if destSpec == "" {
+		destSpec = req.GetImage()
+	}
+	dest, err := alltransports.ParseImageName(destSpec)
+	if err != nil {
+		if strings.Contains(destSpec, "://") {
+			return invalidArgument("parsing destination %q: %v", destSpec, err)
+		}
+		if dest, err = alltransports.ParseImageName("docker://" + destSpec); err != nil {
+			return invalidArgument("parsing destination %q: %v", destSpec, err)
+		}
+	}
+	var manifestType string
+	switch req.GetFormat() {
+	case "":
+	case "oci":
+		manifestType = define.OCIv1ImageManifest
+	case "v2s1":
+		manifestType = manifest.DockerV2Schema1SignedMediaType
+	case "v2s2", "docker":
+		manifestType = define.Dockerv2ImageManifest
+	default:
+		return invalidArgument("unrecognized manifest format %q", req.GetFormat())
+	}
+	options := buildah.PushOptions{
+		Compression:         define.Gzip,
+		ManifestType:        manifestType,
+		SignaturePolicyPath: b.options.SignaturePolicyPath,
+		Store:               b.store,
+		SystemContext:       b.systemContext(),
+		RemoveSignatures:    req.GetRemoveSignatures(),
+		ReportWriter:        s.progressWriter(req.GetQuiet()),
+	}
+	ref, digest, err := buildah.Push(stream.Context(), req.GetImage(), dest, options)
+	if err != nil {
+		return fmt.Errorf("pushing image %q to %q: %w", req.GetImage(), destSpec, err)
+	}
+	result := &pb.PushResult{ManifestDigest: digest.String()}
+	if ref != nil {
+		result.Reference = ref.String()
+	}
+	return s.send(&pb.PushResponse{Event: &pb.PushResponse_Result{Result: result}})
+}
+
+// systemContext returns a copy of the service's SystemContext which a request
+// can modify.
+func (b *buildServer) systemContext() *types.SystemContext {
+	if b.options.SystemContext == nil {
+		return &types.SystemContext{}
+	}
+	systemContext := *b.options.SystemContext
+	return &systemContext
+}
@@ -0,0 +1,2145 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.19.6
+// source: build.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Output_Stream int32
+
+const (
+	Output_STREAM_UNSPECIFIED Output_Stream = 0
+	Output_STREAM_STDOUT      Output_Stream = 1
+	Output_STREAM_STDERR      Output_Stream = 2
+)
+
+// Enum value maps for Output_Stream.
+var (
+	Output_Stream_name = map[int32]string{
+		0: "STREAM_UNSPECIFIED",
+		1: "STREAM_STDOUT",
+		2: "STREAM_STDERR",
+	}
+	Output_Stream_value = map[string]int32{
+		"STREAM_UNSPECIFIED": 0,
+		"STREAM_STDOUT":      1,
+		"STREAM_STDERR":      2,
+	}
+)
+
+func (x Output_Stream) Enum() *Output_Stream {
+	p := new(Output_Stream)
+	*p = x
+	return p
+}
+
+func (x Output_Stream) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Output_Stream) Descriptor() protoreflect.EnumDescriptor {
+	return file_build_proto_enumTypes[0].Descriptor()
+}
+
+func (Output_Stream) Type() protoreflect.EnumType {
+	return &file_build_proto_enumTypes[0]
+}
+
+func (x Output_Stream) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Output_Stream.Descriptor instead.
+func (Output_Stream) EnumDescriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// Output is a chunk of output from a command or a build.
+type Output struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        Output_Stream          `protobuf:"varint,1,opt,name=stream,proto3,enum=io.buildah.v1.Output_Stream" json:"stream,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Output) Reset() {
+	*x = Output{}
+	mi := &file_build_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Output) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Output) ProtoMessage() {}
+
+func (x *Output) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Output.ProtoReflect.Descriptor instead.
+func (*Output) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Output) GetStream() Output_Stream {
+	if x != nil {
+		return x.Stream
+	}
+	return Output_STREAM_UNSPECIFIED
+}
+
+func (x *Output) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Progress is a chunk of text describing the progress of pulling, copying or
+// writing an image.
+type Progress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_build_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Progress) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// StringList is a list of strings which can be distinguished from an unset
+// list.
+type StringList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StringList) Reset() {
+	*x = StringList{}
+	mi := &file_build_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StringList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StringList) ProtoMessage() {}
+
+func (x *StringList) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StringList.ProtoReflect.Descriptor instead.
+func (*StringList) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StringList) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type FromRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The image to start from, or "scratch".
+	Image string `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	// The name to give the working container.  If not set, one is generated.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The pull policy: "missing" (the default), "always", "ifnewer", or "never".
+	Pull string `protobuf:"bytes,3,opt,name=pull,proto3" json:"pull,omitempty"`
+	// The platform to pull an image for, as os/arch[/variant].
+	Platform string `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
+	// Don't report progress while pulling the image.
+	Quiet         bool `protobuf:"varint,5,opt,name=quiet,proto3" json:"quiet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FromRequest) Reset() {
+	*x = FromRequest{}
+	mi := &file_build_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FromRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FromRequest) ProtoMessage() {}
+
+func (x *FromRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FromRequest.ProtoReflect.Descriptor instead.
+func (*FromRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FromRequest) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *FromRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FromRequest) GetPull() string {
+	if x != nil {
+		return x.Pull
+	}
+	return ""
+}
+
+func (x *FromRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *FromRequest) GetQuiet() bool {
+	if x != nil {
+		return x.Quiet
+	}
+	return false
+}
+
+type FromResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContainerName string                 `protobuf:"bytes,1,opt,name=container_name,json=containerName,proto3" json:"container_name,omitempty"`
+	ContainerId   string                 `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	ImageId       string                 `protobuf:"bytes,3,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FromResult) Reset() {
+	*x = FromResult{}
+	mi := &file_build_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FromResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FromResult) ProtoMessage() {}
+
+func (x *FromResult) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FromResult.ProtoReflect.Descriptor instead.
+func (*FromResult) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FromResult) GetContainerName() string {
+	if x != nil {
+		return x.ContainerName
+	}
+	return ""
+}
+
+func (x *FromResult) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *FromResult) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+type FromResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*FromResponse_Progress
+	//	*FromResponse_Result
+	Event         isFromResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FromResponse) Reset() {
+	*x = FromResponse{}
+	mi := &file_build_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FromResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FromResponse) ProtoMessage() {}
+
+func (x *FromResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FromResponse.ProtoReflect.Descriptor instead.
+func (*FromResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FromResponse) GetEvent() isFromResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *FromResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*FromResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *FromResponse) GetResult() *FromResult {
+	if x != nil {
+		if x, ok := x.Event.(*FromResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isFromResponse_Event interface {
+	isFromResponse_Event()
+}
+
+type FromResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type FromResponse_Result struct {
+	Result *FromResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*FromResponse_Progress) isFromResponse_Event() {}
+
+func (*FromResponse_Result) isFromResponse_Event() {}
+
+type RunRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name or ID of the working container.
+	Container string `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	// The command to run, and its arguments.
+	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// Additional environment variables, in KEY=VALUE form.
+	Env []string `protobuf:"bytes,3,rep,name=env,proto3" json:"env,omitempty"`
+	// The working directory to run the command in.
+	Workdir string `protobuf:"bytes,4,opt,name=workdir,proto3" json:"workdir,omitempty"`
+	// The user to run the command as.
+	User string `protobuf:"bytes,5,opt,name=user,proto3" json:"user,omitempty"`
+	// The isolation type: "oci", "rootless", or "chroot".
+	Isolation string `protobuf:"bytes,6,opt,name=isolation,proto3" json:"isolation,omitempty"`
+	// Contents to supply to the command on its standard input.
+	Stdin []byte `protobuf:"bytes,7,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// Add a history entry for the command to the working container.
+	AddHistory    bool `protobuf:"varint,8,opt,name=add_history,json=addHistory,proto3" json:"add_history,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunRequest) Reset() {
+	*x = RunRequest{}
+	mi := &file_build_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunRequest) ProtoMessage() {}
+
+func (x *RunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunRequest.ProtoReflect.Descriptor instead.
+func (*RunRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RunRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *RunRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *RunRequest) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *RunRequest) GetWorkdir() string {
+	if x != nil {
+		return x.Workdir
+	}
+	return ""
+}
+
+func (x *RunRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RunRequest) GetIsolation() string {
+	if x != nil {
+		return x.Isolation
+	}
+	return ""
+}
+
+func (x *RunRequest) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *RunRequest) GetAddHistory() bool {
+	if x != nil {
+		return x.AddHistory
+	}
+	return false
+}
+
+type RunResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunResult) Reset() {
+	*x = RunResult{}
+	mi := &file_build_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunResult) ProtoMessage() {}
+
+func (x *RunResult) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunResult.ProtoReflect.Descriptor instead.
+func (*RunResult) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{7}
+}
+
+type RunResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*RunResponse_Output
+	//	*RunResponse_Result
+	Event         isRunResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunResponse) Reset() {
+	*x = RunResponse{}
+	mi := &file_build_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunResponse) ProtoMessage() {}
+
+func (x *RunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunResponse.ProtoReflect.Descriptor instead.
+func (*RunResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RunResponse) GetEvent() isRunResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *RunResponse) GetOutput() *Output {
+	if x != nil {
+		if x, ok := x.Event.(*RunResponse_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *RunResponse) GetResult() *RunResult {
+	if x != nil {
+		if x, ok := x.Event.(*RunResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isRunResponse_Event interface {
+	isRunResponse_Event()
+}
+
+type RunResponse_Output struct {
+	Output *Output `protobuf:"bytes,1,opt,name=output,proto3,oneof"`
+}
+
+type RunResponse_Result struct {
+	Result *RunResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*RunResponse_Output) isRunResponse_Event() {}
+
+func (*RunResponse_Result) isRunResponse_Event() {}
+
+type CopyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name or ID of the working container.
+	Container string `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	// Locations of content to copy, which are read by the server, and URLs.
+	Sources []string `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	// The location in the working container to copy the content to.
+	Destination string `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	// Extract local archives and download URLs, as ADD would.
+	Add bool `protobuf:"varint,4,opt,name=add,proto3" json:"add,omitempty"`
+	// The owner to set on copied content, as user[:group].
+	Chown string `protobuf:"bytes,5,opt,name=chown,proto3" json:"chown,omitempty"`
+	// The permissions to set on copied content, in octal.
+	Chmod string `protobuf:"bytes,6,opt,name=chmod,proto3" json:"chmod,omitempty"`
+	// The directory which sources are relative to.
+	ContextDirectory string `protobuf:"bytes,7,opt,name=context_directory,json=contextDirectory,proto3" json:"context_directory,omitempty"`
+	// Patterns of content which should not be copied.
+	Excludes []string `protobuf:"bytes,8,rep,name=excludes,proto3" json:"excludes,omitempty"`
+	// Add a history entry for the copy to the working container.
+	AddHistory    bool `protobuf:"varint,9,opt,name=add_history,json=addHistory,proto3" json:"add_history,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyRequest) Reset() {
+	*x = CopyRequest{}
+	mi := &file_build_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyRequest) ProtoMessage() {}
+
+func (x *CopyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyRequest.ProtoReflect.Descriptor instead.
+func (*CopyRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CopyRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *CopyRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetAdd() bool {
+	if x != nil {
+		return x.Add
+	}
+	return false
+}
+
+func (x *CopyRequest) GetChown() string {
+	if x != nil {
+		return x.Chown
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetChmod() string {
+	if x != nil {
+		return x.Chmod
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetContextDirectory() string {
+	if x != nil {
+		return x.ContextDirectory
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetExcludes() []string {
+	if x != nil {
+		return x.Excludes
+	}
+	return nil
+}
+
+func (x *CopyRequest) GetAddHistory() bool {
+	if x != nil {
+		return x.AddHistory
+	}
+	return false
+}
+
+type CopyResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The digest of the content which was copied.
+	Digest        string `protobuf:"bytes,1,opt,name=digest,proto3" json:"digest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyResult) Reset() {
+	*x = CopyResult{}
+	mi := &file_build_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyResult) ProtoMessage() {}
+
+func (x *CopyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyResult.ProtoReflect.Descriptor instead.
+func (*CopyResult) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CopyResult) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+type CopyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*CopyResponse_Progress
+	//	*CopyResponse_Result
+	Event         isCopyResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyResponse) Reset() {
+	*x = CopyResponse{}
+	mi := &file_build_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyResponse) ProtoMessage() {}
+
+func (x *CopyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyResponse.ProtoReflect.Descriptor instead.
+func (*CopyResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CopyResponse) GetEvent() isCopyResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CopyResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*CopyResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *CopyResponse) GetResult() *CopyResult {
+	if x != nil {
+		if x, ok := x.Event.(*CopyResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isCopyResponse_Event interface {
+	isCopyResponse_Event()
+}
+
+type CopyResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type CopyResponse_Result struct {
+	Result *CopyResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*CopyResponse_Progress) isCopyResponse_Event() {}
+
+func (*CopyResponse_Result) isCopyResponse_Event() {}
+
+type ConfigRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name or ID of the working container.
+	Container  string  `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	Author     *string `protobuf:"bytes,2,opt,name=author,proto3,oneof" json:"author,omitempty"`
+	User       *string `protobuf:"bytes,3,opt,name=user,proto3,oneof" json:"user,omitempty"`
+	Workdir    *string `protobuf:"bytes,4,opt,name=workdir,proto3,oneof" json:"workdir,omitempty"`
+	StopSignal *string `protobuf:"bytes,5,opt,name=stop_signal,json=stopSignal,proto3,oneof" json:"stop_signal,omitempty"`
+	// Environment variables to set, in KEY=VALUE form.
+	Env              []string          `protobuf:"bytes,6,rep,name=env,proto3" json:"env,omitempty"`
+	UnsetEnv         []string          `protobuf:"bytes,7,rep,name=unset_env,json=unsetEnv,proto3" json:"unset_env,omitempty"`
+	Labels           map[string]string `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UnsetLabels      []string          `protobuf:"bytes,9,rep,name=unset_labels,json=unsetLabels,proto3" json:"unset_labels,omitempty"`
+	Annotations      map[string]string `protobuf:"bytes,10,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UnsetAnnotations []string          `protobuf:"bytes,11,rep,name=unset_annotations,json=unsetAnnotations,proto3" json:"unset_annotations,omitempty"`
+	Entrypoint       *StringList       `protobuf:"bytes,12,opt,name=entrypoint,proto3" json:"entrypoint,omitempty"`
+	Cmd              *StringList       `protobuf:"bytes,13,opt,name=cmd,proto3" json:"cmd,omitempty"`
+	// Ports to expose, as port[/protocol].
+	Ports         []string `protobuf:"bytes,14,rep,name=ports,proto3" json:"ports,omitempty"`
+	Volumes       []string `protobuf:"bytes,15,rep,name=volumes,proto3" json:"volumes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigRequest) Reset() {
+	*x = ConfigRequest{}
+	mi := &file_build_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigRequest) ProtoMessage() {}
+
+func (x *ConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigRequest.ProtoReflect.Descriptor instead.
+func (*ConfigRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ConfigRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *ConfigRequest) GetAuthor() string {
+	if x != nil && x.Author != nil {
+		return *x.Author
+	}
+	return ""
+}
+
+func (x *ConfigRequest) GetUser() string {
+	if x != nil && x.User != nil {
+		return *x.User
+	}
+	return ""
+}
+
+func (x *ConfigRequest) GetWorkdir() string {
+	if x != nil && x.Workdir != nil {
+		return *x.Workdir
+	}
+	return ""
+}
+
+func (x *ConfigRequest) GetStopSignal() string {
+	if x != nil && x.StopSignal != nil {
+		return *x.StopSignal
+	}
+	return ""
+}
+
+func (x *ConfigRequest) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetUnsetEnv() []string {
+	if x != nil {
+		return x.UnsetEnv
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetUnsetLabels() []string {
+	if x != nil {
+		return x.UnsetLabels
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetAnnotations() map[string]string {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetUnsetAnnotations() []string {
+	if x != nil {
+		return x.UnsetAnnotations
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetEntrypoint() *StringList {
+	if x != nil {
+		return x.Entrypoint
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetCmd() *StringList {
+	if x != nil {
+		return x.Cmd
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetPorts() []string {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *ConfigRequest) GetVolumes() []string {
+	if x != nil {
+		return x.Volumes
+	}
+	return nil
+}
+
+type ConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigResponse) Reset() {
+	*x = ConfigResponse{}
+	mi := &file_build_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigResponse) ProtoMessage() {}
+
+func (x *ConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigResponse.ProtoReflect.Descriptor instead.
+func (*ConfigResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{13}
+}
+
+type CommitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name or ID of the working container.
+	Container string `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	// The name of the image to write.  If not set, the image is not named.
+	Image string `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	// The image format: "oci" (the default) or "docker".
+	Format string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	// Squash all of the image's layers into one.
+	Squash bool `protobuf:"varint,4,opt,name=squash,proto3" json:"squash,omitempty"`
+	// Remove the working container after committing it.
+	Rm bool `protobuf:"varint,5,opt,name=rm,proto3" json:"rm,omitempty"`
+	// Don't report progress while writing the image.
+	Quiet         bool `protobuf:"varint,6,opt,name=quiet,proto3" json:"quiet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitRequest) Reset() {
+	*x = CommitRequest{}
+	mi := &file_build_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitRequest) ProtoMessage() {}
+
+func (x *CommitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitRequest.ProtoReflect.Descriptor instead.
+func (*CommitRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CommitRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetSquash() bool {
+	if x != nil {
+		return x.Squash
+	}
+	return false
+}
+
+func (x *CommitRequest) GetRm() bool {
+	if x != nil {
+		return x.Rm
+	}
+	return false
+}
+
+func (x *CommitRequest) GetQuiet() bool {
+	if x != nil {
+		return x.Quiet
+	}
+	return false
+}
+
+type CommitResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ImageId        string                 `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	ManifestDigest string                 `protobuf:"bytes,2,opt,name=manifest_digest,json=manifestDigest,proto3" json:"manifest_digest,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CommitResult) Reset() {
+	*x = CommitResult{}
+	mi := &file_build_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitResult) ProtoMessage() {}
+
+func (x *CommitResult) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitResult.ProtoReflect.Descriptor instead.
+func (*CommitResult) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CommitResult) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+func (x *CommitResult) GetManifestDigest() string {
+	if x != nil {
+		return x.ManifestDigest
+	}
+	return ""
+}
+
+type CommitResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*CommitResponse_Progress
+	//	*CommitResponse_Result
+	Event         isCommitResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitResponse) Reset() {
+	*x = CommitResponse{}
+	mi := &file_build_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitResponse) ProtoMessage() {}
+
+func (x *CommitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitResponse.ProtoReflect.Descriptor instead.
+func (*CommitResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CommitResponse) GetEvent() isCommitResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CommitResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*CommitResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *CommitResponse) GetResult() *CommitResult {
+	if x != nil {
+		if x, ok := x.Event.(*CommitResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isCommitResponse_Event interface {
+	isCommitResponse_Event()
+}
+
+type CommitResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type CommitResponse_Result struct {
+	Result *CommitResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*CommitResponse_Progress) isCommitResponse_Event() {}
+
+func (*CommitResponse_Result) isCommitResponse_Event() {}
+
+type BuildRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The build context directory, which is read by the server.
+	ContextDirectory string `protobuf:"bytes,1,opt,name=context_directory,json=contextDirectory,proto3" json:"context_directory,omitempty"`
+	// Containerfiles to build.  If not set, the context directory is searched
+	// for a Containerfile or Dockerfile.
+	Containerfiles []string `protobuf:"bytes,2,rep,name=containerfiles,proto3" json:"containerfiles,omitempty"`
+	// Names to give the built image.
+	Tags      []string          `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	BuildArgs map[string]string `protobuf:"bytes,4,rep,name=build_args,json=buildArgs,proto3" json:"build_args,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// The stage to build.  If not set, the last stage is built.
+	Target string `protobuf:"bytes,5,opt,name=target,proto3" json:"target,omitempty"`
+	// Cache intermediate images.
+	Layers bool `protobuf:"varint,6,opt,name=layers,proto3" json:"layers,omitempty"`
+	// Don't use previously-cached intermediate images.
+	NoCache bool `protobuf:"varint,7,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
+	// The pull policy: "missing" (the default), "always", "ifnewer", or "never".
+	Pull string `protobuf:"bytes,8,opt,name=pull,proto3" json:"pull,omitempty"`
+	// The image format: "oci" (the default) or "docker".
+	Format      string            `protobuf:"bytes,9,opt,name=format,proto3" json:"format,omitempty"`
+	Labels      map[string]string `protobuf:"bytes,10,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Annotations map[string]string `protobuf:"bytes,11,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Don't report progress, or the instructions being processed.
+	Quiet         bool `protobuf:"varint,12,opt,name=quiet,proto3" json:"quiet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildRequest) Reset() {
+	*x = BuildRequest{}
+	mi := &file_build_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildRequest) ProtoMessage() {}
+
+func (x *BuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildRequest.ProtoReflect.Descriptor instead.
+func (*BuildRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BuildRequest) GetContextDirectory() string {
+	if x != nil {
+		return x.ContextDirectory
+	}
+	return ""
+}
+
+func (x *BuildRequest) GetContainerfiles() []string {
+	if x != nil {
+		return x.Containerfiles
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetBuildArgs() map[string]string {
+	if x != nil {
+		return x.BuildArgs
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *BuildRequest) GetLayers() bool {
+	if x != nil {
+		return x.Layers
+	}
+	return false
+}
+
+func (x *BuildRequest) GetNoCache() bool {
+	if x != nil {
+		return x.NoCache
+	}
+	return false
+}
+
+func (x *BuildRequest) GetPull() string {
+	if x != nil {
+		return x.Pull
+	}
+	return ""
+}
+
+func (x *BuildRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *BuildRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetAnnotations() map[string]string {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetQuiet() bool {
+	if x != nil {
+		return x.Quiet
+	}
+	return false
+}
+
+type BuildResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ImageId string                 `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	// A reference to the built image which includes its digest, if it was
+	// given a name.
+	Reference     string `protobuf:"bytes,2,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildResult) Reset() {
+	*x = BuildResult{}
+	mi := &file_build_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildResult) ProtoMessage() {}
+
+func (x *BuildResult) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildResult.ProtoReflect.Descriptor instead.
+func (*BuildResult) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BuildResult) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+func (x *BuildResult) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+type BuildResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*BuildResponse_Output
+	//	*BuildResponse_Progress
+	//	*BuildResponse_Result
+	Event         isBuildResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildResponse) Reset() {
+	*x = BuildResponse{}
+	mi := &file_build_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildResponse) ProtoMessage() {}
+
+func (x *BuildResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildResponse.ProtoReflect.Descriptor instead.
+func (*BuildResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BuildResponse) GetEvent() isBuildResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *BuildResponse) GetOutput() *Output {
+	if x != nil {
+		if x, ok := x.Event.(*BuildResponse_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *BuildResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*BuildResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *BuildResponse) GetResult() *BuildResult {
+	if x != nil {
+		if x, ok := x.Event.(*BuildResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isBuildResponse_Event interface {
+	isBuildResponse_Event()
+}
+
+type BuildResponse_Output struct {
+	Output *Output `protobuf:"bytes,1,opt,name=output,proto3,oneof"`
+}
+
+type BuildResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,2,opt,name=progress,proto3,oneof"`
+}
+
+type BuildResponse_Result struct {
+	Result *BuildResult `protobuf:"bytes,3,opt,name=result,proto3,oneof"`
+}
+
+func (*BuildResponse_Output) isBuildResponse_Event() {}
+
+func (*BuildResponse_Progress) isBuildResponse_Event() {}
+
+func (*BuildResponse_Result) isBuildResponse_Event() {}
+
+type PushRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name or ID of the image in local storage.
+	Image string `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	// The destination, which is assumed to be a registry if it does not
+	// include a transport.  If not set, the image's name is used.
+	Destination string `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	// The manifest format: "oci", "v2s1", or "v2s2".  If not set, the
+	// image's format is preserved.
+	Format string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	// Don't copy signatures of the image.
+	RemoveSignatures bool `protobuf:"varint,4,opt,name=remove_signatures,json=removeSignatures,proto3" json:"remove_signatures,omitempty"`
+	// Don't report progress while writing the image.
+	Quiet         bool `protobuf:"varint,5,opt,name=quiet,proto3" json:"quiet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushRequest) Reset() {
+	*x = PushRequest{}
+	mi := &file_build_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushRequest) ProtoMessage() {}
+
+func (x *PushRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushRequest.ProtoReflect.Descriptor instead.
+func (*PushRequest) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *PushRequest) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *PushRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *PushRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *PushRequest) GetRemoveSignatures() bool {
+	if x != nil {
+		return x.RemoveSignatures
+	}
+	return false
+}
+
+func (x *PushRequest) GetQuiet() bool {
+	if x != nil {
+		return x.Quiet
+	}
+	return false
+}
+
+type PushResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ManifestDigest string                 `protobuf:"bytes,1,opt,name=manifest_digest,json=manifestDigest,proto3" json:"manifest_digest,omitempty"`
+	Reference      string                 `protobuf:"bytes,2,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PushResult) Reset() {
+	*x = PushResult{}
+	mi := &file_build_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushResult) ProtoMessage() {}
+
+func (x *PushResult) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushResult.ProtoReflect.Descriptor instead.
+func (*PushResult) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *PushResult) GetManifestDigest() string {
+	if x != nil {
+		return x.ManifestDigest
+	}
+	return ""
+}
+
+func (x *PushResult) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+type PushResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*PushResponse_Progress
+	//	*PushResponse_Result
+	Event         isPushResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushResponse) Reset() {
+	*x = PushResponse{}
+	mi := &file_build_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushResponse) ProtoMessage() {}
+
+func (x *PushResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_build_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushResponse.ProtoReflect.Descriptor instead.
+func (*PushResponse) Descriptor() ([]byte, []int) {
+	return file_build_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *PushResponse) GetEvent() isPushResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *PushResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*PushResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *PushResponse) GetResult() *PushResult {
+	if x != nil {
+		if x, ok := x.Event.(*PushResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isPushResponse_Event interface {
+	isPushResponse_Event()
+}
+
+type PushResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type PushResponse_Result struct {
+	Result *PushResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*PushResponse_Progress) isPushResponse_Event() {}
+
+func (*PushResponse_Result) isPushResponse_Event() {}
+
+var File_build_proto protoreflect.FileDescriptor
+
+const file_build_proto_rawDesc = "" +
+	"\n" +
+	"\vbuild.proto\x12\rio.buildah.v1\"\x9a\x01\n" +
+	"\x06Output\x124\n" +
+	"\x06stream\x18\x01 \x01(\x0e2\x1c.io.buildah.v1.Output.StreamR\x06stream\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"F\n" +
+	"\x06Stream\x12\x16\n" +
+	"\x12STREAM_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTREAM_STDOUT\x10\x01\x12\x11\n" +
+	"\rSTREAM_STDERR\x10\x02\"\x1e\n" +
+	"\bProgress\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"$\n" +
+	"\n" +
+	"StringList\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"}\n" +
+	"\vFromRequest\x12\x14\n" +
+	"\x05image\x18\x01 \x01(\tR\x05image\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04pull\x18\x03 \x01(\tR\x04pull\x12\x1a\n" +
+	"\bplatform\x18\x04 \x01(\tR\bplatform\x12\x14\n" +
+	"\x05quiet\x18\x05 \x01(\bR\x05quiet\"q\n" +
+	"\n" +
+	"FromResult\x12%\n" +
+	"\x0econtainer_name\x18\x01 \x01(\tR\rcontainerName\x12!\n" +
+	"\fcontainer_id\x18\x02 \x01(\tR\vcontainerId\x12\x19\n" +
+	"\bimage_id\x18\x03 \x01(\tR\aimageId\"\x83\x01\n" +
+	"\fFromResponse\x125\n" +
+	"\bprogress\x18\x01 \x01(\v2\x17.io.buildah.v1.ProgressH\x00R\bprogress\x123\n" +
+	"\x06result\x18\x02 \x01(\v2\x19.io.buildah.v1.FromResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\xd3\x01\n" +
+	"\n" +
+	"RunRequest\x12\x1c\n" +
+	"\tcontainer\x18\x01 \x01(\tR\tcontainer\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x12\x10\n" +
+	"\x03env\x18\x03 \x03(\tR\x03env\x12\x18\n" +
+	"\aworkdir\x18\x04 \x01(\tR\aworkdir\x12\x12\n" +
+	"\x04user\x18\x05 \x01(\tR\x04user\x12\x1c\n" +
+	"\tisolation\x18\x06 \x01(\tR\tisolation\x12\x14\n" +
+	"\x05stdin\x18\a \x01(\fR\x05stdin\x12\x1f\n" +
+	"\vadd_history\x18\b \x01(\bR\n" +
+	"addHistory\"\v\n" +
+	"\tRunResult\"{\n" +
+	"\vRunResponse\x12/\n" +
+	"\x06output\x18\x01 \x01(\v2\x15.io.buildah.v1.OutputH\x00R\x06output\x122\n" +
+	"\x06result\x18\x02 \x01(\v2\x18.io.buildah.v1.RunResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\x8f\x02\n" +
+	"\vCopyRequest\x12\x1c\n" +
+	"\tcontainer\x18\x01 \x01(\tR\tcontainer\x12\x18\n" +
+	"\asources\x18\x02 \x03(\tR\asources\x12 \n" +
+	"\vdestination\x18\x03 \x01(\tR\vdestination\x12\x10\n" +
+	"\x03add\x18\x04 \x01(\bR\x03add\x12\x14\n" +
+	"\x05chown\x18\x05 \x01(\tR\x05chown\x12\x14\n" +
+	"\x05chmod\x18\x06 \x01(\tR\x05chmod\x12+\n" +
+	"\x11context_directory\x18\a \x01(\tR\x10contextDirectory\x12\x1a\n" +
+	"\bexcludes\x18\b \x03(\tR\bexcludes\x12\x1f\n" +
+	"\vadd_history\x18\t \x01(\bR\n" +
+	"addHistory\"$\n" +
+	"\n" +
+	"CopyResult\x12\x16\n" +
+	"\x06digest\x18\x01 \x01(\tR\x06digest\"\x83\x01\n" +
+	"\fCopyResponse\x125\n" +
+	"\bprogress\x18\x01 \x01(\v2\x17.io.buildah.v1.ProgressH\x00R\bprogress\x123\n" +
+	"\x06result\x18\x02 \x01(\v2\x19.io.buildah.v1.CopyResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\xfd\x05\n" +
+	"\rConfigRequest\x12\x1c\n" +
+	"\tcontainer\x18\x01 \x01(\tR\tcontainer\x12\x1b\n" +
+	"\x06author\x18\x02 \x01(\tH\x00R\x06author\x88\x01\x01\x12\x17\n" +
+	"\x04user\x18\x03 \x01(\tH\x01R\x04user\x88\x01\x01\x12\x1d\n" +
+	"\aworkdir\x18\x04 \x01(\tH\x02R\aworkdir\x88\x01\x01\x12$\n" +
+	"\vstop_signal\x18\x05 \x01(\tH\x03R\n" +
+	"stopSignal\x88\x01\x01\x12\x10\n" +
+	"\x03env\x18\x06 \x03(\tR\x03env\x12\x1b\n" +
+	"\tunset_env\x18\a \x03(\tR\bunsetEnv\x12@\n" +
+	"\x06labels\x18\b \x03(\v2(.io.buildah.v1.ConfigRequest.LabelsEntryR\x06labels\x12!\n" +
+	"\funset_labels\x18\t \x03(\tR\vunsetLabels\x12O\n" +
+	"\vannotations\x18\n" +
+	" \x03(\v2-.io.buildah.v1.ConfigRequest.AnnotationsEntryR\vannotations\x12+\n" +
+	"\x11unset_annotations\x18\v \x03(\tR\x10unsetAnnotations\x129\n" +
+	"\n" +
+	"entrypoint\x18\f \x01(\v2\x19.io.buildah.v1.StringListR\n" +
+	"entrypoint\x12+\n" +
+	"\x03cmd\x18\r \x01(\v2\x19.io.buildah.v1.StringListR\x03cmd\x12\x14\n" +
+	"\x05ports\x18\x0e \x03(\tR\x05ports\x12\x18\n" +
+	"\avolumes\x18\x0f \x03(\tR\avolumes\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a>\n" +
+	"\x10AnnotationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
+	"\a_authorB\a\n" +
+	"\x05_userB\n" +
+	"\n" +
+	"\b_workdirB\x0e\n" +
+	"\f_stop_signal\"\x10\n" +
+	"\x0eConfigResponse\"\x99\x01\n" +
+	"\rCommitRequest\x12\x1c\n" +
+	"\tcontainer\x18\x01 \x01(\tR\tcontainer\x12\x14\n" +
+	"\x05image\x18\x02 \x01(\tR\x05image\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12\x16\n" +
+	"\x06squash\x18\x04 \x01(\bR\x06squash\x12\x0e\n" +
+	"\x02rm\x18\x05 \x01(\bR\x02rm\x12\x14\n" +
+	"\x05quiet\x18\x06 \x01(\bR\x05quiet\"R\n" +
+	"\fCommitResult\x12\x19\n" +
+	"\bimage_id\x18\x01 \x01(\tR\aimageId\x12'\n" +
+	"\x0fmanifest_digest\x18\x02 \x01(\tR\x0emanifestDigest\"\x87\x01\n" +
+	"\x0eCommitResponse\x125\n" +
+	"\bprogress\x18\x01 \x01(\v2\x17.io.buildah.v1.ProgressH\x00R\bprogress\x125\n" +
+	"\x06result\x18\x02 \x01(\v2\x1b.io.buildah.v1.CommitResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\x99\x05\n" +
+	"\fBuildRequest\x12+\n" +
+	"\x11context_directory\x18\x01 \x01(\tR\x10contextDirectory\x12&\n" +
+	"\x0econtainerfiles\x18\x02 \x03(\tR\x0econtainerfiles\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12I\n" +
+	"\n" +
+	"build_args\x18\x04 \x03(\v2*.io.buildah.v1.BuildRequest.BuildArgsEntryR\tbuildArgs\x12\x16\n" +
+	"\x06target\x18\x05 \x01(\tR\x06target\x12\x16\n" +
+	"\x06layers\x18\x06 \x01(\bR\x06layers\x12\x19\n" +
+	"\bno_cache\x18\a \x01(\bR\anoCache\x12\x12\n" +
+	"\x04pull\x18\b \x01(\tR\x04pull\x12\x16\n" +
+	"\x06format\x18\t \x01(\tR\x06format\x12?\n" +
+	"\x06labels\x18\n" +
+	" \x03(\v2'.io.buildah.v1.BuildRequest.LabelsEntryR\x06labels\x12N\n" +
+	"\vannotations\x18\v \x03(\v2,.io.buildah.v1.BuildRequest.AnnotationsEntryR\vannotations\x12\x14\n" +
+	"\x05quiet\x18\f \x01(\bR\x05quiet\x1a<\n" +
+	"\x0eBuildArgsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a>\n" +
+	"\x10AnnotationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"F\n" +
+	"\vBuildResult\x12\x19\n" +
+	"\bimage_id\x18\x01 \x01(\tR\aimageId\x12\x1c\n" +
+	"\treference\x18\x02 \x01(\tR\treference\"\xb6\x01\n" +
+	"\rBuildResponse\x12/\n" +
+	"\x06output\x18\x01 \x01(\v2\x15.io.buildah.v1.OutputH\x00R\x06output\x125\n" +
+	"\bprogress\x18\x02 \x01(\v2\x17.io.buildah.v1.ProgressH\x00R\bprogress\x124\n" +
+	"\x06result\x18\x03 \x01(\v2\x1a.io.buildah.v1.BuildResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\xa0\x01\n" +
+	"\vPushRequest\x12\x14\n" +
+	"\x05image\x18\x01 \x01(\tR\x05image\x12 \n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12+\n" +
+	"\x11remove_signatures\x18\x04 \x01(\bR\x10removeSignatures\x12\x14\n" +
+	"\x05quiet\x18\x05 \x01(\bR\x05quiet\"S\n" +
+	"\n" +
+	"PushResult\x12'\n" +
+	"\x0fmanifest_digest\x18\x01 \x01(\tR\x0emanifestDigest\x12\x1c\n" +
+	"\treference\x18\x02 \x01(\tR\treference\"\x83\x01\n" +
+	"\fPushResponse\x125\n" +
+	"\bprogress\x18\x01 \x01(\v2\x17.io.buildah.v1.ProgressH\x00R\bprogress\x123\n" +
+	"\x06result\x18\x02 \x01(\v2\x19.io.buildah.v1.PushResultH\x00R\x06resultB\a\n" +
+	"\x05event2\xe6\x03\n" +
+	"\x05Build\x12A\n" +
+	"\x04From\x12\x1a.io.buildah.v1.FromRequest\x1a\x1b.io.buildah.v1.FromResponse0\x01\x12>\n" +
+	"\x03Run\x12\x19.io.buildah.v1.RunRequest\x1a\x1a.io.buildah.v1.RunResponse0\x01\x12A\n" +
+	"\x04Copy\x12\x1a.io.buildah.v1.CopyRequest\x1a\x1b.io.buildah.v1.CopyResponse0\x01\x12E\n" +
+	"\x06Config\x12\x1c.io.buildah.v1.ConfigRequest\x1a\x1d.io.buildah.v1.ConfigResponse\x12G\n" +
+	"\x06Commit\x12\x1c.io.buildah.v1.CommitRequest\x1a\x1d.io.buildah.v1.CommitResponse0\x01\x12D\n" +
+	"\x05Build\x12\x1b.io.buildah.v1.BuildRequest\x1a\x1c.io.buildah.v1.BuildResponse0\x01\x12A\n" +
+	"\x04Push\x12\x1a.io.buildah.v1.PushRequest\x1a\x1b.io.buildah.v1.PushResponse0\x01B,Z*go.podman.io/buildah/internal/rpc/build/pbb\x06proto3"
+
+var (
+	file_build_proto_rawDescOnce sync.Once
+	file_build_proto_rawDescData []byte
+)
+
+func file_build_proto_rawDescGZIP() []byte {
+	file_build_proto_rawDescOnce.Do(func() {
+		file_build_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_build_proto_rawDesc), len(file_build_proto_rawDesc)))
+	})
+	return file_build_proto_rawDescData
+}
+
+var file_build_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_build_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_build_proto_goTypes = []any{
+	(Output_Stream)(0),     // 0: io.buildah.v1.Output.Stream
+	(*Output)(nil),         // 1: io.buildah.v1.Output
+	(*Progress)(nil),       // 2: io.buildah.v1.Progress
+	(*StringList)(nil),     // 3: io.buildah.v1.StringList
+	(*FromRequest)(nil),    // 4: io.buildah.v1.FromRequest
+	(*FromResult)(nil),     // 5: io.buildah.v1.FromResult
+	(*FromResponse)(nil),   // 6: io.buildah.v1.FromResponse
+	(*RunRequest)(nil),     // 7: io.buildah.v1.RunRequest
+	(*RunResult)(nil),      // 8: io.buildah.v1.RunResult
+	(*RunResponse)(nil),    // 9: io.buildah.v1.RunResponse
+	(*CopyRequest)(nil),    // 10: io.buildah.v1.CopyRequest
+	(*CopyResult)(nil),     // 11: io.buildah.v1.CopyResult
+	(*CopyResponse)(nil),   // 12: io.buildah.v1.CopyResponse
+	(*ConfigRequest)(nil),  // 13: io.buildah.v1.ConfigRequest
+	(*ConfigResponse)(nil), // 14: io.buildah.v1.ConfigResponse
+	(*CommitRequest)(nil),  // 15: io.buildah.v1.CommitRequest
+	(*CommitResult)(nil),   // 16: io.buildah.v1.CommitResult
+	(*CommitResponse)(nil), // 17: io.buildah.v1.CommitResponse
+	(*BuildRequest)(nil),   // 18: io.buildah.v1.BuildRequest
+	(*BuildResult)(nil),    // 19: io.buildah.v1.BuildResult
+	(*BuildResponse)(nil),  // 20: io.buildah.v1.BuildResponse
+	(*PushRequest)(nil),    // 21: io.buildah.v1.PushRequest
+	(*PushResult)(nil),     // 22: io.buildah.v1.PushResult
+	(*PushResponse)(nil),   // 23: io.buildah.v1.PushResponse
+	nil,                    // 24: io.buildah.v1.ConfigRequest.LabelsEntry
+	nil,                    // 25: io.buildah.v1.ConfigRequest.AnnotationsEntry
+	nil,                    // 26: io.buildah.v1.BuildRequest.BuildArgsEntry
+	nil,                    // 27: io.buildah.v1.BuildRequest.LabelsEntry
+	nil,                    // 28: io.buildah.v1.BuildRequest.AnnotationsEntry
+}
+var file_build_proto_depIdxs = []int32{
+	0,  // 0: io.buildah.v1.Output.stream:type_name -> io.buildah.v1.Output.Stream
+	2,  // 1: io.buildah.v1.FromResponse.progress:type_name -> io.buildah.v1.Progress
+	5,  // 2: io.buildah.v1.FromResponse.result:type_name -> io.buildah.v1.FromResult
+	1,  // 3: io.buildah.v1.RunResponse.output:type_name -> io.buildah.v1.Output
+	8,  // 4: io.buildah.v1.RunResponse.result:type_name -> io.buildah.v1.RunResult
+	2,  // 5: io.buildah.v1.CopyResponse.progress:type_name -> io.buildah.v1.Progress
+	11, // 6: io.buildah.v1.CopyResponse.result:type_name -> io.buildah.v1.CopyResult
+	24, // 7: io.buildah.v1.ConfigRequest.labels:type_name -> io.buildah.v1.ConfigRequest.LabelsEntry
+	25, // 8: io.buildah.v1.ConfigRequest.annotations:type_name -> io.buildah.v1.ConfigRequest.AnnotationsEntry
+	3,  // 9: io.buildah.v1.ConfigRequest.entrypoint:type_name -> io.buildah.v1.StringList
+	3,  // 10: io.buildah.v1.ConfigRequest.cmd:type_name -> io.buildah.v1.StringList
+	2,  // 11: io.buildah.v1.CommitResponse.progress:type_name -> io.buildah.v1.Progress
+	16, // 12: io.buildah.v1.CommitResponse.result:type_name -> io.buildah.v1.CommitResult
+	26, // 13: io.buildah.v1.BuildRequest.build_args:type_name -> io.buildah.v1.BuildRequest.BuildArgsEntry
+	27, // 14: io.buildah.v1.BuildRequest.labels:type_name -> io.buildah.v1.BuildRequest.LabelsEntry
+	28, // 15: io.buildah.v1.BuildRequest.annotations:type_name -> io.buildah.v1.BuildRequest.AnnotationsEntry
+	1,  // 16: io.buildah.v1.BuildResponse.output:type_name -> io.buildah.v1.Output
+	2,  // 17: io.buildah.v1.BuildResponse.progress:type_name -> io.buildah.v1.Progress
+	19, // 18: io.buildah.v1.BuildResponse.result:type_name -> io.buildah.v1.BuildResult
+	2,  // 19: io.buildah.v1.PushResponse.progress:type_name -> io.buildah.v1.Progress
+	22, // 20: io.buildah.v1.PushResponse.result:type_name -> io.buildah.v1.PushResult
+	4,  // 21: io.buildah.v1.Build.From:input_type -> io.buildah.v1.FromRequest
+	7,  // 22: io.buildah.v1.Build.Run:input_type -> io.buildah.v1.RunRequest
+	10, // 23: io.buildah.v1.Build.Copy:input_type -> io.buildah.v1.CopyRequest
+	13, // 24: io.buildah.v1.Build.Config:input_type -> io.buildah.v1.ConfigRequest
+	15, // 25: io.buildah.v1.Build.Commit:input_type -> io.buildah.v1.CommitRequest
+	18, // 26: io.buildah.v1.Build.Build:input_type -> io.buildah.v1.BuildRequest
+	21, // 27: io.buildah.v1.Build.Push:input_type -> io.buildah.v1.PushRequest
+	6,  // 28: io.buildah.v1.Build.From:output_type -> io.buildah.v1.FromResponse
+	9,  // 29: io.buildah.v1.Build.Run:output_type -> io.buildah.v1.RunResponse
+	12, // 30: io.buildah.v1.Build.Copy:output_type -> io.buildah.v1.CopyResponse
+	14, // 31: io.buildah.v1.Build.Config:output_type -> io.buildah.v1.ConfigResponse
+	17, // 32: io.buildah.v1.Build.Commit:output_type -> io.buildah.v1.CommitResponse
+	20, // 33: io.buildah.v1.Build.Build:output_type -> io.buildah.v1.BuildResponse
+	23, // 34: io.buildah.v1.Build.Push:output_type -> io.buildah.v1.PushResponse
+	28, // [28:35] is the sub-list for method output_type
+	21, // [21:28] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_build_proto_init() }
+func file_build_proto_init() {
+	if File_build_proto != nil {
+		return
+	}
+	file_build_proto_msgTypes[5].OneofWrappers = []any{
+		(*FromResponse_Progress)(nil),
+		(*FromResponse_Result)(nil),
+	}
+	file_build_proto_msgTypes[8].OneofWrappers = []any{
+		(*RunResponse_Output)(nil),
+		(*RunResponse_Result)(nil),
+	}
+	file_build_proto_msgTypes[11].OneofWrappers = []any{
+		(*CopyResponse_Progress)(nil),
+		(*CopyResponse_Result)(nil),
+	}
+	file_build_proto_msgTypes[12].OneofWrappers = []any{}
+	file_build_proto_msgTypes[16].OneofWrappers = []any{
+		(*CommitResponse_Progress)(nil),
+		(*CommitResponse_Result)(nil),
+	}
+	file_build_proto_msgTypes[19].OneofWrappers = []any{
+		(*BuildResponse_Output)(nil),
+		(*BuildResponse_Progress)(nil),
+		(*BuildResponse_Result)(nil),
+	}
+	file_build_proto_msgTypes[22].OneofWrappers = []any{
+		(*PushResponse_Progress)(nil),
+		(*PushResponse_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_build_proto_rawDesc), len(file_build_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_build_proto_goTypes,
+		DependencyIndexes: file_build_proto_depIdxs,
+		EnumInfos:         file_build_proto_enumTypes,
+		MessageInfos:      file_build_proto_msgTypes,
+	}.Build()
+	File_build_proto = out.File
+	file_build_proto_goTypes = nil
+	file_build_proto_depIdxs = nil
+}
@@ -0,0 +1,12 @@
+#!/bin/bash
+set -e
+cd $(dirname ${BASH_SOURCE[0]})
+TOP=../../../..
+PATH=${TOP}/tests/tools/build:${PATH}
+set -x
+for proto in *.proto ; do
+	protoc \
+		--go_opt=paths=source_relative --go_out . \
+		--go-grpc_opt=paths=source_relative --go-grpc_out . \
+	${proto}
+done
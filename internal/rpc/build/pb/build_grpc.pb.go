@@ -0,0 +1,386 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             v3.19.6
+// source: build.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Build_From_FullMethodName   = "/io.buildah.v1.Build/From"
+	Build_Run_FullMethodName    = "/io.buildah.v1.Build/Run"
+	Build_Copy_FullMethodName   = "/io.buildah.v1.Build/Copy"
+	Build_Config_FullMethodName = "/io.buildah.v1.Build/Config"
+	Build_Commit_FullMethodName = "/io.buildah.v1.Build/Commit"
+	Build_Build_FullMethodName  = "/io.buildah.v1.Build/Build"
+	Build_Push_FullMethodName   = "/io.buildah.v1.Build/Push"
+)
+
+// BuildClient is the client API for Build service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Build manipulates working containers, builds images, and pushes them.
+type BuildClient interface {
+	// From creates a working container from an image, or from "scratch".
+	From(ctx context.Context, in *FromRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FromResponse], error)
+	// Run runs a command in a working container.
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunResponse], error)
+	// Copy copies content into a working container.
+	Copy(ctx context.Context, in *CopyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CopyResponse], error)
+	// Config updates the configuration of a working container.
+	Config(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
+	// Commit writes the contents of a working container to an image.
+	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CommitResponse], error)
+	// Build builds an image using one or more Containerfiles.
+	Build(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BuildResponse], error)
+	// Push copies an image from local storage to another location.
+	Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PushResponse], error)
+}
+
+type buildClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBuildClient(cc grpc.ClientConnInterface) BuildClient {
+	return &buildClient{cc}
+}
+
+func (c *buildClient) From(ctx context.Context, in *FromRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FromResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Build_ServiceDesc.Streams[0], Build_From_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FromRequest, FromResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_FromClient = grpc.ServerStreamingClient[FromResponse]
+
+func (c *buildClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Build_ServiceDesc.Streams[1], Build_Run_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunRequest, RunResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_RunClient = grpc.ServerStreamingClient[RunResponse]
+
+func (c *buildClient) Copy(ctx context.Context, in *CopyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CopyResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Build_ServiceDesc.Streams[2], Build_Copy_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CopyRequest, CopyResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_CopyClient = grpc.ServerStreamingClient[CopyResponse]
+
+func (c *buildClient) Config(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfigResponse)
+	err := c.cc.Invoke(ctx, Build_Config_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *buildClient) Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CommitResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Build_ServiceDesc.Streams[3], Build_Commit_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CommitRequest, CommitResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_CommitClient = grpc.ServerStreamingClient[CommitResponse]
+
+func (c *buildClient) Build(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BuildResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Build_ServiceDesc.Streams[4], Build_Build_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BuildRequest, BuildResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_BuildClient = grpc.ServerStreamingClient[BuildResponse]
+
+func (c *buildClient) Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PushResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Build_ServiceDesc.Streams[5], Build_Push_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PushRequest, PushResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_PushClient = grpc.ServerStreamingClient[PushResponse]
+
+// BuildServer is the server API for Build service.
+// All implementations must embed UnimplementedBuildServer
+// for forward compatibility.
+//
+// Build manipulates working containers, builds images, and pushes them.
+type BuildServer interface {
+	// From creates a working container from an image, or from "scratch".
+	From(*FromRequest, grpc.ServerStreamingServer[FromResponse]) error
+	// Run runs a command in a working container.
+	Run(*RunRequest, grpc.ServerStreamingServer[RunResponse]) error
+	// Copy copies content into a working container.
+	Copy(*CopyRequest, grpc.ServerStreamingServer[CopyResponse]) error
+	// Config updates the configuration of a working container.
+	Config(context.Context, *ConfigRequest) (*ConfigResponse, error)
+	// Commit writes the contents of a working container to an image.
+	Commit(*CommitRequest, grpc.ServerStreamingServer[CommitResponse]) error
+	// Build builds an image using one or more Containerfiles.
+	Build(*BuildRequest, grpc.ServerStreamingServer[BuildResponse]) error
+	// Push copies an image from local storage to another location.
+	Push(*PushRequest, grpc.ServerStreamingServer[PushResponse]) error
+	mustEmbedUnimplementedBuildServer()
+}
+
+// UnimplementedBuildServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBuildServer struct{}
+
+func (UnimplementedBuildServer) From(*FromRequest, grpc.ServerStreamingServer[FromResponse]) error {
+	return status.Error(codes.Unimplemented, "method From not implemented")
+}
+func (UnimplementedBuildServer) Run(*RunRequest, grpc.ServerStreamingServer[RunResponse]) error {
+	return status.Error(codes.Unimplemented, "method Run not implemented")
+}
+func (UnimplementedBuildServer) Copy(*CopyRequest, grpc.ServerStreamingServer[CopyResponse]) error {
+	return status.Error(codes.Unimplemented, "method Copy not implemented")
+}
+func (UnimplementedBuildServer) Config(context.Context, *ConfigRequest) (*ConfigResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Config not implemented")
+}
+func (UnimplementedBuildServer) Commit(*CommitRequest, grpc.ServerStreamingServer[CommitResponse]) error {
+	return status.Error(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedBuildServer) Build(*BuildRequest, grpc.ServerStreamingServer[BuildResponse]) error {
+	return status.Error(codes.Unimplemented, "method Build not implemented")
+}
+func (UnimplementedBuildServer) Push(*PushRequest, grpc.ServerStreamingServer[PushResponse]) error {
+	return status.Error(codes.Unimplemented, "method Push not implemented")
+}
+func (UnimplementedBuildServer) mustEmbedUnimplementedBuildServer() {}
+func (UnimplementedBuildServer) testEmbeddedByValue()               {}
+
+// UnsafeBuildServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BuildServer will
+// result in compilation errors.
+type UnsafeBuildServer interface {
+	mustEmbedUnimplementedBuildServer()
+}
+
+func RegisterBuildServer(s grpc.ServiceRegistrar, srv BuildServer) {
+	// If the following call panics, it indicates UnimplementedBuildServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Build_ServiceDesc, srv)
+}
+
+func _Build_From_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FromRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServer).From(m, &grpc.GenericServerStream[FromRequest, FromResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_FromServer = grpc.ServerStreamingServer[FromResponse]
+
+func _Build_Run_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RunRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServer).Run(m, &grpc.GenericServerStream[RunRequest, RunResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_RunServer = grpc.ServerStreamingServer[RunResponse]
+
+func _Build_Copy_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CopyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServer).Copy(m, &grpc.GenericServerStream[CopyRequest, CopyResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_CopyServer = grpc.ServerStreamingServer[CopyResponse]
+
+func _Build_Config_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuildServer).Config(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Build_Config_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuildServer).Config(ctx, req.(*ConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Build_Commit_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CommitRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServer).Commit(m, &grpc.GenericServerStream[CommitRequest, CommitResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_CommitServer = grpc.ServerStreamingServer[CommitResponse]
+
+func _Build_Build_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BuildRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServer).Build(m, &grpc.GenericServerStream[BuildRequest, BuildResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_BuildServer = grpc.ServerStreamingServer[BuildResponse]
+
+func _Build_Push_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PushRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServer).Push(m, &grpc.GenericServerStream[PushRequest, PushResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Build_PushServer = grpc.ServerStreamingServer[PushResponse]
+
+// Build_ServiceDesc is the grpc.ServiceDesc for Build service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Build_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "io.buildah.v1.Build",
+	HandlerType: (*BuildServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Config",
+			Handler:    _Build_Config_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "From",
+			Handler:       _Build_From_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Run",
+			Handler:       _Build_Run_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Copy",
+			Handler:       _Build_Copy_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Commit",
+			Handler:       _Build_Commit_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Build",
+			Handler:       _Build_Build_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Push",
+			Handler:       _Build_Push_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "build.proto",
+}
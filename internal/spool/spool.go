@@ -0,0 +1,168 @@
+// Package spool provides a pipe which never blocks its writer, so that
+// content can be produced before its consumer is ready for it.
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultMemoryLimit is the amount of unread data which a Spool keeps in
+// memory before it starts writing data to a temporary file.
+const DefaultMemoryLimit = 4 * 1024 * 1024
+
+// Spool is a pipe which holds data that has been written to it until it is
+// read.  Up to a limit, unread data is kept in memory.  Past that, data is
+// written to a temporary file, so a writer can get well ahead of its reader
+// without blocking.
+type Spool struct {
+	lock         sync.Mutex
+	cond         *sync.Cond
+	dir          string
+	memoryLimit  int
+	memory       []byte   // unread data, which precedes anything in file
+	file         *os.File // unread data which didn't fit in memory
+	written      int64    // amount written to file
+	read         int64    // amount read from file
+	writerClosed bool
+	writerErr    error
+	readerClosed bool
+}
+
+// New creates a Spool which keeps up to memoryLimit bytes of unread data in
+// memory, and which creates a temporary file in dir if it needs to hold more
+// than that.  If memoryLimit is 0, DefaultMemoryLimit is used.
+func New(dir string, memoryLimit int) *Spool {
+	if memoryLimit <= 0 {
+		memoryLimit = DefaultMemoryLimit
+	}
+	s := &Spool{dir: dir, memoryLimit: memoryLimit}
+	s.cond = sync.NewCond(&s.lock)
+	return s
+}
+
+// Write adds data to the spool.  It fails if the reader has been closed.
+func (s *Spool) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	if s.readerClosed {
+		s.lock.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	if s.writerClosed {
+		s.lock.Unlock()
+		return 0, errors.New("write to closed spool")
+	}
+	if s.file == nil && len(s.memory)+len(p) <= s.memoryLimit {
+		s.memory = append(s.memory, p...)
+		s.cond.Broadcast()
+		s.lock.Unlock()
+		return len(p), nil
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp(s.dir, "spool")
+		if err != nil {
+			s.lock.Unlock()
+			return 0, fmt.Errorf("creating spool file: %w", err)
+		}
+		if err := os.Remove(f.Name()); err != nil {
+			f.Close()
+			s.lock.Unlock()
+			return 0, fmt.Errorf("removing spool file: %w", err)
+		}
+		s.file = f
+	}
+	file, offset := s.file, s.written
+	s.lock.Unlock()
+	// Only we write to the file, and the reader won't read past what
+	// we've recorded as written, so we don't need to hold the lock.
+	n, err := file.WriteAt(p, offset)
+	s.lock.Lock()
+	s.written += int64(n)
+	s.cond.Broadcast()
+	s.lock.Unlock()
+	if err != nil {
+		return n, fmt.Errorf("writing to spool file: %w", err)
+	}
+	return n, nil
+}
+
+// Close marks the end of the data.
+func (s *Spool) Close() error {
+	return s.CloseWithError(nil)
+}
+
+// CloseWithError marks the end of the data.  If err is not nil, the reader
+// will receive it instead of io.EOF after reading everything that was written.
+func (s *Spool) CloseWithError(err error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.writerClosed {
+		s.writerClosed = true
+		s.writerErr = err
+		s.cond.Broadcast()
+	}
+	return nil
+}
+
+// Read reads data from the spool, waiting for more to be written if
+// everything that's been written so far has already been read.
+func (s *Spool) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for {
+		if s.readerClosed {
+			return 0, io.ErrClosedPipe
+		}
+		if len(s.memory) > 0 {
+			n := copy(p, s.memory)
+			s.memory = s.memory[n:]
+			return n, nil
+		}
+		if s.file != nil && s.read < s.written {
+			file, offset := s.file, s.read
+			available := min(int64(len(p)), s.written-s.read)
+			s.lock.Unlock()
+			n, err := file.ReadAt(p[:available], offset)
+			s.lock.Lock()
+			s.read += int64(n)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return n, fmt.Errorf("reading from spool file: %w", err)
+			}
+			return n, nil
+		}
+		if s.writerClosed {
+			if s.writerErr != nil {
+				return 0, s.writerErr
+			}
+			return 0, io.EOF
+		}
+		s.cond.Wait()
+	}
+}
+
+// CloseReader discards any unread data, and causes any further writes to
+// fail.
+func (s *Spool) CloseReader() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.readerClosed {
+		return nil
+	}
+	s.readerClosed = true
+	s.memory = nil
+	s.cond.Broadcast()
+	if s.file != nil {
+		// A write might still be using the file, but its contents
+		// are already gone, and closing it makes the write fail.
+		err := s.file.Close()
+		s.file = nil
+		return err
+	}
+	return nil
+}
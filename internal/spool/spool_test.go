@@ -0,0 +1,71 @@
+package spool
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool(t *testing.T) {
+	t.Parallel()
+	data := make([]byte, 1024*1024+3)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+	for _, memoryLimit := range []int{16, 4096, 2 * len(data)} {
+		s := New(t.TempDir(), memoryLimit)
+		// write everything before reading anything
+		for chunk := range slices.Chunk(data, 1000) {
+			n, err := s.Write(chunk)
+			require.NoError(t, err)
+			require.Equal(t, len(chunk), n)
+		}
+		require.NoError(t, s.Close())
+		read, err := io.ReadAll(s)
+		require.NoError(t, err)
+		assert.Equal(t, data, read, "memory limit %d", memoryLimit)
+		require.NoError(t, s.CloseReader())
+	}
+}
+
+func TestSpoolConcurrent(t *testing.T) {
+	t.Parallel()
+	data := make([]byte, 256*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	s := New(t.TempDir(), 1024)
+	go func() {
+		for chunk := range slices.Chunk(data, 777) {
+			if _, err := s.Write(chunk); err != nil {
+				s.CloseWithError(err)
+				return
+			}
+		}
+		s.Close()
+	}()
+	read, err := io.ReadAll(s)
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+}
+
+func TestSpoolErrors(t *testing.T) {
+	t.Parallel()
+	failure := errors.New("failed")
+	s := New(t.TempDir(), 0)
+	_, err := s.Write([]byte("partial"))
+	require.NoError(t, err)
+	require.NoError(t, s.CloseWithError(failure))
+	read, err := io.ReadAll(s)
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, "partial", string(read))
+
+	s = New(t.TempDir(), 0)
+	require.NoError(t, s.CloseReader())
+	_, err = s.Write([]byte("ignored"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
@@ -14,14 +14,30 @@ import (
 //	github.com/containers/buildah.git#v1.35.0
 var gitURLFragmentSuffix = regexp.Delayed(`\.git(?:#.+)?$`)
 
+// scpLikeGitURL matches the scp-like syntax for SSH Git URLs, e.g.
+//
+//	git@github.com:containers/buildah.git
+//
+// Local paths can look like this too, so it's only used along with
+// gitURLFragmentSuffix.
+var scpLikeGitURL = regexp.Delayed(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:`)
+
 // IsHTTPOrHTTPS reports whether the source is an HTTP(S) URL.
 func IsHTTPOrHTTPS(source string) bool {
 	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
 }
 
-// IsGit reports whether the source is an HTTP(S) Git URL.
+// IsGit reports whether the source is an HTTP(S) Git URL or an SSH Git URL.
 func IsGit(source string) bool {
-	return IsHTTPOrHTTPS(source) && gitURLFragmentSuffix.MatchString(source)
+	return (IsHTTPOrHTTPS(source) && gitURLFragmentSuffix.MatchString(source)) || IsGitSSH(source)
+}
+
+// IsGitSSH reports whether the source is a Git URL which is accessed using
+// SSH, either using the ssh:// scheme or the scp-like syntax.  Since the
+// scp-like syntax can't be told apart from a local path, it is only recognized
+// if the repository's name ends with ".git".
+func IsGitSSH(source string) bool {
+	return strings.HasPrefix(source, "ssh://") || (scpLikeGitURL.MatchString(source) && gitURLFragmentSuffix.MatchString(source))
 }
 
 // IsRemote reports whether the source is a remote HTTP(S) URL
@@ -0,0 +1,29 @@
+package urlsource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGit(t *testing.T) {
+	for _, testCase := range []struct {
+		source string
+		git    bool
+		ssh    bool
+	}{
+		{"https://github.com/containers/buildah.git", true, false},
+		{"https://github.com/containers/buildah.git#main:docs", true, false},
+		{"https://github.com/containers/buildah", false, false},
+		{"ssh://git@github.com/containers/buildah.git", true, true},
+		{"ssh://git@github.com/containers/buildah", true, true},
+		{"git@github.com:containers/buildah.git", true, true},
+		{"git@github.com:containers/buildah.git#v1.35.0", true, true},
+		{"user@host:path", false, false},
+		{"backup@2024:file.txt", false, false},
+		{"file.git", false, false},
+	} {
+		assert.Equalf(t, testCase.git, IsGit(testCase.source), "IsGit(%q)", testCase.source)
+		assert.Equalf(t, testCase.ssh, IsGitSSH(testCase.source), "IsGitSSH(%q)", testCase.source)
+	}
+}
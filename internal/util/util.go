@@ -15,6 +15,7 @@ import (
 	"go.podman.io/storage/pkg/archive"
 	"go.podman.io/storage/pkg/chrootarchive"
 	"go.podman.io/storage/pkg/unshare"
+	"golang.org/x/sync/errgroup"
 )
 
 // LookupImage returns *Image to corresponding imagename or id
@@ -86,7 +87,7 @@ func ExportFromReader(input io.Reader, opts output.BuildOutputOption) error {
 			return fmt.Errorf("failed while performing untar at %q: %w", opts.Path, err)
 		}
 	case output.BuildOutputStdout:
-		if _, err = io.Copy(os.Stdout, input); err != nil {
+		if err = copyCompressed(os.Stdout, input, opts.Compression); err != nil {
 			return fmt.Errorf("failed while writing to stdout: %w", err)
 		}
 	case output.BuildOutputTar:
@@ -96,7 +97,7 @@ func ExportFromReader(input io.Reader, opts output.BuildOutputOption) error {
 		}
 		defer outFile.Close()
 
-		if _, err = io.Copy(outFile, input); err != nil {
+		if err = copyCompressed(outFile, input, opts.Compression); err != nil {
 			return fmt.Errorf("failed while performing copy to %q: %w", opts.Path, err)
 		}
 	default:
@@ -105,6 +106,65 @@ func ExportFromReader(input io.Reader, opts output.BuildOutputOption) error {
 	return nil
 }
 
+// ExportFromReaderMulti reads bytes from given reader once and exports them to
+// each of the destinations, as ExportFromReader does, concurrently.
+func ExportFromReaderMulti(input io.Reader, opts []output.BuildOutputOption) error {
+	if len(opts) == 1 {
+		return ExportFromReader(input, opts[0])
+	}
+	var group errgroup.Group
+	pipeWriters := make([]*io.PipeWriter, 0, len(opts))
+	writers := make([]io.Writer, 0, len(opts))
+	for _, opt := range opts {
+		pipeReader, pipeWriter := io.Pipe()
+		pipeWriters = append(pipeWriters, pipeWriter)
+		writers = append(writers, pipeWriter)
+		group.Go(func() error {
+			err := ExportFromReader(pipeReader, opt)
+			if err == nil {
+				// Consume anything that the destination didn't
+				// need, like padding at the end of the archive,
+				// so that we don't block writes to the others.
+				_, err = io.Copy(io.Discard, pipeReader)
+			}
+			pipeReader.CloseWithError(err)
+			return err
+		})
+	}
+	_, err := io.Copy(io.MultiWriter(writers...), input)
+	for _, pipeWriter := range pipeWriters {
+		pipeWriter.CloseWithError(err)
+	}
+	if groupErr := group.Wait(); groupErr != nil {
+		return groupErr
+	}
+	return err
+}
+
+// copyCompressed copies input to w, compressing it using the named algorithm,
+// which can be "", "uncompressed", "gzip", or "zstd".
+func copyCompressed(w io.Writer, input io.Reader, compression string) error {
+	algorithm := archive.Uncompressed
+	switch compression {
+	case "", "uncompressed":
+	case "gzip":
+		algorithm = archive.Gzip
+	case "zstd":
+		algorithm = archive.Zstd
+	default:
+		return fmt.Errorf("unsupported compression %q", compression)
+	}
+	compressor, err := archive.CompressStream(w, algorithm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(compressor, input); err != nil {
+		compressor.Close()
+		return err
+	}
+	return compressor.Close()
+}
+
 func SetHas[K comparable, V any](m map[K]V, k K) bool {
 	_, ok := m[k]
 	return ok
@@ -1,11 +1,26 @@
 package internalutil
 
 import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.podman.io/buildah/internal/output"
+	"go.podman.io/storage/pkg/archive"
+	"go.podman.io/storage/pkg/reexec"
 )
 
+func TestMain(m *testing.M) {
+	if reexec.Init() {
+		return
+	}
+	os.Exit(m.Run())
+}
+
 func TestSetHas(t *testing.T) {
 	m := map[string]string{
 		"key1": "ignored",
@@ -13,3 +28,40 @@ func TestSetHas(t *testing.T) {
 	assert.True(t, SetHas(m, "key1"))
 	assert.False(t, SetHas(m, "key2"))
 }
+
+func TestExportFromReaderMulti(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	contents := []byte("hello\n")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "hello", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(contents))}))
+	_, err := tw.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	dir := t.TempDir()
+	opts := []output.BuildOutputOption{
+		{Type: output.BuildOutputLocalDir, Path: filepath.Join(dir, "rootfs")},
+		{Type: output.BuildOutputTar, Path: filepath.Join(dir, "rootfs.tar")},
+		{Type: output.BuildOutputTar, Path: filepath.Join(dir, "rootfs.tar.zst"), Compression: "zstd"},
+	}
+	require.NoError(t, ExportFromReaderMulti(bytes.NewReader(buf.Bytes()), opts))
+
+	extracted, err := os.ReadFile(filepath.Join(dir, "rootfs", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, extracted)
+
+	plain, err := os.ReadFile(filepath.Join(dir, "rootfs.tar"))
+	require.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), plain)
+
+	compressed, err := os.Open(filepath.Join(dir, "rootfs.tar.zst"))
+	require.NoError(t, err)
+	defer compressed.Close()
+	decompressed, err := archive.DecompressStream(compressed)
+	require.NoError(t, err)
+	defer decompressed.Close()
+	var decompressedBuf bytes.Buffer
+	_, err = decompressedBuf.ReadFrom(decompressed)
+	require.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), decompressedBuf.Bytes())
+}
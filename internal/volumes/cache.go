@@ -0,0 +1,292 @@
+package volumes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"go.podman.io/storage/pkg/archive"
+	"go.podman.io/storage/pkg/chrootarchive"
+	"go.podman.io/storage/pkg/directory"
+	"go.podman.io/storage/pkg/idtools"
+	"go.podman.io/storage/pkg/ioutils"
+	"go.podman.io/storage/pkg/lockfile"
+)
+
+// All the metadata files are stored in a separate directory inside
+// `BuildahCacheDir`, named after the cache directories that they describe.
+// Example `/var/tmp/buildah-cache/buildah-cache-metadata/<target>.json`
+const buildahCacheMetadataDir = "buildah-cache-metadata"
+
+// ErrCacheInUse is returned when a cache can't be modified because a build is
+// using it with `sharing=locked` or `sharing=private`.
+var ErrCacheInUse = errors.New("cache is in use")
+
+// CacheInfo describes a cache directory which was created on the host for
+// `--mount=type=cache`.
+type CacheInfo struct {
+	// Name is the name of the cache's directory under the cache parent.
+	Name string `json:"name"`
+	// ID is the cache's `id`, or its `target` if no `id` was set.  It is
+	// empty if the cache was created before we started recording it.
+	ID string `json:"id,omitempty"`
+	// UID and GID are the cache's `uid` and `gid` settings, which are part
+	// of what distinguishes one cache from another.
+	UID uint64 `json:"uid"`
+	GID uint64 `json:"gid"`
+	// Created is when the cache was created.
+	Created time.Time `json:"created"`
+	// LastUsed is when the cache was most recently mounted.
+	LastUsed time.Time `json:"lastUsed"`
+	// Size is the disk space used by the cache's contents, in bytes.
+	Size int64 `json:"size"`
+	// Path is the location of the cache's directory.
+	Path string `json:"path"`
+}
+
+// cacheMetadata is the record that we keep for a cache directory.
+type cacheMetadata struct {
+	ID       string    `json:"id"`
+	UID      uint64    `json:"uid"`
+	GID      uint64    `json:"gid"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// cacheDirName returns the name of the directory under the cache parent which
+// holds the cache with the specified ID and ownership.
+func cacheDirName(id string, uid, gid uint64) string {
+	// Don't let the user try to inject pathname components by directly using
+	// the ID when constructing the cache directory location; distinguish
+	// between caches by ID and ownership
+	ownerInfo := fmt.Sprintf(":%d:%d", uid, gid)
+	return digest.FromString(id + ownerInfo).Encoded()[:16]
+}
+
+func cacheMetadataPath(cacheParent, name string) string {
+	return filepath.Join(cacheParent, buildahCacheMetadataDir, name+".json")
+}
+
+func cacheLock(cacheParent, name string) (*lockfile.LockFile, error) {
+	lockDir := filepath.Join(cacheParent, BuildahCacheLockfileDir, name)
+	if err := os.MkdirAll(lockDir, os.FileMode(0o755)); err != nil {
+		return nil, fmt.Errorf("unable to create build cache directory: %w", err)
+	}
+	return lockfile.GetLockFile(filepath.Join(lockDir, BuildahCacheLockfile))
+}
+
+func readCacheMetadata(cacheParent, name string) (*cacheMetadata, error) {
+	data, err := os.ReadFile(cacheMetadataPath(cacheParent, name))
+	if err != nil {
+		return nil, err
+	}
+	var metadata cacheMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("parsing metadata for cache %q: %w", name, err)
+	}
+	return &metadata, nil
+}
+
+func writeCacheMetadata(cacheParent, name string, metadata *cacheMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(cacheParent, buildahCacheMetadataDir), os.FileMode(0o755)); err != nil {
+		return fmt.Errorf("unable to create build cache directory: %w", err)
+	}
+	return ioutils.AtomicWriteFile(cacheMetadataPath(cacheParent, name), data, 0o644)
+}
+
+// recordCacheUse notes that the cache with the specified name is being used,
+// creating a record for it if it doesn't already have one.
+func recordCacheUse(cacheParent, name, id string, uid, gid uint64) {
+	now := time.Now().UTC()
+	metadata, err := readCacheMetadata(cacheParent, name)
+	if err != nil {
+		metadata = &cacheMetadata{ID: id, UID: uid, GID: gid, Created: now}
+	}
+	metadata.LastUsed = now
+	if err := writeCacheMetadata(cacheParent, name, metadata); err != nil {
+		logrus.Warnf("recording use of cache %q: %v", id, err)
+	}
+}
+
+// ListCaches returns information about the caches under cacheParent, sorted by
+// ID.
+func ListCaches(cacheParent string) ([]CacheInfo, error) {
+	entries, err := os.ReadDir(cacheParent)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading build cache directory: %w", err)
+	}
+	var caches []CacheInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name == BuildahCacheLockfileDir || name == buildahCacheMetadataDir || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := cacheInfo(cacheParent, name)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, *info)
+	}
+	slices.SortFunc(caches, func(a, b CacheInfo) int {
+		if c := strings.Compare(a.ID, b.ID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return caches, nil
+}
+
+func cacheInfo(cacheParent, name string) (*CacheInfo, error) {
+	path := filepath.Join(cacheParent, name)
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	size, err := directory.Size(path)
+	if err != nil {
+		return nil, fmt.Errorf("computing size of cache %q: %w", name, err)
+	}
+	info := &CacheInfo{
+		Name:     name,
+		Created:  st.ModTime().UTC(),
+		LastUsed: st.ModTime().UTC(),
+		Size:     size,
+		Path:     path,
+	}
+	metadata, err := readCacheMetadata(cacheParent, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if metadata != nil {
+		info.ID = metadata.ID
+		info.UID, info.GID = metadata.UID, metadata.GID
+		info.Created, info.LastUsed = metadata.Created, metadata.LastUsed
+	}
+	return info, nil
+}
+
+// FindCaches returns information about the caches under cacheParent which have
+// the specified ID, or whose name is the specified value.  There can be more
+// than one cache with a given ID if they have different owners.
+func FindCaches(cacheParent, idOrName string) ([]CacheInfo, error) {
+	caches, err := ListCaches(cacheParent)
+	if err != nil {
+		return nil, err
+	}
+	var matches []CacheInfo
+	for _, cache := range caches {
+		if cache.ID == idOrName || cache.Name == idOrName {
+			matches = append(matches, cache)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no cache with ID or name %q: %w", idOrName, fs.ErrNotExist)
+	}
+	return matches, nil
+}
+
+// RemoveCache removes the cache with the specified name from cacheParent.  It
+// fails with ErrCacheInUse if a build is using the cache with
+// `sharing=locked` or `sharing=private`.
+func RemoveCache(cacheParent, name string) error {
+	lock, err := cacheLock(cacheParent, name)
+	if err != nil {
+		return err
+	}
+	if err := lock.TryLock(); err != nil {
+		return fmt.Errorf("removing cache %q: %w", name, ErrCacheInUse)
+	}
+	defer lock.Unlock()
+	if err := os.RemoveAll(filepath.Join(cacheParent, name)); err != nil {
+		return fmt.Errorf("removing cache %q: %w", name, err)
+	}
+	if err := os.Remove(cacheMetadataPath(cacheParent, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing metadata for cache %q: %w", name, err)
+	}
+	return nil
+}
+
+// ExportCache writes the contents of the cache with the specified name to w
+// as an uncompressed tar archive.
+func ExportCache(cacheParent, name string, w io.Writer) error {
+	path := filepath.Join(cacheParent, name)
+	rc, err := chrootarchive.Tar(path, &archive.TarOptions{Compression: archive.Uncompressed}, path)
+	if err != nil {
+		return fmt.Errorf("archiving cache %q: %w", name, err)
+	}
+	defer rc.Close()
+	if _, err := io.Copy(w, rc); err != nil {
+		return fmt.Errorf("archiving cache %q: %w", name, err)
+	}
+	return nil
+}
+
+// ImportCache creates a cache under cacheParent with the specified ID and
+// ownership, populated with the contents of the tar archive read from r, and
+// returns information about it.  It fails if there is already such a cache.
+func ImportCache(cacheParent, id string, uid, gid uint64, r io.Reader) (*CacheInfo, error) {
+	if id == "" {
+		return nil, errors.New("a cache ID must be specified")
+	}
+	if err := os.MkdirAll(cacheParent, os.FileMode(0o755)); err != nil {
+		return nil, fmt.Errorf("unable to create build cache directory: %w", err)
+	}
+	name := cacheDirName(id, uid, gid)
+	lock, err := cacheLock(cacheParent, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock.TryLock(); err != nil {
+		return nil, fmt.Errorf("importing cache %q: %w", id, ErrCacheInUse)
+	}
+	defer lock.Unlock()
+	path := filepath.Join(cacheParent, name)
+	if _, err := os.Lstat(path); err == nil {
+		return nil, fmt.Errorf("cache %q already exists", id)
+	}
+
+	// Populate a temporary directory, and only put it in place after we've
+	// read the entire archive.
+	tmp, err := os.MkdirTemp(cacheParent, ".import")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create build cache directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			logrus.Debugf("removing %q: %v", tmp, err)
+		}
+	}()
+	if err := chrootarchive.Untar(r, tmp, &archive.TarOptions{}); err != nil {
+		return nil, fmt.Errorf("extracting contents of cache %q: %w", id, err)
+	}
+	if err := idtools.SafeChown(tmp, int(uid), int(gid)); err != nil {
+		return nil, fmt.Errorf("unable to change uid,gid of cache directory: %w", err)
+	}
+	if err := os.Chmod(tmp, os.FileMode(0o755)); err != nil {
+		return nil, fmt.Errorf("setting permissions of cache directory: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("importing cache %q: %w", id, err)
+	}
+	now := time.Now().UTC()
+	if err := writeCacheMetadata(cacheParent, name, &cacheMetadata{ID: id, UID: uid, GID: gid, Created: now, LastUsed: now}); err != nil {
+		return nil, fmt.Errorf("recording metadata for cache %q: %w", id, err)
+	}
+	return cacheInfo(cacheParent, name)
+}
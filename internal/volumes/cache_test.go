@@ -0,0 +1,156 @@
+package volumes
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.podman.io/image/v5/types"
+	"go.podman.io/storage"
+	storageTypes "go.podman.io/storage/types"
+)
+
+func TestCacheMountSharing(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	sys := &types.SystemContext{}
+	store, err := storage.GetStore(storageTypes.StoreOptions{
+		GraphDriverName: "vfs",
+		GraphRoot:       t.TempDir(),
+		RunRoot:         t.TempDir(),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if _, err := store.Shutdown(true); err != nil {
+			t.Logf("shutting down temporary store: %v", err)
+		}
+	})
+	cacheParent := CacheParent()
+	getCacheMount := func(args ...string) (specs.Mount, *CacheInfo, func()) {
+		t.Helper()
+		mount, _, _, _, lock, err := GetCacheMount(sys, append([]string{"type=cache", "id=shared-cache", "target=/cache"}, args...), store, "", nil, nil, nil, "/", t.TempDir())
+		require.NoError(t, err)
+		caches, err := FindCaches(cacheParent, "shared-cache")
+		require.NoError(t, err)
+		require.Len(t, caches, 1)
+		return mount, &caches[0], func() {
+			if lock != nil {
+				lock.Unlock()
+			}
+		}
+	}
+
+	mount, cache, release := getCacheMount("sharing=shared")
+	release()
+	assert.Equal(t, cache.Path, mount.Source)
+	require.NoError(t, os.WriteFile(filepath.Join(cache.Path, "file"), []byte("cached"), 0o644))
+
+	t.Run("unrecognized", func(t *testing.T) {
+		_, _, _, _, _, err := GetCacheMount(sys, []string{"type=cache", "target=/cache", "sharing=bogus"}, store, "", nil, nil, nil, "/", t.TempDir())
+		assert.ErrorIs(t, err, errBadMntOption)
+	})
+
+	t.Run("private", func(t *testing.T) {
+		first, _, releaseFirst := getCacheMount("sharing=private")
+		defer releaseFirst()
+		assert.Equal(t, cache.Path, first.Source, "the first user of a private cache should get the cache")
+
+		second, _, releaseSecond := getCacheMount("sharing=private")
+		defer releaseSecond()
+		assert.NotEqual(t, cache.Path, second.Source, "a concurrent user of a private cache should get a copy")
+		content, err := os.ReadFile(filepath.Join(second.Source, "file"))
+		require.NoError(t, err)
+		assert.Equal(t, "cached", string(content))
+		require.NoError(t, os.WriteFile(filepath.Join(second.Source, "file"), []byte("changed"), 0o644))
+		content, err = os.ReadFile(filepath.Join(cache.Path, "file"))
+		require.NoError(t, err)
+		assert.Equal(t, "cached", string(content), "changes to a private copy should not affect the cache")
+
+		assert.ErrorIs(t, RemoveCache(cacheParent, cache.Name), ErrCacheInUse)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		_, _, release := getCacheMount("sharing=locked")
+		assert.ErrorIs(t, RemoveCache(cacheParent, cache.Name), ErrCacheInUse)
+		release()
+	})
+}
+
+func TestCacheManagement(t *testing.T) {
+	cacheParent := t.TempDir()
+
+	caches, err := ListCaches(filepath.Join(cacheParent, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, caches)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "subdir/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "subdir/file", Typeflag: tar.TypeReg, Mode: 0o644, Size: 7}))
+	_, err = tw.Write([]byte("content"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	imported, err := ImportCache(cacheParent, "go-build", 0, 0, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "go-build", imported.ID)
+	assert.Equal(t, cacheDirName("go-build", 0, 0), imported.Name)
+	assert.Positive(t, imported.Size)
+	assert.False(t, imported.LastUsed.IsZero())
+	content, err := os.ReadFile(filepath.Join(imported.Path, "subdir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	_, err = ImportCache(cacheParent, "go-build", 0, 0, bytes.NewReader(archive.Bytes()))
+	assert.ErrorContains(t, err, "already exists")
+
+	// a cache with the same ID, but a different owner, is a different cache
+	_, err = ImportCache(cacheParent, "go-build", 1000, 1000, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+
+	caches, err = ListCaches(cacheParent)
+	require.NoError(t, err)
+	assert.Len(t, caches, 2)
+	matches, err := FindCaches(cacheParent, "go-build")
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+	matches, err = FindCaches(cacheParent, imported.Name)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, imported.Path, matches[0].Path)
+	_, err = FindCaches(cacheParent, "missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	var exported bytes.Buffer
+	require.NoError(t, ExportCache(cacheParent, imported.Name, &exported))
+	tr := tar.NewReader(&exported)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Name == "subdir/file" {
+			found = true
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(data))
+		}
+	}
+	assert.True(t, found, "exported archive should include subdir/file")
+
+	require.NoError(t, RemoveCache(cacheParent, imported.Name))
+	caches, err = ListCaches(cacheParent)
+	require.NoError(t, err)
+	require.Len(t, caches, 1)
+	assert.Equal(t, uint64(1000), caches[0].UID)
+	_, err = os.Stat(cacheMetadataPath(cacheParent, imported.Name))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
@@ -26,6 +26,7 @@ import (
 	"go.podman.io/common/pkg/parse"
 	"go.podman.io/image/v5/types"
 	"go.podman.io/storage"
+	"go.podman.io/storage/pkg/chrootarchive"
 	"go.podman.io/storage/pkg/idtools"
 	"go.podman.io/storage/pkg/lockfile"
 	"go.podman.io/storage/pkg/mount"
@@ -567,22 +568,13 @@ func GetCacheMount(sys *types.SystemContext, args []string, store storage.Store,
 			return newMount, "", "", "", nil, fmt.Errorf("unable to create build cache directory: %w", err)
 		}
 
-		ownerInfo := fmt.Sprintf(":%d:%d", uid, gid)
-		if id != "" {
-			// Don't let the user try to inject pathname components by directly using
-			// the ID when constructing the cache directory location; distinguish
-			// between caches by ID and ownership
-			dirID := digest.FromString(id + ownerInfo).Encoded()[:16]
-			thisCacheRoot = filepath.Join(cacheParent, dirID)
-			buildahLockFilesDir = filepath.Join(cacheParent, BuildahCacheLockfileDir, dirID)
-		} else {
-			// Don't let the user try to inject pathname components by directly using
-			// the target path when constructing the cache directory location;
-			// distinguish between caches by mount target location and ownership
-			dirID := digest.FromString(newMount.Destination + ownerInfo).Encoded()[:16]
-			thisCacheRoot = filepath.Join(cacheParent, dirID)
-			buildahLockFilesDir = filepath.Join(cacheParent, BuildahCacheLockfileDir, dirID)
+		// buildkit parity: the cache's ID defaults to its target location
+		if id == "" {
+			id = newMount.Destination
 		}
+		dirID := cacheDirName(id, uid, gid)
+		thisCacheRoot = filepath.Join(cacheParent, dirID)
+		buildahLockFilesDir = filepath.Join(cacheParent, BuildahCacheLockfileDir, dirID)
 
 		idPair := idtools.IDPair{
 			UID: int(hostUID),
@@ -594,17 +586,44 @@ func GetCacheMount(sys *types.SystemContext, args []string, store storage.Store,
 		if err != nil {
 			return newMount, "", "", "", nil, fmt.Errorf("unable to change uid,gid of cache directory: %w", err)
 		}
+		recordCacheUse(cacheParent, dirID, id, uid, gid)
 	}
 
-	// path should be /mountPoint/specified path
-	evaluated, err := copier.Eval(thisCacheRoot, thisCacheRoot+string(filepath.Separator)+newMount.Source, copier.EvalOptions{})
-	if err != nil {
-		return newMount, "", "", "", nil, err
-	}
-	newMount.Source = evaluated
-
 	var targetLock *lockfile.LockFile
 	switch sharing {
+	case "private":
+		// create cache parent directories on host if not already present
+		err = os.MkdirAll(buildahLockFilesDir, os.FileMode(0o755))
+		if err != nil {
+			return newMount, "", "", "", nil, fmt.Errorf("unable to create build cache directory: %w", err)
+		}
+
+		lockfile, err := lockfile.GetLockFile(filepath.Join(buildahLockFilesDir, BuildahCacheLockfile))
+		if err != nil {
+			return newMount, "", "", "", nil, fmt.Errorf("unable to acquire lock when sharing mode is private: %w", err)
+		}
+
+		// if nobody else is using the cache exclusively, use it and keep
+		// them out until the RUN step is executed, otherwise give this
+		// RUN step a copy of its own, which will be discarded
+		if err := lockfile.TryLock(); err == nil {
+			targetLock = lockfile
+			defer func() {
+				if !succeeded {
+					targetLock.Unlock()
+				}
+			}()
+			break
+		}
+		privateCopy, err := os.MkdirTemp(tmpDir, "cache")
+		if err != nil {
+			return newMount, "", "", "", nil, fmt.Errorf("creating private copy of cache: %w", err)
+		}
+		if err := chrootarchive.NewArchiver(nil).CopyWithTar(thisCacheRoot, privateCopy); err != nil {
+			return newMount, "", "", "", nil, fmt.Errorf("creating private copy of cache: %w", err)
+		}
+		logrus.Debugf("cache %q is in use, using private copy %q", thisCacheRoot, privateCopy)
+		thisCacheRoot = privateCopy
 	case "locked":
 		// create cache parent directories on host if not already present
 		err = os.MkdirAll(buildahLockFilesDir, os.FileMode(0o755))
@@ -631,8 +650,15 @@ func GetCacheMount(sys *types.SystemContext, args []string, store storage.Store,
 		break
 	default:
 		// error out for unknown values
-		return newMount, "", "", "", nil, fmt.Errorf("unrecognized value %q for field `sharing`: %w", sharing, err)
+		return newMount, "", "", "", nil, fmt.Errorf("unrecognized value %q for field `sharing`: %w", sharing, errBadMntOption)
+	}
+
+	// path should be /mountPoint/specified path
+	evaluated, err := copier.Eval(thisCacheRoot, thisCacheRoot+string(filepath.Separator)+newMount.Source, copier.EvalOptions{})
+	if err != nil {
+		return newMount, "", "", "", nil, err
 	}
+	newMount.Source = evaluated
 
 	// buildkit parity: default sharing should be shared
 	// unless specified
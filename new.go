@@ -156,6 +156,7 @@ func newBuilder(ctx context.Context, store storage.Store, options BuilderOptions
 		pullOptions.OciDecryptConfig = options.OciDecryptConfig
 		pullOptions.SignaturePolicyPath = options.SignaturePolicyPath
 		pullOptions.Writer = options.ReportWriter
+		pullOptions.Progress = options.Progress
 		pullOptions.DestinationLookupReferenceFunc = cacheLookupReferenceFunc(options.BlobDirectory, types.PreserveOriginal)
 
 		maxRetries := uint(options.MaxPullRetries)
@@ -333,6 +334,12 @@ func newBuilder(ctx context.Context, store storage.Store, options BuilderOptions
 		return nil, fmt.Errorf("preparing image configuration: %w", err)
 	}
 
+	if imageID != "" {
+		if builder.ContentManifests, err = ImageContentManifests(store, imageID); err != nil {
+			return nil, err
+		}
+	}
+
 	if !options.PreserveBaseImageAnns {
 		builder.SetAnnotation(v1.AnnotationBaseImageDigest, imageDigest)
 		if !shortnames.IsShortName(imageSpec) {
@@ -7,6 +7,7 @@ package cli
 // here we are.
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"go.podman.io/buildah/define"
+	"go.podman.io/buildah/internal/layercache"
 	"go.podman.io/buildah/internal/output"
 	"go.podman.io/buildah/pkg/parse"
 	"go.podman.io/buildah/pkg/util"
@@ -201,6 +203,28 @@ func GenBuildOptions(c *cobra.Command, inputArgs []string, iopts BuildOptions) (
 		reporter = iopts.Logwriter
 	}
 
+	var events func(define.BuildEvent)
+	switch iopts.Progress {
+	case "", "auto", "plain":
+	case "rawjson":
+		// Keep standard error for events, and send the text that
+		// would otherwise be written there to standard output, unless
+		// it's already being written to a log file.
+		if iopts.Logwriter == nil {
+			logrus.SetOutput(stdout)
+			stderr = stdout
+			reporter = stdout
+		}
+		encoder := json.NewEncoder(os.Stderr)
+		events = func(event define.BuildEvent) {
+			if err := encoder.Encode(event); err != nil {
+				logrus.Debugf("writing build event: %v", err)
+			}
+		}
+	default:
+		return options, nil, nil, fmt.Errorf("unrecognized --progress value %q, expected one of auto, plain, or rawjson", iopts.Progress)
+	}
+
 	systemContext, err := parse.SystemContextFromOptions(c)
 	if err != nil {
 		return options, nil, nil, fmt.Errorf("building system context: %w", err)
@@ -324,6 +348,11 @@ func GenBuildOptions(c *cobra.Command, inputArgs []string, iopts BuildOptions) (
 				return options, nil, nil, err
 			}
 			if buildOption.Type == output.BuildOutputStdout {
+				// with --progress=rawjson, the text would have
+				// been sent to standard output, too
+				if iopts.Progress == "rawjson" && iopts.Logwriter == nil {
+					return options, nil, nil, errors.New("--progress=rawjson can not be used with an --output which writes to standard output unless --logfile is also specified")
+				}
 				iopts.Quiet = true
 			}
 		}
@@ -337,18 +366,40 @@ func GenBuildOptions(c *cobra.Command, inputArgs []string, iopts BuildOptions) (
 	}
 	var cacheTo []reference.Named
 	var cacheFrom []reference.Named
+	var cacheToLocations, cacheFromLocations []string
 	cacheTo = nil
 	cacheFrom = nil
 	if c.Flag("cache-to").Changed {
-		cacheTo, err = parse.RepoNamesToNamedReferences(iopts.CacheTo)
+		var cacheToRepos []string
+		cacheToRepos, cacheToLocations, err = splitCacheLocations(iopts.CacheTo, true)
+		if err != nil {
+			return options, nil, nil, fmt.Errorf("unable to parse value provided `%s` to --cache-to: %w", iopts.CacheTo, err)
+		}
+		cacheTo, err = parse.RepoNamesToNamedReferences(cacheToRepos)
 		if err != nil {
 			return options, nil, nil, fmt.Errorf("unable to parse value provided `%s` to --cache-to: %w", iopts.CacheTo, err)
 		}
 	}
 	if c.Flag("cache-from").Changed {
-		cacheFrom, err = parse.RepoNamesToNamedReferences(iopts.CacheFrom)
+		var cacheFromRepos []string
+		cacheFromRepos, cacheFromLocations, err = splitCacheLocations(iopts.CacheFrom, false)
 		if err != nil {
-			return options, nil, nil, fmt.Errorf("unable to parse value provided `%s` to --cache-from: %w", iopts.CacheTo, err)
+			return options, nil, nil, fmt.Errorf("unable to parse value provided `%s` to --cache-from: %w", iopts.CacheFrom, err)
+		}
+		cacheFrom, err = parse.RepoNamesToNamedReferences(cacheFromRepos)
+		if err != nil {
+			return options, nil, nil, fmt.Errorf("unable to parse value provided `%s` to --cache-from: %w", iopts.CacheFrom, err)
+		}
+	}
+	cacheKeyMode := define.CacheKeyMode(iopts.CacheKeyMode)
+	switch cacheKeyMode {
+	case define.CacheKeyModeHistory, define.CacheKeyModeContent:
+	default:
+		return options, nil, nil, fmt.Errorf("unrecognized --cache-key-mode value %q, expected one of history or content", iopts.CacheKeyMode)
+	}
+	for _, entitlement := range iopts.Allow {
+		if entitlement != define.EntitlementSecurityInsecure {
+			return options, nil, nil, fmt.Errorf("unrecognized --allow value %q, expected %s", entitlement, define.EntitlementSecurityInsecure)
 		}
 	}
 	var cacheTTL time.Duration
@@ -427,10 +478,15 @@ func GenBuildOptions(c *cobra.Command, inputArgs []string, iopts BuildOptions) (
 		BlobDirectory:           iopts.BlobCache,
 		BuildOutputs:            iopts.BuildOutputs,
 		CacheFrom:               cacheFrom,
+		CacheFromLocations:      cacheFromLocations,
 		CacheTo:                 cacheTo,
+		CacheToLocations:        cacheToLocations,
 		CacheTTL:                cacheTTL,
+		CacheExplain:            iopts.CacheExplain,
+		CacheKeyMode:            cacheKeyMode,
 		CDIConfigDir:            iopts.CDIConfigDir,
 		CompatVolumes:           compatVolumes,
+		ContentManifest:         iopts.ContentManifest,
 		ConfidentialWorkload:    confidentialWorkloadOptions,
 		CPPFlags:                iopts.CPPFlags,
 		CommonBuildOpts:         commonOpts,
@@ -443,7 +499,9 @@ func GenBuildOptions(c *cobra.Command, inputArgs []string, iopts BuildOptions) (
 		CreatedAnnotation:       createdAnnotation,
 		Devices:                 iopts.Devices,
 		DropCapabilities:        iopts.CapDrop,
+		Entitlements:            iopts.Allow,
 		Err:                     stderr,
+		Events:                  events,
 		Excludes:                excludes,
 		ForceRmIntermediateCtrs: iopts.ForceRm,
 		From:                    iopts.From,
@@ -486,12 +544,14 @@ func GenBuildOptions(c *cobra.Command, inputArgs []string, iopts BuildOptions) (
 		RusageLogFile:           iopts.RusageLogFile,
 		SaveStages:              iopts.SaveStages,
 		SBOMScanOptions:         sbomScanOptions,
+		SensitiveArgs:           iopts.SensitiveBuildArg,
 		SignBy:                  iopts.SignBy,
 		SignaturePolicyPath:     iopts.SignaturePolicy,
 		SourcePolicyFile:        iopts.SourcePolicyFile,
 		SkipUnusedStages:        skipUnusedStages,
 		SourceDateEpoch:         sourceDateEpoch,
 		Squash:                  iopts.Squash,
+		SquashFrom:              iopts.SquashFrom,
 		StageLabels:             iopts.StageLabels,
 		SystemContext:           systemContext,
 		Target:                  iopts.Target,
@@ -559,3 +619,26 @@ func getContainerfiles(files []string) []string {
 	}
 	return containerfiles
 }
+
+// splitCacheLocations separates the values of a --cache-from or --cache-to
+// flag into plain repository names and values in the "type=...,key=value"
+// form, with "type=registry" values being converted to repository names.
+func splitCacheLocations(values []string, export bool) ([]string, []string, error) {
+	var repos, locations []string
+	for _, value := range values {
+		if !layercache.IsLocation(value) {
+			repos = append(repos, value)
+			continue
+		}
+		location, err := layercache.GetLocation(value, export)
+		if err != nil {
+			return nil, nil, err
+		}
+		if location.Type == layercache.LocationRegistry {
+			repos = append(repos, location.Ref)
+			continue
+		}
+		locations = append(locations, value)
+	}
+	return repos, locations, nil
+}
@@ -57,13 +57,16 @@ type NameSpaceResults struct {
 
 // BudResults represents the results for Build flags
 type BudResults struct {
+	Allow                  []string
 	AllPlatforms           bool
 	Annotation             []string
 	Authfile               string
 	BuildArg               []string
 	BuildArgFile           []string
 	BuildContext           []string
+	CacheExplain           bool
 	CacheFrom              []string
+	CacheKeyMode           string
 	CacheTo                []string
 	CacheTTL               string
 	CertDir                string
@@ -98,6 +101,7 @@ type BudResults struct {
 	Pull                   string
 	PullAlways             bool
 	PullNever              bool
+	Progress               string
 	Quiet                  bool
 	IdentityLabel          bool
 	Rm                     bool
@@ -112,10 +116,12 @@ type BudResults struct {
 	SbomPurlOutput         string
 	SbomImgPurlOutput      string
 	Secrets                []string
+	SensitiveBuildArg      []string
 	SSH                    []string
 	SignaturePolicy        string
 	SignBy                 string
 	Squash                 bool
+	SquashFrom             string
 	SkipUnusedStages       bool
 	Stdin                  bool
 	Tag                    []string
@@ -134,6 +140,7 @@ type BudResults struct {
 	CWOptions              string
 	SBOMOptions            []string
 	CompatVolumes          bool
+	ContentManifest        bool
 	SourceDateEpoch        string
 	RewriteTimestamp       bool
 	CreatedAnnotation      bool
@@ -233,6 +240,7 @@ func GetLayerFlags(flags *LayerResults) pflag.FlagSet {
 // GetBudFlags returns common build flags
 func GetBudFlags(flags *BudResults) pflag.FlagSet {
 	fs := pflag.FlagSet{}
+	fs.StringArrayVar(&flags.Allow, "allow", []string{}, "allow RUN instructions to request the `entitlement` (\"security.insecure\")")
 	fs.BoolVar(&flags.AllPlatforms, "all-platforms", false, "attempt to build for all base image platforms")
 	fs.String("arch", runtime.GOARCH, "set the ARCH of the image to the provided value instead of the architecture of the host")
 	fs.StringArrayVar(&flags.Annotation, "annotation", []string{}, "set metadata for an image (default [])")
@@ -241,12 +249,15 @@ func GetBudFlags(flags *BudResults) pflag.FlagSet {
 	fs.StringArrayVar(&flags.BuildArg, "build-arg", []string{}, "`argument=value` to supply to the builder")
 	fs.StringArrayVar(&flags.BuildArgFile, "build-arg-file", []string{}, "`argfile.conf` containing lines of argument=value to supply to the builder")
 	fs.StringArrayVar(&flags.BuildContext, "build-context", []string{}, "`argument=value` to supply additional build context to the builder")
-	fs.StringArrayVar(&flags.CacheFrom, "cache-from", []string{}, "remote repository list to utilise as potential cache source.")
-	fs.StringArrayVar(&flags.CacheTo, "cache-to", []string{}, "remote repository list to utilise as potential cache destination.")
+	fs.BoolVar(&flags.CacheExplain, "cache-explain", false, "explain why instructions did not match cached images")
+	fs.StringArrayVar(&flags.CacheFrom, "cache-from", []string{}, "remote repository or local cache location list to utilise as potential cache source.")
+	fs.StringVar(&flags.CacheKeyMode, "cache-key-mode", string(define.CacheKeyModeHistory), "`mode` for matching instructions to cached images (history, content)")
+	fs.StringArrayVar(&flags.CacheTo, "cache-to", []string{}, "remote repository or local cache location list to utilise as potential cache destination.")
 	fs.StringVar(&flags.CacheTTL, "cache-ttl", "", "only consider cache images under specified duration.")
 	fs.StringVar(&flags.CertDir, "cert-dir", "", "use certificates at the specified path to access the registry")
 	fs.BoolVar(&flags.Compress, "compress", false, "this is a legacy option, which has no effect on the image")
 	fs.BoolVar(&flags.CompatVolumes, "compat-volumes", false, "preserve the contents of VOLUMEs during RUN instructions")
+	fs.BoolVar(&flags.ContentManifest, "content-manifest", false, "record a manifest of the files which ADD and COPY instructions add to the image")
 	fs.BoolVar(&flags.InheritLabels, "inherit-labels", true, "inherit the labels from the base image or base stages.")
 	fs.BoolVar(&flags.InheritAnnotations, "inherit-annotations", true, "inherit the annotations from the base image or base stages.")
 	fs.StringArrayVar(&flags.CPPFlags, "cpp-flag", []string{}, "set additional flag to pass to C preprocessor (cpp)")
@@ -304,6 +315,7 @@ newer:   only pull base and SBOM scanner images when newer images exist on the r
 	if err := fs.MarkHidden("pull-never"); err != nil {
 		panic(fmt.Sprintf("error marking the pull-never flag as hidden: %v", err))
 	}
+	fs.StringVar(&flags.Progress, "progress", "auto", "`type` of progress output (auto, plain, rawjson)")
 	fs.BoolVarP(&flags.Quiet, "quiet", "q", false, "refrain from announcing build instructions and image read/write progress")
 	fs.BoolVar(&flags.OmitHistory, "omit-history", false, "omit build history information from built image")
 	fs.BoolVar(&flags.IdentityLabel, "identity-label", true, "add default identity label")
@@ -320,6 +332,7 @@ newer:   only pull base and SBOM scanner images when newer images exist on the r
 	fs.StringVar(&flags.SbomPurlOutput, "sbom-purl-output", "", "save scan results to `file``")
 	fs.StringVar(&flags.SbomImgPurlOutput, "sbom-image-purl-output", "", "add scan results to image as `path`")
 	fs.StringArrayVar(&flags.Secrets, "secret", []string{}, "secret file to expose to the build")
+	fs.StringArrayVar(&flags.SensitiveBuildArg, "sensitive-build-arg", []string{}, "treat the value of the build argument `argument` as a secret")
 	fs.StringVar(&flags.SignBy, "sign-by", "", "sign the image using a GPG key with the specified `FINGERPRINT`")
 	fs.StringVar(&flags.SignaturePolicy, "signature-policy", "", "`pathname` of signature policy file (not usually used)")
 	if err := fs.MarkHidden("signature-policy"); err != nil {
@@ -334,6 +347,7 @@ newer:   only pull base and SBOM scanner images when newer images exist on the r
 	fs.StringVar(&flags.SourceDateEpoch, "source-date-epoch", os.Getenv(internal.SourceDateEpochName), "set new timestamps in image info to `seconds` after the epoch"+sourceDateEpochUsageDefault)
 	fs.BoolVar(&flags.RewriteTimestamp, "rewrite-timestamp", false, "set timestamps in layers to no later than the value for --source-date-epoch")
 	fs.BoolVar(&flags.Squash, "squash", false, "squash all image layers into a single layer")
+	fs.StringVar(&flags.SquashFrom, "squash-from", "", "merge base image layers starting with this `stage, index, or history comment` into the final image's new layer")
 	fs.StringArrayVar(&flags.SSH, "ssh", []string{}, "SSH agent socket or keys to expose to the build. (format: default|<id>[=<socket>|<key>[,<key>]])")
 	fs.BoolVar(&flags.Stdin, "stdin", false, "pass stdin into containers")
 	fs.StringArrayVarP(&flags.Tag, "tag", "t", []string{}, "tagged `name` to apply to the built image")
@@ -351,6 +365,7 @@ newer:   only pull base and SBOM scanner images when newer images exist on the r
 // GetBudFlagsCompletions returns the FlagCompletions for the common build flags
 func GetBudFlagsCompletions() commonComp.FlagCompletions {
 	flagCompletion := commonComp.FlagCompletions{}
+	flagCompletion["allow"] = commonComp.AutocompleteNone
 	flagCompletion["annotation"] = commonComp.AutocompleteNone
 	flagCompletion["arch"] = commonComp.AutocompleteNone
 	flagCompletion["authfile"] = commonComp.AutocompleteDefault
@@ -358,6 +373,7 @@ func GetBudFlagsCompletions() commonComp.FlagCompletions {
 	flagCompletion["build-arg-file"] = commonComp.AutocompleteDefault
 	flagCompletion["build-context"] = commonComp.AutocompleteNone
 	flagCompletion["cache-from"] = commonComp.AutocompleteNone
+	flagCompletion["cache-key-mode"] = commonComp.AutocompleteNone
 	flagCompletion["cache-to"] = commonComp.AutocompleteNone
 	flagCompletion["cache-ttl"] = commonComp.AutocompleteNone
 	flagCompletion["cert-dir"] = commonComp.AutocompleteDefault
@@ -385,6 +401,7 @@ func GetBudFlagsCompletions() commonComp.FlagCompletions {
 	flagCompletion["os-feature"] = commonComp.AutocompleteNone
 	flagCompletion["os-version"] = commonComp.AutocompleteNone
 	flagCompletion["output"] = commonComp.AutocompleteNone
+	flagCompletion["progress"] = commonComp.AutocompleteNone
 	flagCompletion["pull"] = commonComp.AutocompleteDefault
 	flagCompletion["runtime-flag"] = commonComp.AutocompleteNone
 	flagCompletion["sbom"] = commonComp.AutocompleteNone
@@ -396,11 +413,13 @@ func GetBudFlagsCompletions() commonComp.FlagCompletions {
 	flagCompletion["sbom-purl-output"] = commonComp.AutocompleteDefault
 	flagCompletion["sbom-image-purl-output"] = commonComp.AutocompleteNone
 	flagCompletion["secret"] = commonComp.AutocompleteNone
+	flagCompletion["sensitive-build-arg"] = commonComp.AutocompleteNone
 	flagCompletion["sign-by"] = commonComp.AutocompleteNone
 	flagCompletion["signature-policy"] = commonComp.AutocompleteNone
 	flagCompletion["source-policy-file"] = commonComp.AutocompleteDefault
 	flagCompletion["ssh"] = commonComp.AutocompleteNone
 	flagCompletion["source-date-epoch"] = commonComp.AutocompleteNone
+	flagCompletion["squash-from"] = commonComp.AutocompleteNone
 	flagCompletion["tag"] = commonComp.AutocompleteNone
 	flagCompletion["target"] = commonComp.AutocompleteNone
 	flagCompletion["timestamp"] = commonComp.AutocompleteNone
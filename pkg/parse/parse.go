@@ -10,6 +10,7 @@ import (
 	"io/fs"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"slices"
 	"strconv"
@@ -1351,8 +1352,10 @@ func Secrets(secrets []string) (map[string]define.Secret, error) {
 				src = value
 				typ = "env"
 			case "type":
-				if value != "file" && value != "env" {
-					return nil, errors.New("invalid secret type, must be file or env")
+				switch value {
+				case "file", "env", "cmd", "keyring", "fd":
+				default:
+					return nil, errors.New("invalid secret type, must be file, env, cmd, keyring, or fd")
 				}
 				typ = value
 			default:
@@ -1373,7 +1376,8 @@ func Secrets(secrets []string) (map[string]define.Secret, error) {
 			}
 		}
 
-		if typ == "file" {
+		switch typ {
+		case "file":
 			fullPath, err := filepath.Abs(src)
 			if err != nil {
 				return nil, fmt.Errorf("could not parse secrets: %w", err)
@@ -1383,6 +1387,20 @@ func Secrets(secrets []string) (map[string]define.Secret, error) {
 				return nil, fmt.Errorf("could not parse secrets: %w", err)
 			}
 			src = fullPath
+		case "cmd":
+			// the command is run each time the secret is used, but
+			// make sure that it's there
+			args := strings.Fields(src)
+			if len(args) == 0 {
+				return nil, errInvalidSecretSyntax
+			}
+			if _, err := exec.LookPath(args[0]); err != nil {
+				return nil, fmt.Errorf("could not parse secrets: %w", err)
+			}
+		case "fd":
+			if _, err := define.ParseSecretDescriptor(src); err != nil {
+				return nil, fmt.Errorf("could not parse secrets: %w", err)
+			}
 		}
 		newSecret := define.Secret{
 			ID:         id,
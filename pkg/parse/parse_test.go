@@ -392,6 +392,10 @@ func TestSecrets(t *testing.T) {
 		{"known-key-without-value", "id=mysecret,src"},
 		{"empty-id", "id="},
 		{"unknown-key", "id=mysecret,bogus=x"},
+		{"unknown-type", "id=mysecret,type=bogus"},
+		{"missing-command", "id=mysecret,type=cmd,src=/nonexistent/get-token"},
+		{"non-numeric-fd", "id=mysecret,type=fd,src=three"},
+		{"negative-fd", "id=mysecret,type=fd,src=-1"},
 	}
 	for _, tc := range errorTests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -430,6 +434,26 @@ func TestSecrets(t *testing.T) {
 			"id=TEST_SECRET_ENV",
 			define.Secret{ID: "TEST_SECRET_ENV", Source: "TEST_SECRET_ENV", SourceType: "env"},
 		},
+		{
+			"cmd",
+			"id=npm,type=cmd,src=sh -c true",
+			define.Secret{ID: "npm", Source: "sh -c true", SourceType: "cmd"},
+		},
+		{
+			"keyring",
+			"id=npm,type=keyring,src=npm-token",
+			define.Secret{ID: "npm", Source: "npm-token", SourceType: "keyring"},
+		},
+		{
+			"keyring-id-as-src",
+			"id=npm-token,type=keyring",
+			define.Secret{ID: "npm-token", Source: "npm-token", SourceType: "keyring"},
+		},
+		{
+			"fd",
+			"id=npm,type=fd,src=3",
+			define.Secret{ID: "npm", Source: "3", SourceType: "fd"},
+		},
 	}
 	for _, tc := range successTests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -3,6 +3,7 @@
 //
 // Source policies allow users to:
 //   - Pin base image tags to specific digests at build time
+//   - Pin the contents of HTTP sources to specific checksums
 //   - Deny specific sources from being used
 //   - Transform source references without modifying Containerfiles or Dockerfiles
 //
@@ -11,10 +12,14 @@ package sourcepolicy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"regexp"
 	"strings"
 
+	digest "github.com/opencontainers/go-digest"
 	"go.podman.io/image/v5/docker/reference"
 )
 
@@ -38,10 +43,20 @@ const (
 	MatchTypeExact MatchType = "EXACT"
 	// MatchTypeWildcard allows * and ? glob patterns.
 	MatchTypeWildcard MatchType = "WILDCARD"
-	// MatchTypeRegex allows regular expression patterns (not implemented in MVP).
+	// MatchTypeRegex allows regular expression patterns.  For CONVERT
+	// actions, the updated identifier can refer to capture groups using
+	// $1 or ${name} syntax.
 	MatchTypeRegex MatchType = "REGEX"
 )
 
+// ErrDenied is wrapped by errors which callers return when a source is denied
+// by a DENY rule.
+var ErrDenied = errors.New("denied by source policy")
+
+// AttrHTTPChecksum is the attribute which, when set by a CONVERT rule for an
+// HTTP source, specifies the digest that the downloaded content must have.
+const AttrHTTPChecksum = "http.checksum"
+
 // Selector specifies which sources a rule applies to.
 type Selector struct {
 	// Identifier is the source identifier to match.
@@ -57,10 +72,11 @@ type Selector struct {
 type Updates struct {
 	// Identifier is the new source identifier to use.
 	// For CONVERT actions, this replaces the original identifier.
+	// If it is empty, the original identifier is kept.
 	Identifier string `json:"identifier,omitempty"`
 
-	// Attrs contains additional attributes (e.g., http.checksum).
-	// Reserved for future use with HTTP sources.
+	// Attrs contains additional attributes to apply to the source.
+	// Currently only http.checksum is supported.
 	Attrs map[string]string `json:"attrs,omitempty"`
 }
 
@@ -91,8 +107,14 @@ type Decision struct {
 	// TargetRef is the new reference to use (for CONVERT actions).
 	TargetRef string
 
+	// Attrs contains attributes to apply to the source (for CONVERT actions).
+	Attrs map[string]string
+
 	// Reason provides context for the decision (e.g., which rule matched).
 	Reason string
+
+	// Rule is the index of the rule which matched.
+	Rule int
 }
 
 // LoadFromFile loads a source policy from a JSON file.
@@ -126,8 +148,8 @@ func (p *Policy) Validate() error {
 		return nil
 	}
 
-	for i, rule := range p.Rules {
-		if err := rule.Validate(); err != nil {
+	for i := range p.Rules {
+		if err := p.Rules[i].Validate(); err != nil {
 			return fmt.Errorf("rule %d: %w", i, err)
 		}
 	}
@@ -157,15 +179,31 @@ func (r *Rule) Validate() error {
 	case MatchTypeExact, MatchTypeWildcard, "":
 		// Valid match types (empty defaults to EXACT)
 	case MatchTypeRegex:
-		return fmt.Errorf("REGEX match type is not supported in this version")
+		if _, err := regexp.Compile(r.Selector.Identifier); err != nil {
+			return fmt.Errorf("parsing selector.identifier as a regular expression: %w", err)
+		}
 	default:
-		return fmt.Errorf("unknown matchType %q (valid: EXACT, WILDCARD)", r.Selector.MatchType)
+		return fmt.Errorf("unknown matchType %q (valid: EXACT, WILDCARD, REGEX)", r.Selector.MatchType)
 	}
 
 	// Validate updates for CONVERT action
 	if r.Action == ActionConvert {
-		if r.Updates == nil || r.Updates.Identifier == "" {
-			return fmt.Errorf("updates.identifier is required for CONVERT action")
+		if r.Updates == nil || (r.Updates.Identifier == "" && len(r.Updates.Attrs) == 0) {
+			return fmt.Errorf("updates.identifier is required for CONVERT action unless updates.attrs is set")
+		}
+	}
+
+	// Validate attributes
+	if r.Updates != nil {
+		for key, value := range r.Updates.Attrs {
+			switch key {
+			case AttrHTTPChecksum:
+				if _, err := digest.Parse(value); err != nil {
+					return fmt.Errorf("parsing %s value %q: %w", key, value, err)
+				}
+			default:
+				return fmt.Errorf("unknown attribute %q in updates.attrs (valid: %s)", key, AttrHTTPChecksum)
+			}
 		}
 	}
 
@@ -189,10 +227,15 @@ func (p *Policy) Evaluate(sourceIdentifier string) (Decision, bool, error) {
 			decision := Decision{
 				Action: rule.Action,
 				Reason: fmt.Sprintf("matched rule %d (selector: %q)", i, rule.Selector.Identifier),
+				Rule:   i,
 			}
 
 			if rule.Action == ActionConvert && rule.Updates != nil {
-				decision.TargetRef = rule.Updates.Identifier
+				decision.TargetRef, err = rule.convert(sourceIdentifier)
+				if err != nil {
+					return Decision{}, false, fmt.Errorf("evaluating rule %d: %w", i, err)
+				}
+				decision.Attrs = maps.Clone(rule.Updates.Attrs)
 			}
 
 			return decision, true, nil
@@ -214,11 +257,34 @@ func (r *Rule) Matches(sourceIdentifier string) (bool, error) {
 		return r.Selector.Identifier == sourceIdentifier, nil
 	case MatchTypeWildcard:
 		return matchWildcard(r.Selector.Identifier, sourceIdentifier), nil
+	case MatchTypeRegex:
+		re, err := regexp.Compile(r.Selector.Identifier)
+		if err != nil {
+			return false, fmt.Errorf("parsing selector.identifier as a regular expression: %w", err)
+		}
+		return re.MatchString(sourceIdentifier), nil
 	default:
 		return false, fmt.Errorf("unsupported match type: %s", matchType)
 	}
 }
 
+// convert returns the identifier that a CONVERT rule replaces a matching
+// source identifier with.  For REGEX rules, references to capture groups in
+// the updated identifier are expanded.
+func (r *Rule) convert(sourceIdentifier string) (string, error) {
+	if r.Updates.Identifier == "" {
+		return sourceIdentifier, nil
+	}
+	if r.Selector.MatchType != MatchTypeRegex {
+		return r.Updates.Identifier, nil
+	}
+	re, err := regexp.Compile(r.Selector.Identifier)
+	if err != nil {
+		return "", fmt.Errorf("parsing selector.identifier as a regular expression: %w", err)
+	}
+	return re.ReplaceAllString(sourceIdentifier, r.Updates.Identifier), nil
+}
+
 // matchWildcard performs glob-style pattern matching.
 // Supports * (matches any sequence of characters) and ? (matches any single character).
 func matchWildcard(pattern, str string) bool {
@@ -294,6 +360,33 @@ func normalizeImageRef(ref string) string {
 	return named.String()
 }
 
+// HTTPSourceIdentifier creates a BuildKit-style source identifier for an HTTP
+// or HTTPS URL, which is the URL itself.
+func HTTPSourceIdentifier(url string) string {
+	return url
+}
+
+// GitSourceIdentifier creates a BuildKit-style source identifier for a Git
+// repository URL, in the form "git://host/path.git#ref".
+func GitSourceIdentifier(url string) string {
+	for _, scheme := range []string{"https://", "http://", "git://"} {
+		if rest, ok := strings.CutPrefix(url, scheme); ok {
+			return "git://" + rest
+		}
+	}
+	return url
+}
+
+// ExtractGitURL extracts an HTTPS Git repository URL from a BuildKit-style
+// source identifier.  It returns the original identifier if it's not a git://
+// reference.
+func ExtractGitURL(sourceIdentifier string) string {
+	if rest, ok := strings.CutPrefix(sourceIdentifier, "git://"); ok {
+		return "https://" + rest
+	}
+	return sourceIdentifier
+}
+
 // ExtractImageRef extracts the image reference from a BuildKit-style source identifier.
 // It returns the original identifier if it's not a docker-image:// reference.
 func ExtractImageRef(sourceIdentifier string) string {
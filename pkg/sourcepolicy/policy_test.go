@@ -1,6 +1,7 @@
 package sourcepolicy
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
@@ -156,7 +157,7 @@ func TestParse(t *testing.T) {
 			errContains: "updates.identifier is required for CONVERT",
 		},
 		{
-			name: "REGEX match type not supported",
+			name: "valid policy with REGEX match type",
 			json: `{
 				"rules": [
 					{
@@ -168,8 +169,80 @@ func TestParse(t *testing.T) {
 					}
 				]
 			}`,
+		},
+		{
+			name: "invalid REGEX",
+			json: `{
+				"rules": [
+					{
+						"action": "DENY",
+						"selector": {
+							"identifier": "docker-image://(",
+							"matchType": "REGEX"
+						}
+					}
+				]
+			}`,
+			wantErr:     true,
+			errContains: "regular expression",
+		},
+		{
+			name: "valid policy with http.checksum attribute",
+			json: `{
+				"rules": [
+					{
+						"action": "CONVERT",
+						"selector": {
+							"identifier": "https://example.com/file.tar.gz"
+						},
+						"updates": {
+							"attrs": {
+								"http.checksum": "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+							}
+						}
+					}
+				]
+			}`,
+		},
+		{
+			name: "invalid http.checksum attribute",
+			json: `{
+				"rules": [
+					{
+						"action": "CONVERT",
+						"selector": {
+							"identifier": "https://example.com/file.tar.gz"
+						},
+						"updates": {
+							"attrs": {
+								"http.checksum": "abc123"
+							}
+						}
+					}
+				]
+			}`,
+			wantErr:     true,
+			errContains: "parsing http.checksum",
+		},
+		{
+			name: "unknown attribute",
+			json: `{
+				"rules": [
+					{
+						"action": "CONVERT",
+						"selector": {
+							"identifier": "https://example.com/file.tar.gz"
+						},
+						"updates": {
+							"attrs": {
+								"http.perm": "0644"
+							}
+						}
+					}
+				]
+			}`,
 			wantErr:     true,
-			errContains: "REGEX match type is not supported",
+			errContains: "unknown attribute",
 		},
 		{
 			name: "unknown match type",
@@ -296,6 +369,8 @@ func TestEvaluate(t *testing.T) {
 		wantMatched   bool
 		wantAction    Action
 		wantTargetRef string
+		wantAttrs     map[string]string
+		wantRule      int
 		wantErr       bool
 	}{
 		{
@@ -483,6 +558,87 @@ func TestEvaluate(t *testing.T) {
 			wantMatched:   true,
 			wantAction:    ActionConvert,
 			wantTargetRef: "docker-image://myregistry/alpine:pinned",
+			wantRule:      1,
+		},
+		{
+			name: "regex match DENY",
+			policyJSON: `{
+				"rules": [
+					{
+						"action": "DENY",
+						"selector": {
+							"identifier": "^docker-image://docker\\.io/library/alpine:3\\.1[0-7]$",
+							"matchType": "REGEX"
+						}
+					}
+				]
+			}`,
+			sourceID:    "docker-image://docker.io/library/alpine:3.17",
+			wantMatched: true,
+			wantAction:  ActionDeny,
+		},
+		{
+			name: "regex no match",
+			policyJSON: `{
+				"rules": [
+					{
+						"action": "DENY",
+						"selector": {
+							"identifier": "^docker-image://docker\\.io/library/alpine:3\\.1[0-7]$",
+							"matchType": "REGEX"
+						}
+					}
+				]
+			}`,
+			sourceID:    "docker-image://docker.io/library/alpine:3.18",
+			wantMatched: false,
+		},
+		{
+			name: "regex CONVERT with capture groups",
+			policyJSON: `{
+				"rules": [
+					{
+						"action": "CONVERT",
+						"selector": {
+							"identifier": "^docker-image://docker\\.io/(library/[^:]+):(.*)$",
+							"matchType": "REGEX"
+						},
+						"updates": {
+							"identifier": "docker-image://mirror.example.com/$1:$2"
+						}
+					}
+				]
+			}`,
+			sourceID:      "docker-image://docker.io/library/alpine:3.18",
+			wantMatched:   true,
+			wantAction:    ActionConvert,
+			wantTargetRef: "docker-image://mirror.example.com/library/alpine:3.18",
+		},
+		{
+			name: "CONVERT with only attrs keeps identifier",
+			policyJSON: `{
+				"rules": [
+					{
+						"action": "CONVERT",
+						"selector": {
+							"identifier": "https://example.com/file.tar.gz",
+							"matchType": "EXACT"
+						},
+						"updates": {
+							"attrs": {
+								"http.checksum": "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+							}
+						}
+					}
+				]
+			}`,
+			sourceID:      "https://example.com/file.tar.gz",
+			wantMatched:   true,
+			wantAction:    ActionConvert,
+			wantTargetRef: "https://example.com/file.tar.gz",
+			wantAttrs: map[string]string{
+				AttrHTTPChecksum: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			},
 		},
 	}
 
@@ -521,6 +677,12 @@ func TestEvaluate(t *testing.T) {
 				if tt.wantTargetRef != "" && decision.TargetRef != tt.wantTargetRef {
 					t.Errorf("Evaluate() targetRef = %v, want %v", decision.TargetRef, tt.wantTargetRef)
 				}
+				if tt.wantAttrs != nil && !maps.Equal(decision.Attrs, tt.wantAttrs) {
+					t.Errorf("Evaluate() attrs = %v, want %v", decision.Attrs, tt.wantAttrs)
+				}
+				if decision.Rule != tt.wantRule {
+					t.Errorf("Evaluate() rule = %v, want %v", decision.Rule, tt.wantRule)
+				}
 			}
 		})
 	}
@@ -659,3 +821,26 @@ func TestExtractImageRef(t *testing.T) {
 		})
 	}
 }
+
+func TestGitSourceIdentifier(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/user/repo.git#main", "git://github.com/user/repo.git#main"},
+		{"http://example.com/repo.git", "git://example.com/repo.git"},
+		{"git://github.com/user/repo.git", "git://github.com/user/repo.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got := GitSourceIdentifier(tt.url)
+			if got != tt.want {
+				t.Errorf("GitSourceIdentifier(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+			if back := ExtractGitURL(got); !strings.HasSuffix(back, strings.TrimPrefix(got, "git://")) {
+				t.Errorf("ExtractGitURL(%q) = %q", got, back)
+			}
+		})
+	}
+}
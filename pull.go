@@ -25,6 +25,9 @@ type PullOptions struct {
 	// ReportWriter is an io.Writer which will be used to log the writing
 	// of the new image.
 	ReportWriter io.Writer
+	// Progress, if set, will be sent updates on the progress of copying
+	// each of the image's blobs.  It is not closed.
+	Progress chan types.ProgressProperties
 	// Store is the local storage store which holds the source image.
 	Store storage.Store
 	// github.com/containers/image/types SystemContext to hold credentials
@@ -66,6 +69,7 @@ func Pull(_ context.Context, imageName string, options PullOptions) (imageID str
 	libimageOptions := &libimage.PullOptions{}
 	libimageOptions.SignaturePolicyPath = options.SignaturePolicyPath
 	libimageOptions.Writer = options.ReportWriter
+	libimageOptions.Progress = options.Progress
 	libimageOptions.RemoveSignatures = options.RemoveSignatures
 	libimageOptions.OciDecryptConfig = options.OciDecryptConfig
 	libimageOptions.AllTags = options.AllTags
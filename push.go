@@ -1,23 +1,34 @@
 package buildah
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	encconfig "github.com/containers/ocicrypt/config"
 	digest "github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"go.podman.io/buildah/define"
+	"go.podman.io/buildah/internal/tmpdir"
 	"go.podman.io/buildah/pkg/blobcache"
 	"go.podman.io/common/libimage"
 	"go.podman.io/image/v5/docker/reference"
+	"go.podman.io/image/v5/image"
 	"go.podman.io/image/v5/manifest"
+	"go.podman.io/image/v5/pkg/blobinfocache/none"
 	"go.podman.io/image/v5/pkg/compression"
 	"go.podman.io/image/v5/transports"
 	"go.podman.io/image/v5/types"
 	"go.podman.io/storage"
 	"go.podman.io/storage/pkg/archive"
+	"go.podman.io/storage/pkg/ioutils"
 )
 
 // cacheLookupReferenceFunc wraps a BlobCache into a
@@ -54,6 +65,9 @@ type PushOptions struct {
 	// ReportWriter is an io.Writer which will be used to log the writing
 	// of the new image.
 	ReportWriter io.Writer
+	// Progress, if set, will be sent updates on the progress of copying
+	// each of the image's blobs.  It is not closed.
+	Progress chan types.ProgressProperties
 	// Store is the local storage store which holds the source image.
 	Store storage.Store
 	// github.com/containers/image/types SystemContext to hold credentials
@@ -107,6 +121,18 @@ type PushOptions struct {
 	// CompressionFormat is used exclusively, and blobs of other compression
 	// algorithms are not reused.
 	ForceCompressionFormat bool
+	// Annotations is a list of annotations (in the form "key=value") to
+	// add to the manifest of the copy of the image.  If the image does not
+	// use an OCI manifest, the copy will be converted to use one.
+	Annotations []string
+	// LatestTimestamp, if set, causes any timestamps in the image's layers
+	// which are later than it, other than in its first PreservedLayers
+	// layers, to be replaced with it in the copy of the image.
+	LatestTimestamp *time.Time
+	// PreservedLayers is the number of layers, usually those of the base
+	// image, at the bottom of the image which LatestTimestamp does not
+	// affect.
+	PreservedLayers int
 }
 
 // Push copies the contents of the image to a new location.
@@ -114,6 +140,7 @@ func Push(ctx context.Context, image string, dest types.ImageReference, options
 	libimageOptions := &libimage.PushOptions{}
 	libimageOptions.SignaturePolicyPath = options.SignaturePolicyPath
 	libimageOptions.Writer = options.ReportWriter
+	libimageOptions.Progress = options.Progress
 	libimageOptions.ManifestMIMEType = options.ManifestType
 	libimageOptions.SignBy = options.SignBy
 	libimageOptions.RemoveSignatures = options.RemoveSignatures
@@ -142,6 +169,9 @@ func Push(ctx context.Context, image string, dest types.ImageReference, options
 		}
 		libimageOptions.SourceLookupReferenceFunc = cacheLookupReferenceFunc(options.BlobDirectory, compress, cacheOpts...)
 	}
+	if len(options.Annotations) > 0 || options.LatestTimestamp != nil {
+		libimageOptions.SourceLookupReferenceFunc = editLookupReferenceFunc(libimageOptions.SourceLookupReferenceFunc, options)
+	}
 	libimageOptions.DestinationLookupReferenceFunc = options.DestinationLookupReferenceFunc
 
 	runtime, err := libimage.RuntimeFromStore(options.Store, &libimage.RuntimeOptions{SystemContext: options.SystemContext})
@@ -170,3 +200,289 @@ func Push(ctx context.Context, image string, dest types.ImageReference, options
 
 	return ref, manifestDigest, nil
 }
+
+// editLookupReferenceFunc wraps a libimage.LookupReferenceFunc so that the
+// image read using the reference that it returns has the annotations and
+// timestamp changes called for in options applied to it.
+func editLookupReferenceFunc(lookup libimage.LookupReferenceFunc, options PushOptions) libimage.LookupReferenceFunc {
+	return func(ref types.ImageReference) (types.ImageReference, error) {
+		ref, err := lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		return &editedImageReference{
+			ImageReference:  ref,
+			annotations:     slices.Clone(options.Annotations),
+			latestTimestamp: options.LatestTimestamp,
+			preservedLayers: options.PreservedLayers,
+		}, nil
+	}
+}
+
+// editedImageReference is an image reference which, when read, returns an
+// edited version of the image that the reference it wraps points to.
+type editedImageReference struct {
+	types.ImageReference
+	annotations     []string
+	latestTimestamp *time.Time
+	preservedLayers int
+}
+
+func (r *editedImageReference) NewImage(ctx context.Context, sys *types.SystemContext) (types.ImageCloser, error) {
+	src, err := r.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	img, err := image.FromSource(ctx, sys, src)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return img, nil
+}
+
+func (r *editedImageReference) NewImageSource(ctx context.Context, sys *types.SystemContext) (types.ImageSource, error) {
+	src, err := r.ImageReference.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	edited, err := r.edit(ctx, sys, src)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("editing image %q: %w", transports.ImageName(r.ImageReference), err)
+	}
+	return edited, nil
+}
+
+// edit reads the manifest and configuration blob for the image, rewrites
+// any layers that need their timestamps changed, and builds the manifest and
+// configuration blob for the edited image.
+func (r *editedImageReference) edit(ctx context.Context, sys *types.SystemContext, src types.ImageSource) (_ *editedImageSource, err error) {
+	var img types.Image
+	img, err = image.FromUnparsedImage(ctx, sys, image.UnparsedInstance(src, nil))
+	if err != nil {
+		return nil, err
+	}
+	// Start from the manifest that we'd be copying if we weren't
+	// changing anything, converted to the OCI format if we're adding
+	// annotations.
+	layerInfos, err := img.LayerInfosForCopy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, manifestType, err := img.Manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	updates := types.ManifestUpdateOptions{
+		LayerInfos: layerInfos,
+	}
+	if len(r.annotations) > 0 && manifestType != v1.MediaTypeImageManifest {
+		updates.ManifestMIMEType = v1.MediaTypeImageManifest
+	}
+	if updates.LayerInfos != nil || updates.ManifestMIMEType != "" {
+		if img, err = img.UpdatedImage(ctx, updates); err != nil {
+			return nil, err
+		}
+	}
+	manifestBytes, manifestType, err := img.Manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, err := img.ConfigBlob(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := manifest.FromBlob(manifestBytes, manifestType)
+	if err != nil {
+		return nil, err
+	}
+	edited := &editedImageSource{
+		ImageSource: src,
+		ref:         r,
+		blobs:       make(map[digest.Digest]string),
+	}
+	defer func() {
+		if err != nil && edited.directory != "" {
+			os.RemoveAll(edited.directory)
+		}
+	}()
+	// Rewrite the layers that need to have their timestamps changed, and
+	// update the list of diffIDs in the configuration to match.
+	layers := m.LayerInfos()
+	if r.latestTimestamp != nil && len(layers) > r.preservedLayers {
+		if edited.directory, err = os.MkdirTemp(tmpdir.GetTempDir(), define.Package); err != nil {
+			return nil, err
+		}
+		var configFields map[string]json.RawMessage
+		if err := json.Unmarshal(config, &configFields); err != nil {
+			return nil, fmt.Errorf("parsing image configuration: %w", err)
+		}
+		var rootfs struct {
+			Type    string          `json:"type"`
+			DiffIDs []digest.Digest `json:"diff_ids"`
+		}
+		if err := json.Unmarshal(configFields["rootfs"], &rootfs); err != nil {
+			return nil, fmt.Errorf("parsing image configuration's list of layers: %w", err)
+		}
+		if len(rootfs.DiffIDs) != len(layers) {
+			return nil, fmt.Errorf("image configuration lists %d layers, manifest lists %d", len(rootfs.DiffIDs), len(layers))
+		}
+		updatedLayers := make([]types.BlobInfo, 0, len(layers))
+		for i, layer := range layers {
+			if i < r.preservedLayers {
+				updatedLayers = append(updatedLayers, layer.BlobInfo)
+				continue
+			}
+			rewritten, filename, err := rewriteLayerTimestamps(ctx, src, layer.BlobInfo, *r.latestTimestamp, edited.directory)
+			if err != nil {
+				return nil, fmt.Errorf("rewriting timestamps in layer %s: %w", layer.Digest, err)
+			}
+			edited.blobs[rewritten.Digest] = filename
+			rootfs.DiffIDs[i] = rewritten.Digest
+			updatedLayers = append(updatedLayers, rewritten)
+		}
+		if err := m.UpdateLayerInfos(updatedLayers); err != nil {
+			return nil, err
+		}
+		if configFields["rootfs"], err = json.Marshal(rootfs); err != nil {
+			return nil, err
+		}
+		if config, err = json.Marshal(configFields); err != nil {
+			return nil, err
+		}
+	}
+	edited.config = config
+	edited.configDigest = digest.Canonical.FromBytes(config)
+	// Point the manifest at the updated configuration blob, and add
+	// annotations to it.
+	switch m := m.(type) {
+	case *manifest.OCI1:
+		m.Config.Digest = edited.configDigest
+		m.Config.Size = int64(len(config))
+		if len(r.annotations) > 0 && m.Annotations == nil {
+			m.Annotations = make(map[string]string)
+		}
+		for _, kv := range r.annotations {
+			k, v, _ := strings.Cut(kv, "=")
+			m.Annotations[k] = v
+		}
+	case *manifest.Schema2:
+		m.ConfigDescriptor.Digest = edited.configDigest
+		m.ConfigDescriptor.Size = int64(len(config))
+	default:
+		return nil, fmt.Errorf("editing %q manifests is not supported", manifestType)
+	}
+	if edited.manifest, err = m.Serialize(); err != nil {
+		return nil, err
+	}
+	edited.manifestType = manifestType
+	return edited, nil
+}
+
+// rewriteLayerTimestamps reads a layer blob and writes an uncompressed copy
+// of it to a new file in directory, replacing any timestamps in it which are
+// later than latest with latest.  It returns the new blob's information and
+// the name of the file.
+func rewriteLayerTimestamps(ctx context.Context, src types.ImageSource, info types.BlobInfo, latest time.Time, directory string) (types.BlobInfo, string, error) {
+	rc, _, err := src.GetBlob(ctx, info, none.NoCache)
+	if err != nil {
+		return types.BlobInfo{}, "", err
+	}
+	defer rc.Close()
+	decompressed, err := archive.DecompressStream(rc)
+	if err != nil {
+		return types.BlobInfo{}, "", err
+	}
+	defer decompressed.Close()
+	f, err := os.CreateTemp(directory, "layer")
+	if err != nil {
+		return types.BlobInfo{}, "", err
+	}
+	defer f.Close()
+	digester := digest.Canonical.Digester()
+	counter := ioutils.NewWriteCounter(io.MultiWriter(f, digester.Hash()))
+	writeCloser, err := makeFilteredLayerWriteCloser(ioutils.NopWriteCloser(counter), nil, &latest, nil, false)
+	if err != nil {
+		return types.BlobInfo{}, "", err
+	}
+	if _, err := io.Copy(writeCloser, decompressed); err != nil {
+		writeCloser.Close()
+		return types.BlobInfo{}, "", err
+	}
+	if err := writeCloser.Close(); err != nil {
+		return types.BlobInfo{}, "", err
+	}
+	if err := f.Close(); err != nil {
+		return types.BlobInfo{}, "", err
+	}
+	rewritten := types.BlobInfo{
+		Digest:               digester.Digest(),
+		Size:                 counter.Count,
+		CompressionOperation: types.Decompress,
+	}
+	return rewritten, f.Name(), nil
+}
+
+// editedImageSource serves an edited manifest and configuration blob, along
+// with any layer blobs which were rewritten, reading everything else from the
+// image source that it wraps.
+type editedImageSource struct {
+	types.ImageSource
+	ref          types.ImageReference
+	manifest     []byte
+	manifestType string
+	config       []byte
+	configDigest digest.Digest
+	blobs        map[digest.Digest]string
+	directory    string
+}
+
+func (s *editedImageSource) Reference() types.ImageReference {
+	return s.ref
+}
+
+func (s *editedImageSource) Close() error {
+	if s.directory != "" {
+		if err := os.RemoveAll(s.directory); err != nil {
+			logrus.Warnf("removing %q: %v", s.directory, err)
+		}
+	}
+	return s.ImageSource.Close()
+}
+
+func (s *editedImageSource) GetManifest(_ context.Context, instanceDigest *digest.Digest) ([]byte, string, error) {
+	if instanceDigest != nil {
+		return nil, "", fmt.Errorf("edited image %q is not a list", transports.ImageName(s.ref))
+	}
+	return slices.Clone(s.manifest), s.manifestType, nil
+}
+
+func (s *editedImageSource) GetBlob(ctx context.Context, info types.BlobInfo, cache types.BlobInfoCache) (io.ReadCloser, int64, error) {
+	if info.Digest == s.configDigest {
+		return io.NopCloser(bytes.NewReader(s.config)), int64(len(s.config)), nil
+	}
+	if filename, ok := s.blobs[info.Digest]; ok {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, -1, err
+		}
+		st, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, -1, err
+		}
+		return f, st.Size(), nil
+	}
+	return s.ImageSource.GetBlob(ctx, info, cache)
+}
+
+func (s *editedImageSource) GetSignatures(context.Context, *digest.Digest) ([][]byte, error) {
+	// Any signatures for the original image don't match our manifest.
+	return nil, nil
+}
+
+func (s *editedImageSource) LayerInfosForCopy(context.Context, *digest.Digest) ([]types.BlobInfo, error) {
+	// Our manifest already lists the blobs that should be copied.
+	return nil, nil
+}
@@ -162,10 +162,18 @@ type RunOptions struct {
 	Devices define.ContainerDevices
 	// DeviceSpecs are unparsed additional devices to add
 	DeviceSpecs []string
+	// Insecure runs the command with all capabilities and all of the host's
+	// devices, without seccomp, AppArmor, or SELinux confinement, and
+	// without masking or write-protecting any parts of /proc and /sys.
+	// Not supported on FreeBSD.
+	Insecure bool
 	// Secrets are the available secrets to use
 	Secrets map[string]define.Secret
 	// SSHSources is the available ssh agents to use
 	SSHSources map[string]*sshagent.Source `json:"-"`
+	// RecordSecret, if set, is called with the value of each secret which
+	// is read for use by the command, before the command is started.
+	RecordSecret func(value []byte) `json:"-"`
 	// RunMounts are unparsed mounts to be added for this run
 	RunMounts []string
 	// Map of already-mounted stages, images, and container mountpoints
@@ -208,6 +216,8 @@ type runMountArtifacts struct {
 	IntermediateMounts []string
 	// Environment variables that should be set for RUN that may contain secrets, each is name=value form
 	EnvVars []string
+	// Temporary files, such as copies of secrets, which should be removed
+	TmpFiles []string
 }
 
 // RunMountInfo are the available run mounts for this run
@@ -220,6 +230,8 @@ type runMountInfo struct {
 	Secrets map[string]define.Secret
 	// SSHSources is the available ssh agents to use in a RUN
 	SSHSources map[string]*sshagent.Source `json:"-"`
+	// RecordSecret is called with the value of each secret used in a RUN
+	RecordSecret func(value []byte)
 	// Map of stages and container mountpoint if any from stage executor
 	StageMountPoints map[string]internal.StageMountDetails
 	// System context of current build
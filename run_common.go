@@ -1565,7 +1565,7 @@ func (b *Builder) runSetupRunMounts(bundlePath string, mounts []string, sources
 		}
 		switch mountType {
 		case "secret":
-			mountOrEnvSpec, err := b.getSecretMount(tokens, sources.Secrets, idMaps, sources.WorkDir)
+			mountOrEnvSpec, err := b.getSecretMount(tokens, sources.Secrets, sources.RecordSecret, idMaps, sources.WorkDir)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -1653,6 +1653,7 @@ func (b *Builder) runSetupRunMounts(bundlePath string, mounts []string, sources
 		TargetLocks:        targetLocks,
 		IntermediateMounts: intermediateMounts,
 		EnvVars:            envVars,
+		TmpFiles:           tmpFiles,
 	}
 	return finalMounts, artifacts, nil
 }
@@ -1722,14 +1723,15 @@ type secretMountOrEnv struct {
 	// set if mount created
 	Mount *specs.Mount
 
-	// set if caller mount created from temp created env file
+	// set if caller mount created from a temporary copy of the secret,
+	// which should be removed
 	EnvFile string
 
 	// set if caller should add to env variable list
 	EnvVariable string
 }
 
-func (b *Builder) getSecretMount(tokens []string, secrets map[string]define.Secret, idMaps IDMaps, workdir string) (_ secretMountOrEnv, retErr error) {
+func (b *Builder) getSecretMount(tokens []string, secrets map[string]define.Secret, recordSecret func([]byte), idMaps IDMaps, workdir string) (_ secretMountOrEnv, retErr error) {
 	errInvalidSyntax := errors.New("secret should have syntax id=id[,target=path,required=bool,mode=uint,uid=uint,gid=uint,env=dstVarName")
 	if len(tokens) == 0 {
 		return secretMountOrEnv{}, errInvalidSyntax
@@ -1816,6 +1818,9 @@ func (b *Builder) getSecretMount(tokens []string, secrets map[string]define.Secr
 	if err != nil {
 		return secretMountOrEnv{}, err
 	}
+	if recordSecret != nil {
+		recordSecret(data)
+	}
 
 	// if env is set, then we set that
 	if env != "" {
@@ -1849,6 +1854,29 @@ func (b *Builder) getSecretMount(tokens []string, secrets map[string]define.Secr
 			return secretMountOrEnv{}, err
 		}
 		ctrFileOnHost = filepath.Join(containerWorkingDir, "secrets", digest.FromString(id).Encoded()[:16])
+	case "cmd", "keyring", "fd":
+		// These are never meant to be stored on disk, so use the
+		// container's run directory, which is usually on a tmpfs, and
+		// remove the copy when we're done.
+		containerRunDir, err := b.store.ContainerRunDirectory(b.ContainerID)
+		if err != nil {
+			return secretMountOrEnv{}, err
+		}
+		if err := os.MkdirAll(filepath.Join(containerRunDir, "secrets"), 0o700); err != nil {
+			return secretMountOrEnv{}, err
+		}
+		tmpFile, err := os.CreateTemp(filepath.Join(containerRunDir, "secrets"), "secret")
+		if err != nil {
+			return secretMountOrEnv{}, err
+		}
+		tmpFile.Close()
+		defer func() {
+			if retErr != nil {
+				os.Remove(tmpFile.Name())
+			}
+		}()
+		rv.EnvFile = tmpFile.Name()
+		ctrFileOnHost = tmpFile.Name()
 	default:
 		return secretMountOrEnv{}, errors.New("invalid source secret type")
 	}
@@ -2031,6 +2059,12 @@ func (b *Builder) cleanupRunMounts(artifacts *runMountArtifacts) error {
 	}
 	// unlock locks we took, most likely for cache mounts
 	volumes.UnlockLockArray(artifacts.TargetLocks)
+	// remove temporary files, most likely copies of secrets
+	for _, tmpFile := range artifacts.TmpFiles {
+		if err := os.Remove(tmpFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -82,6 +82,10 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 		}()
 	}
 
+	if options.Insecure {
+		return errors.New("running commands with elevated privileges is not supported on FreeBSD")
+	}
+
 	p, err := os.MkdirTemp(tmpdir.GetTempDir(), define.Package)
 	if err != nil {
 		return err
@@ -268,6 +272,7 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 		ContextDir:       options.ContextDir,
 		Secrets:          options.Secrets,
 		SSHSources:       options.SSHSources,
+		RecordSecret:     options.RecordSecret,
 		StageMountPoints: options.StageMountPoints,
 		SystemContext:    options.SystemContext,
 	}
@@ -14,6 +14,7 @@ import (
 	"sync"
 
 	"github.com/docker/go-units"
+	"github.com/moby/sys/devices"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/sirupsen/logrus"
@@ -229,6 +230,12 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 	if err := checkAndOverrideIsolationOptions(isolation, &options); err != nil {
 		return err
 	}
+	if options.Insecure {
+		// Grant every capability, and don't let anything be dropped.
+		options.AddCapabilities = append(slices.Clone(options.AddCapabilities), "ALL")
+		options.DropCapabilities = nil
+		setupInsecureMounts(g)
+	}
 
 	// hardwire the environment to match docker build to avoid subtle and hard-to-debug differences due to containers.conf
 	b.configureEnvironment(g, options, []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"})
@@ -252,7 +259,11 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 	if workDir == "" {
 		workDir = string(os.PathSeparator)
 	}
-	setupSelinux(g, b.ProcessLabel, b.MountLabel)
+	processLabel := b.ProcessLabel
+	if options.Insecure {
+		processLabel = ""
+	}
+	setupSelinux(g, processLabel, b.MountLabel)
 	mountPoint, err := b.Mount(b.MountLabel)
 	if err != nil {
 		return fmt.Errorf("mounting container %q: %w", b.ContainerID, err)
@@ -285,6 +296,13 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 		devices = append(devices, device...)
 	}
 	devices = slices.Concat(devices, options.Devices, b.Devices)
+	if options.Insecure {
+		hostDevices, err := insecureDevices()
+		if err != nil {
+			return err
+		}
+		devices = append(devices, hostDevices...)
+	}
 
 	// Mount devices, if any, and if we're rootless attempt to work around not
 	// being able to create device nodes by bind-mounting them from the host, like podman does.
@@ -338,8 +356,10 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 		}
 	}
 
-	setupMaskedPaths(g, b.CommonBuildOpts)
-	setupReadOnlyPaths(g)
+	if !options.Insecure {
+		setupMaskedPaths(g, b.CommonBuildOpts)
+		setupReadOnlyPaths(g)
+	}
 
 	setupTerminal(g, options.Terminal, options.TerminalSize)
 
@@ -355,7 +375,11 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 
 	g.SetProcessNoNewPrivileges(b.CommonBuildOpts.NoNewPrivileges)
 
-	g.SetProcessApparmorProfile(b.CommonBuildOpts.ApparmorProfile)
+	apparmorProfile, seccompProfilePath := b.CommonBuildOpts.ApparmorProfile, b.CommonBuildOpts.SeccompProfilePath
+	if options.Insecure {
+		apparmorProfile, seccompProfilePath = "", "unconfined"
+	}
+	g.SetProcessApparmorProfile(apparmorProfile)
 
 	// Now grab the spec from the generator.  Set the generator to nil so that future contributors
 	// will quickly be able to tell that they're supposed to be modifying the spec directly from here.
@@ -365,7 +389,7 @@ func (b *Builder) Run(command []string, options RunOptions) error {
 	// Set the seccomp configuration using the specified profile name.  Some syscalls are
 	// allowed if certain capabilities are to be granted (example: CAP_SYS_CHROOT and chroot),
 	// so we sorted out the capabilities lists first.
-	if err = setupSeccomp(spec, b.CommonBuildOpts.SeccompProfilePath); err != nil {
+	if err = setupSeccomp(spec, seccompProfilePath); err != nil {
 		return err
 	}
 
@@ -504,6 +528,7 @@ rootless=%d
 		ContextDir:       options.ContextDir,
 		Secrets:          options.Secrets,
 		SSHSources:       options.SSHSources,
+		RecordSecret:     options.RecordSecret,
 		StageMountPoints: options.StageMountPoints,
 		SystemContext:    options.SystemContext,
 	}
@@ -1246,6 +1271,32 @@ func setupReadOnlyPaths(g *generate.Generator) {
 	}
 }
 
+// setupInsecureMounts makes the sysfs and cgroup filesystems writable, as they
+// would be for a privileged container.
+func setupInsecureMounts(g *generate.Generator) {
+	for i := range g.Config.Mounts {
+		if g.Config.Mounts[i].Destination != "/sys" && g.Config.Mounts[i].Destination != "/sys/fs/cgroup" {
+			continue
+		}
+		g.Config.Mounts[i].Options = slices.DeleteFunc(slices.Clone(g.Config.Mounts[i].Options), func(o string) bool { return o == "ro" })
+		g.Config.Mounts[i].Options = append(g.Config.Mounts[i].Options, "rw")
+	}
+}
+
+// insecureDevices returns the host's devices, which are all made available
+// when a command is run with RunOptions.Insecure set.
+func insecureDevices() (define.ContainerDevices, error) {
+	hostDevices, err := devices.HostDevices()
+	if err != nil {
+		return nil, fmt.Errorf("listing host devices: %w", err)
+	}
+	var insecure define.ContainerDevices
+	for _, d := range hostDevices {
+		insecure = append(insecure, define.BuildahDevice{Device: *d, Source: d.Path, Destination: d.Path})
+	}
+	return insecure, nil
+}
+
 func setupCapAdd(g *generate.Generator, caps ...string) error {
 	for _, cap := range caps {
 		if err := g.AddProcessCapabilityBounding(cap); err != nil {
@@ -483,6 +483,42 @@ _EOF
   expect_output --substring "unsupported value"
 }
 
+@test "build with inline RUN --network=private" {
+  skip_if_chroot
+  _prefetch alpine
+  run readlink /proc/self/ns/net
+  hostns="$output"
+  run_buildah build --network=host $WITH_POLICY_JSON -t source -f $BUDFILES/inline-network/Dockerfile5
+  local build_output="$output"
+  for network in private bridge ; do
+    run grep "^$network netns:" <<< "$build_output"
+    assert "$output" =~ "^$network netns: net:" "RUN --network=$network should report its network namespace"
+    assert "$output" != "$network netns: ${hostns}" "RUN --network=$network should not use the host's network namespace"
+    # unlike --network=none, the namespace should have been configured
+    run grep "^$network interfaces:" <<< "$build_output"
+    assert "$output" =~ "^$network interfaces: [[:alnum:]]+" "RUN --network=$network should have an interface other than loopback"
+  done
+}
+
+@test "build with inline RUN --network=<network name>" {
+  skip_if_chroot
+  skip_if_rootless_environment
+  _prefetch alpine
+  run readlink /proc/self/ns/net
+  hostns="$output"
+  # the default network is always present
+  run_buildah build --network=none $WITH_POLICY_JSON -t source -f $BUDFILES/inline-network/Dockerfile6
+  local build_output="$output"
+  run grep "^podman netns:" <<< "$build_output"
+  assert "$output" =~ "^podman netns: net:" "RUN --network=podman should report its network namespace"
+  assert "$output" != "podman netns: ${hostns}" "RUN --network=podman should not use the host's network namespace"
+  run grep "^podman interfaces:" <<< "$build_output"
+  assert "$output" = "podman interfaces: eth0" "RUN --network=podman should be attached to the network"
+  # other steps should still use --network=none
+  run grep "^default interfaces:" <<< "$build_output"
+  assert "$output" = "default interfaces:" "RUN without --network should not have an interface other than loopback"
+}
+
 @test "build with inline default RUN --network=default" {
   skip_if_chroot
   _prefetch alpine
@@ -1561,8 +1597,10 @@ EOF
   _prefetch alpine
   local contextdir=$BUDFILES/secret-relative
   run_buildah build $WITH_POLICY_JSON --no-cache --secret id=secret-foo,src=$contextdir/secret1.txt --secret id=secret-bar,src=$contextdir/secret2.txt -t test -f $contextdir/Dockerfile
-  expect_output --substring "secret:foo"
-  expect_output --substring "secret:bar"
+  # the contents of the secrets are masked in the build's output
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "secret:foo"
+  assert "$output" !~ "secret:bar"
 }
 
 @test "build-test --mount=type=cache test relative to workdir mount" {
@@ -2914,6 +2952,36 @@ _EOF
   expect_output --substring 'bin'
 }
 
+@test "build with custom build output and output image to archives and local storage" {
+  _prefetch alpine
+  mytmpdir=${TEST_SCRATCH_DIR}/my-dir
+  mkdir -p $mytmpdir
+  echo hello > $mytmpdir/hello
+  cat > $mytmpdir/Containerfile << _EOF
+FROM alpine
+COPY hello /hello
+_EOF
+  run_buildah build $WITH_POLICY_JSON -t test-bud -f $mytmpdir/Containerfile \
+    --output type=oci,dest=$mytmpdir/oci.tar,name=localhost/oci-output \
+    --output type=docker,dest=$mytmpdir/docker.tar,name=localhost/docker-output \
+    --output type=image,name=localhost/image-output \
+    $mytmpdir
+  run_buildah from --quiet oci-archive:$mytmpdir/oci.tar
+  cid=$output
+  run_buildah run $cid cat /hello
+  expect_output hello
+  run_buildah from --quiet docker-archive:$mytmpdir/docker.tar
+  run_buildah inspect --type image --format '{{.FromImageID}}' test-bud
+  iid=$output
+  run_buildah inspect --type image --format '{{.FromImageID}}' localhost/image-output
+  expect_output $iid "image output should name the built image"
+
+  run_buildah 125 build $WITH_POLICY_JSON --output type=registry -f $mytmpdir/Containerfile $mytmpdir
+  expect_output --substring 'missing required key "name"'
+  run_buildah 125 build $WITH_POLICY_JSON --output type=oci,dest=$mytmpdir/oci.tar,push=true -f $mytmpdir/Containerfile $mytmpdir
+  expect_output --substring 'push'
+}
+
 @test "build with custom build output must fail for bad input" {
   _prefetch alpine
   mytmpdir=${TEST_SCRATCH_DIR}/my-dir
@@ -5198,6 +5266,57 @@ _EOF
   expect_output --substring "/dev/fuse"
 }
 
+@test "bud with RUN --device" {
+  _prefetch alpine
+  skip_if_in_container # unable to perform mount of /dev/null for test in CI container setup
+  local contextdir=${TEST_SCRATCH_DIR}/bud/run-device
+  mkdir -p $contextdir
+
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+ARG DEVICE=/dev/null
+RUN --device=\$DEVICE:/test/dev/null ls /test/dev
+_EOF
+  run_buildah build $WITH_POLICY_JSON -t test -f $contextdir/Dockerfile
+  expect_output --substring "null"
+
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+RUN --device= true
+_EOF
+  run_buildah 125 build $WITH_POLICY_JSON -t test -f $contextdir/Dockerfile
+  expect_output --substring "RUN: --device requires a value"
+}
+
+@test "bud with RUN --security=insecure" {
+  _prefetch alpine
+  local contextdir=${TEST_SCRATCH_DIR}/bud/run-security
+  mkdir -p $contextdir
+
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+RUN --security=insecure grep ^CapEff: /proc/self/status
+RUN --security=sandbox grep ^CapEff: /proc/self/status
+_EOF
+  run_buildah 125 build $WITH_POLICY_JSON -t test -f $contextdir/Dockerfile
+  expect_output --substring 'RUN --security=insecure requires the "security.insecure" entitlement'
+
+  run_buildah 125 build --allow network.host $WITH_POLICY_JSON -t test -f $contextdir/Dockerfile
+  expect_output --substring 'unrecognized --allow value "network.host"'
+
+  run_buildah build --allow security.insecure $WITH_POLICY_JSON -t test -f $contextdir/Dockerfile
+  assert "${lines[2]}" =~ "CapEff:"
+  assert "${lines[4]}" =~ "CapEff:"
+  assert "${lines[2]}" != "${lines[4]}" "RUN --security=insecure should have more capabilities than RUN --security=sandbox"
+
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+RUN --security=privileged true
+_EOF
+  run_buildah 125 build --allow security.insecure $WITH_POLICY_JSON -t test -f $contextdir/Dockerfile
+  expect_output --substring 'invalid value for --security: "privileged"'
+}
+
 @test "bud with Containerfile" {
   _prefetch alpine
   target=alpine-image
@@ -5767,6 +5886,29 @@ EOM
   expect_output --substring ".*\(system\).*\(user\).*\(elapsed\).*input.*output"
 }
 
+@test "bud with-rusage-and-secrets" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  echo hello > $contextdir/file.txt
+  echo secret > ${TEST_SCRATCH_DIR}/secret
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+COPY file.txt /file.txt
+_EOF
+  # logging resource usage to our output shouldn't cause it to be closed
+  # before the build is finished
+  run_buildah build --log-rusage --secret id=secret,src=${TEST_SCRATCH_DIR}/secret $WITH_POLICY_JSON --iidfile ${TEST_SCRATCH_DIR}/iid $contextdir
+  expect_output --substring ".*\(system\).*\(user\).*\(elapsed\).*input.*output"
+  assert "${lines[-1]}" = "$(cut -d: -f2 ${TEST_SCRATCH_DIR}/iid)" "image ID at the end of the output"
+
+  run_buildah build --log-rusage --secret id=secret,src=${TEST_SCRATCH_DIR}/secret $WITH_POLICY_JSON --platform linux/amd64,linux/arm64 --manifest rusage-list $contextdir
+  expect_output --substring "\[linux/amd64\] COMMIT"
+  expect_output --substring "\[linux/arm64\] COMMIT"
+  run_buildah manifest inspect rusage-list
+  run jq -r '.manifests[].platform.architecture' <<< "$output"
+  assert "$(sort <<< "$output" | tr '\n' ' ')" = "amd64 arm64 " "architectures in the list"
+}
+
 @test "bud with-rusage-logfile" {
   _prefetch alpine
   run_buildah build --log-rusage --rusage-logfile ${TEST_SCRATCH_DIR}/foo.log --layers --pull=false --format docker $WITH_POLICY_JSON $BUDFILES/shell
@@ -6476,6 +6618,36 @@ _EOF
   assert "$output" !~ "Cache pulled"
 }
 
+@test "build test pushing and pulling from local cache locations" {
+  _prefetch alpine
+  mytmpdir=${TEST_SCRATCH_DIR}/my-dir
+  mkdir -p $mytmpdir
+  cat > $mytmpdir/Containerfile << _EOF
+FROM alpine
+RUN echo hello
+RUN touch hello
+_EOF
+
+  for cachetype in local oci; do
+    cachedest=${TEST_SCRATCH_DIR}/cache-${cachetype}
+    if [[ "$cachetype" == oci ]]; then
+      cachedest=${cachedest}.tar
+    fi
+    # populate the cache without using a registry
+    run_buildah build $WITH_POLICY_JSON --layers --cache-to type=${cachetype},dest=${cachedest} -t test -f ${mytmpdir}/Containerfile ${mytmpdir}
+    expect_output --substring "Pushing cache"
+    test -e ${cachedest}
+
+    # clean all cache and intermediate images so that we
+    # can only use what's in the cache location
+    run_buildah rmi --all -f
+
+    run_buildah build $WITH_POLICY_JSON --layers --cache-from type=${cachetype},src=${cachedest} -t test -f ${mytmpdir}/Containerfile ${mytmpdir}
+    expect_output --substring "Cache pulled from remote ${cachetype}:${cachedest}"
+    run_buildah rmi --all -f
+  done
+}
+
 @test "build test pushing and pulling from remote cache sources - after adding content summary" {
   _prefetch alpine
 
@@ -7192,7 +7364,8 @@ SOMESECRETDATA
 _EOF
 
   run_buildah build --secret=id=mysecret,src=${mytmpdir}/mysecret $WITH_POLICY_JSON  -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
 
   run_buildah from secretimg
   run_buildah 1 run secretimg-working-container cat /run/secrets/mysecret
@@ -7218,7 +7391,8 @@ _EOF
 
   run_buildah build --secret=id=mysecret,src=${mytmpdir}/mysecret $WITH_POLICY_JSON  -t secretimg -f ${mytmpdir}/Dockerfile
   expect_output --substring "hello"
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
 }
 
 @test "bud with containerfile secret accessed on second RUN" {
@@ -7230,11 +7404,13 @@ SOMESECRETDATA
 _EOF
 
   run_buildah 1 bud --secret=id=mysecret,src=${mytmpdir}/mysecret $WITH_POLICY_JSON  -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret-access $BUDFILES/run-mounts
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
   expect_output --substring "cat: can't open '/mysecret': No such file or directory"
 
   run_buildah 1 bud --no-cache --layers --secret=id=mysecret,src=${mytmpdir}/mysecret $WITH_POLICY_JSON  -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret-access $BUDFILES/run-mounts
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
   expect_output --substring "cat: can't open '/mysecret': No such file or directory"
 }
 
@@ -7288,7 +7464,8 @@ _EOF
   _prefetch alpine
   export MYSECRET=SOMESECRETDATA
   run_buildah build --secret=id=mysecret,src=MYSECRET,type=env $WITH_POLICY_JSON  -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
 
   run_buildah from secretimg
   run_buildah 1 run secretimg-working-container cat /run/secrets/mysecret
@@ -7296,7 +7473,8 @@ _EOF
   run_buildah rm -a
 
   run_buildah build --secret=id=mysecret,env=MYSECRET $WITH_POLICY_JSON  -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
 
   run_buildah from secretimg
   run_buildah 1 run secretimg-working-container cat /run/secrets/mysecret
@@ -7314,11 +7492,152 @@ _EOF
   mkdir -p ${mytmpdir}
   cat > $mytmpdir/mysecret << _EOF
 SOMESECRETDATA
+_EOF
+
+  # print only the beginning of the secret, which is too short to be masked
+  cat > $mytmpdir/Dockerfile << _EOF
+FROM alpine
+RUN --mount=type=secret,id=mysecret cut -c1-3 /run/secrets/mysecret
 _EOF
 
   export mysecret=ENVDATA
-  run_buildah build --secret=id=mysecret $WITH_POLICY_JSON  -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
-  expect_output --substring "ENVDATA"
+  run_buildah build --secret=id=mysecret $WITH_POLICY_JSON  -t secretimg -f $mytmpdir/Dockerfile $mytmpdir
+  expect_output --substring "ENV"
+  assert "$output" !~ "SOM"
+}
+
+@test "bud with containerfile cmd secret" {
+  _prefetch alpine
+  mytmpdir=${TEST_SCRATCH_DIR}/my-dir1
+  mkdir -p ${mytmpdir}
+  cat > $mytmpdir/get-secret << _EOF
+#!/bin/sh
+echo CMDSECRETDATA-\$1
+_EOF
+  chmod +x $mytmpdir/get-secret
+
+  run_buildah build "--secret=id=mysecret,src=${mytmpdir}/get-secret arg,type=cmd" $WITH_POLICY_JSON -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "CMDSECRETDATA"
+
+  run_buildah 125 build --secret=id=mysecret,src=${mytmpdir}/missing,type=cmd $WITH_POLICY_JSON -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
+  expect_output --substring "could not parse secrets"
+}
+
+@test "bud with containerfile fd secret" {
+  _prefetch alpine
+  mytmpdir=${TEST_SCRATCH_DIR}/my-dir1
+  mkdir -p ${mytmpdir}
+  echo FDSECRETDATA > $mytmpdir/mysecret
+
+  # bats uses descriptor 3, and buildah uses descriptors 3 and 4 when it
+  # re-executes itself in a user namespace
+  run_buildah build --secret=id=mysecret,src=7,type=fd $WITH_POLICY_JSON -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts 7< $mytmpdir/mysecret
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "FDSECRETDATA"
+
+  run_buildah 125 build --secret=id=mysecret,src=seven,type=fd $WITH_POLICY_JSON -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
+  expect_output --substring 'invalid file descriptor "seven"'
+}
+
+@test "bud with containerfile keyring secret" {
+  if ! which keyctl > /dev/null 2> /dev/null; then
+    skip "keyctl not available"
+  fi
+  _prefetch alpine
+  keyctl add user buildah-test-secret KEYRINGSECRETDATA @s || skip "unable to add a key to the session keyring"
+  run_buildah build --secret=id=mysecret,src=buildah-test-secret,type=keyring $WITH_POLICY_JSON -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
+  keyctl purge -s user buildah-test-secret
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "KEYRINGSECRETDATA"
+}
+
+@test "bud with secret masked in logfile" {
+  _prefetch alpine
+  mytmpdir=${TEST_SCRATCH_DIR}/my-dir1
+  mkdir -p ${mytmpdir}
+  cat > $mytmpdir/mysecret << _EOF
+SOMESECRETDATA
+_EOF
+
+  run_buildah build --logfile ${mytmpdir}/logfile --secret=id=mysecret,src=${mytmpdir}/mysecret $WITH_POLICY_JSON -t secretimg -f $BUDFILES/run-mounts/Dockerfile.secret $BUDFILES/run-mounts
+  run cat ${mytmpdir}/logfile
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
+}
+
+@test "bud with secret in image configuration" {
+  _prefetch alpine
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+RUN --mount=type=secret,id=mysecret,env=MYSECRET true
+ENV LEAKED=SOMESECRETDATA
+_EOF
+
+  export MYSECRET=SOMESECRETDATA
+  run_buildah 125 build --secret=id=mysecret,env=MYSECRET $WITH_POLICY_JSON -t secretimg $contextdir
+  expect_output --substring 'value of a secret in environment variable "LEAKED"'
+}
+
+@test "bud with sensitive build arg" {
+  _prefetch alpine
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+ARG TOKEN
+ARG OTHER
+RUN echo "token is \$TOKEN, other is \$OTHER"
+_EOF
+
+  run_buildah build --sensitive-build-arg TOKEN --build-arg TOKEN=SENSITIVEVALUE --build-arg OTHER=PLAINVALUE --metadata-file ${TEST_SCRATCH_DIR}/metadata.json $WITH_POLICY_JSON -t sensitive $contextdir
+  expect_output --substring "token is \*\*\*\*, other is PLAINVALUE"
+  assert "$output" !~ "SENSITIVEVALUE"
+  run grep SENSITIVEVALUE ${TEST_SCRATCH_DIR}/metadata.json
+  assert "$status" -ne 0 "the metadata file should not include the value of a sensitive build arg"
+
+  run_buildah inspect --format '{{range .Docker.History}}{{.CreatedBy}}{{"\n"}}{{end}}' sensitive
+  expect_output --substring "OTHER=PLAINVALUE TOKEN=\*\*\*\*"
+  assert "$output" !~ "SENSITIVEVALUE"
+
+  cat >> $contextdir/Dockerfile << _EOF
+ENV TOKEN=\$TOKEN
+_EOF
+  run_buildah 125 build --sensitive-build-arg TOKEN --build-arg TOKEN=SENSITIVEVALUE $WITH_POLICY_JSON -t sensitive $contextdir
+  expect_output --substring 'value of a secret in environment variable "TOKEN"'
+}
+
+@test "bud with sensitive build arg and layers" {
+  _prefetch alpine
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  cat > $contextdir/Dockerfile << _EOF
+FROM alpine
+ARG TOKEN
+RUN echo "\$TOKEN" > /token
+_EOF
+
+  for mode in history content ; do
+    run_buildah build --layers --cache-key-mode=$mode --sensitive-build-arg TOKEN --build-arg TOKEN=FIRSTVALUE $WITH_POLICY_JSON -t sensitive-$mode $contextdir
+    assert "$output" !~ "Using cache" "first build with --cache-key-mode=$mode"
+    run_buildah build --layers --cache-key-mode=$mode --sensitive-build-arg TOKEN --build-arg TOKEN=FIRSTVALUE $WITH_POLICY_JSON -t sensitive-$mode $contextdir
+    expect_output --substring "Using cache" "second build with --cache-key-mode=$mode"
+
+    # the value is masked in the history, but a different one still
+    # shouldn't match the cached result
+    run_buildah build --layers --cache-key-mode=$mode --cache-explain --sensitive-build-arg TOKEN --build-arg TOKEN=SECONDVALUE $WITH_POLICY_JSON -t sensitive-$mode $contextdir
+    expect_output --substring "Cache miss:" "build with a different value with --cache-key-mode=$mode"
+    assert "$output" !~ "SECONDVALUE"
+    run_buildah inspect --format '{{range .Docker.History}}{{.CreatedBy}}{{"\n"}}{{end}}' sensitive-$mode
+    assert "$output" !~ "VALUE"
+    run_buildah from --quiet sensitive-$mode
+    local cid=$output
+    run_buildah run $cid cat /token
+    expect_output "SECONDVALUE"
+    run_buildah rm $cid
+  done
 }
 
 @test "bud with malformed --secret flag should fail not panic" {
@@ -7691,7 +8010,8 @@ _EOF
   _prefetch alpine ubuntu
   run_buildah 1 build -t testbud $WITH_POLICY_JSON --secret id=secret-foo,src=$BUDFILES/verify-cleanup/secret1.txt $BUDFILES/verify-cleanup/
   expect_output --substring "hello"
-  expect_output --substring "secrettext"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "secrettext"
   expect_output --substring "Directory /tmp exists."
   expect_output --substring "Directory /var/tmp exists."
   expect_output --substring "Directory /testdir DOES NOT exist."
@@ -8189,7 +8509,8 @@ _EOF
   local contextdir=$BUDFILES/secret-env
   export MYSECRET=SOMESECRETDATA
   run_buildah build $WITH_POLICY_JSON --no-cache --isolation chroot --secret id=MYSECRET -t test -f $contextdir/Dockerfile
-  expect_output --substring "SOMESECRETDATA"
+  expect_output --substring "\*\*\*\*"
+  assert "$output" !~ "SOMESECRETDATA"
 }
 
 @test "build-logs-from-platform" {
@@ -8521,6 +8842,73 @@ _EOF
   test -s "${TEST_SCRATCH_DIR}"/output2/built.txt
 }
 
+@test "build-with-outputs-with-options" {
+  _prefetch busybox
+  mkdir -p "${TEST_SCRATCH_DIR}"/context
+  cat > "${TEST_SCRATCH_DIR}"/context/Containerfile << _EOF
+FROM busybox
+RUN truncate -s1 /built.txt
+_EOF
+  run_buildah build --timestamp 0 $WITH_POLICY_JSON \
+    --output type=local,dest=${TEST_SCRATCH_DIR}/output,platform-split=true \
+    --output type=tar,dest=${TEST_SCRATCH_DIR}/output.tar.gz,compression=gzip \
+    --output type=tar,dest=${TEST_SCRATCH_DIR}/preserved.tar,rewrite-timestamp=false \
+    --output type=oci,dest=${TEST_SCRATCH_DIR}/oci.tar,annotation.org.example.output=yes \
+    "${TEST_SCRATCH_DIR}"/context
+  run_buildah info --format '{{.host.os}}_{{.host.arch}}'
+  platform="$output"
+  test -s "${TEST_SCRATCH_DIR}"/output/${platform}*/built.txt
+  run tar tzvf "${TEST_SCRATCH_DIR}"/output.tar.gz built.txt
+  assert "$status" -eq 0 "listing compressed archive"
+  assert "$output" =~ "1970-01-01" "timestamps should be forced to --timestamp"
+  run tar tvf "${TEST_SCRATCH_DIR}"/preserved.tar built.txt
+  assert "$status" -eq 0 "listing archive"
+  assert "$output" !~ "1970-01-01" "timestamps should be preserved"
+  mkdir "${TEST_SCRATCH_DIR}"/oci
+  tar -C "${TEST_SCRATCH_DIR}"/oci -xf "${TEST_SCRATCH_DIR}"/oci.tar
+  grep -rq '"org.example.output":"yes"' "${TEST_SCRATCH_DIR}"/oci/blobs
+}
+
+@test "build-with-image-outputs-copies-built-image" {
+  mkdir -p "${TEST_SCRATCH_DIR}"/context
+  echo hello > "${TEST_SCRATCH_DIR}"/context/hello
+  cat > "${TEST_SCRATCH_DIR}"/context/Containerfile << _EOF
+FROM scratch
+COPY hello /hello
+_EOF
+  run_buildah build --source-date-epoch 100 -t built $WITH_POLICY_JSON \
+    --output type=oci,dest=${TEST_SCRATCH_DIR}/annotated.tar,annotation.org.example.output=yes \
+    --output type=oci,dest=${TEST_SCRATCH_DIR}/rewritten.tar,rewrite-timestamp=true \
+    "${TEST_SCRATCH_DIR}"/context
+  run_buildah inspect --type image --format '{{.FromImageID}}' built
+  iid="$output"
+
+  # the annotated copy should be the image that was built, not a new commit
+  mkdir "${TEST_SCRATCH_DIR}"/annotated
+  tar -C "${TEST_SCRATCH_DIR}"/annotated -xf "${TEST_SCRATCH_DIR}"/annotated.tar
+  run jq -r '.manifests[0].digest' "${TEST_SCRATCH_DIR}"/annotated/index.json
+  manifest="${TEST_SCRATCH_DIR}"/annotated/blobs/sha256/${output##*:}
+  run jq -r '.config.digest' "$manifest"
+  expect_output "sha256:$iid"
+  run jq -r '.annotations["org.example.output"]' "$manifest"
+  expect_output yes
+
+  # the rewritten copy should have the timestamps in its new layer clamped
+  mkdir "${TEST_SCRATCH_DIR}"/rewritten
+  tar -C "${TEST_SCRATCH_DIR}"/rewritten -xf "${TEST_SCRATCH_DIR}"/rewritten.tar
+  run jq -r '.manifests[0].digest' "${TEST_SCRATCH_DIR}"/rewritten/index.json
+  manifest="${TEST_SCRATCH_DIR}"/rewritten/blobs/sha256/${output##*:}
+  run jq -r '.layers[0].digest' "$manifest"
+  run tar tvzf "${TEST_SCRATCH_DIR}"/rewritten/blobs/sha256/${output##*:} hello
+  assert "$status" -eq 0 "listing rewritten layer"
+  assert "$output" =~ "1970-01-01" "timestamps should be clamped to --source-date-epoch"
+
+  run_buildah 125 build --source-date-epoch 100 --rewrite-timestamp $WITH_POLICY_JSON \
+    --output type=oci,dest=${TEST_SCRATCH_DIR}/preserved.tar,rewrite-timestamp=false \
+    "${TEST_SCRATCH_DIR}"/context
+  expect_output --substring "rewrite-timestamp=false can not be used with --rewrite-timestamp"
+}
+
 @test "build-with-timestamp-applies-to-oci-archive" {
   local outpath="${TEST_SCRATCH_DIR}/timestamp-oci.tar"
 
@@ -10752,3 +11140,412 @@ _EOF
     done
   done
 }
+
+@test "bud-progress-rawjson" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  echo hello > $contextdir/file.txt
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch AS first
+COPY file.txt /file.txt
+FROM scratch
+COPY --from=first /file.txt /copied.txt
+_EOF
+
+  run_buildah 125 build $WITH_POLICY_JSON --progress=bogus $contextdir
+  expect_output --substring 'unrecognized --progress value "bogus"'
+
+  for cache in miss hit ; do
+    run_buildah build $WITH_POLICY_JSON --layers --progress=rawjson --iidfile ${TEST_SCRATCH_DIR}/iid -t progress $contextdir
+    local events=$(grep '^{' <<< "$output")
+    run jq -r 'select(.type == "stage-start") | "\(.stage) \(.stageName)"' <<< "$events"
+    expect_output "0 first
+1 null" "stage-start events"
+    run jq -r 'select(.type == "step-finish") | "\(.stage) \(.step) \(.instruction) \(.cache)"' <<< "$events"
+    expect_output "0 1 COPY file.txt /file.txt $cache
+1 1 COPY --from=first /file.txt /copied.txt $cache" "step-finish events"
+    run jq -r 'select(.type == "stage-finish") | .imageID' <<< "$events"
+    expect_output --substring "$(cat ${TEST_SCRATCH_DIR}/iid | cut -d: -f2)\$" "stage-finish image ID"
+    run jq -r 'select(.type == "layer-commit") | .layer' <<< "$events"
+    if [[ $cache == miss ]]; then
+      assert "${#lines[*]}" = 2 "layer-commit events when building"
+      expect_output --substring "sha256:"
+    else
+      expect_output "" "layer-commit events when using cache"
+    fi
+  done
+
+  # standard error should only carry events
+  ${BUILDAH_BINARY} ${BUILDAH_REGISTRY_OPTS} ${ROOTDIR_OPTS} $WITH_POLICY_JSON build --layers --progress=rawjson -t progress $contextdir > ${TEST_SCRATCH_DIR}/stdout 2> ${TEST_SCRATCH_DIR}/stderr
+  run grep -v '^{' ${TEST_SCRATCH_DIR}/stderr
+  expect_output "" "non-event text on standard error"
+  run jq -r '.type' ${TEST_SCRATCH_DIR}/stderr
+  expect_output --substring "stage-start"
+  run cat ${TEST_SCRATCH_DIR}/stdout
+  expect_output --substring "STEP 2/2: COPY file.txt /file.txt"
+
+  # text would be mixed in with an archive written to standard output
+  run_buildah 125 build $WITH_POLICY_JSON --progress=rawjson --output - $contextdir
+  expect_output --substring "can not be used with an --output which writes to standard output unless --logfile is also specified"
+  ${BUILDAH_BINARY} ${BUILDAH_REGISTRY_OPTS} ${ROOTDIR_OPTS} $WITH_POLICY_JSON build --progress=rawjson --logfile ${TEST_SCRATCH_DIR}/logfile --output - $contextdir 2> ${TEST_SCRATCH_DIR}/stderr | tar -t > ${TEST_SCRATCH_DIR}/listing
+  run cat ${TEST_SCRATCH_DIR}/listing
+  expect_output "copied.txt"
+  run jq -r '.type' ${TEST_SCRATCH_DIR}/stderr
+  expect_output --substring "stage-start"
+}
+
+@test "bud-cache-explain" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  echo hello > $contextdir/file.txt
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ARG VALUE
+COPY file.txt /file.txt
+_EOF
+
+  run_buildah build $WITH_POLICY_JSON --layers --cache-explain -t explain $contextdir
+  expect_output --substring "Cache miss: no cached images were built on top of this step's base image"
+
+  echo goodbye > $contextdir/file.txt
+  run_buildah build $WITH_POLICY_JSON --layers --cache-explain -t explain $contextdir
+  expect_output --substring "Using cache"
+  expect_output --substring "Cache miss: the digest of added content differs in closest cached image [0-9a-f]{12}"
+
+  run_buildah build $WITH_POLICY_JSON --layers --cache-explain --build-arg VALUE=two -t explain $contextdir
+  expect_output --substring "Cache miss: build arguments differ in closest cached image [0-9a-f]{12}"
+
+  echo hello again > $contextdir/file.txt
+  run_buildah build $WITH_POLICY_JSON --layers --progress=rawjson --cache-explain --build-arg VALUE=two -t explain $contextdir
+  run jq -r 'select(.type == "step-finish" and .cache == "miss") | "\(.step) \(.cacheMiss)"' <<< "$(grep '^{' <<< "$output")"
+  expect_output --substring "^2 the digest of added content differs in closest cached image"
+
+  # without the flag, we don't explain anything
+  echo again > $contextdir/file.txt
+  run_buildah build $WITH_POLICY_JSON --layers -t explain $contextdir
+  assert "$output" !~ "Cache miss"
+}
+
+@test "bud-cache-key-mode-content" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  echo hello > $contextdir/file.txt
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ARG NAME
+ARG UNUSED
+LABEL b=2 a=1
+COPY file.txt /\$NAME.txt
+_EOF
+
+  run_buildah 125 build $WITH_POLICY_JSON --cache-key-mode=bogus $contextdir
+  expect_output --substring 'unrecognized --cache-key-mode value "bogus"'
+
+  run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=content --build-arg NAME=one --build-arg UNUSED=one --iidfile ${TEST_SCRATCH_DIR}/iid1 -t keyed $contextdir
+  # the key isn't recorded in the image's configuration
+  run_buildah inspect --format '{{range $key, $value := .Docker.Config.Labels}}{{$key}} {{end}}' keyed
+  expect_output --substring "a b "
+  assert "$output" !~ "cache-key"
+
+  # changing an argument which isn't referenced, or the formatting of an
+  # instruction, doesn't cause a cache miss
+  sed -i -e 's/^LABEL b=2 a=1$/LABEL  a=1   b=2/' $contextdir/Containerfile
+  run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=content --build-arg NAME=one --build-arg UNUSED=two --iidfile ${TEST_SCRATCH_DIR}/iid2 -t keyed $contextdir
+  expect_output --substring "Using cache"
+  cmp ${TEST_SCRATCH_DIR}/iid1 ${TEST_SCRATCH_DIR}/iid2
+
+  # changing an argument which is referenced does
+  run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=content --build-arg NAME=two --build-arg UNUSED=two --iidfile ${TEST_SCRATCH_DIR}/iid3 -t keyed $contextdir
+  run cmp -s ${TEST_SCRATCH_DIR}/iid1 ${TEST_SCRATCH_DIR}/iid3
+  assert "$status" -ne 0 "image ID after cache miss"
+}
+
+@test "bud-cache-key-mode-content-run-args" {
+  _prefetch alpine
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  cat > $contextdir/script.sh << _EOF
+#!/bin/sh
+echo "version is \$VERSION"
+_EOF
+  cat > $contextdir/Containerfile << _EOF
+FROM alpine
+ARG VERSION
+COPY script.sh /script.sh
+RUN sh /script.sh
+_EOF
+
+  run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=content --build-arg VERSION=1 -t run-args $contextdir
+  expect_output --substring "version is 1"
+
+  run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=content --build-arg VERSION=1 -t run-args $contextdir
+  expect_output --substring "Using cache"
+  assert "$output" !~ "version is"
+
+  # the argument is only read by the script, but it's still part of the key
+  run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=content --build-arg VERSION=2 -t run-args $contextdir
+  expect_output --substring "version is 2"
+}
+
+@test "bud-copy-jobs" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir/a/bin $contextdir/b/bin $contextdir/c
+  echo a > $contextdir/a/bin/tool
+  echo b > $contextdir/b/bin/tool
+  dd if=/dev/urandom of=$contextdir/c/big bs=1M count=8 status=none
+  for i in $(seq 1 20); do echo $i > $contextdir/file$i; done
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+COPY a/ b/ c/ file* /dest/
+_EOF
+
+  # sources are read in parallel, but still written in order, and produce
+  # the same content digest
+  for jobs in 1 4 0; do
+    run_buildah build $WITH_POLICY_JSON --jobs $jobs -t jobs$jobs $contextdir
+    run_buildah inspect --format '{{range .Docker.History}}{{.CreatedBy}}{{end}}' jobs$jobs
+    if [[ $jobs == 1 ]]; then
+      local createdBy="$output"
+    else
+      expect_output "$createdBy" "history with --jobs=$jobs"
+    fi
+    run_buildah from --pull=false jobs$jobs
+    cid=$output
+    run_buildah_mount $cid
+    mnt=$output
+    run cat $mnt/dest/bin/tool
+    expect_output "b" "later sources overwrite earlier ones with --jobs=$jobs"
+    cmp $contextdir/c/big $mnt/dest/big
+    run ls $mnt/dest
+    assert "${#lines[@]}" = 22 "items copied with --jobs=$jobs"
+    run_buildah_umount $cid
+    run_buildah rm $cid
+  done
+}
+
+@test "bud-content-manifest" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir/subdir
+  echo hello > $contextdir/subdir/file
+  ln -s file $contextdir/subdir/link
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+COPY subdir/ /dest/
+_EOF
+
+  run_buildah build $WITH_POLICY_JSON --content-manifest --metadata-file ${TEST_SCRATCH_DIR}/metadata.json -t content-manifest $contextdir
+  run jq -r '."buildah.content-manifest"[0].destination' ${TEST_SCRATCH_DIR}/metadata.json
+  expect_output "/dest/"
+  run jq -r '."buildah.content-manifest"[0].sources[0]' ${TEST_SCRATCH_DIR}/metadata.json
+  expect_output "subdir"
+  run jq -r '."buildah.content-manifest"[0].items[] | select(.path == "/dest/file") | .digest' ${TEST_SCRATCH_DIR}/metadata.json
+  expect_output "sha256:$(sha256sum < $contextdir/subdir/file | cut -f1 -d' ')"
+  run jq -r '."buildah.content-manifest"[0].items[] | select(.path == "/dest/link") | .linkname' ${TEST_SCRATCH_DIR}/metadata.json
+  expect_output "file"
+
+  # without the flag, no manifest is recorded
+  run_buildah build $WITH_POLICY_JSON --metadata-file ${TEST_SCRATCH_DIR}/plain.json -t no-content-manifest $contextdir
+  run jq -r 'has("buildah.content-manifest")' ${TEST_SCRATCH_DIR}/plain.json
+  expect_output "false"
+}
+
+@test "bud with ADD with git repository source - checksum and subdirectory" {
+  if ! which git ; then
+    skip "no git in PATH"
+  fi
+
+  local workdir=${TEST_SCRATCH_DIR}/work
+  mkdir -p ${workdir}/wanted ${workdir}/unwanted
+  echo wanted > ${workdir}/wanted/file.txt
+  echo unwanted > ${workdir}/unwanted/file.txt
+  git -C ${workdir} init -q -b main
+  git -C ${workdir} add .
+  git -C ${workdir} -c user.name="Test User" -c user.email=test@example.com commit -q -m "initial commit"
+  git -C ${workdir} -c user.name="Test User" -c user.email=test@example.com tag -a -m "annotated tag" v1.0
+  local commit=$(git -C ${workdir} rev-parse HEAD)
+
+  local repodir=${TEST_SCRATCH_DIR}/repository
+  mkdir -p ${repodir}
+  git clone -q --bare ${workdir} ${repodir}/project.git
+  starthttpd /git/=${repodir}:"git http-backend":GIT_HTTP_EXPORT_ALL=1:GIT_PROJECT_ROOT=${repodir} ${repodir}
+
+  local contextdir=${TEST_SCRATCH_DIR}/add-git
+  mkdir -p $contextdir
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD --checksum=${commit:0:12} http://0.0.0.0:${HTTP_SERVER_PORT}/git/project.git#v1.0:wanted /wanted
+_EOF
+  run_buildah build $WITH_POLICY_JSON -t git-checksum $contextdir
+  run_buildah from --pull=false git-checksum
+  cid=$output
+  run_buildah_mount $cid
+  mnt=$output
+  run cat $mnt/wanted/file.txt
+  expect_output "wanted"
+  test ! -e $mnt/unwanted
+  run_buildah_umount $cid
+
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD --checksum=0123456789abcdef http://0.0.0.0:${HTTP_SERVER_PORT}/git/project.git#v1.0 /src
+_EOF
+  run_buildah 125 build $WITH_POLICY_JSON --no-cache $contextdir
+  expect_output --substring "is commit ${commit}, which does not match checksum"
+
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD --checksum=sha256:${commit} http://0.0.0.0:${HTTP_SERVER_PORT}/git/project.git#v1.0 /src
+_EOF
+  run_buildah 125 build $WITH_POLICY_JSON --no-cache $contextdir
+  expect_output --substring "is not a commit ID"
+}
+
+@test "bud with ADD --unpack of remote archive" {
+  local srcdir=${TEST_SCRATCH_DIR}/archive-content
+  mkdir -p ${srcdir}/subdir
+  echo hello > ${srcdir}/subdir/file.txt
+  local servedir=${TEST_SCRATCH_DIR}/served
+  mkdir -p ${servedir}
+  tar -C ${srcdir} -czf ${servedir}/archive.tar.gz subdir
+  local sum=$(sha256sum ${servedir}/archive.tar.gz | cut -d' ' -f1)
+  starthttpd ${servedir}
+
+  local contextdir=${TEST_SCRATCH_DIR}/add-unpack
+  mkdir -p $contextdir
+  cp ${servedir}/archive.tar.gz $contextdir/local.tar.gz
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD --unpack=true --checksum=sha256:${sum} http://0.0.0.0:${HTTP_SERVER_PORT}/archive.tar.gz /unpacked/
+ADD http://0.0.0.0:${HTTP_SERVER_PORT}/archive.tar.gz /packed/
+ADD --unpack=false local.tar.gz /local/
+_EOF
+  run_buildah build $WITH_POLICY_JSON -t unpack $contextdir
+  run_buildah from --pull=false unpack
+  cid=$output
+  run_buildah_mount $cid
+  mnt=$output
+  run cat $mnt/unpacked/subdir/file.txt
+  expect_output "hello"
+  test -f $mnt/packed/archive.tar.gz
+  test -f $mnt/local/local.tar.gz
+  run_buildah_umount $cid
+
+  # the second download should come from the cache
+  run_buildah build $WITH_POLICY_JSON --no-cache --log-level=debug $contextdir
+  expect_output --substring "using cached copy of"
+
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD --unpack=true --checksum=sha256:0000000000000000000000000000000000000000000000000000000000000000 http://0.0.0.0:${HTTP_SERVER_PORT}/archive.tar.gz /unpacked/
+_EOF
+  run_buildah 125 build $WITH_POLICY_JSON --no-cache $contextdir
+  expect_output --substring "unexpected response digest"
+
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD --unpack=maybe http://0.0.0.0:${HTTP_SERVER_PORT}/archive.tar.gz /unpacked/
+_EOF
+  run_buildah 125 build $WITH_POLICY_JSON --no-cache $contextdir
+  expect_output --substring "invalid value for --unpack"
+}
+
+@test "bud with ADD of remote file uses download cache" {
+  local servedir=${TEST_SCRATCH_DIR}/served
+  mkdir -p ${servedir}
+  echo first > ${servedir}/file.txt
+  touch -d "2020-01-01 00:00:00" ${servedir}/file.txt
+  starthttpd ${servedir}
+
+  local contextdir=${TEST_SCRATCH_DIR}/add-download
+  mkdir -p $contextdir
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+ADD http://0.0.0.0:${HTTP_SERVER_PORT}/file.txt /file.txt
+_EOF
+  run_buildah build $WITH_POLICY_JSON --layers -t download $contextdir
+  test -d ${TEST_SCRATCH_DIR}/root/buildah-downloads
+
+  # the server reports that the file hasn't changed, so the cached copy
+  # is used, and the step matches the cached layer
+  run_buildah build $WITH_POLICY_JSON --layers --log-level=debug -t download $contextdir
+  expect_output --substring "using cached copy of"
+  expect_output --substring "Using cache"
+
+  # the file has changed, so it gets downloaded again
+  echo second > ${servedir}/file.txt
+  touch -d "2021-01-01 00:00:00" ${servedir}/file.txt
+  run_buildah build $WITH_POLICY_JSON --layers --log-level=debug -t download $contextdir
+  assert "$output" !~ "using cached copy of"
+  run_buildah from --pull=false download
+  cid=$output
+  run_buildah_mount $cid
+  mnt=$output
+  run cat $mnt/file.txt
+  expect_output "second"
+  run_buildah_umount $cid
+
+  run_buildah prune
+  test ! -e ${TEST_SCRATCH_DIR}/root/buildah-downloads
+}
+
+@test "bud reuses identical layers from unrelated builds" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p ${contextdir}/first ${contextdir}/second
+  dd if=/dev/urandom of=${contextdir}/payload bs=1024 count=64 status=none
+  touch -d "2020-01-01 00:00:00" ${contextdir}/payload
+  cp -p ${contextdir}/payload ${contextdir}/first/
+  cp -p ${contextdir}/payload ${contextdir}/second/
+  cat > ${contextdir}/first/Containerfile << _EOF
+FROM scratch
+COPY payload /payload
+LABEL build=first
+_EOF
+  cat > ${contextdir}/second/Containerfile << _EOF
+FROM scratch
+COPY payload /payload
+LABEL build=second
+_EOF
+  run_buildah build $WITH_POLICY_JSON -t first ${contextdir}/first
+  assert "$output" !~ "Reusing layer"
+  run_buildah build $WITH_POLICY_JSON -t second ${contextdir}/second
+  expect_output --substring "Reusing layer"
+
+  run_buildah inspect --format '{{index .OCIv1.RootFS.DiffIDs 0}}' first
+  local diffid=$output
+  run_buildah inspect --format '{{index .OCIv1.RootFS.DiffIDs 0}}' second
+  expect_output "$diffid"
+}
+
+@test "bud-content-manifest-with-layers" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  echo first > $contextdir/first
+  echo second > $contextdir/second
+  cat > $contextdir/Containerfile << _EOF
+FROM scratch
+COPY first /first
+COPY second /second
+_EOF
+
+  run_buildah build $WITH_POLICY_JSON --layers --content-manifest --metadata-file ${TEST_SCRATCH_DIR}/metadata.json -t content-manifest $contextdir
+  run jq -r '[."buildah.content-manifest"[].destination] | join(",")' ${TEST_SCRATCH_DIR}/metadata.json
+  expect_output "/first,/second"
+  run_buildah inspect --type image --format '{{range .ContentManifests}}{{.Destination}} {{end}}' content-manifest
+  expect_output "/first /second "
+
+  # manifests for cached steps are carried forward, too
+  run_buildah build $WITH_POLICY_JSON --layers --content-manifest --metadata-file ${TEST_SCRATCH_DIR}/cached.json -t content-manifest-cached $contextdir
+  expect_output --substring "Using cache"
+  run jq -r '[."buildah.content-manifest"[].destination] | join(",")' ${TEST_SCRATCH_DIR}/cached.json
+  expect_output "/first,/second"
+
+  # images built without manifests can't be used for steps which need them
+  for mode in history content ; do
+    echo $mode > $contextdir/first
+    echo $mode > $contextdir/second
+    run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=$mode -t no-content-manifest-$mode $contextdir
+    run_buildah build $WITH_POLICY_JSON --layers --cache-key-mode=$mode --content-manifest --metadata-file ${TEST_SCRATCH_DIR}/$mode.json -t content-manifest-$mode $contextdir
+    run jq -r '[."buildah.content-manifest"[].destination] | join(",")' ${TEST_SCRATCH_DIR}/$mode.json
+    expect_output "/first,/second" "content manifests with --cache-key-mode=$mode"
+  done
+}
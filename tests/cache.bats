@@ -0,0 +1,65 @@
+#!/usr/bin/env bats
+
+load helpers
+
+@test "cache-import-ls-inspect-export-rm" {
+  export TMPDIR=${TEST_SCRATCH_DIR}
+  mkdir -p ${TEST_SCRATCH_DIR}/content/subdir
+  echo cached > ${TEST_SCRATCH_DIR}/content/subdir/file
+  tar -C ${TEST_SCRATCH_DIR}/content -cf ${TEST_SCRATCH_DIR}/cache.tar .
+
+  run_buildah cache ls --noheading
+  expect_output ""
+
+  run_buildah cache import go-build ${TEST_SCRATCH_DIR}/cache.tar
+  name=$output
+  test -d ${TEST_SCRATCH_DIR}/buildah-cache-$UID/$name
+  run_buildah 125 cache import go-build ${TEST_SCRATCH_DIR}/cache.tar
+  expect_output --substring 'cache "go-build" already exists'
+
+  run_buildah cache import --uid 1000 --gid 1000 go-build < ${TEST_SCRATCH_DIR}/cache.tar
+  othername=$output
+  assert "$othername" != "$name" "caches with different owners should be different"
+
+  run_buildah cache ls --format '{{.ID}} {{.Owner}}'
+  expect_output --substring "go-build +0:0"
+  expect_output --substring "go-build +1000:1000"
+  run_buildah cache ls --quiet
+  expect_output "go-build
+go-build"
+
+  run_buildah cache inspect $name
+  expect_output --substring '"id": "go-build"'
+  expect_output --substring '"uid": 0'
+  expect_output --substring "\"path\": \"${TEST_SCRATCH_DIR}/buildah-cache-$UID/$name\""
+
+  run_buildah 125 cache export -o ${TEST_SCRATCH_DIR}/exported.tar go-build
+  expect_output --substring "2 caches with different owners"
+  run_buildah cache export -o ${TEST_SCRATCH_DIR}/exported.tar $name
+  run tar -tf ${TEST_SCRATCH_DIR}/exported.tar
+  assert "$status" -eq 0 "listing exported archive"
+  expect_output --substring "subdir/file"
+
+  run_buildah cache rm $othername
+  run_buildah cache ls --quiet
+  expect_output "go-build"
+  run_buildah cache rm --all
+  run_buildah cache ls --noheading
+  expect_output ""
+  run_buildah 125 cache rm go-build
+  expect_output --substring 'no cache with ID or name "go-build"'
+}
+
+@test "cache-mount-records-use" {
+  skip_if_no_runtime
+  skip_if_in_container
+  _prefetch alpine
+  export TMPDIR=${TEST_SCRATCH_DIR}
+  run_buildah build -t testbud $WITH_POLICY_JSON -f $BUDFILES/buildkit-mount/Dockerfilecachewriteprivate $BUDFILES/buildkit-mount
+  expect_output --substring "hello"
+  run_buildah cache ls --format '{{.ID}} {{.Owner}}'
+  expect_output "private-cache 0:0"
+  run_buildah cache export -o ${TEST_SCRATCH_DIR}/exported.tar private-cache
+  run tar -tf ${TEST_SCRATCH_DIR}/exported.tar
+  expect_output --substring "world"
+}
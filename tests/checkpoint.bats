@@ -0,0 +1,72 @@
+#!/usr/bin/env bats
+
+load helpers
+
+@test "checkpoint-and-rollback" {
+  createrandom ${TEST_SCRATCH_DIR}/one
+  createrandom ${TEST_SCRATCH_DIR}/two
+  run_buildah from --name checkpointed $WITH_POLICY_JSON scratch
+  cid=$output
+  run_buildah copy checkpointed ${TEST_SCRATCH_DIR}/one /one
+  run_buildah config --env A=1 checkpointed
+  run_buildah checkpoint checkpointed first
+
+  run_buildah copy checkpointed ${TEST_SCRATCH_DIR}/two /two
+  run_buildah config --env A=2 checkpointed
+  run_buildah checkpoint checkpointed second
+
+  run_buildah checkpoint --list checkpointed
+  expect_output "first
+second"
+
+  run_buildah rollback checkpointed first
+  newcid=$output
+  assert "$newcid" != "$cid" "rollback should replace the container"
+  run_buildah inspect --format '{{.ContainerID}}' checkpointed
+  expect_output "$newcid"
+  run_buildah inspect --format '{{.OCIv1.Config.Env}}' checkpointed
+  expect_output "[A=1]"
+  run_buildah mount checkpointed
+  root=$output
+  test -s $root/one
+  test ! -e $root/two
+  cmp ${TEST_SCRATCH_DIR}/one $root/one
+  run_buildah umount checkpointed
+
+  run_buildah checkpoint --list checkpointed
+  expect_output "first
+second"
+
+  run_buildah rollback checkpointed second
+  run_buildah inspect --format '{{.OCIv1.Config.Env}}' checkpointed
+  expect_output "[A=2]"
+  run_buildah mount checkpointed
+  root=$output
+  cmp ${TEST_SCRATCH_DIR}/one $root/one
+  cmp ${TEST_SCRATCH_DIR}/two $root/two
+  run_buildah umount checkpointed
+}
+
+@test "checkpoint-rm" {
+  run_buildah from --name checkpointed $WITH_POLICY_JSON scratch
+  run_buildah checkpoint checkpointed first
+  run_buildah checkpoint --rm checkpointed first
+  run_buildah checkpoint --list checkpointed
+  expect_output ""
+  run_buildah 125 rollback checkpointed first
+  expect_output --substring 'has no checkpoint named "first"'
+  run_buildah 125 checkpoint --rm checkpointed first
+  expect_output --substring 'has no checkpoint named "first"'
+}
+
+@test "checkpoint-invalid-arguments" {
+  run_buildah from --name checkpointed $WITH_POLICY_JSON scratch
+  run_buildah 125 checkpoint checkpointed ../escape
+  expect_output --substring 'invalid checkpoint name "../escape"'
+  run_buildah 125 checkpoint checkpointed
+  expect_output --substring "checkpoint name must be specified"
+  run_buildah 125 checkpoint --list checkpointed first
+  expect_output --substring "requires only a container name"
+  run_buildah 125 checkpoint --list --rm checkpointed
+  expect_output --substring "mutually exclusive"
+}
@@ -807,3 +807,18 @@ parents/y/b.txt"
   run_buildah 125 copy --allow-empty-wildcard=true $cid ${TEST_SCRATCH_DIR}/no-such-file /dest4/
   expect_output --substring "no such file or directory"
 }
+
+@test "copy-content-manifest" {
+  createrandom ${TEST_SCRATCH_DIR}/file-a
+
+  run_buildah from $WITH_POLICY_JSON scratch
+  cid=$output
+  run_buildah copy --content-manifest $cid ${TEST_SCRATCH_DIR}/file-a /dest/
+  run_buildah inspect --format '{{range .ContentManifests}}{{range .Items}}{{.Path}} {{.Digest}}{{end}}{{end}}' $cid
+  expect_output "/dest/file-a sha256:$(sha256sum < ${TEST_SCRATCH_DIR}/file-a | cut -f1 -d' ')"
+
+  # copies made without the flag aren't recorded
+  run_buildah copy $cid ${TEST_SCRATCH_DIR}/file-a /dest2/
+  run_buildah inspect --format '{{len .ContentManifests}}' $cid
+  expect_output "1"
+}
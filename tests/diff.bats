@@ -0,0 +1,59 @@
+#!/usr/bin/env bats
+
+load helpers
+
+@test "diff" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p ${contextdir}/first ${contextdir}/second
+  echo unchanged > ${contextdir}/first/unchanged
+  echo mode > ${contextdir}/first/mode
+  echo removed > ${contextdir}/first/removed
+  cp -p ${contextdir}/first/unchanged ${contextdir}/first/mode ${contextdir}/second/
+  chmod 600 ${contextdir}/second/mode
+  echo added > ${contextdir}/second/added
+
+  for image in first second ; do
+    run_buildah from --quiet $WITH_POLICY_JSON scratch
+    cid=$output
+    run_buildah copy $cid ${contextdir}/${image}/. /
+    run_buildah commit $WITH_POLICY_JSON --rm $cid $image
+  done
+
+  run_buildah diff first second
+  expect_output --from="${lines[0]}" --substring "^A +/added"
+  expect_output --from="${lines[1]}" --substring "^M +/mode +mode -rw-r--r-- -> -rw-------"
+  expect_output --from="${lines[2]}" --substring "^D +/removed"
+  assert "${#lines[@]}" = 3 "number of differences"
+
+  run_buildah diff --format json --digests first second
+  run jq -r '.[] | .change + " " + .path' <<< "$output"
+  assert "$status" = 0 "parsing JSON output"
+  assert "${lines[0]}" = "added /added"
+  assert "${lines[1]}" = "modified /mode"
+  assert "${lines[2]}" = "removed /removed"
+  run_buildah diff --format json --digests first second
+  run jq -r '.[0].new.digest' <<< "$output"
+  assert "$output" = "sha256:$(sha256sum ${contextdir}/second/added | cut -f1 -d' ')"
+
+  run_buildah diff --format '{{.Change}} {{.Path}}' second first
+  expect_output --from="${lines[0]}" --substring "^removed +/added"
+  expect_output --from="${lines[1]}" --substring "^modified +/mode"
+  expect_output --from="${lines[2]}" --substring "^added +/removed"
+}
+
+@test "diff working container with its base image" {
+  _prefetch busybox
+  run_buildah from --quiet $WITH_POLICY_JSON busybox
+  cid=$output
+  run_buildah diff $cid
+  expect_output ""
+
+  echo hello > ${TEST_SCRATCH_DIR}/hello
+  run_buildah copy $cid ${TEST_SCRATCH_DIR}/hello /etc/hello
+  run_buildah diff $cid
+  expect_output --substring "^A +/etc/hello"
+  assert "$output" !~ "/bin" "unchanged content should not be listed"
+
+  run_buildah 125 diff $cid no-such-image
+  expect_output --substring "\"no-such-image\" is neither a working container nor an image"
+}
@@ -13,6 +13,7 @@ CRASH_BINARY=${CRASH_BINARY:-$TEST_SOURCES/../bin/crash}
 WAIT_BINARY=${WAIT_BINARY:-$TEST_SOURCES/../bin/wait}
 PASSWD_BINARY=${PASSWD_BINARY:-$TEST_SOURCES/../bin/passwd}
 GRPCNOOP_BINARY=${GRPCNOOP_BINARY:-$TEST_SOURCES/../bin/grpcnoop}
+GRPCBUILD_BINARY=${GRPCBUILD_BINARY:-$TEST_SOURCES/../bin/grpcbuild}
 PIPELOOP_BINARY=${PIPELOOP_BINARY:-$TEST_SOURCES/../bin/pipeloop}
 STORAGE_DRIVER=${STORAGE_DRIVER:-vfs}
 PATH=$(dirname ${BASH_SOURCE})/../bin:${PATH}
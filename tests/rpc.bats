@@ -8,3 +8,115 @@ load helpers
   run_buildah rpc --env LISTENER ${GRPCNOOP_BINARY} --env LISTENER first-arg second-arg
   assert "$output" = 'ignored:"first-arg,second-arg"'
 }
+
+@test "rpc build" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  echo hello > $contextdir/hello
+  cat > $contextdir/Containerfile << EOF
+FROM scratch
+COPY hello /greeting
+LABEL built=rpc
+EOF
+  cat > ${TEST_SCRATCH_DIR}/script.sh << EOF
+set -e
+${GRPCBUILD_BINARY} -e BUILD_SOCKET from '{"image":"scratch","name":"rpc-ctr"}'
+${GRPCBUILD_BINARY} -e BUILD_SOCKET copy '{"container":"rpc-ctr","sources":["hello"],"destination":"/hello","contextDirectory":"$contextdir"}'
+${GRPCBUILD_BINARY} -e BUILD_SOCKET config '{"container":"rpc-ctr","env":["GREETING=hello"],"labels":{"committed":"rpc"},"entrypoint":{"values":["/hello"]}}'
+${GRPCBUILD_BINARY} -e BUILD_SOCKET commit '{"container":"rpc-ctr","image":"rpc-committed","rm":true,"quiet":true}'
+${GRPCBUILD_BINARY} -e BUILD_SOCKET build '{"contextDirectory":"$contextdir","tags":["rpc-built"]}'
+${GRPCBUILD_BINARY} -e BUILD_SOCKET push '{"image":"rpc-built","destination":"dir:${TEST_SCRATCH_DIR}/pushed","quiet":true}'
+EOF
+  run_buildah rpc $WITH_POLICY_JSON --env BUILD_SOCKET bash ${TEST_SCRATCH_DIR}/script.sh
+  expect_output --substring '"containerName":\s*"rpc-ctr"'
+  expect_output --substring "STEP 2/3: COPY hello /greeting"
+  expect_output --substring '"reference":\s*"localhost/rpc-built:latest@sha256:'
+
+  run_buildah inspect --format '{{.OCIv1.Config.Env}} {{.OCIv1.Config.Labels.committed}} {{.OCIv1.Config.Entrypoint}}' rpc-committed
+  assert "$output" = "[GREETING=hello] rpc [/hello]"
+  run_buildah inspect --format '{{.OCIv1.Config.Labels.built}}' rpc-built
+  assert "$output" = "rpc"
+  test -s ${TEST_SCRATCH_DIR}/pushed/manifest.json
+  run_buildah containers --format '{{.ContainerName}}' --filter name=rpc-ctr
+  expect_output ""
+
+  run_buildah 125 rpc $WITH_POLICY_JSON --env BUILD_SOCKET ${GRPCBUILD_BINARY} --env BUILD_SOCKET run '{"container":"rpc-ctr","args":["true"]}'
+  expect_output --substring "code = NotFound"
+}
+
+@test "rpc run" {
+  _prefetch alpine
+  cat > ${TEST_SCRATCH_DIR}/script.sh << EOF
+set -e
+${GRPCBUILD_BINARY} -e BUILD_SOCKET from '{"image":"alpine","name":"rpc-run-ctr","quiet":true}'
+${GRPCBUILD_BINARY} -e BUILD_SOCKET run '{"container":"rpc-run-ctr","args":["sh","-c","echo ran; echo hello > /hello"],"addHistory":true}'
+# Requests which use the same working container are handled one at a time,
+# so neither of these should lose the other's changes.
+${GRPCBUILD_BINARY} -e BUILD_SOCKET run '{"container":"rpc-run-ctr","args":["sh","-c","echo first >> /log"],"addHistory":true}' &
+first=\$!
+${GRPCBUILD_BINARY} -e BUILD_SOCKET run '{"container":"rpc-run-ctr","args":["sh","-c","echo second >> /log"],"addHistory":true}' &
+second=\$!
+wait \$first
+wait \$second
+${GRPCBUILD_BINARY} -e BUILD_SOCKET commit '{"container":"rpc-run-ctr","image":"rpc-run","rm":true,"quiet":true}'
+EOF
+  run_buildah rpc $WITH_POLICY_JSON --env BUILD_SOCKET bash ${TEST_SCRATCH_DIR}/script.sh
+  expect_output --substring "ran"
+
+  run_buildah from --quiet rpc-run
+  cid=$output
+  run_buildah run $cid cat /hello
+  expect_output hello
+  run_buildah run $cid sort /log
+  expect_output "first
+second"
+  run_buildah inspect --format '{{range .Docker.History}}{{println .CreatedBy}}{{end}}' rpc-run
+  expect_output --substring "echo ran; echo hello > /hello"
+  expect_output --substring "echo first >> /log"
+  expect_output --substring "echo second >> /log"
+}
+
+@test "rpc serve" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p $contextdir
+  for i in 1 2 3 ; do
+    mkdir -p $contextdir/$i
+    echo $i > $contextdir/$i/file
+    cat > $contextdir/$i/Containerfile << EOF
+FROM scratch
+COPY file /file$i
+EOF
+  done
+
+  local socket=${TEST_SCRATCH_DIR}/build.sock
+  ${BUILDAH_BINARY} ${BUILDAH_REGISTRY_OPTS} ${ROOTDIR_OPTS} $WITH_POLICY_JSON serve --listen $socket --max-requests 2 > ${TEST_SCRATCH_DIR}/serve.log 2>&1 &
+  local servepid=$!
+  for i in $(seq 1 50) ; do
+    test -S $socket && break
+    sleep 0.1
+  done
+  test -S $socket
+
+  # Build several images at the same time, sharing the service's store.
+  local pids=()
+  for i in 1 2 3 ; do
+    ${GRPCBUILD_BINARY} -c unix://$socket build '{"contextDirectory":"'$contextdir/$i'","tags":["served-'$i'"]}' > ${TEST_SCRATCH_DIR}/build.$i 2>&1 &
+    pids+=($!)
+  done
+  for i in 1 2 3 ; do
+    wait ${pids[$((i-1))]}
+    run cat ${TEST_SCRATCH_DIR}/build.$i
+    expect_output --substring '"reference":\s*"localhost/served-'$i':latest@sha256:'
+  done
+
+  kill -TERM $servepid
+  wait $servepid
+  test ! -e $socket
+  run cat ${TEST_SCRATCH_DIR}/serve.log
+  expect_output --substring "unix://$socket"
+
+  for i in 1 2 3 ; do
+    run_buildah images --format '{{.Name}}' localhost/served-$i
+    expect_output "localhost/served-$i"
+  done
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go.podman.io/buildah/define"
+	build "go.podman.io/buildah/internal/rpc/build/pb"
+	"go.podman.io/buildah/pkg/cli"
+	"go.podman.io/storage/pkg/reexec"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	if reexec.Init() {
+		return
+	}
+
+	rootCmd := cobra.Command{
+		Use:          "rpc",
+		Short:        "call a build GRPC endpoint",
+		Long:         "call a build GRPC endpoint",
+		Args:         cobra.ExactArgs(2),
+		RunE:         call,
+		Version:      define.Version,
+		SilenceUsage: true,
+	}
+	rootCmd.Flags().StringP("env", "e", "", "connect to location set in environment `variable`")
+	rootCmd.Flags().StringP("connect", "c", "", "connect to `location`")
+	rootCmd.Flags().SetInterspersed(true)
+	rootCmd.Flags().Usage = func() {
+		fmt.Println("[-e|--env var] [-c|--connect socket] method json")
+	}
+
+	var exitCode int
+
+	if err := rootCmd.Execute(); err != nil {
+		if logrus.IsLevelEnabled(logrus.TraceLevel) {
+			fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		exitCode = cli.ExecErrorCodeGeneric
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			if w, ok := ee.Sys().(syscall.WaitStatus); ok {
+				exitCode = w.ExitStatus()
+			}
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// handle writes output from a response to stdout or stderr, progress
+// information to stderr, and a result to stdout as JSON.
+func handle(msg proto.Message) error {
+	m := msg.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("event"))
+	if field == nil {
+		return nil
+	}
+	switch event := m.Get(field).Message().Interface().(type) {
+	case *build.Output:
+		w := os.Stdout
+		if event.GetStream() == build.Output_STREAM_STDERR {
+			w = os.Stderr
+		}
+		_, err := w.Write(event.GetData())
+		return err
+	case *build.Progress:
+		_, err := fmt.Fprint(os.Stderr, event.GetText())
+		return err
+	default:
+		return printJSON(event)
+	}
+}
+
+func printJSON(msg proto.Message) error {
+	encoded, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoded))
+	return nil
+}
+
+// receive handles each of the responses on a stream.
+func receive[T any, PT interface {
+	*T
+	proto.Message
+}](stream grpc.ServerStreamingClient[T], err error,
+) error {
+	if err != nil {
+		return fmt.Errorf("server responded with error: %w", err)
+	}
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("server responded with error: %w", err)
+		}
+		if err := handle(PT(response)); err != nil {
+			return err
+		}
+	}
+}
+
+func call(c *cobra.Command, args []string) error {
+	ctx := context.TODO()
+
+	socketPath := c.Flag("connect").Value.String()
+	if socketPath == "" {
+		envVar := c.Flag("env").Value.String()
+		if envVar == "" {
+			return errors.New("neither --connect nor --env were specified")
+		}
+		var ok bool
+		socketPath, ok = os.LookupEnv(envVar)
+		if !ok {
+			return fmt.Errorf("environment variable %q not set", envVar)
+		}
+	}
+	if socketPath == "" {
+		return errors.New("configured server location is empty")
+	}
+
+	cc, err := grpc.NewClient(socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("connecting to service: %w", err)
+	}
+	client := build.NewBuildClient(cc)
+
+	method, request := args[0], []byte(args[1])
+	switch method {
+	case "from":
+		var req build.FromRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		return receive(client.From(ctx, &req))
+	case "run":
+		var req build.RunRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		return receive(client.Run(ctx, &req))
+	case "copy":
+		var req build.CopyRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		return receive(client.Copy(ctx, &req))
+	case "config":
+		var req build.ConfigRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		response, err := client.Config(ctx, &req)
+		if err != nil {
+			return fmt.Errorf("server responded with error: %w", err)
+		}
+		return printJSON(response)
+	case "commit":
+		var req build.CommitRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		return receive(client.Commit(ctx, &req))
+	case "build":
+		var req build.BuildRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		return receive(client.Build(ctx, &req))
+	case "push":
+		var req build.PushRequest
+		if err := protojson.Unmarshal(request, &req); err != nil {
+			return err
+		}
+		return receive(client.Push(ctx, &req))
+	}
+	return fmt.Errorf("unrecognized method %q", method)
+}
@@ -421,3 +421,232 @@ EOF
   run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
   expect_output --substring "updates.identifier is required for CONVERT"
 }
+
+@test "source-policy: DENY rule with REGEX match" {
+  policyfile=${TEST_SCRATCH_DIR}/policy.json
+  cat > $policyfile << 'EOF'
+{
+  "rules": [
+    {
+      "action": "DENY",
+      "selector": {
+        "identifier": "^docker-image://docker\\.io/library/(alpine|busybox):.*$",
+        "matchType": "REGEX"
+      }
+    }
+  ]
+}
+EOF
+
+  dockerfile=${TEST_SCRATCH_DIR}/Dockerfile
+  cat > $dockerfile << 'EOF'
+FROM busybox:latest
+EOF
+
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "denied by source policy"
+}
+
+@test "source-policy: policy validation - invalid REGEX" {
+  policyfile=${TEST_SCRATCH_DIR}/policy.json
+  cat > $policyfile << 'EOF'
+{
+  "rules": [
+    {
+      "action": "DENY",
+      "selector": {
+        "identifier": "docker-image://(",
+        "matchType": "REGEX"
+      }
+    }
+  ]
+}
+EOF
+
+  dockerfile=${TEST_SCRATCH_DIR}/Dockerfile
+  cat > $dockerfile << 'EOF'
+FROM scratch
+EOF
+
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "regular expression"
+}
+
+@test "source-policy: ADD of URLs is evaluated and pinned" {
+  local contentdir=${TEST_SCRATCH_DIR}/content
+  mkdir -p $contentdir
+  echo hello > $contentdir/hello
+  echo goodbye > $contentdir/goodbye
+  starthttpd $contentdir
+  checksum=sha256:$(sha256sum $contentdir/hello | cut -f1 -d' ')
+
+  dockerfile=${TEST_SCRATCH_DIR}/Dockerfile
+  cat > $dockerfile << EOF
+FROM scratch
+ADD http://0.0.0.0:${HTTP_SERVER_PORT}/hello /hello
+EOF
+
+  # A URL can be denied
+  policyfile=${TEST_SCRATCH_DIR}/deny.json
+  cat > $policyfile << EOF
+{
+  "rules": [
+    {
+      "action": "DENY",
+      "selector": {
+        "identifier": "http://0.0.0.0:${HTTP_SERVER_PORT}/*"
+      }
+    }
+  ]
+}
+EOF
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "denied by source policy"
+
+  # A URL can be pinned to a checksum, which its contents match
+  policyfile=${TEST_SCRATCH_DIR}/pin.json
+  cat > $policyfile << EOF
+{
+  "rules": [
+    {
+      "action": "CONVERT",
+      "selector": {
+        "identifier": "http://0.0.0.0:${HTTP_SERVER_PORT}/hello",
+        "matchType": "EXACT"
+      },
+      "updates": {
+        "attrs": {
+          "http.checksum": "${checksum}"
+        }
+      }
+    }
+  ]
+}
+EOF
+  run_buildah build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+
+  # A URL can be redirected, but its new contents have to match the checksum
+  policyfile=${TEST_SCRATCH_DIR}/convert.json
+  cat > $policyfile << EOF
+{
+  "rules": [
+    {
+      "action": "CONVERT",
+      "selector": {
+        "identifier": "^(http://[^/]+)/hello$",
+        "matchType": "REGEX"
+      },
+      "updates": {
+        "identifier": "\$1/goodbye",
+        "attrs": {
+          "http.checksum": "${checksum}"
+        }
+      }
+    }
+  ]
+}
+EOF
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "goodbye"
+}
+
+@test "source-policy: DENY rule blocks COPY --from image" {
+  policyfile=${TEST_SCRATCH_DIR}/policy.json
+  cat > $policyfile << 'EOF'
+{
+  "rules": [
+    {
+      "action": "DENY",
+      "selector": {
+        "identifier": "docker-image://docker.io/library/alpine:*",
+        "matchType": "WILDCARD"
+      }
+    }
+  ]
+}
+EOF
+
+  dockerfile=${TEST_SCRATCH_DIR}/Dockerfile
+  cat > $dockerfile << 'EOF'
+FROM scratch
+COPY --from=alpine:latest /etc/os-release /os-release
+EOF
+
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "denied by source policy"
+  expect_output --substring "Source policy rule 0 .* matched COPY --from=alpine:latest"
+}
+
+@test "source-policy: DENY rule blocks RUN --mount from image" {
+  policyfile=${TEST_SCRATCH_DIR}/policy.json
+  cat > $policyfile << 'EOF'
+{
+  "rules": [
+    {
+      "action": "DENY",
+      "selector": {
+        "identifier": "docker-image://docker.io/library/alpine:*",
+        "matchType": "WILDCARD"
+      }
+    }
+  ]
+}
+EOF
+
+  dockerfile=${TEST_SCRATCH_DIR}/Dockerfile
+  cat > $dockerfile << 'EOF'
+FROM scratch
+RUN --mount=type=bind,from=alpine:latest,target=/mnt true
+EOF
+
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "denied by source policy"
+}
+
+@test "source-policy: build context images are evaluated and reported" {
+  _prefetch alpine busybox
+
+  policyfile=${TEST_SCRATCH_DIR}/policy.json
+  cat > $policyfile << 'EOF'
+{
+  "rules": [
+    {
+      "action": "DENY",
+      "selector": {
+        "identifier": "docker-image://docker.io/library/alpine:*",
+        "matchType": "WILDCARD"
+      }
+    },
+    {
+      "action": "CONVERT",
+      "selector": {
+        "identifier": "docker-image://docker.io/library/ubuntu:latest"
+      },
+      "updates": {
+        "identifier": "docker-image://docker.io/library/busybox:latest"
+      }
+    }
+  ]
+}
+EOF
+
+  dockerfile=${TEST_SCRATCH_DIR}/Dockerfile
+  cat > $dockerfile << 'EOF'
+FROM scratch
+COPY --from=tools /bin/busybox /busybox
+EOF
+
+  # A denied image is refused when it is supplied as a build context
+  run_buildah 125 build $WITH_POLICY_JSON --source-policy-file $policyfile --build-context tools=docker-image://alpine:latest -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "denied by source policy"
+
+  # A converted image is replaced, and the rule which fired is recorded
+  run_buildah build $WITH_POLICY_JSON --source-policy-file $policyfile --build-context tools=docker-image://ubuntu:latest --metadata-file ${TEST_SCRATCH_DIR}/metadata.json -t source-policy-context -f $dockerfile ${TEST_SCRATCH_DIR}
+  expect_output --substring "Source policy rule 1 .* converted COPY --from=tools"
+  run jq -r '."buildah.source-policy"[0].rule' ${TEST_SCRATCH_DIR}/metadata.json
+  assert "$output" = "1"
+  run jq -r '."buildah.source-policy"[0].action' ${TEST_SCRATCH_DIR}/metadata.json
+  assert "$output" = "CONVERT"
+  run jq -r '."buildah.source-policy"[0].target' ${TEST_SCRATCH_DIR}/metadata.json
+  assert "$output" = "docker-image://docker.io/library/busybox:latest"
+}
@@ -169,3 +169,87 @@ _EOF
   expect_output --substring "data"
   expect_output --substring "hello"
 }
+
+@test "commit-squash-from" {
+  createrandom ${TEST_SCRATCH_DIR}/randomfile
+  run_buildah from --quiet $WITH_POLICY_JSON scratch
+  cid=$output
+  for layer in 1 2 ; do
+    run_buildah copy $cid ${TEST_SCRATCH_DIR}/randomfile /layer$layer
+    run_buildah commit $WITH_POLICY_JSON $cid base
+    run_buildah rm $cid
+    run_buildah from --quiet $WITH_POLICY_JSON base
+    cid=$output
+  done
+  run_buildah config --history-comment packages $cid
+  for layer in 3 4 ; do
+    run_buildah copy $cid ${TEST_SCRATCH_DIR}/randomfile /layer$layer
+    run_buildah commit $WITH_POLICY_JSON $cid base
+    run_buildah rm $cid
+    run_buildah from --quiet $WITH_POLICY_JSON base
+    cid=$output
+  done
+  run_buildah copy $cid ${TEST_SCRATCH_DIR}/randomfile /layer5
+
+  # merge everything that was added after the comment was set
+  run_buildah commit $WITH_POLICY_JSON --squash-from=packages $cid by-marker
+  run_buildah inspect --format '{{len .OCIv1.RootFS.DiffIDs}}' by-marker
+  expect_output 3
+  run_buildah inspect --format '{{range .OCIv1.History}}{{if not .EmptyLayer}}x{{end}}{{end}}' by-marker
+  expect_output xxx
+
+  # merge everything above the bottom layer
+  run_buildah commit $WITH_POLICY_JSON --squash-from=1 $cid by-index
+  run_buildah inspect --format '{{len .OCIv1.RootFS.DiffIDs}}' by-index
+  expect_output 2
+
+  run_buildah from --quiet $WITH_POLICY_JSON by-index
+  run_buildah_mount $output
+  mountpoint=$output
+  for layer in 1 2 3 4 5 ; do
+    cmp ${TEST_SCRATCH_DIR}/randomfile $mountpoint/layer$layer
+  done
+
+  run_buildah 125 commit $WITH_POLICY_JSON --squash-from=5 $cid too-far
+  expect_output --substring "base image has 4 layers"
+}
+
+@test "bud with --squash-from a stage" {
+  local contextdir=${TEST_SCRATCH_DIR}/context
+  mkdir -p ${contextdir}
+  for file in a b c d e ; do
+    echo $file > ${contextdir}/$file
+  done
+  cat > ${contextdir}/Containerfile << _EOF
+FROM scratch AS base
+COPY a /a
+COPY b /b
+FROM base AS packages
+COPY c /c
+COPY d /d
+FROM packages
+COPY e /e
+_EOF
+  run_buildah build $WITH_POLICY_JSON --layers -t base --target base ${contextdir}
+  run_buildah inspect --format '{{len .OCIv1.RootFS.DiffIDs}}' base
+  expect_output 2
+  run_buildah inspect --format '{{index .OCIv1.RootFS.DiffIDs 1}}' base
+  local basetop=$output
+
+  run_buildah build $WITH_POLICY_JSON --layers --squash-from=packages -t squashed ${contextdir}
+  # the base stage's layers are kept, and the rest end up in one layer
+  run_buildah inspect --format '{{len .OCIv1.RootFS.DiffIDs}}' squashed
+  expect_output 3
+  run_buildah inspect --format '{{index .OCIv1.RootFS.DiffIDs 1}}' squashed
+  expect_output "$basetop"
+  run_buildah from --quiet $WITH_POLICY_JSON squashed
+  cid=$output
+  run_buildah_mount $cid
+  mountpoint=$output
+  for file in a b c d e ; do
+    test -s $mountpoint/$file
+  done
+
+  run_buildah 125 build $WITH_POLICY_JSON --squash-from=no-such-marker ${contextdir}
+  expect_output --substring "no history entry has that comment"
+}